@@ -19,29 +19,138 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
 )
 
 /*
-A utility command to load queue configuration file and check its validity
+A utility command to load queue configuration file and check its validity.
+Warnings for likely mistakes in a valid configuration are printed as text or JSON, they do not change the exit code.
+In dry-run mode the file is sent to a running scheduler which reports the impact the configuration would have.
 */
 func main() {
-	if len(os.Args) != 2 {
-		log.Println("Usage: " + os.Args[0] + " <queue-config-file>")
+	dryRun := flag.String("dryrun", "", "URL of a running scheduler, e.g. http://localhost:9080, to report the impact of the configuration")
+	token := flag.String("token", "", "bearer token used to authenticate with the scheduler in dry-run mode")
+	output := flag.String("output", outputText, "output format of the result: text or json")
+	flag.Usage = func() {
+		log.Println("Usage: " + os.Args[0] + " [-output text|json] [-dryrun <scheduler-url> [-token <token>]] <queue-config-file>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || (*output != outputText && *output != outputJSON) {
+		flag.Usage()
 		os.Exit(1)
 	}
-	queueFile := os.Args[1]
+	queueFile := flag.Arg(0)
 	conf, err := os.ReadFile(queueFile)
 	if err != nil {
 		log.Printf("Could not read file: %v", err)
 		os.Exit(2)
 	}
-	_, err = configs.LoadSchedulerConfigFromByteArray(conf)
+	result := &checkResult{Valid: true}
+	exitCode := 0
+	schedulerConf, err := configs.LoadSchedulerConfigFromByteArray(conf)
 	if err != nil {
-		log.Printf("Config validation failed: %v", err)
-		os.Exit(3)
+		result.Valid = false
+		result.Error = err.Error()
+		exitCode = 3
+	} else {
+		result.Warnings = configs.CheckWarnings(schedulerConf)
+		if *dryRun != "" {
+			result.DryRun, err = dryRunConfig(*dryRun, *token, conf)
+			if err != nil {
+				log.Printf("Config dry-run failed: %v", err)
+				os.Exit(4)
+			}
+			if !result.DryRun.Allowed {
+				exitCode = 3
+			}
+		}
+	}
+	if err = printResult(result, *output); err != nil {
+		log.Printf("Could not format result: %v", err)
+		os.Exit(4)
+	}
+	os.Exit(exitCode)
+}
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// checkResult is the outcome of the checks of a configuration file.
+type checkResult struct {
+	Valid    bool                      `json:"valid"`
+	Error    string                    `json:"error,omitempty"`
+	Warnings []configs.ConfigWarning   `json:"warnings,omitempty"`
+	DryRun   *dao.ConfigDryRunResponse `json:"dryRun,omitempty"`
+}
+
+// printResult writes the result to stdout in the requested format.
+// In text format the validation error is logged as before, the dry-run result is always printed as JSON.
+func printResult(result *checkResult, output string) error {
+	if output == outputJSON {
+		content, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+		return nil
+	}
+	if !result.Valid {
+		log.Printf("Config validation failed: %s", result.Error)
+		return nil
+	}
+	for _, warning := range result.Warnings {
+		fmt.Printf("WARNING: %s\n", warning)
+	}
+	if result.DryRun != nil {
+		content, err := json.MarshalIndent(result.DryRun, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(content))
+	}
+	return nil
+}
+
+// dryRunConfig sends the config to the dry-run endpoint of the scheduler and returns the decoded result.
+func dryRunConfig(schedulerURL, token string, conf []byte) (*dao.ConfigDryRunResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(schedulerURL, "/")+"/ws/v1/config/dryrun", bytes.NewReader(conf))
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scheduler returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var result dao.ConfigDryRunResponse
+	if err = json.Unmarshal(body, &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
 }
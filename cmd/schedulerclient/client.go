@@ -20,93 +20,558 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"os"
+	"sync"
 	"time"
 
-	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
-
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
-)
 
-const (
-	address = "localhost:3333"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
-func main() {
-	if err := runApp(); err != nil {
-		log.Fatalf("error: %v", err)
+// schedulerClient executes operations against a running scheduler. The streams to the scheduler are opened on
+// first use and all responses received on them are printed.
+type schedulerClient struct {
+	rmID      string
+	partition string
+	timeout   time.Duration
+	out       *printer
+
+	ctx   context.Context
+	conn  *grpc.ClientConn
+	sched si.SchedulerClient
+
+	allocStream si.Scheduler_UpdateAllocationClient
+	appStream   si.Scheduler_UpdateApplicationClient
+	nodeStream  si.Scheduler_UpdateNodeClient
+
+	appResponses  chan *si.ApplicationResponse
+	nodeResponses chan *si.NodeResponse
+	receivers     sync.WaitGroup
+}
+
+// clientTLSConfig defines the TLS settings for the connection, TLS is used if any of the files is set.
+type clientTLSConfig struct {
+	caFile     string
+	certFile   string
+	keyFile    string
+	serverName string
+}
+
+func (c clientTLSConfig) transportCredentials() (credentials.TransportCredentials, error) {
+	if c.caFile == "" && c.certFile == "" && c.keyFile == "" {
+		return insecure.NewCredentials(), nil
 	}
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.serverName,
+	}
+	if c.caFile != "" {
+		pem, err := os.ReadFile(c.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid certificates found in CA file %s", c.caFile)
+		}
+	}
+	if c.certFile != "" || c.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
 }
 
-func runApp() error {
-	// Set up a connection to the server.
-	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func newSchedulerClient(ctx context.Context, endpoint string, tlsConfig clientTLSConfig, rmID, partition string, timeout time.Duration, out *printer) (*schedulerClient, error) {
+	creds, err := tlsConfig.transportCredentials()
 	if err != nil {
-		log.Fatalf("did not connect: %v", err)
+		return nil, err
 	}
-	defer conn.Close()
-	c := si.NewSchedulerClient(conn)
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Hour*100000)
-	defer cancel()
-	_, err = c.RegisterResourceManager(ctx, &si.RegisterResourceManagerRequest{})
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
 	if err != nil {
-		return fmt.Errorf("could not greet: %v", err)
+		return nil, fmt.Errorf("could not connect to %s: %w", endpoint, err)
+	}
+	return &schedulerClient{
+		rmID:          rmID,
+		partition:     partition,
+		timeout:       timeout,
+		out:           out,
+		ctx:           ctx,
+		conn:          conn,
+		sched:         si.NewSchedulerClient(conn),
+		appResponses:  make(chan *si.ApplicationResponse, 1024),
+		nodeResponses: make(chan *si.NodeResponse, 1024),
+	}, nil
+}
+
+// close closes the send side of all open streams and waits for the scheduler to close the streams. This makes
+// sure all requests have been received by the scheduler before the connection is closed.
+func (c *schedulerClient) close() {
+	if c.allocStream != nil {
+		_ = c.allocStream.CloseSend()
+	}
+	if c.appStream != nil {
+		_ = c.appStream.CloseSend()
+	}
+	if c.nodeStream != nil {
+		_ = c.nodeStream.CloseSend()
 	}
-	log.Printf("Responded")
+	done := make(chan struct{})
+	go func() {
+		c.receivers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(c.timeout):
+	}
+	_ = c.conn.Close()
+}
 
-	stream, err := c.UpdateAllocation(ctx)
+func (c *schedulerClient) run(ops []operation) error {
+	for i, op := range ops {
+		if err := c.execute(op); err != nil {
+			return fmt.Errorf("operation %d failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (c *schedulerClient) execute(op operation) error {
+	switch {
+	case op.Register != nil:
+		return c.register(op.Register)
+	case len(op.AddNodes) != 0:
+		return c.updateNodes(op.AddNodes, si.NodeInfo_CREATE)
+	case len(op.UpdateNodes) != 0:
+		return c.updateNodes(op.UpdateNodes, si.NodeInfo_UPDATE)
+	case len(op.RemoveNodes) != 0:
+		nodes := make([]nodeOp, len(op.RemoveNodes))
+		for i, nodeID := range op.RemoveNodes {
+			nodes[i] = nodeOp{NodeID: nodeID}
+		}
+		return c.updateNodes(nodes, si.NodeInfo_DECOMISSION)
+	case len(op.SubmitApps) != 0:
+		return c.submitApps(op.SubmitApps)
+	case len(op.RemoveApps) != 0:
+		return c.removeApps(op.RemoveApps)
+	case len(op.AddAsks) != 0:
+		var asks []*si.Allocation
+		for _, ask := range op.AddAsks {
+			asks = append(asks, ask.allocations(c.partition)...)
+		}
+		return c.addAsks(asks)
+	case len(op.Release) != 0:
+		return c.release(op.Release)
+	case op.Sleep != "":
+		duration, err := time.ParseDuration(op.Sleep)
+		if err != nil {
+			return err
+		}
+		return c.wait(duration)
+	case op.Tail != "":
+		duration, err := time.ParseDuration(op.Tail)
+		if err != nil {
+			return err
+		}
+		return c.tail(duration)
+	}
+	return fmt.Errorf("empty operation")
+}
+
+func (c *schedulerClient) register(op *registerOp) error {
+	req, err := op.registerRequest(c.rmID)
 	if err != nil {
-		return fmt.Errorf("error on update: %v", err)
+		return err
 	}
-	done := make(chan bool)
+	ctx, cancel := context.WithTimeout(c.ctx, c.timeout)
+	defer cancel()
+	if _, err = c.sched.RegisterResourceManager(ctx, req); err != nil {
+		return fmt.Errorf("registration failed: %w", err)
+	}
+	c.out.message("resource manager registered", "rmID", c.rmID, "policyGroup", req.PolicyGroup)
+	return nil
+}
 
-	// Connect to server and send streaming
-	// first goroutine sends requests
-	go func() {
-		for i := 1; i <= 10; i++ {
-			req := si.AllocationRequest{}
-			if err := stream.Send(&req); err != nil {
-				log.Fatalf("can not send %v", err)
+// updateNodes sends the node changes, only the addition of nodes is confirmed by the scheduler and waited for.
+func (c *schedulerClient) updateNodes(nodes []nodeOp, action si.NodeInfo_ActionFromRM) error {
+	if err := c.openNodeStream(); err != nil {
+		return err
+	}
+	req := &si.NodeRequest{RmID: c.rmID}
+	pending := make(map[string]bool)
+	for _, node := range nodes {
+		req.Nodes = append(req.Nodes, node.nodeInfo(action))
+		pending[node.NodeID] = true
+	}
+	if err := c.nodeStream.Send(req); err != nil {
+		return fmt.Errorf("could not send node request: %w", err)
+	}
+	if action != si.NodeInfo_CREATE {
+		return nil
+	}
+	var rejected []string
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	for len(pending) != 0 {
+		select {
+		case resp := <-c.nodeResponses:
+			for _, node := range resp.Accepted {
+				delete(pending, node.NodeID)
+			}
+			for _, node := range resp.Rejected {
+				if pending[node.NodeID] {
+					rejected = append(rejected, node.NodeID)
+				}
+				delete(pending, node.NodeID)
 			}
+		case <-timer.C:
+			return fmt.Errorf("timed out waiting for %d node(s) to be accepted", len(pending))
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
+	}
+	if len(rejected) != 0 {
+		return fmt.Errorf("nodes rejected: %v", rejected)
+	}
+	return nil
+}
 
-			log.Print("Send request")
-			time.Sleep(time.Millisecond * 100)
+// submitApps sends the new applications and waits for them to be accepted or rejected. Placeholders for the task
+// groups are only requested for applications that were accepted.
+func (c *schedulerClient) submitApps(apps []appOp) error {
+	if err := c.openApplicationStream(); err != nil {
+		return err
+	}
+	req := &si.ApplicationRequest{RmID: c.rmID}
+	pending := make(map[string]appOp)
+	for _, app := range apps {
+		add, err := app.addApplicationRequest(c.partition)
+		if err != nil {
+			return fmt.Errorf("application %s: %w", app.ApplicationID, err)
 		}
-	}()
+		req.New = append(req.New, add)
+		pending[app.ApplicationID] = app
+	}
+	if err := c.appStream.Send(req); err != nil {
+		return fmt.Errorf("could not send application request: %w", err)
+	}
+	var placeholders []*si.Allocation
+	var rejected []string
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	for len(pending) != 0 {
+		select {
+		case resp := <-c.appResponses:
+			for _, app := range resp.Accepted {
+				if op, ok := pending[app.ApplicationID]; ok {
+					placeholders = append(placeholders, op.placeholders(c.partition)...)
+					delete(pending, app.ApplicationID)
+				}
+			}
+			for _, app := range resp.Rejected {
+				if _, ok := pending[app.ApplicationID]; ok {
+					rejected = append(rejected, app.ApplicationID)
+					delete(pending, app.ApplicationID)
+				}
+			}
+		case <-timer.C:
+			return fmt.Errorf("timed out waiting for %d application(s) to be accepted", len(pending))
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		}
+	}
+	if len(placeholders) != 0 {
+		if err := c.addAsks(placeholders); err != nil {
+			return err
+		}
+	}
+	if len(rejected) != 0 {
+		return fmt.Errorf("applications rejected: %v", rejected)
+	}
+	return nil
+}
+
+func (c *schedulerClient) removeApps(appIDs []string) error {
+	if err := c.openApplicationStream(); err != nil {
+		return err
+	}
+	req := &si.ApplicationRequest{RmID: c.rmID}
+	for _, appID := range appIDs {
+		req.Remove = append(req.Remove, &si.RemoveApplicationRequest{
+			ApplicationID: appID,
+			PartitionName: c.partition,
+		})
+	}
+	if err := c.appStream.Send(req); err != nil {
+		return fmt.Errorf("could not send application request: %w", err)
+	}
+	return nil
+}
+
+func (c *schedulerClient) addAsks(asks []*si.Allocation) error {
+	if err := c.openAllocationStream(); err != nil {
+		return err
+	}
+	if err := c.allocStream.Send(&si.AllocationRequest{RmID: c.rmID, Allocations: asks}); err != nil {
+		return fmt.Errorf("could not send allocation request: %w", err)
+	}
+	return nil
+}
 
-	// second goroutine receives data from stream
-	// and saves result in max variable
-	//
-	// if stream is finished it closes done channel
+func (c *schedulerClient) release(releases []releaseOp) error {
+	if err := c.openAllocationStream(); err != nil {
+		return err
+	}
+	req := &si.AllocationRequest{
+		RmID:     c.rmID,
+		Releases: &si.AllocationReleasesRequest{},
+	}
+	for _, rel := range releases {
+		req.Releases.AllocationsToRelease = append(req.Releases.AllocationsToRelease, rel.allocationRelease(c.partition))
+	}
+	if err := c.allocStream.Send(req); err != nil {
+		return fmt.Errorf("could not send release request: %w", err)
+	}
+	return nil
+}
+
+// tail follows the allocation responses for the duration given, a zero duration follows until interrupted.
+// An empty request links the stream to the RM on the scheduler side.
+func (c *schedulerClient) tail(duration time.Duration) error {
+	if c.allocStream == nil {
+		if err := c.openAllocationStream(); err != nil {
+			return err
+		}
+		if err := c.allocStream.Send(&si.AllocationRequest{RmID: c.rmID}); err != nil {
+			return fmt.Errorf("could not attach allocation stream: %w", err)
+		}
+	}
+	return c.wait(duration)
+}
+
+// wait blocks for the duration given or until the client is interrupted, a zero duration waits until interrupted.
+func (c *schedulerClient) wait(duration time.Duration) error {
+	if duration <= 0 {
+		<-c.ctx.Done()
+		return nil
+	}
+	select {
+	case <-time.After(duration):
+	case <-c.ctx.Done():
+	}
+	return nil
+}
+
+func (c *schedulerClient) openAllocationStream() error {
+	if c.allocStream != nil {
+		return nil
+	}
+	stream, err := c.sched.UpdateAllocation(c.ctx)
+	if err != nil {
+		return fmt.Errorf("could not open allocation stream: %w", err)
+	}
+	c.allocStream = stream
+	c.receivers.Add(1)
 	go func() {
+		defer c.receivers.Done()
 		for {
-			_, err := stream.Recv()
-			if err == io.EOF {
-				close(done)
+			resp, err := stream.Recv()
+			if err != nil {
+				c.streamClosed("allocation", err)
 				return
 			}
+			c.out.allocationResponse(resp)
+		}
+	}()
+	return nil
+}
+
+func (c *schedulerClient) openApplicationStream() error {
+	if c.appStream != nil {
+		return nil
+	}
+	stream, err := c.sched.UpdateApplication(c.ctx)
+	if err != nil {
+		return fmt.Errorf("could not open application stream: %w", err)
+	}
+	c.appStream = stream
+	c.receivers.Add(1)
+	go func() {
+		defer c.receivers.Done()
+		for {
+			resp, err := stream.Recv()
 			if err != nil {
-				log.Fatalf("can not receive %v", err)
+				c.streamClosed("application", err)
+				return
+			}
+			c.out.applicationResponse(resp)
+			select {
+			case c.appResponses <- resp:
+			default:
 			}
-			log.Printf("Responded by server")
 		}
 	}()
+	return nil
+}
 
-	// third goroutine closes done channel
-	// if context is done
+func (c *schedulerClient) openNodeStream() error {
+	if c.nodeStream != nil {
+		return nil
+	}
+	stream, err := c.sched.UpdateNode(c.ctx)
+	if err != nil {
+		return fmt.Errorf("could not open node stream: %w", err)
+	}
+	c.nodeStream = stream
+	c.receivers.Add(1)
 	go func() {
-		<-ctx.Done()
-		if err := ctx.Err(); err != nil {
-			log.Println(err)
+		defer c.receivers.Done()
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				c.streamClosed("node", err)
+				return
+			}
+			c.out.nodeResponse(resp)
+			select {
+			case c.nodeResponses <- resp:
+			default:
+			}
 		}
-		close(done)
 	}()
-
-	<-done
-	log.Printf("Finished")
 	return nil
 }
+
+func (c *schedulerClient) streamClosed(name string, err error) {
+	if err == io.EOF || c.ctx.Err() != nil {
+		return
+	}
+	c.out.message(name+" stream closed", "error", err.Error())
+}
+
+// printer writes responses received from the scheduler as text or as JSON, one response per line.
+type printer struct {
+	jsonOutput bool
+	w          io.Writer
+	sync.Mutex
+}
+
+func newPrinter(format string) (*printer, error) {
+	switch format {
+	case "text":
+		return &printer{w: os.Stdout}, nil
+	case "json":
+		return &printer{w: os.Stdout, jsonOutput: true}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// message prints an informational line, fields are given as key value pairs.
+func (p *printer) message(msg string, fields ...string) {
+	p.Lock()
+	defer p.Unlock()
+	if p.jsonOutput {
+		m := map[string]string{"message": msg}
+		for i := 0; i+1 < len(fields); i += 2 {
+			m[fields[i]] = fields[i+1]
+		}
+		p.writeJSON("message", m)
+		return
+	}
+	line := msg
+	for i := 0; i+1 < len(fields); i += 2 {
+		line += fmt.Sprintf(" %s=%s", fields[i], fields[i+1])
+	}
+	fmt.Fprintln(p.w, line)
+}
+
+func (p *printer) allocationResponse(resp *si.AllocationResponse) {
+	p.Lock()
+	defer p.Unlock()
+	if p.jsonOutput {
+		p.writeJSON("allocation", resp)
+		return
+	}
+	for _, alloc := range resp.New {
+		fmt.Fprintf(p.w, "allocated: app=%s key=%s node=%s resource=%s\n", alloc.ApplicationID, alloc.AllocationKey, alloc.NodeID, formatResource(alloc.ResourcePerAlloc))
+	}
+	for _, rel := range resp.Released {
+		fmt.Fprintf(p.w, "released: app=%s key=%s type=%s message=%q\n", rel.ApplicationID, rel.AllocationKey, rel.TerminationType, rel.Message)
+	}
+	for _, rej := range resp.RejectedAllocations {
+		fmt.Fprintf(p.w, "rejected allocation: app=%s key=%s reason=%q\n", rej.ApplicationID, rej.AllocationKey, rej.Reason)
+	}
+}
+
+func (p *printer) applicationResponse(resp *si.ApplicationResponse) {
+	p.Lock()
+	defer p.Unlock()
+	if p.jsonOutput {
+		p.writeJSON("application", resp)
+		return
+	}
+	for _, app := range resp.Accepted {
+		fmt.Fprintf(p.w, "application accepted: app=%s\n", app.ApplicationID)
+	}
+	for _, app := range resp.Rejected {
+		fmt.Fprintf(p.w, "application rejected: app=%s reason=%q\n", app.ApplicationID, app.Reason)
+	}
+	for _, app := range resp.Updated {
+		fmt.Fprintf(p.w, "application updated: app=%s state=%s message=%q\n", app.ApplicationID, app.State, app.Message)
+	}
+}
+
+func (p *printer) nodeResponse(resp *si.NodeResponse) {
+	p.Lock()
+	defer p.Unlock()
+	if p.jsonOutput {
+		p.writeJSON("node", resp)
+		return
+	}
+	for _, node := range resp.Accepted {
+		fmt.Fprintf(p.w, "node accepted: node=%s\n", node.NodeID)
+	}
+	for _, node := range resp.Rejected {
+		fmt.Fprintf(p.w, "node rejected: node=%s reason=%q\n", node.NodeID, node.Reason)
+	}
+}
+
+func (p *printer) writeJSON(kind string, value interface{}) {
+	line, err := json.Marshal(map[string]interface{}{
+		"type":      kind,
+		"timestamp": time.Now().Format(time.RFC3339Nano),
+		"response":  value,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not marshal %s response: %v\n", kind, err)
+		return
+	}
+	fmt.Fprintln(p.w, string(line))
+}
+
+func formatResource(res *si.Resource) string {
+	if res == nil {
+		return "{}"
+	}
+	values := make(map[string]int64, len(res.Resources))
+	for name, quantity := range res.Resources {
+		values[name] = quantity.GetValue()
+	}
+	out, err := json.Marshal(values)
+	if err != nil {
+		return "{}"
+	}
+	return string(out)
+}
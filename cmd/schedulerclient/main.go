@@ -0,0 +1,325 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const usage = `Usage: %s [global flags] <command> [flags]
+
+Commands:
+  register     register the resource manager, this resets all state kept for a registered RM
+  node         add, update or remove nodes:      node add|update|remove -id <node> [flags]
+  app          submit or remove applications:    app submit|remove -id <app> [flags]
+  ask          add asks to an application:       ask add -app <app> -key <key> [flags]
+  release      release allocations or asks:      release -app <app> [-key <key>]
+  tail         print the allocation responses:   tail [-duration <duration>]
+  run          run the operations from a YAML or JSON script: run <script>
+
+Run "%s <command> -h" for the flags of a command.
+
+Global flags:
+`
+
+// keyValueFlag collects repeated key=value flags into a map
+type keyValueFlag map[string]string
+
+func (kv keyValueFlag) String() string {
+	pairs := make([]string, 0, len(kv))
+	for k, v := range kv {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (kv keyValueFlag) Set(value string) error {
+	k, v, ok := strings.Cut(value, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	kv[k] = v
+	return nil
+}
+
+// listFlag collects repeated flags into a slice
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func main() {
+	if err := runApp(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runApp(args []string) error {
+	global := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	endpoint := global.String("endpoint", "localhost:3333", "gRPC address of the scheduler")
+	rmID := global.String("rmid", "schedulerclient", "ID of the resource manager")
+	partition := global.String("partition", "default", "partition to use for nodes, applications and asks")
+	timeout := global.Duration("timeout", 10*time.Second, "time to wait for the scheduler to respond")
+	output := global.String("output", "text", "output format for responses: text or json")
+	tlsConfig := clientTLSConfig{}
+	global.StringVar(&tlsConfig.caFile, "tls-ca", "", "CA file to verify the server certificate, enables TLS")
+	global.StringVar(&tlsConfig.certFile, "tls-cert", "", "client certificate file for mutual TLS, the identity must match the RM ID")
+	global.StringVar(&tlsConfig.keyFile, "tls-key", "", "client private key file for mutual TLS")
+	global.StringVar(&tlsConfig.serverName, "tls-server-name", "", "override the server name used to verify the server certificate")
+	global.Usage = func() {
+		fmt.Fprintf(global.Output(), usage, os.Args[0], os.Args[0])
+		global.PrintDefaults()
+	}
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return fmt.Errorf("no command given")
+	}
+	out, err := newPrinter(*output)
+	if err != nil {
+		return err
+	}
+
+	var ops []operation
+	cmd, cmdArgs := global.Arg(0), global.Args()[1:]
+	if cmd == "run" {
+		if len(cmdArgs) != 1 {
+			return fmt.Errorf("run expects exactly one script file")
+		}
+		var s *script
+		if s, err = loadScript(cmdArgs[0]); err != nil {
+			return err
+		}
+		if s.RmID != "" {
+			*rmID = s.RmID
+		}
+		if s.Partition != "" {
+			*partition = s.Partition
+		}
+		ops = s.Operations
+	} else {
+		var op operation
+		if op, err = parseCommand(cmd, cmdArgs); err != nil {
+			return err
+		}
+		ops = []operation{op}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	client, err := newSchedulerClient(ctx, *endpoint, tlsConfig, *rmID, *partition, *timeout, out)
+	if err != nil {
+		return err
+	}
+	defer client.close()
+	return client.run(ops)
+}
+
+// parseCommand converts a command line into the same operation that a script would contain.
+func parseCommand(cmd string, args []string) (operation, error) {
+	switch cmd {
+	case "register":
+		return parseRegister(args)
+	case "node":
+		return parseNode(args)
+	case "app":
+		return parseApp(args)
+	case "ask":
+		return parseAsk(args)
+	case "release":
+		return parseRelease(args)
+	case "tail":
+		return parseTail(args)
+	}
+	return operation{}, fmt.Errorf("unknown command %q", cmd)
+}
+
+// subCommand splits off the action for commands that have one, like "node add"
+func subCommand(cmd string, args []string, actions ...string) (string, []string, error) {
+	if len(args) != 0 {
+		for _, action := range actions {
+			if args[0] == action {
+				return action, args[1:], nil
+			}
+		}
+	}
+	return "", nil, fmt.Errorf("%s expects one of: %s", cmd, strings.Join(actions, ", "))
+}
+
+func parseRegister(args []string) (operation, error) {
+	fs := flag.NewFlagSet("register", flag.ContinueOnError)
+	config := fs.String("config", "", "queue configuration file to send with the registration")
+	policyGroup := fs.String("policy-group", "queues", "policy group of the resource manager")
+	extra := keyValueFlag{}
+	fs.Var(extra, "extra", "extra configuration as key=value, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return operation{}, err
+	}
+	return operation{Register: &registerOp{
+		PolicyGroup: *policyGroup,
+		ConfigFile:  *config,
+		ExtraConfig: extra,
+	}}, nil
+}
+
+func parseNode(args []string) (operation, error) {
+	action, args, err := subCommand("node", args, "add", "update", "remove")
+	if err != nil {
+		return operation{}, err
+	}
+	fs := flag.NewFlagSet("node "+action, flag.ContinueOnError)
+	nodeID := fs.String("id", "", "ID of the node")
+	resource := fs.String("resource", "", "schedulable resource of the node: memory=1000,vcore=10")
+	attributes := keyValueFlag{}
+	fs.Var(attributes, "attr", "node attribute as key=value, can be repeated")
+	if err = fs.Parse(args); err != nil {
+		return operation{}, err
+	}
+	if *nodeID == "" {
+		return operation{}, fmt.Errorf("node %s: -id is required", action)
+	}
+	if action == "remove" {
+		return operation{RemoveNodes: []string{*nodeID}}, nil
+	}
+	res, err := parseResource(*resource)
+	if err != nil {
+		return operation{}, err
+	}
+	node := nodeOp{NodeID: *nodeID, Resources: res, Attributes: attributes}
+	if action == "add" {
+		return operation{AddNodes: []nodeOp{node}}, nil
+	}
+	return operation{UpdateNodes: []nodeOp{node}}, nil
+}
+
+func parseApp(args []string) (operation, error) {
+	action, args, err := subCommand("app", args, "submit", "remove")
+	if err != nil {
+		return operation{}, err
+	}
+	fs := flag.NewFlagSet("app "+action, flag.ContinueOnError)
+	appID := fs.String("id", "", "ID of the application")
+	queue := fs.String("queue", "", "queue to submit the application to")
+	user := fs.String("user", "", "user that submits the application")
+	groups := fs.String("groups", "", "comma separated groups of the user")
+	placeholderTimeout := fs.String("placeholder-timeout", "", "timeout for the gang placeholders, for example 5m")
+	gangStyle := fs.String("gang-style", "", "gang scheduling style: Soft or Hard")
+	tags := keyValueFlag{}
+	fs.Var(tags, "tag", "application tag as key=value, can be repeated")
+	var taskGroups listFlag
+	fs.Var(&taskGroups, "task-group", "task group as name:minMember:resource, for example tg1:3:memory=1000,vcore=1, can be repeated")
+	if err = fs.Parse(args); err != nil {
+		return operation{}, err
+	}
+	if *appID == "" {
+		return operation{}, fmt.Errorf("app %s: -id is required", action)
+	}
+	if action == "remove" {
+		return operation{RemoveApps: []string{*appID}}, nil
+	}
+	app := appOp{
+		ApplicationID:      *appID,
+		Queue:              *queue,
+		User:               *user,
+		Tags:               tags,
+		PlaceholderTimeout: *placeholderTimeout,
+		GangStyle:          *gangStyle,
+	}
+	if *groups != "" {
+		app.Groups = strings.Split(*groups, ",")
+	}
+	for _, spec := range taskGroups {
+		var tg taskGroupOp
+		if tg, err = parseTaskGroup(spec); err != nil {
+			return operation{}, err
+		}
+		app.TaskGroups = append(app.TaskGroups, tg)
+	}
+	op := operation{SubmitApps: []appOp{app}}
+	return op, op.validate()
+}
+
+func parseAsk(args []string) (operation, error) {
+	_, args, err := subCommand("ask", args, "add")
+	if err != nil {
+		return operation{}, err
+	}
+	fs := flag.NewFlagSet("ask add", flag.ContinueOnError)
+	appID := fs.String("app", "", "ID of the application")
+	key := fs.String("key", "", "allocation key of the ask, with a count larger than 1 an index is added")
+	count := fs.Int("count", 1, "number of asks to add")
+	resource := fs.String("resource", "", "resource per ask: memory=1000,vcore=1")
+	priority := fs.Int("priority", 0, "priority of the ask")
+	taskGroup := fs.String("task-group", "", "task group the ask belongs to")
+	tags := keyValueFlag{}
+	fs.Var(tags, "tag", "allocation tag as key=value, can be repeated")
+	if err = fs.Parse(args); err != nil {
+		return operation{}, err
+	}
+	res, err := parseResource(*resource)
+	if err != nil {
+		return operation{}, err
+	}
+	op := operation{AddAsks: []askOp{{
+		ApplicationID: *appID,
+		AllocationKey: *key,
+		Count:         *count,
+		Resources:     res,
+		Priority:      int32(*priority),
+		Tags:          tags,
+		TaskGroup:     *taskGroup,
+	}}}
+	return op, op.validate()
+}
+
+func parseRelease(args []string) (operation, error) {
+	fs := flag.NewFlagSet("release", flag.ContinueOnError)
+	appID := fs.String("app", "", "ID of the application")
+	key := fs.String("key", "", "allocation key to release, releases all allocations of the application if not set")
+	if err := fs.Parse(args); err != nil {
+		return operation{}, err
+	}
+	op := operation{Release: []releaseOp{{ApplicationID: *appID, AllocationKey: *key}}}
+	return op, op.validate()
+}
+
+func parseTail(args []string) (operation, error) {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	duration := fs.Duration("duration", 0, "time to follow the responses, follows until interrupted if not set")
+	if err := fs.Parse(args); err != nil {
+		return operation{}, err
+	}
+	return operation{Tail: duration.String()}, nil
+}
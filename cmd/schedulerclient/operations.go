@@ -0,0 +1,328 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// script is the content of a file with operations to execute, the file can be in YAML or JSON format.
+// The RM ID and partition in the script override the values set on the command line.
+type script struct {
+	RmID       string      `yaml:"rmID,omitempty" json:"rmID,omitempty"`
+	Partition  string      `yaml:"partition,omitempty" json:"partition,omitempty"`
+	Operations []operation `yaml:"operations" json:"operations"`
+}
+
+// operation is one step in a script, exactly one of the fields must be set.
+type operation struct {
+	Register    *registerOp `yaml:"register,omitempty" json:"register,omitempty"`
+	AddNodes    []nodeOp    `yaml:"addNodes,omitempty" json:"addNodes,omitempty"`
+	UpdateNodes []nodeOp    `yaml:"updateNodes,omitempty" json:"updateNodes,omitempty"`
+	RemoveNodes []string    `yaml:"removeNodes,omitempty" json:"removeNodes,omitempty"`
+	SubmitApps  []appOp     `yaml:"submitApps,omitempty" json:"submitApps,omitempty"`
+	RemoveApps  []string    `yaml:"removeApps,omitempty" json:"removeApps,omitempty"`
+	AddAsks     []askOp     `yaml:"addAsks,omitempty" json:"addAsks,omitempty"`
+	Release     []releaseOp `yaml:"release,omitempty" json:"release,omitempty"`
+	Sleep       string      `yaml:"sleep,omitempty" json:"sleep,omitempty"`
+	Tail        string      `yaml:"tail,omitempty" json:"tail,omitempty"`
+}
+
+type registerOp struct {
+	PolicyGroup string            `yaml:"policyGroup,omitempty" json:"policyGroup,omitempty"`
+	ConfigFile  string            `yaml:"configFile,omitempty" json:"configFile,omitempty"`
+	ExtraConfig map[string]string `yaml:"extraConfig,omitempty" json:"extraConfig,omitempty"`
+}
+
+type nodeOp struct {
+	NodeID     string            `yaml:"nodeID" json:"nodeID"`
+	Resources  map[string]int64  `yaml:"resources,omitempty" json:"resources,omitempty"`
+	Attributes map[string]string `yaml:"attributes,omitempty" json:"attributes,omitempty"`
+}
+
+type taskGroupOp struct {
+	Name        string           `yaml:"name" json:"name"`
+	MinMember   int              `yaml:"minMember" json:"minMember"`
+	MinResource map[string]int64 `yaml:"minResource" json:"minResource"`
+}
+
+type appOp struct {
+	ApplicationID      string            `yaml:"applicationID" json:"applicationID"`
+	Queue              string            `yaml:"queue,omitempty" json:"queue,omitempty"`
+	User               string            `yaml:"user,omitempty" json:"user,omitempty"`
+	Groups             []string          `yaml:"groups,omitempty" json:"groups,omitempty"`
+	Tags               map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	TaskGroups         []taskGroupOp     `yaml:"taskGroups,omitempty" json:"taskGroups,omitempty"`
+	PlaceholderTimeout string            `yaml:"placeholderTimeout,omitempty" json:"placeholderTimeout,omitempty"`
+	GangStyle          string            `yaml:"gangStyle,omitempty" json:"gangStyle,omitempty"`
+}
+
+type askOp struct {
+	ApplicationID string            `yaml:"applicationID" json:"applicationID"`
+	AllocationKey string            `yaml:"allocationKey" json:"allocationKey"`
+	Count         int               `yaml:"count,omitempty" json:"count,omitempty"`
+	Resources     map[string]int64  `yaml:"resources" json:"resources"`
+	Priority      int32             `yaml:"priority,omitempty" json:"priority,omitempty"`
+	Tags          map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	TaskGroup     string            `yaml:"taskGroup,omitempty" json:"taskGroup,omitempty"`
+}
+
+type releaseOp struct {
+	ApplicationID string `yaml:"applicationID" json:"applicationID"`
+	AllocationKey string `yaml:"allocationKey,omitempty" json:"allocationKey,omitempty"`
+}
+
+// loadScript reads the script file, JSON is a subset of YAML and is handled by the same parser.
+func loadScript(path string) (*script, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read script file: %w", err)
+	}
+	s := &script{}
+	if err = yaml.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("could not parse script file: %w", err)
+	}
+	for i, op := range s.Operations {
+		if err = op.validate(); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i+1, err)
+		}
+	}
+	return s, nil
+}
+
+func (op operation) validate() error {
+	set := 0
+	if op.Register != nil {
+		set++
+	}
+	for _, l := range []int{len(op.AddNodes), len(op.UpdateNodes), len(op.RemoveNodes), len(op.SubmitApps),
+		len(op.RemoveApps), len(op.AddAsks), len(op.Release), len(op.Sleep), len(op.Tail)} {
+		if l != 0 {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one action must be set, found %d", set)
+	}
+	for _, node := range append(op.AddNodes, op.UpdateNodes...) {
+		if node.NodeID == "" {
+			return fmt.Errorf("node without nodeID")
+		}
+	}
+	for _, app := range op.SubmitApps {
+		if app.ApplicationID == "" {
+			return fmt.Errorf("application without applicationID")
+		}
+		for _, tg := range app.TaskGroups {
+			if tg.Name == "" || tg.MinMember <= 0 {
+				return fmt.Errorf("application %s: task group must have a name and a positive minMember", app.ApplicationID)
+			}
+		}
+	}
+	for _, ask := range op.AddAsks {
+		if ask.ApplicationID == "" || ask.AllocationKey == "" {
+			return fmt.Errorf("ask must have an applicationID and allocationKey")
+		}
+	}
+	for _, rel := range op.Release {
+		if rel.ApplicationID == "" {
+			return fmt.Errorf("release without applicationID")
+		}
+	}
+	if op.Sleep != "" {
+		if _, err := time.ParseDuration(op.Sleep); err != nil {
+			return fmt.Errorf("invalid sleep duration: %w", err)
+		}
+	}
+	if op.Tail != "" {
+		if _, err := time.ParseDuration(op.Tail); err != nil {
+			return fmt.Errorf("invalid tail duration: %w", err)
+		}
+	}
+	return nil
+}
+
+// registerRequest converts the operation into the request for the scheduler, the config file is read if set.
+func (op *registerOp) registerRequest(rmID string) (*si.RegisterResourceManagerRequest, error) {
+	req := &si.RegisterResourceManagerRequest{
+		RmID:        rmID,
+		PolicyGroup: op.PolicyGroup,
+		Version:     "schedulerclient",
+		ExtraConfig: op.ExtraConfig,
+	}
+	if req.PolicyGroup == "" {
+		req.PolicyGroup = "queues"
+	}
+	if op.ConfigFile != "" {
+		conf, err := os.ReadFile(op.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read config file: %w", err)
+		}
+		req.Config = string(conf)
+	}
+	return req, nil
+}
+
+func (n nodeOp) nodeInfo(action si.NodeInfo_ActionFromRM) *si.NodeInfo {
+	return &si.NodeInfo{
+		NodeID:              n.NodeID,
+		Action:              action,
+		Attributes:          n.Attributes,
+		SchedulableResource: toSIResource(n.Resources),
+	}
+}
+
+// addApplicationRequest converts the operation into the request for the scheduler. The placeholder ask is the
+// total of all task group members.
+func (a appOp) addApplicationRequest(partition string) (*si.AddApplicationRequest, error) {
+	req := &si.AddApplicationRequest{
+		ApplicationID:       a.ApplicationID,
+		QueueName:           a.Queue,
+		PartitionName:       partition,
+		Ugi:                 &si.UserGroupInformation{User: a.User, Groups: a.Groups},
+		Tags:                a.Tags,
+		GangSchedulingStyle: a.GangStyle,
+	}
+	if a.PlaceholderTimeout != "" {
+		timeout, err := time.ParseDuration(a.PlaceholderTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid placeholder timeout: %w", err)
+		}
+		req.ExecutionTimeoutMilliSeconds = timeout.Milliseconds()
+	}
+	if len(a.TaskGroups) != 0 {
+		total := make(map[string]int64)
+		for _, tg := range a.TaskGroups {
+			for name, value := range tg.MinResource {
+				total[name] += value * int64(tg.MinMember)
+			}
+		}
+		req.PlaceholderAsk = toSIResource(total)
+	}
+	return req, nil
+}
+
+// placeholders returns one placeholder ask for each member of each task group of the application.
+func (a appOp) placeholders(partition string) []*si.Allocation {
+	var asks []*si.Allocation
+	for _, tg := range a.TaskGroups {
+		for i := 0; i < tg.MinMember; i++ {
+			asks = append(asks, &si.Allocation{
+				AllocationKey:    fmt.Sprintf("%s-%s-%d", a.ApplicationID, tg.Name, i),
+				ApplicationID:    a.ApplicationID,
+				PartitionName:    partition,
+				ResourcePerAlloc: toSIResource(tg.MinResource),
+				TaskGroupName:    tg.Name,
+				Placeholder:      true,
+			})
+		}
+	}
+	return asks
+}
+
+// allocations returns the asks for the operation, for a count larger than one the key gets an index suffix.
+func (a askOp) allocations(partition string) []*si.Allocation {
+	count := a.Count
+	if count <= 0 {
+		count = 1
+	}
+	asks := make([]*si.Allocation, 0, count)
+	for i := 0; i < count; i++ {
+		key := a.AllocationKey
+		if count > 1 {
+			key = fmt.Sprintf("%s-%d", a.AllocationKey, i)
+		}
+		asks = append(asks, &si.Allocation{
+			AllocationKey:    key,
+			ApplicationID:    a.ApplicationID,
+			PartitionName:    partition,
+			ResourcePerAlloc: toSIResource(a.Resources),
+			Priority:         a.Priority,
+			AllocationTags:   a.Tags,
+			TaskGroupName:    a.TaskGroup,
+		})
+	}
+	return asks
+}
+
+func (r releaseOp) allocationRelease(partition string) *si.AllocationRelease {
+	return &si.AllocationRelease{
+		PartitionName:   partition,
+		ApplicationID:   r.ApplicationID,
+		AllocationKey:   r.AllocationKey,
+		TerminationType: si.TerminationType_STOPPED_BY_RM,
+		Message:         "released by schedulerclient",
+	}
+}
+
+func toSIResource(res map[string]int64) *si.Resource {
+	if len(res) == 0 {
+		return nil
+	}
+	result := &si.Resource{Resources: make(map[string]*si.Quantity, len(res))}
+	for name, value := range res {
+		result.Resources[name] = &si.Quantity{Value: value}
+	}
+	return result
+}
+
+// parseResource parses a resource given as a comma separated list of name=value pairs: "memory=1000,vcore=1"
+func parseResource(value string) (map[string]int64, error) {
+	res := make(map[string]int64)
+	if value == "" {
+		return res, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, quantity, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid resource %q, expected name=value", pair)
+		}
+		v, err := strconv.ParseInt(quantity, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity for resource %s: %w", name, err)
+		}
+		res[name] = v
+	}
+	return res, nil
+}
+
+// parseTaskGroup parses a task group given as name:minMember:resource, for example "tg1:3:memory=1000,vcore=1"
+func parseTaskGroup(value string) (taskGroupOp, error) {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) != 3 || parts[0] == "" {
+		return taskGroupOp{}, fmt.Errorf("invalid task group %q, expected name:minMember:resource", value)
+	}
+	members, err := strconv.Atoi(parts[1])
+	if err != nil || members <= 0 {
+		return taskGroupOp{}, fmt.Errorf("invalid minMember for task group %s", parts[0])
+	}
+	res, err := parseResource(parts[2])
+	if err != nil {
+		return taskGroupOp{}, err
+	}
+	return taskGroupOp{Name: parts[0], MinMember: members, MinResource: res}, nil
+}
@@ -21,10 +21,24 @@ package main
 import (
 	"flag"
 	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/scheduler"
 )
 
 var (
-	endpoint = flag.String("endpoint", "tcp://localhost:3333", "YuniKorn endpoint")
+	endpoint    = flag.String("endpoint", "tcp://localhost:3333", "YuniKorn endpoint")
+	tlsCert     = flag.String("tls-cert", "", "server certificate file, enables TLS when set together with the key")
+	tlsKey      = flag.String("tls-key", "", "server private key file")
+	tlsClientCA = flag.String("tls-client-ca", "", "CA file to verify client certificates, enables mutual TLS")
+	webTLSCert  = flag.String("web-tls-cert", "", "REST server certificate file, enables HTTPS when set together with the key")
+	webTLSKey   = flag.String("web-tls-key", "", "REST server private key file")
+	webClientCA = flag.String("web-tls-client-ca", "", "CA file to verify REST client certificates used for authentication")
+	queuesFile  = flag.String("queues-file", "", "scheduler config file to watch and apply after an RM registers")
+	configFile  = flag.String("configmap-file", "", "key=value file with config map settings to watch and apply after an RM registers")
+	configCheck = flag.Duration("config-check-interval", scheduler.DefaultConfigFileCheckInterval, "interval to check the config files for changes")
 )
 
 func main() {
@@ -34,6 +48,26 @@ func main() {
 }
 
 func handle() {
-	scheduler := &SimpleScheduler{}
-	scheduler.Run(*endpoint)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	server := newSchedulerServer()
+	server.Run(*endpoint, &common.ServerTLSConfig{
+		CertFile:     *tlsCert,
+		KeyFile:      *tlsKey,
+		ClientCAFile: *tlsClientCA,
+	}, &common.ServerTLSConfig{
+		CertFile:     *webTLSCert,
+		KeyFile:      *webTLSKey,
+		ClientCAFile: *webClientCA,
+	}, &configFiles{
+		queuesFile:    *queuesFile,
+		configMapFile: *configFile,
+		interval:      *configCheck,
+	}, stop)
 }
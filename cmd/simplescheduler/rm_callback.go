@@ -0,0 +1,274 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// sendQueueSize is the number of responses queued for a stream before it is dropped
+const sendQueueSize = 1024
+
+var errStreamTooSlow = errors.New("stream dropped: responses are not received fast enough")
+
+// streamSender sends the responses for one stream from its own goroutine. A stream that does not keep up with the
+// responses is dropped: a slow client, like one that follows the responses, must never block the core.
+type streamSender[T any] struct {
+	send  func(T) error
+	queue chan T
+	done  chan struct{}
+	err   error // reason the sender stopped, only read after done is closed
+	once  sync.Once
+}
+
+func newStreamSender[T any](send func(T) error) *streamSender[T] {
+	sender := &streamSender[T]{
+		send:  send,
+		queue: make(chan T, sendQueueSize),
+		done:  make(chan struct{}),
+	}
+	go sender.run()
+	return sender
+}
+
+func (sender *streamSender[T]) run() {
+	for {
+		// a stopped sender must not send queued responses
+		select {
+		case <-sender.done:
+			return
+		default:
+		}
+		select {
+		case response := <-sender.queue:
+			if err := sender.send(response); err != nil {
+				sender.stop(err)
+				return
+			}
+		case <-sender.done:
+			return
+		}
+	}
+}
+
+// offer queues the response without blocking. The sender is stopped if the queue is full.
+func (sender *streamSender[T]) offer(response T) error {
+	select {
+	case <-sender.done:
+		return sender.err
+	default:
+	}
+	select {
+	case sender.queue <- response:
+		return nil
+	default:
+		sender.stop(errStreamTooSlow)
+		return errStreamTooSlow
+	}
+}
+
+// stop stops sending, queued responses are discarded. Only the first reason is kept.
+func (sender *streamSender[T]) stop(err error) {
+	sender.once.Do(func() {
+		sender.err = err
+		close(sender.done)
+	})
+}
+
+// serve runs the receive loop of the stream until it returns or the sender is stopped. The stream is closed by
+// returning from the gRPC handler, which also ends a receive loop that is still blocked.
+func (sender *streamSender[T]) serve(receive func() error) error {
+	received := make(chan error, 1)
+	go func() {
+		received <- receive()
+	}()
+	select {
+	case err := <-received:
+		return err
+	case <-sender.done:
+		return sender.err
+	}
+}
+
+// offerAll queues the response on all senders and returns the combined errors of the senders that were dropped.
+func offerAll[T any](senders []*streamSender[T], response T) error {
+	var errs []error
+	for _, sender := range senders {
+		if err := sender.offer(response); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeSender removes the sender from the list and stops it.
+func removeSender[T any](senders []*streamSender[T], sender *streamSender[T]) []*streamSender[T] {
+	sender.stop(nil)
+	for i, s := range senders {
+		if s == sender {
+			return append(senders[:i], senders[i+1:]...)
+		}
+	}
+	return senders
+}
+
+// grpcRMCallback implements the api.ResourceManagerCallback for a resource manager that is connected over gRPC.
+// Responses from the core are pushed back to the RM over the streams the RM has opened. An RM can have more than
+// one stream of each type connected at the same time, for instance a client that only follows the allocation
+// responses, all connected streams receive a copy of each response. Each stream is sent to by its own
+// streamSender so that one stream cannot hold up the others or the core.
+type grpcRMCallback struct {
+	rmID         string
+	allocStreams []*streamSender[*si.AllocationResponse]
+	appStreams   []*streamSender[*si.ApplicationResponse]
+	nodeStreams  []*streamSender[*si.NodeResponse]
+
+	locking.RWMutex
+}
+
+func newGRPCRMCallback(rmID string) *grpcRMCallback {
+	return &grpcRMCallback{
+		rmID: rmID,
+	}
+}
+
+func (cb *grpcRMCallback) addAllocationStream(stream si.Scheduler_UpdateAllocationServer) *streamSender[*si.AllocationResponse] {
+	cb.Lock()
+	defer cb.Unlock()
+	sender := newStreamSender(stream.Send)
+	cb.allocStreams = append(cb.allocStreams, sender)
+	return sender
+}
+
+func (cb *grpcRMCallback) removeAllocationStream(sender *streamSender[*si.AllocationResponse]) {
+	cb.Lock()
+	defer cb.Unlock()
+	cb.allocStreams = removeSender(cb.allocStreams, sender)
+}
+
+func (cb *grpcRMCallback) getAllocationStreams() []*streamSender[*si.AllocationResponse] {
+	cb.RLock()
+	defer cb.RUnlock()
+	return append([]*streamSender[*si.AllocationResponse](nil), cb.allocStreams...)
+}
+
+func (cb *grpcRMCallback) addApplicationStream(stream si.Scheduler_UpdateApplicationServer) *streamSender[*si.ApplicationResponse] {
+	cb.Lock()
+	defer cb.Unlock()
+	sender := newStreamSender(stream.Send)
+	cb.appStreams = append(cb.appStreams, sender)
+	return sender
+}
+
+func (cb *grpcRMCallback) removeApplicationStream(sender *streamSender[*si.ApplicationResponse]) {
+	cb.Lock()
+	defer cb.Unlock()
+	cb.appStreams = removeSender(cb.appStreams, sender)
+}
+
+func (cb *grpcRMCallback) getApplicationStreams() []*streamSender[*si.ApplicationResponse] {
+	cb.RLock()
+	defer cb.RUnlock()
+	return append([]*streamSender[*si.ApplicationResponse](nil), cb.appStreams...)
+}
+
+func (cb *grpcRMCallback) addNodeStream(stream si.Scheduler_UpdateNodeServer) *streamSender[*si.NodeResponse] {
+	cb.Lock()
+	defer cb.Unlock()
+	sender := newStreamSender(stream.Send)
+	cb.nodeStreams = append(cb.nodeStreams, sender)
+	return sender
+}
+
+func (cb *grpcRMCallback) removeNodeStream(sender *streamSender[*si.NodeResponse]) {
+	cb.Lock()
+	defer cb.Unlock()
+	cb.nodeStreams = removeSender(cb.nodeStreams, sender)
+}
+
+func (cb *grpcRMCallback) getNodeStreams() []*streamSender[*si.NodeResponse] {
+	cb.RLock()
+	defer cb.RUnlock()
+	return append([]*streamSender[*si.NodeResponse](nil), cb.nodeStreams...)
+}
+
+// UpdateAllocation queues new, released and rejected allocations for the RM.
+func (cb *grpcRMCallback) UpdateAllocation(response *si.AllocationResponse) error {
+	streams := cb.getAllocationStreams()
+	if len(streams) == 0 {
+		return fmt.Errorf("no allocation stream connected for RM %s", cb.rmID)
+	}
+	return offerAll(streams, response)
+}
+
+// UpdateApplication queues accepted, rejected and updated applications for the RM.
+func (cb *grpcRMCallback) UpdateApplication(response *si.ApplicationResponse) error {
+	streams := cb.getApplicationStreams()
+	if len(streams) == 0 {
+		return fmt.Errorf("no application stream connected for RM %s", cb.rmID)
+	}
+	return offerAll(streams, response)
+}
+
+// UpdateNode queues accepted and rejected nodes for the RM.
+func (cb *grpcRMCallback) UpdateNode(response *si.NodeResponse) error {
+	streams := cb.getNodeStreams()
+	if len(streams) == 0 {
+		return fmt.Errorf("no node stream connected for RM %s", cb.rmID)
+	}
+	return offerAll(streams, response)
+}
+
+// Predicates cannot be called remotely over the scheduler interface, all nodes are considered a fit.
+func (cb *grpcRMCallback) Predicates(_ *si.PredicatesArgs) error {
+	return nil
+}
+
+// PreemptionPredicates cannot be called remotely over the scheduler interface: the first victim proposed is
+// accepted as the preemption outcome.
+func (cb *grpcRMCallback) PreemptionPredicates(args *si.PreemptionPredicatesArgs) *si.PreemptionPredicatesResponse {
+	return &si.PreemptionPredicatesResponse{
+		Success: true,
+		Index:   args.StartIndex,
+	}
+}
+
+// SendEvent has no stream to use over the scheduler interface, events are logged only.
+func (cb *grpcRMCallback) SendEvent(events []*si.EventRecord) {
+	for _, event := range events {
+		log.Log(log.RPC).Debug("event for RM",
+			zap.String("rmID", cb.rmID),
+			zap.Stringer("event", event))
+	}
+}
+
+// UpdateContainerSchedulingState has no stream to use over the scheduler interface, updates are logged only.
+func (cb *grpcRMCallback) UpdateContainerSchedulingState(request *si.UpdateContainerSchedulingStateRequest) {
+	log.Log(log.RPC).Debug("container scheduling state update for RM",
+		zap.String("rmID", cb.rmID),
+		zap.Stringer("request", request))
+}
@@ -20,158 +20,195 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"io"
-	"log"
 	"time"
 
+	"go.uber.org/zap"
+
 	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/entrypoint"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
+// SimpleScheduler exposes the scheduler core over gRPC. All requests received on the streams are passed on to the
+// RMProxy, responses from the core are sent back over the streams via the grpcRMCallback of the RM.
 type SimpleScheduler struct {
 	si.UnimplementedSchedulerServer
-}
 
-func (scheduler *SimpleScheduler) Run(endpoint string) {
-	// Create gRPC servers
-	ss := newSchedulerServer()
-	s := common.NewNonBlockingGRPCServer()
-	s.Start(endpoint, ss)
-	s.Wait()
-}
+	proxy     api.SchedulerAPI
+	callbacks map[string]*grpcRMCallback
 
-func newSchedulerServer() si.SchedulerServer {
-	return &SimpleScheduler{}
+	locking.RWMutex
 }
 
-func (scheduler *SimpleScheduler) RegisterResourceManager(ctx context.Context, in *si.RegisterResourceManagerRequest) (*si.RegisterResourceManagerResponse, error) {
-	log.Printf("Received registeration")
-
-	return &(si.RegisterResourceManagerResponse{}), nil
+// configFiles are the local config files watched in standalone mode
+type configFiles struct {
+	queuesFile    string
+	configMapFile string
+	interval      time.Duration
 }
 
-func (scheduler *SimpleScheduler) UpdateAllocation(conn si.Scheduler_UpdateAllocationServer) error {
-	log.Println("start new server")
-	ctx := conn.Context()
-
-	for {
-		// exit if context is done
-		// or continue
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// receive data from stream
-		_, err := conn.Recv()
-
-		log.Printf("Requested recved")
-
-		if err == io.EOF {
-			// return will close stream from server side
-			log.Println("exit")
-			return nil
-		}
-		if err != nil {
-			log.Printf("receive error %v", err)
-			continue
-		}
-
-		// Send response to stream
-		resp := si.AllocationResponse{}
-
-		time.Sleep(2 * time.Second)
-
-		if err := conn.Send(&resp); err != nil {
-			log.Printf("send error %v", err)
-			return err
-		}
+func (scheduler *SimpleScheduler) Run(endpoint string, tlsConfig, webTLSConfig *common.ServerTLSConfig, files *configFiles, stop <-chan struct{}) {
+	serviceContext := entrypoint.StartAllServicesWithWebTLS(webTLSConfig)
+	defer serviceContext.StopAll()
+	serviceContext.WatchConfigFiles(files.queuesFile, files.configMapFile, files.interval)
+	scheduler.proxy = serviceContext.RMProxy
 
-		log.Printf("Responded")
+	// Create gRPC servers
+	var s common.NonBlockingGRPCServer
+	if tlsConfig.Enabled() {
+		s = common.NewNonBlockingGRPCServerWithTLS(tlsConfig)
+	} else {
+		s = common.NewNonBlockingGRPCServer()
 	}
+	s.Start(endpoint, scheduler)
+	go func() {
+		<-stop
+		log.Log(log.RPC).Info("stopping gRPC server")
+		s.Stop()
+	}()
+	s.Wait()
 }
 
-func (scheduler *SimpleScheduler) UpdateApplication(conn si.Scheduler_UpdateApplicationServer) error {
-	log.Println("start new server")
-	ctx := conn.Context()
-
-	for {
-		// exit if context is done
-		// or continue
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// receive data from stream
-		_, err := conn.Recv()
-
-		log.Printf("Requested recved")
-
-		if err == io.EOF {
-			// return will close stream from server side
-			log.Println("exit")
-			return nil
-		}
-		if err != nil {
-			log.Printf("receive error %v", err)
-			continue
-		}
-
-		// Send response to stream
-		resp := si.ApplicationResponse{}
+func newSchedulerServer() *SimpleScheduler {
+	return &SimpleScheduler{
+		callbacks: make(map[string]*grpcRMCallback),
+	}
+}
 
-		time.Sleep(2 * time.Second)
+// getOrCreateCallback returns the callback for the RM, re-registration of an RM reuses the existing callback.
+// This makes sure that streams that are already connected keep receiving responses.
+func (scheduler *SimpleScheduler) getOrCreateCallback(rmID string) *grpcRMCallback {
+	scheduler.Lock()
+	defer scheduler.Unlock()
+	if cb, ok := scheduler.callbacks[rmID]; ok {
+		return cb
+	}
+	cb := newGRPCRMCallback(rmID)
+	scheduler.callbacks[rmID] = cb
+	return cb
+}
 
-		if err := conn.Send(&resp); err != nil {
-			log.Printf("send error %v", err)
-			return err
-		}
+func (scheduler *SimpleScheduler) getCallback(rmID string) (*grpcRMCallback, error) {
+	scheduler.RLock()
+	defer scheduler.RUnlock()
+	if cb, ok := scheduler.callbacks[rmID]; ok {
+		return cb, nil
+	}
+	return nil, fmt.Errorf("RmID=\"%s\" not registered", rmID)
+}
 
-		log.Printf("Responded")
+func (scheduler *SimpleScheduler) RegisterResourceManager(_ context.Context, in *si.RegisterResourceManagerRequest) (*si.RegisterResourceManagerResponse, error) {
+	log.Log(log.RPC).Info("registering resource manager",
+		zap.String("rmID", in.RmID),
+		zap.String("policyGroup", in.PolicyGroup),
+		zap.String("version", in.Version))
+	if in.RmID == "" {
+		return nil, fmt.Errorf("registration of RM failed: RmID is not set")
 	}
+	return scheduler.proxy.RegisterResourceManager(in, scheduler.getOrCreateCallback(in.RmID))
 }
 
-func (scheduler *SimpleScheduler) UpdateNode(conn si.Scheduler_UpdateNodeServer) error {
-	log.Println("start new server")
-	ctx := conn.Context()
-
-	for {
-		// exit if context is done
-		// or continue
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
+func (scheduler *SimpleScheduler) UpdateAllocation(conn si.Scheduler_UpdateAllocationServer) error {
+	// link the stream to the RM on the first request
+	req, err := conn.Recv()
+	if err != nil {
+		return streamClosed("allocation", err)
+	}
+	var cb *grpcRMCallback
+	if cb, err = scheduler.getCallback(req.RmID); err != nil {
+		return err
+	}
+	sender := cb.addAllocationStream(conn)
+	defer cb.removeAllocationStream(sender)
+	log.Log(log.RPC).Info("allocation stream connected",
+		zap.String("rmID", req.RmID))
+	return sender.serve(func() error {
+		for {
+			if err := scheduler.proxy.UpdateAllocation(req); err != nil {
+				log.Log(log.RPC).Warn("allocation request failed",
+					zap.String("rmID", req.RmID),
+					zap.Error(err))
+			}
+			// receive data from stream, this blocks until the stream is closed or the context is done
+			var err error
+			if req, err = conn.Recv(); err != nil {
+				return streamClosed("allocation", err)
+			}
 		}
+	})
+}
 
-		// receive data from stream
-		_, err := conn.Recv()
-
-		log.Printf("Requested recved")
-
-		if err == io.EOF {
-			// return will close stream from server side
-			log.Println("exit")
-			return nil
-		}
-		if err != nil {
-			log.Printf("receive error %v", err)
-			continue
+func (scheduler *SimpleScheduler) UpdateApplication(conn si.Scheduler_UpdateApplicationServer) error {
+	// link the stream to the RM on the first request
+	req, err := conn.Recv()
+	if err != nil {
+		return streamClosed("application", err)
+	}
+	var cb *grpcRMCallback
+	if cb, err = scheduler.getCallback(req.RmID); err != nil {
+		return err
+	}
+	sender := cb.addApplicationStream(conn)
+	defer cb.removeApplicationStream(sender)
+	log.Log(log.RPC).Info("application stream connected",
+		zap.String("rmID", req.RmID))
+	return sender.serve(func() error {
+		for {
+			if err := scheduler.proxy.UpdateApplication(req); err != nil {
+				log.Log(log.RPC).Warn("application request failed",
+					zap.String("rmID", req.RmID),
+					zap.Error(err))
+			}
+			// receive data from stream, this blocks until the stream is closed or the context is done
+			var err error
+			if req, err = conn.Recv(); err != nil {
+				return streamClosed("application", err)
+			}
 		}
+	})
+}
 
-		// Send response to stream
-		resp := si.NodeResponse{}
-
-		time.Sleep(2 * time.Second)
-
-		if err := conn.Send(&resp); err != nil {
-			log.Printf("send error %v", err)
-			return err
+func (scheduler *SimpleScheduler) UpdateNode(conn si.Scheduler_UpdateNodeServer) error {
+	// link the stream to the RM on the first request
+	req, err := conn.Recv()
+	if err != nil {
+		return streamClosed("node", err)
+	}
+	var cb *grpcRMCallback
+	if cb, err = scheduler.getCallback(req.RmID); err != nil {
+		return err
+	}
+	sender := cb.addNodeStream(conn)
+	defer cb.removeNodeStream(sender)
+	log.Log(log.RPC).Info("node stream connected",
+		zap.String("rmID", req.RmID))
+	return sender.serve(func() error {
+		for {
+			if err := scheduler.proxy.UpdateNode(req); err != nil {
+				log.Log(log.RPC).Warn("node request failed",
+					zap.String("rmID", req.RmID),
+					zap.Error(err))
+			}
+			// receive data from stream, this blocks until the stream is closed or the context is done
+			var err error
+			if req, err = conn.Recv(); err != nil {
+				return streamClosed("node", err)
+			}
 		}
+	})
+}
 
-		log.Printf("Responded")
+// streamClosed returns the error to close the stream with after a receive failed
+func streamClosed(name string, err error) error {
+	if err == io.EOF {
+		// return will close stream from server side
+		return nil
 	}
+	log.Log(log.RPC).Info(name+" stream closed", zap.Error(err))
+	return err
 }
@@ -0,0 +1,138 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/configs/yarn"
+)
+
+const (
+	typeAuto     = "auto"
+	typeFair     = "fair"
+	typeCapacity = "capacity"
+)
+
+/*
+A utility command to convert a YARN fair-scheduler.xml or capacity-scheduler.xml file into a queue configuration.
+The converted configuration is written to stdout, the conversion report with all settings that were not converted
+exactly is written to stderr.
+*/
+func main() {
+	schedulerType := flag.String("type", typeAuto, "type of the YARN configuration: auto, fair or capacity")
+	cluster := flag.String("cluster", "", "cluster resources used to convert percentages, e.g. memory=512Gi,vcore=128")
+	reportFormat := flag.String("report", "text", "format of the conversion report: text or json")
+	flag.Usage = func() {
+		log.Println("Usage: " + os.Args[0] + " [-type auto|fair|capacity] [-cluster <resources>] [-report text|json] <yarn-config-file>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || (*reportFormat != "text" && *reportFormat != "json") {
+		flag.Usage()
+		os.Exit(1)
+	}
+	opts, err := parseOptions(*cluster)
+	if err != nil {
+		log.Printf("Invalid cluster resources: %v", err)
+		os.Exit(1)
+	}
+	content, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Printf("Could not read file: %v", err)
+		os.Exit(2)
+	}
+	if *schedulerType == typeAuto {
+		*schedulerType = typeCapacity
+		if bytes.Contains(content, []byte("<allocations")) {
+			*schedulerType = typeFair
+		}
+	}
+	var conf *configs.SchedulerConfig
+	var report *yarn.Report
+	switch *schedulerType {
+	case typeFair:
+		conf, report, err = yarn.ConvertFairScheduler(content, opts)
+	case typeCapacity:
+		conf, report, err = yarn.ConvertCapacityScheduler(content, opts)
+	default:
+		flag.Usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Printf("Conversion failed: %v", err)
+		os.Exit(3)
+	}
+	output, err := yaml.Marshal(conf)
+	if err != nil {
+		log.Printf("Could not format converted configuration: %v", err)
+		os.Exit(3)
+	}
+	fmt.Print(string(output))
+	if err = printReport(report, *reportFormat); err != nil {
+		log.Printf("Could not format conversion report: %v", err)
+		os.Exit(3)
+	}
+	// the converted configuration is printed even if it is not valid: it can be fixed by hand
+	if _, err = configs.LoadSchedulerConfigFromByteArray(output); err != nil {
+		log.Printf("Converted configuration is not valid: %v", err)
+		os.Exit(4)
+	}
+}
+
+// parseOptions parses the cluster resources in the "name=value,name=value" format.
+func parseOptions(cluster string) (yarn.Options, error) {
+	opts := yarn.Options{}
+	if cluster == "" {
+		return opts, nil
+	}
+	opts.ClusterResources = make(map[string]string)
+	for _, part := range strings.Split(cluster, ",") {
+		name, value, found := strings.Cut(part, "=")
+		if !found || strings.TrimSpace(name) == "" {
+			return opts, fmt.Errorf("expected name=value: %s", part)
+		}
+		opts.ClusterResources[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return opts, nil
+}
+
+func printReport(report *yarn.Report, format string) error {
+	if format == "json" {
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, string(content))
+		return nil
+	}
+	for _, entry := range report.Entries {
+		fmt.Fprintf(os.Stderr, "NOT CONVERTED: %s\n", entry)
+	}
+	return nil
+}
@@ -33,6 +33,7 @@ require (
 	github.com/prometheus/common v0.45.0
 	github.com/sasha-s/go-deadlock v0.3.5
 	go.uber.org/zap v1.26.0
+	golang.org/x/crypto v0.31.0
 	golang.org/x/exp v0.0.0-20240409090435-93d18d7e34b8
 	golang.org/x/net v0.25.0
 	golang.org/x/time v0.5.0
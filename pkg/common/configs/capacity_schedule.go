@@ -0,0 +1,242 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package configs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCapacityWindow is the longest duration of a capacity schedule window.
+const MaxCapacityWindow = 7 * 24 * time.Hour
+
+var (
+	cronMonths = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronDays   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// CronSchedule is a parsed cron expression with the standard five fields: minute, hour, day of month, month and
+// day of week. A field supports "*", values, ranges, lists and steps. Months and days of the week can be names.
+// Like cron, a time matches if either the day of the month or the day of the week matches when both are restricted.
+type CronSchedule struct {
+	minutes    uint64
+	hours      uint64
+	days       uint64
+	months     uint64
+	weekdays   uint64
+	anyDay     bool
+	anyWeekDay bool
+}
+
+// ParseCronSchedule parses a five field cron expression.
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression '%s' must have 5 fields", expr)
+	}
+	var err error
+	cron := &CronSchedule{
+		anyDay:     fields[2] == "*",
+		anyWeekDay: fields[4] == "*",
+	}
+	if cron.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute in cron expression '%s': %w", expr, err)
+	}
+	if cron.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour in cron expression '%s': %w", expr, err)
+	}
+	if cron.days, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day of month in cron expression '%s': %w", expr, err)
+	}
+	if cron.months, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return nil, fmt.Errorf("invalid month in cron expression '%s': %w", expr, err)
+	}
+	// Sunday can be 0 or 7
+	if cron.weekdays, err = parseCronField(fields[4], 0, 7, cronDays); err != nil {
+		return nil, fmt.Errorf("invalid day of week in cron expression '%s': %w", expr, err)
+	}
+	if cron.weekdays&(1<<7) != 0 {
+		cron.weekdays |= 1
+	}
+	return cron, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps into a bit set.
+// Names, if given, map to the values starting at the minimum.
+func parseCronField(field string, minimum, maximum int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if rangePart, stepPart, found := strings.Cut(part, "/"); found {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step '%s'", stepPart)
+			}
+			part = rangePart
+		}
+		low, high := minimum, maximum
+		if part != "*" {
+			startPart, endPart, isRange := strings.Cut(part, "-")
+			var err error
+			if low, err = parseCronValue(startPart, minimum, maximum, names); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseCronValue(endPart, minimum, maximum, names); err != nil {
+					return 0, err
+				}
+			} else if step != 1 {
+				// a single value with a step runs to the end of the range
+				high = maximum
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range '%s'", part)
+			}
+		}
+		for i := low; i <= high; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, minimum, maximum int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(value, name) {
+			return i + minimum, nil
+		}
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < minimum || number > maximum {
+		return 0, fmt.Errorf("value '%s' out of range %d-%d", value, minimum, maximum)
+	}
+	return number, nil
+}
+
+// Matches returns true if the minute of the time matches the cron expression.
+func (c *CronSchedule) Matches(t time.Time) bool {
+	return c.minutes&(1<<uint(t.Minute())) != 0 &&
+		c.hours&(1<<uint(t.Hour())) != 0 &&
+		c.months&(1<<uint(t.Month())) != 0 &&
+		c.matchesDay(t)
+}
+
+func (c *CronSchedule) matchesDay(t time.Time) bool {
+	day := c.days&(1<<uint(t.Day())) != 0
+	weekday := c.weekdays&(1<<uint(t.Weekday())) != 0
+	if !c.anyDay && !c.anyWeekDay {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+// Next returns the first time after the given time that matches the cron expression.
+// A zero time is returned if there is no match within five years.
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Previous returns the last time at or before the given time that matches the cron expression.
+// A zero time is returned if there is no match within five years.
+func (c *CronSchedule) Previous(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute)
+	limit := t.AddDate(-5, 0, 0)
+	for t.After(limit) {
+		switch {
+		case c.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Minute)
+		case c.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc).Add(-time.Minute)
+		case c.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ParseCapacityWindow parses the duration of a capacity schedule window: at least a minute and at most a week.
+func ParseCapacityWindow(duration string) (time.Duration, error) {
+	window, err := time.ParseDuration(duration)
+	if err != nil {
+		return 0, err
+	}
+	if window < time.Minute || window > MaxCapacityWindow {
+		return 0, fmt.Errorf("window duration %s must be between 1m and %s", duration, MaxCapacityWindow)
+	}
+	return window, nil
+}
+
+// ApplyCapacitySchedule returns a copy of the queue configuration with the queue capacities of the schedule applied.
+// The queue configuration passed in is not changed. Queues in the schedule that do not exist are ignored.
+func ApplyCapacitySchedule(queue QueueConfig, schedule CapacitySchedule) QueueConfig {
+	for _, capacity := range schedule.Queues {
+		queue = applyQueueCapacity(queue, strings.Split(capacity.Name, DOT), capacity)
+	}
+	return queue
+}
+
+// applyQueueCapacity applies the capacity to the queue at the end of the path, copying the queues on the path.
+func applyQueueCapacity(queue QueueConfig, path []string, capacity QueueCapacity) QueueConfig {
+	if len(path) == 0 || !strings.EqualFold(queue.Name, path[0]) {
+		return queue
+	}
+	if len(path) == 1 {
+		if capacity.Resources.Guaranteed != nil {
+			queue.Resources.Guaranteed = capacity.Resources.Guaranteed
+		}
+		if capacity.Resources.Max != nil {
+			queue.Resources.Max = capacity.Resources.Max
+		}
+		if capacity.MaxApplications != 0 {
+			queue.MaxApplications = capacity.MaxApplications
+		}
+		return queue
+	}
+	children := make([]QueueConfig, len(queue.Queues))
+	for i, child := range queue.Queues {
+		children[i] = applyQueueCapacity(child, path[1:], capacity)
+	}
+	queue.Queues = children
+	return queue
+}
@@ -0,0 +1,140 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package configs
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	for _, expr := range []string{"* * * * *", "0 8 * * mon-fri", "*/15 8-17 1,15 jan-jun 0", "30 22 * * 7", "5/10 * * * SUN"} {
+		_, err := ParseCronSchedule(expr)
+		assert.NilError(t, err, "expression %s should parse", expr)
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "x * * * *", "* * * foo *"} {
+		_, err := ParseCronSchedule(expr)
+		assert.Assert(t, err != nil, "expression '%s' should fail", expr)
+	}
+}
+
+func TestCronScheduleMatches(t *testing.T) {
+	// 2024-01-01 is a Monday
+	monday := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	cron, err := ParseCronSchedule("0 8 * * mon-fri")
+	assert.NilError(t, err)
+	assert.Assert(t, cron.Matches(monday))
+	assert.Assert(t, !cron.Matches(monday.Add(time.Minute)))
+	assert.Assert(t, !cron.Matches(monday.AddDate(0, 0, 5)), "saturday should not match")
+	// Sunday as 7
+	cron, err = ParseCronSchedule("0 8 * * 7")
+	assert.NilError(t, err)
+	assert.Assert(t, cron.Matches(monday.AddDate(0, 0, 6)))
+	// day of month or day of week if both are restricted
+	cron, err = ParseCronSchedule("0 8 15 * mon")
+	assert.NilError(t, err)
+	assert.Assert(t, cron.Matches(monday), "monday should match")
+	assert.Assert(t, cron.Matches(monday.AddDate(0, 0, 14)), "15th should match")
+	assert.Assert(t, !cron.Matches(monday.AddDate(0, 0, 1)), "tuesday 2nd should not match")
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	start := time.Date(2024, 1, 1, 8, 0, 30, 0, time.UTC)
+	cron, err := ParseCronSchedule("0 8 * * mon-fri")
+	assert.NilError(t, err)
+	assert.Equal(t, cron.Next(start), time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC))
+	// friday to monday
+	assert.Equal(t, cron.Next(start.AddDate(0, 0, 4)), time.Date(2024, 1, 8, 8, 0, 0, 0, time.UTC))
+	cron, err = ParseCronSchedule("*/20 * * * *")
+	assert.NilError(t, err)
+	assert.Equal(t, cron.Next(start), time.Date(2024, 1, 1, 8, 20, 0, 0, time.UTC))
+	cron, err = ParseCronSchedule("0 0 29 feb *")
+	assert.NilError(t, err)
+	assert.Equal(t, cron.Next(start), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, cron.Next(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC))
+	cron, err = ParseCronSchedule("0 0 31 feb *")
+	assert.NilError(t, err)
+	assert.Assert(t, cron.Next(start).IsZero(), "impossible date should not match")
+}
+
+func TestCronSchedulePrevious(t *testing.T) {
+	cron, err := ParseCronSchedule("0 8 * * mon-fri")
+	assert.NilError(t, err)
+	// 2024-01-01 is a Monday
+	start := time.Date(2024, 1, 1, 9, 30, 15, 0, time.UTC)
+	assert.Equal(t, cron.Previous(start), time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	// the time itself matches
+	assert.Equal(t, cron.Previous(time.Date(2024, 1, 1, 8, 0, 30, 0, time.UTC)), time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+	// weekend is skipped
+	assert.Equal(t, cron.Previous(time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC)), time.Date(2024, 1, 5, 8, 0, 0, 0, time.UTC))
+	cron, err = ParseCronSchedule("*/20 8 * * *")
+	assert.NilError(t, err)
+	assert.Equal(t, cron.Previous(start), time.Date(2024, 1, 1, 8, 40, 0, 0, time.UTC))
+	cron, err = ParseCronSchedule("0 0 29 feb *")
+	assert.NilError(t, err)
+	assert.Equal(t, cron.Previous(start), time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC))
+	cron, err = ParseCronSchedule("0 0 31 feb *")
+	assert.NilError(t, err)
+	assert.Assert(t, cron.Previous(start).IsZero(), "impossible date should not match")
+}
+
+func TestParseCapacityWindow(t *testing.T) {
+	window, err := ParseCapacityWindow("10h")
+	assert.NilError(t, err)
+	assert.Equal(t, window, 10*time.Hour)
+	for _, value := range []string{"", "x", "30s", "-1h", "169h"} {
+		_, err = ParseCapacityWindow(value)
+		assert.Assert(t, err != nil, "window '%s' should fail", value)
+	}
+}
+
+func TestApplyCapacitySchedule(t *testing.T) {
+	root := QueueConfig{
+		Name: RootQueue,
+		Queues: []QueueConfig{
+			{
+				Name:            "interactive",
+				MaxApplications: 10,
+				Resources:       Resources{Guaranteed: map[string]string{"memory": "10"}, Max: map[string]string{"memory": "20"}},
+			},
+			{Name: "batch", Resources: Resources{Max: map[string]string{"memory": "50"}}},
+		},
+	}
+	schedule := CapacitySchedule{
+		Name: "daytime",
+		Queues: []QueueCapacity{
+			{Name: "root.Interactive", Resources: Resources{Guaranteed: map[string]string{"memory": "40"}}, MaxApplications: 50},
+			{Name: "root.batch", Resources: Resources{Max: map[string]string{"memory": "10"}}},
+			{Name: "root.unknown", MaxApplications: 1},
+		},
+	}
+	result := ApplyCapacitySchedule(root, schedule)
+	assert.DeepEqual(t, result.Queues[0].Resources.Guaranteed, map[string]string{"memory": "40"})
+	// unset values do not change
+	assert.DeepEqual(t, result.Queues[0].Resources.Max, map[string]string{"memory": "20"})
+	assert.Equal(t, result.Queues[0].MaxApplications, uint64(50))
+	assert.DeepEqual(t, result.Queues[1].Resources.Max, map[string]string{"memory": "10"})
+	assert.Equal(t, len(result.Queues), 2)
+	// the original config must not change
+	assert.DeepEqual(t, root.Queues[0].Resources.Guaranteed, map[string]string{"memory": "10"})
+	assert.Equal(t, root.Queues[0].MaxApplications, uint64(10))
+	assert.DeepEqual(t, root.Queues[1].Resources.Max, map[string]string{"memory": "50"})
+}
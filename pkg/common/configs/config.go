@@ -45,13 +45,15 @@ type SchedulerConfig struct {
 // - a list of placement rule definition objects
 // - a list of users specifying limits on the partition
 // - the preemption configuration for the partition
+// - a list of capacity schedules that override queue capacities during time windows
 type PartitionConfig struct {
-	Name           string
-	Queues         []QueueConfig
-	PlacementRules []PlacementRule           `yaml:",omitempty" json:",omitempty"`
-	Limits         []Limit                   `yaml:",omitempty" json:",omitempty"`
-	Preemption     PartitionPreemptionConfig `yaml:",omitempty" json:",omitempty"`
-	NodeSortPolicy NodeSortingPolicy         `yaml:",omitempty" json:",omitempty"`
+	Name              string
+	Queues            []QueueConfig
+	PlacementRules    []PlacementRule           `yaml:",omitempty" json:",omitempty"`
+	Limits            []Limit                   `yaml:",omitempty" json:",omitempty"`
+	Preemption        PartitionPreemptionConfig `yaml:",omitempty" json:",omitempty"`
+	NodeSortPolicy    NodeSortingPolicy         `yaml:",omitempty" json:",omitempty"`
+	CapacitySchedules []CapacitySchedule        `yaml:",omitempty" json:",omitempty"`
 }
 
 // The partition preemption configuration
@@ -67,23 +69,29 @@ type PartitionPreemptionConfig struct {
 // - ACL for submit and or admin access
 // - a list of sub or child queues
 // - a list of users specifying limits on a queue
+// - the maximum wall-clock time an application can run in the queue
+// - maximum resources multiplied by hours all applications in the queue can consume in a budget period, and the period
 type QueueConfig struct {
-	Name            string
-	Parent          bool              `yaml:",omitempty" json:",omitempty"`
-	Resources       Resources         `yaml:",omitempty" json:",omitempty"`
-	MaxApplications uint64            `yaml:",omitempty" json:",omitempty"`
-	Properties      map[string]string `yaml:",omitempty" json:",omitempty"`
-	AdminACL        string            `yaml:",omitempty" json:",omitempty"`
-	SubmitACL       string            `yaml:",omitempty" json:",omitempty"`
-	ChildTemplate   ChildTemplate     `yaml:",omitempty" json:",omitempty"`
-	Queues          []QueueConfig     `yaml:",omitempty" json:",omitempty"`
-	Limits          []Limit           `yaml:",omitempty" json:",omitempty"`
+	Name             string
+	Parent           bool              `yaml:",omitempty" json:",omitempty"`
+	Resources        Resources         `yaml:",omitempty" json:",omitempty"`
+	MaxApplications  uint64            `yaml:",omitempty" json:",omitempty"`
+	Properties       map[string]string `yaml:",omitempty" json:",omitempty"`
+	AdminACL         string            `yaml:",omitempty" json:",omitempty"`
+	SubmitACL        string            `yaml:",omitempty" json:",omitempty"`
+	ChildTemplate    ChildTemplate     `yaml:",omitempty" json:",omitempty"`
+	Queues           []QueueConfig     `yaml:",omitempty" json:",omitempty"`
+	Limits           []Limit           `yaml:",omitempty" json:",omitempty"`
+	MaxRuntime       string            `yaml:",omitempty" json:",omitempty"`
+	MaxResourceHours map[string]string `yaml:",omitempty" json:",omitempty"`
+	BudgetPeriod     string            `yaml:",omitempty" json:",omitempty"`
 }
 
 type ChildTemplate struct {
 	MaxApplications uint64            `yaml:",omitempty" json:",omitempty"`
 	Properties      map[string]string `yaml:",omitempty" json:",omitempty"`
 	Resources       Resources         `yaml:",omitempty" json:",omitempty"`
+	MaxRuntime      string            `yaml:",omitempty" json:",omitempty"`
 }
 
 // The resource limits to set on the queue. The definition allows for an unlimited number of types to be used.
@@ -95,6 +103,26 @@ type Resources struct {
 	Max        map[string]string `yaml:",omitempty" json:",omitempty"`
 }
 
+// The capacity schedule object, a named profile that is active during recurring time windows:
+// - the name of the schedule
+// - the start of the window as a cron expression: minute hour day-of-month month day-of-week
+// - the duration of the window
+// - the queue capacities that override the configured values while the window is active
+type CapacitySchedule struct {
+	Name     string
+	Start    string
+	Duration string
+	Queues   []QueueCapacity
+}
+
+// The capacity override for a queue, the queue is referenced by its full path.
+// Only the values that are set override the configured values of the queue.
+type QueueCapacity struct {
+	Name            string
+	Resources       Resources `yaml:",omitempty" json:",omitempty"`
+	MaxApplications uint64    `yaml:",omitempty" json:",omitempty"`
+}
+
 // The queue placement rule definition
 // - the name of the rule
 // - create flag: can the rule create a queue
@@ -133,12 +161,17 @@ type Limits struct {
 // - list of groups (maybe empty)
 // - maximum resources as a resource object to allow for the user or group
 // - maximum number of applications the user or group can have running
+// - maximum resources multiplied by hours the user or group can consume in a budget period
+// - the budget period, consumption is reset at the start of each period: periods are aligned to the zero time,
+// a day starts at midnight UTC and a week on Monday
 type Limit struct {
-	Limit           string
-	Users           []string          `yaml:",omitempty" json:",omitempty"`
-	Groups          []string          `yaml:",omitempty" json:",omitempty"`
-	MaxResources    map[string]string `yaml:",omitempty" json:",omitempty"`
-	MaxApplications uint64            `yaml:",omitempty" json:",omitempty"`
+	Limit            string
+	Users            []string          `yaml:",omitempty" json:",omitempty"`
+	Groups           []string          `yaml:",omitempty" json:",omitempty"`
+	MaxResources     map[string]string `yaml:",omitempty" json:",omitempty"`
+	MaxApplications  uint64            `yaml:",omitempty" json:",omitempty"`
+	MaxResourceHours map[string]string `yaml:",omitempty" json:",omitempty"`
+	BudgetPeriod     string            `yaml:",omitempty" json:",omitempty"`
 }
 
 // Global Node Sorting Policy section
@@ -180,6 +213,12 @@ func ParseAndValidateConfig(content []byte) (*SchedulerConfig, error) {
 			zap.Error(err))
 		return nil, err
 	}
+	for _, warning := range CheckWarnings(conf) {
+		log.Log(log.Config).Warn("queue configuration warning",
+			zap.String("partition", warning.Partition),
+			zap.String("queue", warning.Queue),
+			zap.String("warning", warning.Message))
+	}
 	return conf, nil
 }
 
@@ -0,0 +1,166 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package configs
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/apache/yunikorn-core/pkg/log"
+)
+
+// maxDiffSize limits the size of the table used to compare the changed lines of two configs
+const maxDiffSize = 1 << 20
+
+// ConfigHistoryEntry is one applied version of the scheduler config for a policy group.
+type ConfigHistoryEntry struct {
+	Version   uint64
+	Checksum  string
+	Timestamp time.Time
+	Source    string
+	Diff      string // line diff of the config against the previous version in the history
+	Config    *SchedulerConfig
+}
+
+// configHistory keeps the last applied configs of a policy group, oldest first.
+type configHistory struct {
+	entries     []*ConfigHistoryEntry
+	lastVersion uint64
+}
+
+// last returns the latest version in the history, nil if the history is empty.
+func (h *configHistory) last() *ConfigHistoryEntry {
+	if len(h.entries) == 0 {
+		return nil
+	}
+	return h.entries[len(h.entries)-1]
+}
+
+// add records the config as the latest version with the diff against the previous version.
+func (h *configHistory) add(config *SchedulerConfig, source, diff string, size int) {
+	h.lastVersion++
+	h.entries = append(h.entries, &ConfigHistoryEntry{
+		Version:   h.lastVersion,
+		Checksum:  config.Checksum,
+		Timestamp: time.Now(),
+		Source:    source,
+		Diff:      diff,
+		Config:    config,
+	})
+	if len(h.entries) > size {
+		h.entries = h.entries[len(h.entries)-size:]
+	}
+}
+
+// getHistorySize returns the number of configs to keep in the history from the config map.
+func getHistorySize() int {
+	value, ok := GetConfigMap()[CMConfigHistorySize]
+	if !ok {
+		return DefaultConfigHistorySize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 1 {
+		log.Log(log.Config).Warn("invalid config history size, using default",
+			zap.String("value", value),
+			zap.Int("default", DefaultConfigHistorySize))
+		return DefaultConfigHistorySize
+	}
+	return size
+}
+
+// marshalForDiff returns the YAML of the config without the checksum, used to diff configs.
+func marshalForDiff(config *SchedulerConfig) string {
+	if config == nil {
+		return ""
+	}
+	confCopy := *config
+	confCopy.Checksum = ""
+	content, err := yaml.Marshal(&confCopy)
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
+
+// diffLines returns a line based diff between two texts. Only changed lines are returned: removed lines are
+// prefixed with "-", added lines with "+". Lines at the start and end that did not change are skipped, the longest
+// common subsequence of the remaining lines is kept unchanged. If the remaining part is too large to compare
+// all its lines are shown as removed and added.
+func diffLines(before, after string) string {
+	oldLines := splitLines(before)
+	newLines := splitLines(after)
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[0] == newLines[0] {
+		oldLines = oldLines[1:]
+		newLines = newLines[1:]
+	}
+	for len(oldLines) > 0 && len(newLines) > 0 && oldLines[len(oldLines)-1] == newLines[len(newLines)-1] {
+		oldLines = oldLines[:len(oldLines)-1]
+		newLines = newLines[:len(newLines)-1]
+	}
+	var diff strings.Builder
+	if (len(oldLines)+1)*(len(newLines)+1) > maxDiffSize {
+		for _, line := range oldLines {
+			diff.WriteString("-" + line + "\n")
+		}
+		for _, line := range newLines {
+			diff.WriteString("+" + line + "\n")
+		}
+		return diff.String()
+	}
+	// lcs[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff.WriteString("-" + oldLines[i] + "\n")
+			i++
+		default:
+			diff.WriteString("+" + newLines[j] + "\n")
+			j++
+		}
+	}
+	return diff.String()
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -29,9 +29,16 @@ const (
 	// prefixes
 	PrefixEvent  = "event."
 	PrefixHealth = "health."
+	PrefixREST   = "rest."
+	PrefixConfig = "config."
+
+	PrefixAccounting = "accounting."
 
 	HealthCheckInterval = PrefixHealth + "checkInterval"
 
+	// number of applied scheduler configs kept per policy group
+	CMConfigHistorySize = PrefixConfig + "historySize"
+
 	// events
 	CMEventTrackingEnabled    = PrefixEvent + "trackingEnabled"    // Application Tracking
 	CMEventRequestCapacity    = PrefixEvent + "requestCapacity"    // Request Capacity
@@ -40,6 +47,18 @@ const (
 	CMMaxEventStreamsPerHost  = PrefixEvent + "maxStreamsPerHost"
 	CMRESTResponseSize        = PrefixEvent + "RESTResponseSize"
 
+	// REST authentication and authorization
+	CMRESTAuthTokenFile    = PrefixREST + "auth.tokenFile"    // static bearer tokens
+	CMRESTAuthHtpasswdFile = PrefixREST + "auth.htpasswdFile" // bcrypt hashed htpasswd for HTTP basic
+	CMRESTAuthClientCert   = PrefixREST + "auth.clientCert"   // client certificate identity
+	CMRESTAuthAdminACL     = PrefixREST + "auth.adminACL"     // cluster admins
+
+	// usage accounting
+	CMAccountingDirectory = PrefixAccounting + "directory" // directory for the export files, no export if not set
+	CMAccountingFormat    = PrefixAccounting + "format"    // export file format: csv or jsonl
+	CMAccountingTags      = PrefixAccounting + "tags"      // comma separated application tags added to the records
+	CMAccountingRetention = PrefixAccounting + "retention" // how long records are kept in memory
+
 	// defaults
 	DefaultHealthCheckInterval     = 30 * time.Second
 	DefaultEventTrackingEnabled    = true
@@ -49,6 +68,10 @@ const (
 	DefaultMaxStreams              = uint64(100)
 	DefaultMaxStreamsPerHost       = uint64(15)
 	DefaultRESTResponseSize        = uint64(10000)
+	DefaultRESTAuthClientCert      = false
+	DefaultConfigHistorySize       = 10
+	DefaultAccountingFormat        = "jsonl"
+	DefaultAccountingRetention     = 7 * 24 * time.Hour
 )
 
 var ConfigContext *SchedulerConfigContext
@@ -62,6 +85,7 @@ func init() {
 	configMapCallbacks = make(map[string]func())
 	ConfigContext = &SchedulerConfigContext{
 		configs: make(map[string]*SchedulerConfig),
+		history: make(map[string]*configHistory),
 		lock:    &locking.RWMutex{},
 	}
 
@@ -74,13 +98,91 @@ func init() {
 // scheduler config context provides thread-safe access for scheduler configurations
 type SchedulerConfigContext struct {
 	configs map[string]*SchedulerConfig
+	history map[string]*configHistory
 	lock    *locking.RWMutex
 }
 
 func (ctx *SchedulerConfigContext) Set(policyGroup string, config *SchedulerConfig) {
+	ctx.SetWithSource(policyGroup, config, "")
+}
+
+// SetWithSource sets the config for the policy group and records it in the config history with the source of the change.
+// The diff against the previous version is calculated without holding the lock.
+func (ctx *SchedulerConfigContext) SetWithSource(policyGroup string, config *SchedulerConfig, source string) {
+	size := getHistorySize()
+	for {
+		previous := ctx.getLastHistory(policyGroup)
+		record := config != nil && (previous == nil || previous.Checksum != config.Checksum)
+		var diff string
+		if record {
+			var previousConfig *SchedulerConfig
+			if previous != nil {
+				previousConfig = previous.Config
+			}
+			diff = diffLines(marshalForDiff(previousConfig), marshalForDiff(config))
+		}
+		if ctx.setWithHistory(policyGroup, config, source, diff, record, previous, size) {
+			return
+		}
+	}
+}
+
+// getLastHistory returns the latest version in the history of the policy group, nil if there is no history.
+func (ctx *SchedulerConfigContext) getLastHistory(policyGroup string) *ConfigHistoryEntry {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	if history, ok := ctx.history[policyGroup]; ok {
+		return history.last()
+	}
+	return nil
+}
+
+// setWithHistory sets the config and records it in the history if requested. Nothing is changed and false is
+// returned if the latest version in the history is not the one the diff was calculated against.
+func (ctx *SchedulerConfigContext) setWithHistory(policyGroup string, config *SchedulerConfig, source, diff string, record bool, previous *ConfigHistoryEntry, size int) bool {
 	ctx.lock.Lock()
 	defer ctx.lock.Unlock()
+	history, ok := ctx.history[policyGroup]
+	if !ok {
+		history = &configHistory{}
+	}
+	if history.last() != previous {
+		return false
+	}
 	ctx.configs[policyGroup] = config
+	if record {
+		ctx.history[policyGroup] = history
+		history.add(config, source, diff, size)
+	}
+	return true
+}
+
+// GetHistory returns the recorded config history for the policy group, oldest version first.
+func (ctx *SchedulerConfigContext) GetHistory(policyGroup string) []*ConfigHistoryEntry {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	history, ok := ctx.history[policyGroup]
+	if !ok {
+		return nil
+	}
+	return append([]*ConfigHistoryEntry(nil), history.entries...)
+}
+
+// GetVersion returns the config history entry for the version of the policy group config, nil if the version is not
+// in the history.
+func (ctx *SchedulerConfigContext) GetVersion(policyGroup string, version uint64) *ConfigHistoryEntry {
+	ctx.lock.RLock()
+	defer ctx.lock.RUnlock()
+	history, ok := ctx.history[policyGroup]
+	if !ok {
+		return nil
+	}
+	for _, entry := range history.entries {
+		if entry.Version == version {
+			return entry
+		}
+	}
+	return nil
 }
 
 func (ctx *SchedulerConfigContext) Get(policyGroup string) *SchedulerConfig {
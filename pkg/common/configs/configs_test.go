@@ -19,6 +19,8 @@
 package configs
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -55,3 +57,55 @@ func TestCallback(t *testing.T) {
 	SetConfigMap(nil)
 	assert.Assert(t, !callbackReceived, "callback still received")
 }
+
+func TestConfigHistory(t *testing.T) {
+	defer SetConfigMap(nil)
+	SetConfigMap(map[string]string{CMConfigHistorySize: "2"})
+	newConf := func(queue, checksum string) *SchedulerConfig {
+		return &SchedulerConfig{
+			Partitions: []PartitionConfig{{Name: "default", Queues: []QueueConfig{{Name: "root", Queues: []QueueConfig{{Name: queue}}}}}},
+			Checksum:   checksum,
+		}
+	}
+	assert.Assert(t, ConfigContext.GetHistory("history") == nil, "unknown policy group should have no history")
+
+	ConfigContext.SetWithSource("history", newConf("a", "1"), "rm-1")
+	ConfigContext.SetWithSource("history", newConf("a", "1"), "rm-1")
+	history := ConfigContext.GetHistory("history")
+	assert.Equal(t, len(history), 1, "unchanged config should not be recorded")
+	assert.Equal(t, history[0].Version, uint64(1))
+	assert.Equal(t, history[0].Source, "rm-1")
+
+	ConfigContext.SetWithSource("history", newConf("b", "2"), "rest")
+	history = ConfigContext.GetHistory("history")
+	assert.Equal(t, len(history), 2)
+	assert.Equal(t, history[1].Diff, "-            - name: a\n+            - name: b\n")
+
+	// the history is bounded, versions keep increasing
+	ConfigContext.SetWithSource("history", newConf("c", "3"), "rm-1")
+	history = ConfigContext.GetHistory("history")
+	assert.Equal(t, len(history), 2)
+	assert.Equal(t, history[0].Version, uint64(2))
+	assert.Equal(t, history[1].Version, uint64(3))
+	assert.Assert(t, ConfigContext.GetVersion("history", 1) == nil, "dropped version should not be found")
+	assert.Equal(t, ConfigContext.GetVersion("history", 3).Checksum, "3")
+	assert.Equal(t, ConfigContext.Get("history").Checksum, "3")
+}
+
+func TestDiffLines(t *testing.T) {
+	assert.Equal(t, diffLines("", ""), "")
+	assert.Equal(t, diffLines("a\nb\n", "a\nb\n"), "")
+	assert.Equal(t, diffLines("", "a\n"), "+a\n")
+	assert.Equal(t, diffLines("a\nb\nc\n", "a\nc\nd\n"), "-b\n+d\n")
+
+	// unchanged lines around the change are skipped, a change that is too large to compare is shown as a whole
+	var before, after strings.Builder
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&before, "old-%d\n", i)
+		fmt.Fprintf(&after, "new-%d\n", i)
+	}
+	assert.Equal(t, diffLines("start\n"+before.String()+"a\nend\n", "start\n"+before.String()+"b\nend\n"), "-a\n+b\n")
+	diff := diffLines(before.String(), after.String())
+	assert.Equal(t, strings.Count(diff, "\n-"), 1999)
+	assert.Equal(t, strings.Count(diff, "\n+"), 2000)
+}
@@ -47,6 +47,13 @@ const (
 	PriorityOffset          = "priority.offset"
 	PreemptionPolicy        = "preemption.policy"
 	PreemptionDelay         = "preemption.delay"
+	QueueWeight             = "weight"
+	DRFResourceWeights      = "drf.resource.weights"
+	PriorityAgingInterval   = "priority.aging.interval"
+	PriorityAgingMax        = "priority.aging.max"
+	PriorityDeadlineBoost   = "priority.deadline.boost"
+	NodeSelector            = "node.selector"
+	AskTTL                  = "ask.ttl"
 
 	// app sort priority values
 	ApplicationSortPriorityEnabled  = "enabled"
@@ -67,6 +74,15 @@ var MaxPriority int32 = math.MaxInt32
 
 var DefaultPreemptionDelay = 30 * time.Second
 
+// DefaultPriorityAgingMax is the maximum increase of the priority of a waiting application if aging is enabled
+var DefaultPriorityAgingMax int32 = 10
+
+// DefaultPriorityDeadlineBoost is the priority increase of an application in an edf queue with its deadline at risk
+var DefaultPriorityDeadlineBoost int32 = 10
+
+// DefaultQueueWeight is the weight of a queue without a weight property, relative to its siblings
+var DefaultQueueWeight = 1.0
+
 // A queue can be a username with the dot replaced. Most systems allow a 32 character user name.
 // The queue name must thus allow for at least that length with the replacement of dots.
 var QueueNameRegExp = regexp.MustCompile(`^[a-zA-Z0-9_:#/@-]{1,64}$`)
@@ -542,8 +558,14 @@ func checkLimit(limit Limit, existingUserName map[string]bool, existingGroupName
 			return fmt.Errorf("MaxResources should be greater than zero in '%s' limit", limit.Limit)
 		}
 	}
+	// check the budget (if defined), a budget requires a period
+	if len(limit.MaxResourceHours) != 0 || limit.BudgetPeriod != "" {
+		if err = checkBudget(limit.MaxResourceHours, limit.BudgetPeriod, fmt.Sprintf("'%s' limit", limit.Limit)); err != nil {
+			return err
+		}
+	}
 	// at least some resource should be not null
-	if limit.MaxApplications == 0 && len(limit.MaxResources) == 0 {
+	if limit.MaxApplications == 0 && len(limit.MaxResources) == 0 && len(limit.MaxResourceHours) == 0 {
 		return fmt.Errorf("invalid resource combination for limit %s all resource limits are null", limit.Limit)
 	}
 
@@ -568,6 +590,21 @@ func checkLimit(limit Limit, existingUserName map[string]bool, existingGroupName
 	return nil
 }
 
+// checkBudget checks the resource hours and the period of a budget set on a limit or a queue
+func checkBudget(maxResourceHours map[string]string, budgetPeriod string, owner string) error {
+	resourceHours, err := resources.NewResourceFromConf(maxResourceHours)
+	if err != nil {
+		return fmt.Errorf("invalid MaxResourceHours in %s: %w", owner, err)
+	}
+	if len(maxResourceHours) == 0 || !resources.StrictlyGreaterThanZero(resourceHours) {
+		return fmt.Errorf("MaxResourceHours should be greater than zero in %s", owner)
+	}
+	if _, err = ParseBudgetPeriod(budgetPeriod); err != nil {
+		return fmt.Errorf("invalid BudgetPeriod in %s: %w", owner, err)
+	}
+	return nil
+}
+
 // Check the defined limits list
 func checkLimits(limits []Limit, obj string, queue *QueueConfig) error {
 	// return if nothing defined
@@ -634,6 +671,68 @@ func checkNodeSortingPolicy(partition *PartitionConfig) error {
 // - no duplicate names at each branched level in the tree
 // - queue name is alphanumeric (case ignore) with - and _
 // - queue name is maximum 64 char long
+// Check the capacity schedules of the partition: the queue hierarchy with the capacities of a schedule applied must
+// pass the same resource and application checks as the configured hierarchy.
+func checkCapacitySchedules(partition *PartitionConfig) error {
+	names := make(map[string]bool)
+	for _, schedule := range partition.CapacitySchedules {
+		if schedule.Name == "" {
+			return fmt.Errorf("capacity schedule name must be set")
+		}
+		if names[strings.ToLower(schedule.Name)] {
+			return fmt.Errorf("duplicate capacity schedule name found with name %s", schedule.Name)
+		}
+		names[strings.ToLower(schedule.Name)] = true
+		if _, err := ParseCronSchedule(schedule.Start); err != nil {
+			return fmt.Errorf("invalid start for capacity schedule %s: %w", schedule.Name, err)
+		}
+		if _, err := ParseCapacityWindow(schedule.Duration); err != nil {
+			return fmt.Errorf("invalid duration for capacity schedule %s: %w", schedule.Name, err)
+		}
+		if len(schedule.Queues) == 0 {
+			return fmt.Errorf("capacity schedule %s has no queues", schedule.Name)
+		}
+		queues := make(map[string]bool)
+		for _, capacity := range schedule.Queues {
+			path := strings.ToLower(capacity.Name)
+			if queues[path] {
+				return fmt.Errorf("duplicate queue %s in capacity schedule %s", capacity.Name, schedule.Name)
+			}
+			queues[path] = true
+			if path == RootQueue {
+				return fmt.Errorf("capacity schedule %s must not change the root queue", schedule.Name)
+			}
+			if !queueConfigExists(partition.Queues[0], strings.Split(path, DOT)) {
+				return fmt.Errorf("queue %s in capacity schedule %s is not configured", capacity.Name, schedule.Name)
+			}
+		}
+		queueConf := ApplyCapacitySchedule(partition.Queues[0], schedule)
+		if _, err := checkQueueResource(queueConf, nil); err != nil {
+			return fmt.Errorf("invalid resources for capacity schedule %s: %w", schedule.Name, err)
+		}
+		if err := checkQueueMaxApplications(queueConf); err != nil {
+			return fmt.Errorf("invalid max applications for capacity schedule %s: %w", schedule.Name, err)
+		}
+	}
+	return nil
+}
+
+// queueConfigExists returns true if the queue path, starting at the passed in queue, is part of the configuration.
+func queueConfigExists(queue QueueConfig, path []string) bool {
+	if len(path) == 0 || !strings.EqualFold(queue.Name, path[0]) {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	for _, child := range queue.Queues {
+		if queueConfigExists(child, path[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
 func checkQueues(queue *QueueConfig, level int) error {
 	// check the ACLs (if defined)
 	err := checkACL(queue.AdminACL)
@@ -645,6 +744,33 @@ func checkQueues(queue *QueueConfig, level int) error {
 		return err
 	}
 
+	// check the max runtime of the queue and the template (if defined)
+	if err = checkMaxRuntime(queue.MaxRuntime); err != nil {
+		return fmt.Errorf("invalid max runtime for queue %s: %w", queue.Name, err)
+	}
+	if err = checkMaxRuntime(queue.ChildTemplate.MaxRuntime); err != nil {
+		return fmt.Errorf("invalid child template max runtime for queue %s: %w", queue.Name, err)
+	}
+
+	// check the budget of the queue (if defined), a budget requires a period
+	if len(queue.MaxResourceHours) != 0 || queue.BudgetPeriod != "" {
+		if err = checkBudget(queue.MaxResourceHours, queue.BudgetPeriod, "queue "+queue.Name); err != nil {
+			return err
+		}
+	}
+
+	// check the node selector of the queue and the template (if defined)
+	if selector, ok := queue.Properties[NodeSelector]; ok {
+		if _, err = ParseNodePoolSelector(selector); err != nil {
+			return fmt.Errorf("invalid node selector for queue %s: %w", queue.Name, err)
+		}
+	}
+	if selector, ok := queue.ChildTemplate.Properties[NodeSelector]; ok {
+		if _, err = ParseNodePoolSelector(selector); err != nil {
+			return fmt.Errorf("invalid child template node selector for queue %s: %w", queue.Name, err)
+		}
+	}
+
 	// check the limits for this child (if defined)
 	err = checkLimits(queue.Limits, queue.Name, queue)
 	if err != nil {
@@ -675,6 +801,39 @@ func checkQueues(queue *QueueConfig, level int) error {
 	return nil
 }
 
+// checkMaxRuntime checks that the max runtime is a positive duration, an empty value is not set
+func checkMaxRuntime(maxRuntime string) error {
+	if maxRuntime == "" {
+		return nil
+	}
+	_, err := ParseMaxRuntime(maxRuntime)
+	return err
+}
+
+// ParseBudgetPeriod parses the period of a budget limit: a duration string like "168h" of at least a minute.
+func ParseBudgetPeriod(period string) (time.Duration, error) {
+	duration, err := time.ParseDuration(period)
+	if err != nil {
+		return 0, err
+	}
+	if duration < time.Minute {
+		return 0, fmt.Errorf("budget period %s must be at least 1m", period)
+	}
+	return duration, nil
+}
+
+// ParseMaxRuntime parses a max runtime setting: a duration string like "4h" that must be larger than zero.
+func ParseMaxRuntime(maxRuntime string) (time.Duration, error) {
+	duration, err := time.ParseDuration(maxRuntime)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("max runtime must be larger than zero: %s", maxRuntime)
+	}
+	return duration, nil
+}
+
 func IsQueueNameValid(queueName string) error {
 	if !QueueNameRegExp.MatchString(queueName) {
 		return common.InvalidQueueName
@@ -796,6 +955,10 @@ func Validate(newConfig *SchedulerConfig) error {
 		if err = checkLimitMaxApplications(partition.Queues[0], make(map[string]map[string]uint64), make(map[string]map[string]uint64), common.Empty); err != nil {
 			return err
 		}
+
+		if err = checkCapacitySchedules(&partition); err != nil {
+			return err
+		}
 		// write back the partition to keep changes
 		newConfig.Partitions[i] = partition
 	}
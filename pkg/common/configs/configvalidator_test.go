@@ -2004,6 +2004,80 @@ func TestCheckLimits(t *testing.T) { //nolint:funlen
 			},
 			errMsg: "empty user and group lists defined in limit",
 		},
+		{
+			name: "budget only limit",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:            "user-budget",
+						Users:            []string{"test-user"},
+						MaxResourceHours: map[string]string{"vcore": "10000"},
+						BudgetPeriod:     "168h",
+					},
+				},
+			},
+			errMsg: "",
+		},
+		{
+			name: "budget without period",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:            "user-budget",
+						Users:            []string{"test-user"},
+						MaxResourceHours: map[string]string{"vcore": "10000"},
+					},
+				},
+			},
+			errMsg: "invalid BudgetPeriod in 'user-budget' limit",
+		},
+		{
+			name: "budget period too short",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:            "user-budget",
+						Users:            []string{"test-user"},
+						MaxResourceHours: map[string]string{"vcore": "10000"},
+						BudgetPeriod:     "10s",
+					},
+				},
+			},
+			errMsg: "must be at least 1m",
+		},
+		{
+			name: "period without budget",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:           "user-budget",
+						Users:           []string{"test-user"},
+						MaxApplications: 1,
+						BudgetPeriod:    "24h",
+					},
+				},
+			},
+			errMsg: "MaxResourceHours should be greater than zero in 'user-budget' limit",
+		},
+		{
+			name: "invalid budget resource",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:            "user-budget",
+						Groups:           []string{"test-group"},
+						MaxResourceHours: map[string]string{"vcore": "x"},
+						BudgetPeriod:     "24h",
+					},
+				},
+			},
+			errMsg: "invalid MaxResourceHours in 'user-budget' limit",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -2229,6 +2303,98 @@ func TestCheckQueues(t *testing.T) { //nolint:funlen
 			level:            0,
 			expectedErrorMsg: "multiple spaces found in ACL: 'submit group extra'",
 		},
+		{
+			name: "Invalid MaxRuntime",
+			queue: &QueueConfig{
+				Name:       "root",
+				MaxRuntime: "1x",
+			},
+			level:            0,
+			expectedErrorMsg: "invalid max runtime for queue root",
+		},
+		{
+			name: "Negative MaxRuntime on ChildTemplate",
+			queue: &QueueConfig{
+				Name: "root",
+				Queues: []QueueConfig{{
+					Name:          "parent",
+					Parent:        true,
+					ChildTemplate: ChildTemplate{MaxRuntime: "-1h"},
+				}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid child template max runtime for queue parent",
+		},
+		{
+			name: "Queue budget without period",
+			queue: &QueueConfig{
+				Name:             "root",
+				MaxResourceHours: map[string]string{"vcore": "10000"},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid BudgetPeriod in queue root",
+		},
+		{
+			name: "Queue period without budget",
+			queue: &QueueConfig{
+				Name: "root",
+				Queues: []QueueConfig{{
+					Name:         "leaf",
+					BudgetPeriod: "168h",
+				}},
+			},
+			level:            0,
+			expectedErrorMsg: "MaxResourceHours should be greater than zero in queue leaf",
+		},
+		{
+			name: "Valid queue budget",
+			queue: &QueueConfig{
+				Name:             "root",
+				MaxResourceHours: map[string]string{"vcore": "10000"},
+				BudgetPeriod:     "168h",
+			},
+			level:            0,
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid MaxRuntime",
+			queue: &QueueConfig{
+				Name:          "root",
+				MaxRuntime:    "24h",
+				ChildTemplate: ChildTemplate{MaxRuntime: "90m"},
+			},
+			level: 0,
+		},
+		{
+			name: "Invalid NodeSelector",
+			queue: &QueueConfig{
+				Name:       "root",
+				Properties: map[string]string{NodeSelector: "pool in gpu"},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid node selector for queue root",
+		},
+		{
+			name: "Invalid NodeSelector on ChildTemplate",
+			queue: &QueueConfig{
+				Name: "root",
+				Queues: []QueueConfig{{
+					Name:          "parent",
+					Parent:        true,
+					ChildTemplate: ChildTemplate{Properties: map[string]string{NodeSelector: "pool="}},
+				}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid child template node selector for queue parent",
+		},
+		{
+			name: "Valid NodeSelector",
+			queue: &QueueConfig{
+				Name:       "root",
+				Properties: map[string]string{NodeSelector: "pool in (gpu, cpu), !spot"},
+			},
+			level: 0,
+		},
 		{
 			name: "Duplicate Child Queue Names",
 			queue: &QueueConfig{
@@ -2427,3 +2593,78 @@ func TestIsQueueNameValid(t *testing.T) {
 		t.Errorf("invalid queue name, validation should have failed. err is %v", err)
 	}
 }
+
+func TestCheckCapacitySchedules(t *testing.T) {
+	newPartition := func(schedules ...CapacitySchedule) *PartitionConfig {
+		return &PartitionConfig{
+			Name: DefaultPartition,
+			Queues: []QueueConfig{{
+				Name:   RootQueue,
+				Parent: true,
+				Queues: []QueueConfig{
+					{
+						Name:            "parent",
+						Parent:          true,
+						MaxApplications: 20,
+						Resources:       Resources{Max: map[string]string{"memory": "100"}},
+						Queues: []QueueConfig{
+							{Name: "interactive", MaxApplications: 10, Resources: Resources{Guaranteed: map[string]string{"memory": "10"}}},
+							{Name: "batch", MaxApplications: 10, Resources: Resources{Guaranteed: map[string]string{"memory": "50"}}},
+						},
+					},
+				},
+			}},
+			CapacitySchedules: schedules,
+		}
+	}
+	daytime := CapacitySchedule{
+		Name:     "daytime",
+		Start:    "0 8 * * mon-fri",
+		Duration: "10h",
+		Queues: []QueueCapacity{
+			{Name: "root.parent.interactive", Resources: Resources{Guaranteed: map[string]string{"memory": "60"}}, MaxApplications: 15},
+			{Name: "root.parent.batch", Resources: Resources{Guaranteed: map[string]string{"memory": "10"}}, MaxApplications: 5},
+		},
+	}
+	assert.NilError(t, checkCapacitySchedules(newPartition()))
+	assert.NilError(t, checkCapacitySchedules(newPartition(daytime)))
+
+	testCases := []struct {
+		name     string
+		modify   func(schedule *CapacitySchedule)
+		extra    bool
+		errorMsg string
+	}{
+		{"no name", func(schedule *CapacitySchedule) { schedule.Name = "" }, false, "name must be set"},
+		{"duplicate name", func(schedule *CapacitySchedule) { schedule.Name = "DAYTIME" }, true, "duplicate capacity schedule name"},
+		{"invalid start", func(schedule *CapacitySchedule) { schedule.Start = "0 25 * * *" }, false, "invalid start"},
+		{"invalid duration", func(schedule *CapacitySchedule) { schedule.Duration = "0s" }, false, "invalid duration"},
+		{"no queues", func(schedule *CapacitySchedule) { schedule.Queues = nil }, false, "has no queues"},
+		{"root queue", func(schedule *CapacitySchedule) {
+			schedule.Queues = []QueueCapacity{{Name: "root", MaxApplications: 1}}
+		}, false, "must not change the root queue"},
+		{"unknown queue", func(schedule *CapacitySchedule) {
+			schedule.Queues = []QueueCapacity{{Name: "root.unknown", MaxApplications: 1}}
+		}, false, "is not configured"},
+		{"duplicate queue", func(schedule *CapacitySchedule) {
+			schedule.Queues = append(schedule.Queues, QueueCapacity{Name: "root.parent.Batch"})
+		}, false, "duplicate queue"},
+		{"guaranteed over parent max", func(schedule *CapacitySchedule) {
+			schedule.Queues[1].Resources.Guaranteed = map[string]string{"memory": "50"}
+		}, false, "invalid resources"},
+		{"invalid resource", func(schedule *CapacitySchedule) { schedule.Queues[1].Resources.Max = map[string]string{"memory": "x"} }, false, "invalid resources"},
+		{"max applications over parent", func(schedule *CapacitySchedule) { schedule.Queues[0].MaxApplications = 30 }, false, "invalid max applications"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule := daytime
+			schedule.Queues = append([]QueueCapacity{}, daytime.Queues...)
+			tc.modify(&schedule)
+			partition := newPartition(schedule)
+			if tc.extra {
+				partition = newPartition(daytime, schedule)
+			}
+			assert.ErrorContains(t, checkCapacitySchedules(partition), tc.errorMsg)
+		})
+	}
+}
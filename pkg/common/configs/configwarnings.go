@@ -0,0 +1,349 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package configs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+)
+
+// ConfigWarning is a likely mistake in a configuration that passed validation.
+// Warnings never cause a configuration to be rejected.
+type ConfigWarning struct {
+	Partition string `json:"partition"`
+	Queue     string `json:"queue,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (w ConfigWarning) String() string {
+	if w.Queue == "" {
+		return fmt.Sprintf("partition %s: %s", w.Partition, w.Message)
+	}
+	return fmt.Sprintf("partition %s, queue %s: %s", w.Partition, w.Queue, w.Message)
+}
+
+// CheckWarnings returns the warnings for a configuration. The configuration must have passed Validate.
+// Children with a guaranteed resource larger than the max of the parent are not checked: Validate rejects that.
+func CheckWarnings(conf *SchedulerConfig) []ConfigWarning {
+	if conf == nil {
+		return nil
+	}
+	var warnings []ConfigWarning
+	for i := range conf.Partitions {
+		partition := &conf.Partitions[i]
+		if len(partition.Queues) == 0 {
+			continue
+		}
+		name := strings.ToLower(partition.Name)
+		if name == "" {
+			name = DefaultPartition
+		}
+		root := &partition.Queues[0]
+		warnings = append(warnings, unreachableRuleWarnings(name, partition.PlacementRules, root)...)
+		warnings = append(warnings, unplacedLimitUserWarnings(name, partition.PlacementRules, root)...)
+		warnings = append(warnings, unknownLimitResourceWarnings(name, root)...)
+		warnings = append(warnings, shadowedACLWarnings(name, root, RootQueue, newACLEntries(""))...)
+	}
+	return warnings
+}
+
+// unreachableRuleWarnings reports rules that follow a fixed rule which places every application.
+// A fixed rule places every application if it has no filter and no parent rule, and the queue it returns is a leaf
+// queue, or can be created, with a wildcard submit ACL on the path.
+func unreachableRuleWarnings(partition string, rules []PlacementRule, root *QueueConfig) []ConfigWarning {
+	for i, rule := range rules {
+		if i == len(rules)-1 {
+			break
+		}
+		if !isCatchAllRule(rule, root) {
+			continue
+		}
+		var names []string
+		for _, unreachable := range rules[i+1:] {
+			names = append(names, unreachable.Name)
+		}
+		return []ConfigWarning{{
+			Partition: partition,
+			Message: fmt.Sprintf("placement rule %d (fixed %s) places all applications, rules after it are never used: %s",
+				i+1, rule.Value, strings.Join(names, ", ")),
+		}}
+	}
+	return nil
+}
+
+func isCatchAllRule(rule PlacementRule, root *QueueConfig) bool {
+	if !strings.EqualFold(rule.Name, "fixed") || rule.Parent != nil || !isEmptyFilter(rule.Filter) {
+		return false
+	}
+	queuePath := strings.ToLower(rule.Value)
+	if !strings.HasPrefix(queuePath, RootQueue+DOT) {
+		queuePath = RootQueue + DOT + queuePath
+	}
+	parts := strings.Split(queuePath, DOT)
+	current := root
+	openACL := isWildcardACL(current.SubmitACL)
+	for _, part := range parts[1:] {
+		current = findChildQueue(current, part)
+		if current == nil {
+			// the queue must be created, the ACL of the last existing queue decides
+			return rule.Create && openACL
+		}
+		openACL = openACL || isWildcardACL(current.SubmitACL)
+	}
+	return openACL && !current.Parent && len(current.Queues) == 0
+}
+
+// unplacedLimitUserWarnings reports users in limits that every placement rule filters out.
+// Queues on the path to the default queue are not checked: applications not placed by a rule end up there.
+func unplacedLimitUserWarnings(partition string, rules []PlacementRule, root *QueueConfig) []ConfigWarning {
+	if len(rules) == 0 {
+		return nil
+	}
+	hasDefault := findChildQueue(root, "default") != nil
+	var warnings []ConfigWarning
+	walkQueues(root, RootQueue, func(queue *QueueConfig, queuePath string) {
+		if hasDefault && (queuePath == RootQueue || queuePath == common.DefaultPlacementQueue) {
+			return
+		}
+		for _, limit := range queue.Limits {
+			for _, user := range limit.Users {
+				if user == common.Wildcard || !rulesRejectUser(rules, user) {
+					continue
+				}
+				warnings = append(warnings, ConfigWarning{
+					Partition: partition,
+					Queue:     queuePath,
+					Message:   fmt.Sprintf("limit '%s' names user %s who is rejected by the filter of every placement rule", limit.Limit, user),
+				})
+			}
+		}
+	})
+	return warnings
+}
+
+func rulesRejectUser(rules []PlacementRule, user string) bool {
+	for _, rule := range rules {
+		if !filterRejectsUser(rule.Filter, user) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterRejectsUser returns true if the filter rejects the user whatever groups the user is a member of.
+// This follows the evaluation of the filter by the placement rules.
+func filterRejectsUser(filter Filter, user string) bool {
+	if isEmptyFilter(filter) {
+		return false
+	}
+	matched := filterMatchesUser(filter.Users, user)
+	if filter.Type == "deny" {
+		return matched
+	}
+	// an allow filter with groups might allow the user based on a group
+	return !matched && len(filter.Groups) == 0
+}
+
+func filterMatchesUser(users []string, user string) bool {
+	if len(users) == 1 && SpecialRegExp.MatchString(users[0]) {
+		userExp, err := regexp.Compile(users[0])
+		return err == nil && userExp.MatchString(user)
+	}
+	for _, name := range users {
+		if name == user {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyFilter(filter Filter) bool {
+	return len(filter.Users) == 0 && len(filter.Groups) == 0
+}
+
+// unknownLimitResourceWarnings reports resource names used in a limit that are not set on any queue.
+func unknownLimitResourceWarnings(partition string, root *QueueConfig) []ConfigWarning {
+	known := make(map[string]bool)
+	walkQueues(root, RootQueue, func(queue *QueueConfig, _ string) {
+		for _, res := range []map[string]string{queue.Resources.Guaranteed, queue.Resources.Max,
+			queue.ChildTemplate.Resources.Guaranteed, queue.ChildTemplate.Resources.Max} {
+			for name := range res {
+				known[name] = true
+			}
+		}
+	})
+	var warnings []ConfigWarning
+	walkQueues(root, RootQueue, func(queue *QueueConfig, queuePath string) {
+		for _, limit := range queue.Limits {
+			var unknown []string
+			for name := range limit.MaxResources {
+				if !known[name] {
+					unknown = append(unknown, name)
+				}
+			}
+			if len(unknown) == 0 {
+				continue
+			}
+			sort.Strings(unknown)
+			warnings = append(warnings, ConfigWarning{
+				Partition: partition,
+				Queue:     queuePath,
+				Message:   fmt.Sprintf("limit '%s' uses resources that are not set on any queue: %s", limit.Limit, strings.Join(unknown, ", ")),
+			})
+		}
+	})
+	return warnings
+}
+
+// aclEntries is the content of an ACL used to compare ACLs in the hierarchy.
+type aclEntries struct {
+	all    bool
+	users  map[string]bool
+	groups map[string]bool
+}
+
+// newACLEntries parses the ACL in the same way as the security package does.
+func newACLEntries(acl string) aclEntries {
+	entries := aclEntries{
+		users:  make(map[string]bool),
+		groups: make(map[string]bool),
+	}
+	if strings.TrimSpace(acl) == common.Wildcard {
+		entries.all = true
+		return entries
+	}
+	fields := strings.Split(acl, common.Space)
+	if len(fields) > 2 {
+		return entries
+	}
+	users := strings.Split(fields[0], common.Separator)
+	if len(users) == 1 && users[0] == common.Wildcard {
+		entries.all = true
+		return entries
+	}
+	for _, user := range users {
+		if user != "" {
+			entries.users[user] = true
+		}
+	}
+	if len(fields) == 2 {
+		groups := strings.Split(fields[1], common.Separator)
+		if len(groups) == 1 && groups[0] == common.Wildcard {
+			entries.all = true
+			return entries
+		}
+		for _, group := range groups {
+			if group != "" {
+				entries.groups[group] = true
+			}
+		}
+	}
+	return entries
+}
+
+// merge returns the combination of both ACLs: access granted by a parent is granted for all children.
+func (a aclEntries) merge(other aclEntries) aclEntries {
+	merged := aclEntries{
+		all:    a.all || other.all,
+		users:  make(map[string]bool),
+		groups: make(map[string]bool),
+	}
+	for _, set := range []map[string]bool{a.users, other.users} {
+		for name := range set {
+			merged.users[name] = true
+		}
+	}
+	for _, set := range []map[string]bool{a.groups, other.groups} {
+		for name := range set {
+			merged.groups[name] = true
+		}
+	}
+	return merged
+}
+
+// shadowedACLWarnings reports submit ACL entries that grant access already granted by an ancestor queue.
+func shadowedACLWarnings(partition string, queue *QueueConfig, queuePath string, inherited aclEntries) []ConfigWarning {
+	var warnings []ConfigWarning
+	current := newACLEntries(queue.SubmitACL)
+	if strings.TrimSpace(queue.SubmitACL) != "" {
+		var shadowed []string
+		if inherited.all {
+			shadowed = append(shadowed, "all entries")
+		} else {
+			for _, user := range sortedSet(current.users) {
+				if inherited.users[user] {
+					shadowed = append(shadowed, "user "+user)
+				}
+			}
+			for _, group := range sortedSet(current.groups) {
+				if inherited.groups[group] {
+					shadowed = append(shadowed, "group "+group)
+				}
+			}
+		}
+		if len(shadowed) > 0 {
+			warnings = append(warnings, ConfigWarning{
+				Partition: partition,
+				Queue:     queuePath,
+				Message:   fmt.Sprintf("submit ACL is shadowed by the submit ACL of a parent queue: %s", strings.Join(shadowed, ", ")),
+			})
+		}
+	}
+	inherited = inherited.merge(current)
+	for i := range queue.Queues {
+		child := &queue.Queues[i]
+		warnings = append(warnings, shadowedACLWarnings(partition, child, queuePath+DOT+strings.ToLower(child.Name), inherited)...)
+	}
+	return warnings
+}
+
+func isWildcardACL(acl string) bool {
+	return newACLEntries(acl).all
+}
+
+// walkQueues calls the function for the queue and all queues below it, parents before children.
+func walkQueues(queue *QueueConfig, queuePath string, fn func(queue *QueueConfig, queuePath string)) {
+	fn(queue, queuePath)
+	for i := range queue.Queues {
+		child := &queue.Queues[i]
+		walkQueues(child, queuePath+DOT+strings.ToLower(child.Name), fn)
+	}
+}
+
+func findChildQueue(queue *QueueConfig, name string) *QueueConfig {
+	for i := range queue.Queues {
+		if strings.EqualFold(queue.Queues[i].Name, name) {
+			return &queue.Queues[i]
+		}
+	}
+	return nil
+}
+
+func sortedSet(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
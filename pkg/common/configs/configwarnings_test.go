@@ -0,0 +1,161 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package configs
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckWarnings(t *testing.T) {
+	assert.Assert(t, CheckWarnings(nil) == nil, "nil config should not have warnings")
+
+	data := `
+partitions:
+  - name: default
+    placementrules:
+      - name: fixed
+        value: root.batch
+    queues:
+      - name: root
+        submitacl: "*"
+        queues:
+          - name: batch
+            resources:
+              max: {memory: 100}
+`
+	conf, err := CreateConfig(data)
+	assert.NilError(t, err, "config should be valid")
+	assert.Equal(t, len(CheckWarnings(conf)), 0, "clean config should not have warnings")
+
+	data = `
+partitions:
+  - name: default
+    placementrules:
+      - name: user
+        filter:
+          type: allow
+          users:
+            - alice
+      - name: fixed
+        value: batch
+      - name: provided
+    queues:
+      - name: root
+        submitacl: "alice,bob"
+        queues:
+          - name: batch
+            submitacl: "*"
+            resources:
+              max: {memory: 100}
+          - name: shared
+            submitacl: "bob,carol admins"
+            limits:
+              - limit: gpu limit
+                users:
+                  - bob
+                maxresources: {nvidia.com/gpu: 1, memory: 10}
+`
+	conf, err = CreateConfig(data)
+	assert.NilError(t, err, "config with warnings should be valid")
+	warnings := CheckWarnings(conf)
+	assert.Equal(t, len(warnings), 3, "unexpected warnings: %v", warnings)
+	assert.Equal(t, warnings[0].Partition, DefaultPartition)
+	assert.Equal(t, warnings[0].Queue, "")
+	assert.Equal(t, warnings[0].Message, "placement rule 2 (fixed batch) places all applications, rules after it are never used: provided")
+	assert.Equal(t, warnings[1].Queue, "root.shared")
+	assert.Equal(t, warnings[1].Message, "limit 'gpu limit' uses resources that are not set on any queue: nvidia.com/gpu")
+	assert.Equal(t, warnings[2].Queue, "root.shared")
+	assert.Equal(t, warnings[2].Message, "submit ACL is shadowed by the submit ACL of a parent queue: user bob")
+	assert.Equal(t, warnings[2].String(), "partition default, queue root.shared: submit ACL is shadowed by the submit ACL of a parent queue: user bob")
+
+	data = `
+partitions:
+  - name: default
+    placementrules:
+      - name: user
+        filter:
+          type: allow
+          users:
+            - alice
+      - name: provided
+        filter:
+          type: deny
+          users:
+            - bob
+    queues:
+      - name: root
+        submitacl: "*"
+        queues:
+          - name: shared
+            submitacl: "carol"
+            limits:
+              - limit: user limit
+                users:
+                  - bob
+                  - carol
+                maxapplications: 2
+`
+	conf, err = CreateConfig(data)
+	assert.NilError(t, err, "config with warnings should be valid")
+	warnings = CheckWarnings(conf)
+	assert.Equal(t, len(warnings), 2, "unexpected warnings: %v", warnings)
+	assert.Equal(t, warnings[0].Message, "limit 'user limit' names user bob who is rejected by the filter of every placement rule")
+	assert.Equal(t, warnings[1].Message, "submit ACL is shadowed by the submit ACL of a parent queue: all entries")
+}
+
+func TestFilterRejectsUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		user     string
+		rejected bool
+	}{
+		{"empty", Filter{}, "bob", false},
+		{"empty deny", Filter{Type: "deny"}, "bob", false},
+		{"allow listed", Filter{Users: []string{"alice", "bob"}}, "bob", false},
+		{"allow not listed", Filter{Type: "allow", Users: []string{"alice"}}, "bob", true},
+		{"allow group", Filter{Users: []string{"alice"}, Groups: []string{"dev"}}, "bob", false},
+		{"allow regexp", Filter{Users: []string{"^b.*$"}}, "bob", false},
+		{"allow regexp not matched", Filter{Users: []string{"^a.*$"}}, "bob", true},
+		{"deny listed", Filter{Type: "deny", Users: []string{"bob"}}, "bob", true},
+		{"deny not listed", Filter{Type: "deny", Users: []string{"alice"}}, "bob", false},
+		{"deny group", Filter{Type: "deny", Groups: []string{"dev"}}, "bob", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, filterRejectsUser(tt.filter, tt.user), tt.rejected)
+		})
+	}
+}
+
+func TestNewACLEntries(t *testing.T) {
+	entries := newACLEntries("")
+	assert.Assert(t, !entries.all && len(entries.users) == 0 && len(entries.groups) == 0, "empty ACL should have no entries")
+	assert.Assert(t, newACLEntries(" * ").all, "wildcard ACL should allow all")
+	assert.Assert(t, newACLEntries("* ").all, "wildcard user list should allow all")
+	assert.Assert(t, newACLEntries(" *").all, "wildcard group list should allow all")
+	entries = newACLEntries("alice,bob dev")
+	assert.DeepEqual(t, sortedSet(entries.users), []string{"alice", "bob"})
+	assert.DeepEqual(t, sortedSet(entries.groups), []string{"dev"})
+	entries = newACLEntries(" dev,ops")
+	assert.Equal(t, len(entries.users), 0)
+	assert.DeepEqual(t, sortedSet(entries.groups), []string{"dev", "ops"})
+}
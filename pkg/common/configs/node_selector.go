@@ -0,0 +1,195 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package configs
+
+import (
+	"fmt"
+	"strings"
+)
+
+type selectorOperator int
+
+const (
+	selectorEquals selectorOperator = iota
+	selectorNotEquals
+	selectorIn
+	selectorNotIn
+	selectorExists
+	selectorNotExists
+)
+
+// NodePoolSelector selects nodes based on their attributes. The selector is a comma separated list of
+// expressions that must all match: "key=value", "key==value", "key!=value", "key in (v1, v2)",
+// "key notin (v1, v2)", "key" for an attribute that is set and "!key" for an attribute that is not set.
+type NodePoolSelector struct {
+	expr         string
+	requirements []selectorRequirement
+}
+
+type selectorRequirement struct {
+	key      string
+	operator selectorOperator
+	values   []string
+}
+
+// ParseNodePoolSelector parses the selector expressions, an empty selector is not allowed.
+func ParseNodePoolSelector(expr string) (*NodePoolSelector, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("node selector must not be empty")
+	}
+	parts, err := splitSelector(expr)
+	if err != nil {
+		return nil, err
+	}
+	selector := &NodePoolSelector{expr: expr}
+	for _, part := range parts {
+		requirement, err := parseSelectorRequirement(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node selector '%s': %w", expr, err)
+		}
+		selector.requirements = append(selector.requirements, requirement)
+	}
+	return selector, nil
+}
+
+// splitSelector splits the expression on the commas that are not part of a value set.
+func splitSelector(expr string) ([]string, error) {
+	var parts []string
+	depth := 0
+	last := 0
+	for i, c := range expr {
+		switch c {
+		case '(':
+			depth++
+			if depth > 1 {
+				return nil, fmt.Errorf("invalid node selector '%s': nested parentheses", expr)
+			}
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("invalid node selector '%s': unbalanced parentheses", expr)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, expr[last:i])
+				last = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("invalid node selector '%s': unbalanced parentheses", expr)
+	}
+	return append(parts, expr[last:]), nil
+}
+
+func parseSelectorRequirement(expr string) (selectorRequirement, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return selectorRequirement{}, fmt.Errorf("empty expression")
+	}
+	if key, found := strings.CutPrefix(expr, "!"); found && !strings.Contains(key, "=") {
+		return newSelectorRequirement(key, selectorNotExists, nil)
+	}
+	for _, op := range []struct {
+		token    string
+		operator selectorOperator
+	}{{"!=", selectorNotEquals}, {"==", selectorEquals}, {"=", selectorEquals}} {
+		if key, value, found := strings.Cut(expr, op.token); found {
+			return newSelectorRequirement(key, op.operator, []string{value})
+		}
+	}
+	fields := strings.Fields(expr)
+	if len(fields) == 1 {
+		return newSelectorRequirement(fields[0], selectorExists, nil)
+	}
+	operator := selectorIn
+	switch fields[1] {
+	case "in":
+	case "notin":
+		operator = selectorNotIn
+	default:
+		return selectorRequirement{}, fmt.Errorf("unknown operator '%s' in expression '%s'", fields[1], expr)
+	}
+	// the expression starts with the key followed by the operator
+	set := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(expr[len(fields[0]):]), fields[1]))
+	if !strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+		return selectorRequirement{}, fmt.Errorf("values must be enclosed in parentheses in expression '%s'", expr)
+	}
+	values := strings.Split(set[1:len(set)-1], ",")
+	return newSelectorRequirement(fields[0], operator, values)
+}
+
+func newSelectorRequirement(key string, operator selectorOperator, values []string) (selectorRequirement, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t()!=") {
+		return selectorRequirement{}, fmt.Errorf("invalid attribute name '%s'", key)
+	}
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+		if values[i] == "" || strings.ContainsAny(values[i], " \t()!=") {
+			return selectorRequirement{}, fmt.Errorf("invalid value '%s' for attribute '%s'", value, key)
+		}
+	}
+	return selectorRequirement{key: key, operator: operator, values: values}, nil
+}
+
+// Matches returns true if all expressions match the attributes. The attribute function returns the value of an
+// attribute, or an empty string if the attribute is not set.
+func (s *NodePoolSelector) Matches(attribute func(key string) string) bool {
+	if s == nil {
+		return true
+	}
+	for _, requirement := range s.requirements {
+		if !requirement.matches(attribute(requirement.key)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r selectorRequirement) matches(value string) bool {
+	switch r.operator {
+	case selectorEquals, selectorIn:
+		return value != "" && r.contains(value)
+	case selectorNotEquals, selectorNotIn:
+		return value == "" || !r.contains(value)
+	case selectorExists:
+		return value != ""
+	default:
+		return value == ""
+	}
+}
+
+func (r selectorRequirement) contains(value string) bool {
+	for _, v := range r.values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the selector expression as configured.
+func (s *NodePoolSelector) String() string {
+	if s == nil {
+		return ""
+	}
+	return s.expr
+}
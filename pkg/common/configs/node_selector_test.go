@@ -0,0 +1,69 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package configs
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseNodePoolSelector(t *testing.T) {
+	for _, expr := range []string{"pool=gpu", "pool==gpu", "pool!=gpu", "pool in (gpu, cpu)", "pool notin (gpu)", "pool", "!pool", "instance-type in (a,b), zone=east, !spot"} {
+		selector, err := ParseNodePoolSelector(expr)
+		assert.NilError(t, err, "expression %s should parse", expr)
+		assert.Equal(t, selector.String(), expr)
+	}
+	for _, expr := range []string{"", " ", "pool=", "=gpu", "pool in gpu", "pool in (gpu", "pool in ((gpu))", "pool in ()", "pool between (a)", "pool=a,,zone=b", "a b=c"} {
+		_, err := ParseNodePoolSelector(expr)
+		assert.Assert(t, err != nil, "expression '%s' should fail", expr)
+	}
+}
+
+func TestNodePoolSelectorMatches(t *testing.T) {
+	attributes := func(values map[string]string) func(string) string {
+		return func(key string) string {
+			return values[key]
+		}
+	}
+	gpu := attributes(map[string]string{"pool": "gpu", "instance-type": "large"})
+	cpu := attributes(map[string]string{"pool": "cpu"})
+	none := attributes(nil)
+	var selector *NodePoolSelector
+	assert.Assert(t, selector.Matches(none), "nil selector should match all nodes")
+	assert.Equal(t, selector.String(), "")
+
+	tests := map[string][]bool{
+		// matches for gpu, cpu and no attributes
+		"pool=gpu":                          {true, false, false},
+		"pool!=gpu":                         {false, true, true},
+		"pool in (gpu, cpu)":                {true, true, false},
+		"pool notin (gpu)":                  {false, true, true},
+		"pool":                              {true, true, false},
+		"!pool":                             {false, false, true},
+		"instance-type in (large)":          {true, false, false},
+		"pool in (gpu,cpu), !instance-type": {false, true, false},
+	}
+	for expr, expected := range tests {
+		selector, err := ParseNodePoolSelector(expr)
+		assert.NilError(t, err, "expression %s should parse", expr)
+		assert.Equal(t, selector.Matches(gpu), expected[0], "unexpected gpu match for %s", expr)
+		assert.Equal(t, selector.Matches(cpu), expected[1], "unexpected cpu match for %s", expr)
+		assert.Equal(t, selector.Matches(none), expected[2], "unexpected empty match for %s", expr)
+	}
+}
@@ -0,0 +1,296 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yarn
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/placement/types"
+)
+
+const (
+	capacityPrefix          = "yarn.scheduler.capacity."
+	capacityMappings        = capacityPrefix + "queue-mappings"
+	capacityMappingOverride = capacityPrefix + "queue-mappings-override.enable"
+)
+
+// capacity scheduler settings that have no equivalent, with the reason
+var capacityUnsupported = map[string]string{
+	"user-limit-factor":           "user limits relative to the queue capacity are not supported",
+	"minimum-user-limit-percent":  "user limits relative to the queue capacity are not supported",
+	"maximum-am-resource-percent": "application master resource limits are not supported",
+}
+
+// capacityConfiguration is the content of a capacity-scheduler.xml file.
+type capacityConfiguration struct {
+	Properties []capacityProperty `xml:"property"`
+}
+
+type capacityProperty struct {
+	Name  string `xml:"name"`
+	Value string `xml:"value"`
+}
+
+// capacitySettings tracks which settings of the configuration were converted.
+type capacitySettings struct {
+	values map[string]string
+	used   map[string]bool
+}
+
+func (s *capacitySettings) get(key string) string {
+	s.used[key] = true
+	return strings.TrimSpace(s.values[key])
+}
+
+// ConvertCapacityScheduler converts the content of a YARN capacity-scheduler.xml file.
+// Capacities are percentages of the parent queue: they are only converted if the cluster resources are set.
+func ConvertCapacityScheduler(content []byte, opts Options) (*configs.SchedulerConfig, *Report, error) {
+	var conf capacityConfiguration
+	if err := xml.Unmarshal(content, &conf); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse capacity scheduler configuration: %w", err)
+	}
+	c, err := newConverter(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	settings := &capacitySettings{
+		values: make(map[string]string),
+		used:   make(map[string]bool),
+	}
+	for _, property := range conf.Properties {
+		settings.values[strings.TrimSpace(property.Name)] = property.Value
+	}
+	leaves := make(map[string][]string)
+	root := c.capacityQueue(settings, configs.RootQueue, configs.RootQueue, c.cluster, c.cluster, leaves)
+	// YARN allows everyone on the root queue if no ACL is set
+	if _, ok := settings.values[capacityPrefix+"root.acl_submit_applications"]; !ok {
+		root.SubmitACL = common.Wildcard
+		c.report.add(configs.RootQueue, "acl_submit_applications", "", "not set: YARN allows all users, submit ACL set to *")
+	}
+	if _, ok := settings.values[capacityPrefix+"root.acl_administer_queue"]; !ok {
+		root.AdminACL = common.Wildcard
+		c.report.add(configs.RootQueue, "acl_administer_queue", "", "not set: YARN allows all users, admin ACL set to *")
+	}
+	rules := c.capacityRules(settings, leaves)
+	// everything that is left was not converted
+	keys := make([]string, 0, len(settings.values))
+	for key := range settings.values {
+		if !settings.used[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		message := "not supported, not converted"
+		if reason, ok := capacityUnsupported[key[strings.LastIndex(key, configs.DOT)+1:]]; ok {
+			message = reason + ", not converted"
+		}
+		c.report.add("", key, settings.values[key], message)
+	}
+	return newSchedulerConfig(root, rules), c.report, nil
+}
+
+// capacityQueue converts the queue and all queues below it. The guaranteed and max resources of the parent are used
+// to convert percentages, they are nil if the cluster resources are not known.
+// The YARN path is case-sensitive, the queue path is the converted path.
+func (c *converter) capacityQueue(settings *capacitySettings, yarnPath, queuePath string, parentGuaranteed, parentMax *resources.Resource, leaves map[string][]string) configs.QueueConfig {
+	prefix := capacityPrefix + yarnPath + configs.DOT
+	name := queuePath[strings.LastIndex(queuePath, configs.DOT)+1:]
+	queue := configs.QueueConfig{Name: name}
+	guaranteed, maximum := parentGuaranteed, parentMax
+	// the root queue always has all resources of the cluster
+	if value := settings.get(prefix + "capacity"); queuePath != configs.RootQueue && strings.HasSuffix(value, "w") {
+		// a weight replaces the capacity: the children use the resources of the parent
+		c.weight(&queue, queuePath, "capacity", strings.TrimSuffix(value, "w"))
+		guaranteed = nil
+		queue.Resources.Max, maximum = c.capacity(queuePath, "maximum-capacity", settings.get(prefix+"maximum-capacity"), parentMax)
+		if queue.Resources.Max == nil {
+			maximum = parentMax
+		}
+	} else if queuePath != configs.RootQueue {
+		queue.Resources.Guaranteed, guaranteed = c.capacity(queuePath, "capacity", value, parentGuaranteed)
+		queue.Resources.Max, maximum = c.capacity(queuePath, "maximum-capacity", settings.get(prefix+"maximum-capacity"), parentMax)
+		if queue.Resources.Max == nil {
+			maximum = parentMax
+		}
+	} else {
+		settings.get(prefix + "maximum-capacity")
+	}
+	if value := settings.get(prefix + "maximum-applications"); value != "" {
+		maxApps, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.report.add(queuePath, "maximum-applications", value, "invalid number, not converted")
+		} else {
+			queue.MaxApplications = maxApps
+		}
+	}
+	if acl, ok := settings.values[prefix+"acl_submit_applications"]; ok {
+		settings.used[prefix+"acl_submit_applications"] = true
+		queue.SubmitACL = convertACL(acl)
+	}
+	if acl, ok := settings.values[prefix+"acl_administer_queue"]; ok {
+		settings.used[prefix+"acl_administer_queue"] = true
+		queue.AdminACL = convertACL(acl)
+	}
+	c.applicationSortPolicy(&queue, queuePath, "ordering-policy", settings.get(prefix+"ordering-policy"))
+	if state := settings.get(prefix + "state"); state != "" && !strings.EqualFold(state, "RUNNING") {
+		c.report.add(queuePath, "state", state, "queue state cannot be configured, not converted")
+	}
+	children := settings.get(prefix + "queues")
+	for _, child := range strings.Split(children, common.Separator) {
+		child = strings.TrimSpace(child)
+		if child == "" {
+			continue
+		}
+		queue.Queues = append(queue.Queues, c.capacityQueue(settings, yarnPath+configs.DOT+child, queuePath+configs.DOT+queueName(child), guaranteed, maximum, leaves))
+	}
+	if len(queue.Queues) == 0 {
+		leaves[name] = append(leaves[name], queuePath)
+	}
+	return queue
+}
+
+// capacity converts a capacity value. It returns the config values and the converted resource, used for the
+// capacities of the children. Capacities are a percentage of the parent, or absolute as "[memory=1024,vcores=4]".
+func (c *converter) capacity(queuePath, setting, value string, parent *resources.Resource) (map[string]string, *resources.Resource) {
+	if value == "" {
+		return nil, nil
+	}
+	var result map[string]string
+	var err error
+	switch {
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		result, err = c.parseFairResources(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	default:
+		var percentage float64
+		percentage, err = strconv.ParseFloat(value, 64)
+		switch {
+		case err != nil || percentage > 100:
+			err = fmt.Errorf("invalid capacity")
+		case percentage < 0 || (setting == "maximum-capacity" && percentage == 100):
+			// unlimited: the max of the parent applies
+			return nil, nil
+		case parent == nil:
+			err = fmt.Errorf("resources of the parent are unknown, percentage requires the cluster resources")
+		default:
+			result = make(map[string]string)
+			for name, quantity := range parent.Resources {
+				result[name] = fractionValue(name, quantity, percentage/100)
+			}
+		}
+	}
+	if err != nil {
+		c.report.add(queuePath, setting, value, err.Error()+", not converted")
+		return nil, nil
+	}
+	res, err := resources.NewResourceFromConf(result)
+	if err != nil {
+		c.report.add(queuePath, setting, value, err.Error()+", not converted")
+		return nil, nil
+	}
+	return result, res
+}
+
+// capacityRules converts the queue mappings. Unless the mappings override the queue specified by the application,
+// the specified queue is used first.
+func (c *converter) capacityRules(settings *capacitySettings, leaves map[string][]string) []configs.PlacementRule {
+	var rules []configs.PlacementRule
+	mappings := settings.get(capacityMappings)
+	if !strings.EqualFold(settings.get(capacityMappingOverride), "true") {
+		rules = append(rules, configs.PlacementRule{Name: types.Provided})
+		if mappings != "" {
+			c.report.add("", capacityMappings, "", "YARN applies mappings to applications submitted to the default queue, mappings are only used for applications without a queue")
+		}
+	}
+	for _, mapping := range strings.Split(mappings, common.Separator) {
+		mapping = strings.TrimSpace(mapping)
+		if mapping == "" {
+			continue
+		}
+		if rule := c.capacityMapping(mapping, leaves); rule != nil {
+			rules = append(rules, *rule)
+		}
+	}
+	return rules
+}
+
+// capacityMapping converts a single "u:user:queue" or "g:group:queue" mapping into a placement rule.
+func (c *converter) capacityMapping(mapping string, leaves map[string][]string) *configs.PlacementRule {
+	parts := strings.Split(mapping, ":")
+	if len(parts) != 3 {
+		c.report.add("", capacityMappings, mapping, "unknown mapping format, not converted")
+		return nil
+	}
+	kind, source, target := parts[0], parts[1], parts[2]
+	if strings.Contains(target, "%primary_group") || strings.Contains(target, "%secondary_group") {
+		c.report.add("", capacityMappings, mapping, "group based placement is not supported, not converted")
+		return nil
+	}
+	var rule *configs.PlacementRule
+	switch {
+	case target == "%user":
+		rule = &configs.PlacementRule{Name: types.User}
+	case strings.HasSuffix(target, ".%user"):
+		rule = &configs.PlacementRule{
+			Name: types.User,
+			Parent: &configs.PlacementRule{
+				Name:  types.Fixed,
+				Value: c.mappingQueue(strings.TrimSuffix(target, ".%user"), mapping, leaves),
+			},
+		}
+	case strings.Contains(target, "%"):
+		c.report.add("", capacityMappings, mapping, "unknown mapping target, not converted")
+		return nil
+	default:
+		rule = &configs.PlacementRule{Name: types.Fixed, Value: c.mappingQueue(target, mapping, leaves)}
+	}
+	switch {
+	case kind == "u" && source == "%user":
+		// applies to all users
+	case kind == "u":
+		rule.Filter = configs.Filter{Type: "allow", Users: []string{source}}
+	case kind == "g":
+		rule.Filter = configs.Filter{Type: "allow", Groups: []string{source}}
+	default:
+		c.report.add("", capacityMappings, mapping, "unknown mapping type, not converted")
+		return nil
+	}
+	return rule
+}
+
+// mappingQueue returns the full queue path for a mapping target: a leaf queue name is resolved to its path.
+func (c *converter) mappingQueue(target, mapping string, leaves map[string][]string) string {
+	target = queueName(target)
+	if target == configs.RootQueue || strings.HasPrefix(target, configs.RootQueue+configs.DOT) {
+		return target
+	}
+	if paths := leaves[target]; len(paths) == 1 {
+		return paths[0]
+	} else if len(paths) > 1 {
+		c.report.add("", capacityMappings, mapping, "leaf queue name is not unique, mapped below root")
+	}
+	return configs.RootQueue + configs.DOT + target
+}
@@ -0,0 +1,137 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yarn
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+)
+
+const capacitySchedulerFile = `<?xml version="1.0"?>
+<configuration>
+  <property><name>yarn.scheduler.capacity.maximum-applications</name><value>10000</value></property>
+  <property><name>yarn.scheduler.capacity.root.queues</name><value>default,eng,Science</value></property>
+  <property><name>yarn.scheduler.capacity.root.acl_submit_applications</name><value> </value></property>
+  <property><name>yarn.scheduler.capacity.root.default.capacity</name><value>20</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.capacity</name><value>50</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.maximum-capacity</name><value>80</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.maximum-applications</name><value>100</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.acl_submit_applications</name><value>alice,bob eng</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.queues</name><value>dev,ops</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.dev.capacity</name><value>60</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.ops.capacity</name><value>40</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.ops.user-limit-factor</name><value>2</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.ops.queues</name><value>batch,stream</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.ops.batch.capacity</name><value>3w</value></property>
+  <property><name>yarn.scheduler.capacity.root.eng.ops.stream.capacity</name><value>0w</value></property>
+  <property><name>yarn.scheduler.capacity.root.Science.capacity</name><value>[memory=1024,vcores=2]</value></property>
+  <property><name>yarn.scheduler.capacity.root.Science.state</name><value>STOPPED</value></property>
+  <property><name>yarn.scheduler.capacity.root.Science.ordering-policy</name><value>fair</value></property>
+  <property><name>yarn.scheduler.capacity.queue-mappings</name><value>u:alice:dev,g:science:Science,u:%user:%primary_group,u:%user:eng.%user,u:%user:%user</value></property>
+</configuration>
+`
+
+func TestConvertCapacityScheduler(t *testing.T) {
+	_, _, err := ConvertCapacityScheduler([]byte("<configuration>"), Options{})
+	assert.ErrorContains(t, err, "failed to parse")
+
+	conf, report, err := ConvertCapacityScheduler([]byte(capacitySchedulerFile), Options{ClusterResources: map[string]string{"memory": "1000", "vcore": "10"}})
+	assert.NilError(t, err, "conversion failed")
+	root := conf.Partitions[0].Queues[0]
+	assert.Equal(t, root.SubmitACL, "", "blank ACL allows nobody")
+	assert.Equal(t, root.AdminACL, "*")
+	assert.Equal(t, len(root.Queues), 3)
+	assert.DeepEqual(t, root.Queues[0].Resources.Guaranteed, map[string]string{"memory": "200", "vcore": "2000m"})
+	eng := root.Queues[1]
+	assert.DeepEqual(t, eng.Resources.Guaranteed, map[string]string{"memory": "500", "vcore": "5000m"})
+	assert.DeepEqual(t, eng.Resources.Max, map[string]string{"memory": "800", "vcore": "8000m"})
+	assert.Equal(t, eng.MaxApplications, uint64(100))
+	assert.Equal(t, eng.SubmitACL, "alice,bob eng")
+	assert.Assert(t, eng.Parent, "queue with children should be a parent")
+	// children are relative to the parent
+	assert.DeepEqual(t, eng.Queues[0].Resources.Guaranteed, map[string]string{"memory": "300", "vcore": "3000m"})
+	assert.Equal(t, eng.Queues[0].MaxApplications, uint64(100), "max applications should be inherited")
+	batch := eng.Queues[1].Queues[0]
+	assert.Equal(t, batch.Properties[configs.QueueWeight], "3")
+	assert.Assert(t, batch.Resources.Guaranteed == nil, "weight should not set a guarantee")
+	science := root.Queues[2]
+	assert.Equal(t, science.Name, "science")
+	assert.DeepEqual(t, science.Resources.Guaranteed, map[string]string{"memory": "1024Mi", "vcore": "2"})
+	assert.Equal(t, science.Properties[configs.ApplicationSortPolicy], "fair")
+
+	rules := conf.Partitions[0].PlacementRules
+	assert.Equal(t, len(rules), 5, "unexpected rules: %v", rules)
+	assert.DeepEqual(t, rules[0], configs.PlacementRule{Name: "provided"})
+	assert.DeepEqual(t, rules[1], configs.PlacementRule{Name: "fixed", Value: "root.eng.dev", Filter: configs.Filter{Type: "allow", Users: []string{"alice"}}})
+	assert.DeepEqual(t, rules[2], configs.PlacementRule{Name: "fixed", Value: "root.science", Filter: configs.Filter{Type: "allow", Groups: []string{"science"}}})
+	assert.DeepEqual(t, rules[3], configs.PlacementRule{Name: "user", Parent: &configs.PlacementRule{Name: "fixed", Value: "root.eng"}})
+	assert.DeepEqual(t, rules[4], configs.PlacementRule{Name: "user"})
+
+	settings := make(map[string]string)
+	for _, entry := range report.Entries {
+		settings[entry.Queue+":"+entry.Setting+"="+entry.Value] = entry.Message
+	}
+	for _, expected := range []string{
+		"root.science:state=STOPPED",
+		"root:acl_administer_queue=",
+		":yarn.scheduler.capacity.queue-mappings=",
+		":yarn.scheduler.capacity.queue-mappings=u:%user:%primary_group",
+		":yarn.scheduler.capacity.maximum-applications=10000",
+		"root.eng.ops.stream:capacity=0",
+	} {
+		_, ok := settings[expected]
+		assert.Assert(t, ok, "missing report entry %s in %v", expected, report.Entries)
+	}
+	assert.Equal(t, settings[":yarn.scheduler.capacity.root.eng.ops.user-limit-factor=2"], "user limits relative to the queue capacity are not supported, not converted")
+
+	// the converted config must pass validation
+	content, err := yaml.Marshal(conf)
+	assert.NilError(t, err)
+	_, err = configs.LoadSchedulerConfigFromByteArray(content)
+	assert.NilError(t, err, "converted config is not valid")
+
+	// without cluster resources percentages are reported
+	conf, report, err = ConvertCapacityScheduler([]byte(capacitySchedulerFile), Options{})
+	assert.NilError(t, err, "conversion failed")
+	assert.Assert(t, conf.Partitions[0].Queues[0].Queues[1].Resources.Guaranteed == nil, "percentage should not be converted")
+	found := false
+	for _, entry := range report.Entries {
+		if entry.Queue == "root.eng" && entry.Setting == "capacity" {
+			found = true
+		}
+	}
+	assert.Assert(t, found, "capacity should be reported: %v", report.Entries)
+}
+
+func TestCapacity(t *testing.T) {
+	c, err := newConverter(Options{})
+	assert.NilError(t, err)
+	values, res := c.capacity("root.a", "maximum-capacity", "100", nil)
+	assert.Assert(t, values == nil && res == nil, "unlimited max should not be set")
+	values, res = c.capacity("root.a", "maximum-capacity", "-1", nil)
+	assert.Assert(t, values == nil && res == nil, "unlimited max should not be set")
+	values, res = c.capacity("root.a", "capacity", "120", nil)
+	assert.Assert(t, values == nil && res == nil, "invalid capacity should not be converted")
+	assert.Equal(t, len(c.report.Entries), 1)
+	assert.Equal(t, c.report.Entries[0].String(), "root.a: capacity=120: invalid capacity, not converted")
+}
@@ -0,0 +1,277 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package yarn converts Hadoop YARN Fair Scheduler and Capacity Scheduler configurations into a scheduler config.
+// Settings that cannot be converted exactly are listed in a conversion report.
+package yarn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/placement/types"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+)
+
+// YARN resource names
+const (
+	yarnMemory   = "memory-mb"
+	yarnVCores   = "vcores"
+	yarnMemoryMB = "mb"
+	yarnCPU      = "cpu"
+	yarnMemShort = "memory"
+)
+
+// Options change how a YARN configuration is converted.
+type Options struct {
+	// ClusterResources is used to convert percentages of the cluster into absolute resources,
+	// e.g. {"memory": "512Gi", "vcore": "128"}. Without it percentages are not converted and reported.
+	ClusterResources map[string]string
+}
+
+// ReportEntry describes a YARN setting that was not converted or not converted exactly.
+type ReportEntry struct {
+	Queue   string `json:"queue,omitempty"`
+	Setting string `json:"setting"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ReportEntry) String() string {
+	location := e.Setting
+	if e.Queue != "" {
+		location = e.Queue + ": " + e.Setting
+	}
+	if e.Value != "" {
+		location += "=" + e.Value
+	}
+	return location + ": " + e.Message
+}
+
+// Report lists the settings that need a manual check after the conversion.
+type Report struct {
+	Entries []ReportEntry `json:"entries"`
+}
+
+func (r *Report) add(queue, setting, value, message string) {
+	r.Entries = append(r.Entries, ReportEntry{
+		Queue:   queue,
+		Setting: setting,
+		Value:   strings.TrimSpace(value),
+		Message: message,
+	})
+}
+
+// converter holds the state shared by the Fair and Capacity Scheduler conversions.
+type converter struct {
+	cluster *resources.Resource
+	report  *Report
+}
+
+func newConverter(opts Options) (*converter, error) {
+	c := &converter{report: &Report{}}
+	if len(opts.ClusterResources) != 0 {
+		cluster, err := resources.NewResourceFromConf(opts.ClusterResources)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster resources: %w", err)
+		}
+		c.cluster = cluster
+	}
+	return c, nil
+}
+
+// newSchedulerConfig wraps the converted queues and rules in the default partition.
+func newSchedulerConfig(root configs.QueueConfig, rules []configs.PlacementRule) *configs.SchedulerConfig {
+	inheritMaxApplications(&root, 0)
+	markParentQueues(&root)
+	for _, rule := range rules {
+		markRuleParents(&root, rule.Parent)
+	}
+	return &configs.SchedulerConfig{
+		Partitions: []configs.PartitionConfig{
+			{
+				Name:           configs.DefaultPartition,
+				Queues:         []configs.QueueConfig{root},
+				PlacementRules: rules,
+			},
+		},
+	}
+}
+
+// resourceName returns the resource name for a YARN resource name.
+func resourceName(name string) string {
+	switch strings.ToLower(name) {
+	case yarnMemory, yarnMemoryMB, yarnMemShort:
+		return siCommon.Memory
+	case yarnVCores, yarnCPU:
+		return siCommon.CPU
+	default:
+		return name
+	}
+}
+
+// absoluteValue returns the config value for an absolute YARN quantity: memory is set in MB in YARN.
+func absoluteValue(name string, value string) (string, error) {
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 {
+		return "", fmt.Errorf("invalid quantity %s for resource %s", value, name)
+	}
+	whole := number == math.Trunc(number)
+	switch {
+	case name == siCommon.Memory && whole:
+		return strconv.FormatInt(int64(number), 10) + "Mi", nil
+	case name == siCommon.Memory:
+		return strconv.FormatInt(int64(number*1024*1024), 10), nil
+	case name == siCommon.CPU && !whole:
+		return strconv.FormatInt(int64(number*1000), 10) + "m", nil
+	default:
+		return strconv.FormatInt(int64(number), 10), nil
+	}
+}
+
+// markParentQueues marks all queues with children as parent queues.
+func markParentQueues(queue *configs.QueueConfig) {
+	if len(queue.Queues) != 0 {
+		queue.Parent = true
+	}
+	for i := range queue.Queues {
+		markParentQueues(&queue.Queues[i])
+	}
+}
+
+// markRuleParents marks the queues used by fixed parent rules as parent queues: a YARN queue is a parent
+// when queues are created below it.
+func markRuleParents(root *configs.QueueConfig, rule *configs.PlacementRule) {
+	if rule == nil {
+		return
+	}
+	if rule.Name == types.Fixed {
+		queue := root
+		for _, name := range strings.Split(rule.Value, configs.DOT)[1:] {
+			if queue = findQueue(queue, name); queue == nil {
+				return
+			}
+		}
+		queue.Parent = true
+	}
+	markRuleParents(root, rule.Parent)
+}
+
+func findQueue(queue *configs.QueueConfig, name string) *configs.QueueConfig {
+	for i := range queue.Queues {
+		if queue.Queues[i].Name == name {
+			return &queue.Queues[i]
+		}
+	}
+	return nil
+}
+
+// inheritMaxApplications sets the max applications of the parent on children without a limit of their own.
+// A YARN queue without a limit is only limited by its parents, the scheduler requires a limit on the children.
+func inheritMaxApplications(queue *configs.QueueConfig, parentMax uint64) {
+	if queue.MaxApplications == 0 {
+		queue.MaxApplications = parentMax
+	}
+	for i := range queue.Queues {
+		inheritMaxApplications(&queue.Queues[i], queue.MaxApplications)
+	}
+}
+
+// fractionValue returns the config value for a fraction of a quantity, rounded down.
+func fractionValue(name string, quantity resources.Quantity, fraction float64) string {
+	value := strconv.FormatInt(int64(math.Floor(float64(quantity)*fraction)), 10)
+	if name == siCommon.CPU {
+		return value + "m"
+	}
+	return value
+}
+
+// clusterFraction returns the config values for a fraction of the cluster resources.
+// Returns false if the cluster resources are not set.
+func (c *converter) clusterFraction(fraction float64, names ...string) (map[string]string, bool) {
+	if c.cluster == nil {
+		return nil, false
+	}
+	result := make(map[string]string)
+	for name, quantity := range c.cluster.Resources {
+		if len(names) != 0 && !slices.Contains(names, name) {
+			continue
+		}
+		result[name] = fractionValue(name, quantity, fraction)
+	}
+	return result, true
+}
+
+// convertACL returns the ACL for a YARN ACL: both use a user list and a group list separated by a space.
+// A blank YARN ACL allows nobody, as does an empty ACL.
+func convertACL(acl string) string {
+	if strings.TrimSpace(acl) == "" {
+		return ""
+	}
+	acl = strings.TrimRight(acl, " \t\r\n")
+	if strings.TrimSpace(acl) == common.Wildcard {
+		return common.Wildcard
+	}
+	return acl
+}
+
+// applicationSortPolicy sets the application sort policy of the queue for a YARN scheduling or ordering policy.
+func (c *converter) applicationSortPolicy(queue *configs.QueueConfig, queuePath, setting, policy string) {
+	policy = strings.TrimSpace(policy)
+	switch strings.ToLower(policy) {
+	case "":
+		return
+	case "fair":
+		setProperty(queue, configs.ApplicationSortPolicy, "fair")
+	case "fifo":
+		setProperty(queue, configs.ApplicationSortPolicy, "fifo")
+	case "drf":
+		setProperty(queue, configs.ApplicationSortPolicy, "drf")
+	default:
+		c.report.add(queuePath, setting, policy, "unknown policy, not converted")
+	}
+}
+
+// weight sets the weight of the queue, a weight of zero has no equivalent.
+func (c *converter) weight(queue *configs.QueueConfig, queuePath, setting, value string) {
+	value = strings.TrimSpace(value)
+	weight, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) || weight <= 0 {
+		c.report.add(queuePath, setting, value, "weight must be a positive number, not converted")
+		return
+	}
+	setProperty(queue, configs.QueueWeight, strconv.FormatFloat(weight, 'f', -1, 64))
+}
+
+func setProperty(queue *configs.QueueConfig, key, value string) {
+	if queue.Properties == nil {
+		queue.Properties = make(map[string]string)
+	}
+	queue.Properties[key] = value
+}
+
+// queueName returns the lower case queue name as used by the scheduler, the name is checked by the validation.
+func queueName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
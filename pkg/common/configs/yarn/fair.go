@@ -0,0 +1,319 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yarn
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/scheduler/placement/types"
+)
+
+// a resource in the "1024 mb, 4 vcores" or "50% memory, 25% cpu" format
+var fairResourceRegExp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*(%?)\s*([a-zA-Z][a-zA-Z0-9./_-]*)$`)
+
+// fairAllocations is the content of a fair-scheduler.xml allocation file.
+type fairAllocations struct {
+	Queues                       []fairQueue          `xml:"queue"`
+	Users                        []fairUser           `xml:"user"`
+	UserMaxAppsDefault           *uint64              `xml:"userMaxAppsDefault"`
+	QueueMaxAppsDefault          *uint64              `xml:"queueMaxAppsDefault"`
+	QueueMaxResourcesDefault     string               `xml:"queueMaxResourcesDefault"`
+	DefaultQueueSchedulingPolicy string               `xml:"defaultQueueSchedulingPolicy"`
+	PlacementPolicy              *fairPlacementPolicy `xml:"queuePlacementPolicy"`
+	Other                        []xmlElement         `xml:",any"`
+}
+
+type fairQueue struct {
+	Name              string       `xml:"name,attr"`
+	Type              string       `xml:"type,attr"`
+	MinResources      string       `xml:"minResources"`
+	MaxResources      string       `xml:"maxResources"`
+	MaxChildResources string       `xml:"maxChildResources"`
+	MaxRunningApps    *uint64      `xml:"maxRunningApps"`
+	Weight            string       `xml:"weight"`
+	SchedulingPolicy  string       `xml:"schedulingPolicy"`
+	ACLSubmitApps     *string      `xml:"aclSubmitApps"`
+	ACLAdministerApps *string      `xml:"aclAdministerApps"`
+	Queues            []fairQueue  `xml:"queue"`
+	Other             []xmlElement `xml:",any"`
+}
+
+type fairUser struct {
+	Name           string       `xml:"name,attr"`
+	MaxRunningApps *uint64      `xml:"maxRunningApps"`
+	Other          []xmlElement `xml:",any"`
+}
+
+type fairPlacementPolicy struct {
+	Rules []fairRule `xml:"rule"`
+}
+
+type fairRule struct {
+	Name   string     `xml:"name,attr"`
+	Create string     `xml:"create,attr"`
+	Queue  string     `xml:"queue,attr"`
+	Rules  []fairRule `xml:"rule"`
+}
+
+// xmlElement is an element that is not converted, it is only reported.
+type xmlElement struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// ConvertFairScheduler converts the content of a YARN fair-scheduler.xml allocation file.
+func ConvertFairScheduler(content []byte, opts Options) (*configs.SchedulerConfig, *Report, error) {
+	var alloc fairAllocations
+	if err := xml.Unmarshal(content, &alloc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse fair scheduler allocation file: %w", err)
+	}
+	c, err := newConverter(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, other := range alloc.Other {
+		c.report.add("", other.XMLName.Local, other.Value, "not supported, not converted")
+	}
+	// the root queue is optional in the allocation file
+	rootQueue := fairQueue{Name: configs.RootQueue, Queues: alloc.Queues}
+	if len(alloc.Queues) == 1 && queueName(alloc.Queues[0].Name) == configs.RootQueue {
+		rootQueue = alloc.Queues[0]
+	}
+	root := c.fairQueue(rootQueue, "", &alloc)
+	// YARN allows everyone on the root queue if no ACL is set
+	if rootQueue.ACLSubmitApps == nil {
+		root.SubmitACL = common.Wildcard
+		c.report.add(configs.RootQueue, "aclSubmitApps", "", "not set: YARN allows all users, submit ACL set to *")
+	}
+	if rootQueue.ACLAdministerApps == nil {
+		root.AdminACL = common.Wildcard
+		c.report.add(configs.RootQueue, "aclAdministerApps", "", "not set: YARN allows all users, admin ACL set to *")
+	}
+	c.applicationSortPolicy(&root, configs.RootQueue, "defaultQueueSchedulingPolicy", alloc.DefaultQueueSchedulingPolicy)
+	root.Limits = append(root.Limits, c.fairLimits(&alloc)...)
+	return newSchedulerConfig(root, c.fairRules(alloc.PlacementPolicy)), c.report, nil
+}
+
+// fairQueue converts the queue and all queues below it.
+func (c *converter) fairQueue(yarnQueue fairQueue, parentPath string, alloc *fairAllocations) configs.QueueConfig {
+	name := queueName(yarnQueue.Name)
+	queuePath := name
+	if parentPath != "" {
+		queuePath = parentPath + configs.DOT + name
+	}
+	queue := configs.QueueConfig{
+		Name:   name,
+		Parent: strings.EqualFold(yarnQueue.Type, "parent"),
+	}
+	for _, other := range yarnQueue.Other {
+		c.report.add(queuePath, other.XMLName.Local, other.Value, "not supported, not converted")
+	}
+	queue.Resources.Guaranteed = c.fairResources(queuePath, "minResources", yarnQueue.MinResources)
+	maxResources := yarnQueue.MaxResources
+	if maxResources == "" && parentPath != "" {
+		maxResources = alloc.QueueMaxResourcesDefault
+	}
+	queue.Resources.Max = c.fairResources(queuePath, "maxResources", maxResources)
+	queue.ChildTemplate.Resources.Max = c.fairResources(queuePath, "maxChildResources", yarnQueue.MaxChildResources)
+	if yarnQueue.MaxRunningApps != nil {
+		queue.MaxApplications = *yarnQueue.MaxRunningApps
+	} else if alloc.QueueMaxAppsDefault != nil && parentPath != "" {
+		queue.MaxApplications = *alloc.QueueMaxAppsDefault
+	}
+	if yarnQueue.Weight != "" {
+		c.weight(&queue, queuePath, "weight", yarnQueue.Weight)
+	}
+	c.applicationSortPolicy(&queue, queuePath, "schedulingPolicy", yarnQueue.SchedulingPolicy)
+	if yarnQueue.ACLSubmitApps != nil {
+		queue.SubmitACL = convertACL(*yarnQueue.ACLSubmitApps)
+	}
+	if yarnQueue.ACLAdministerApps != nil {
+		queue.AdminACL = convertACL(*yarnQueue.ACLAdministerApps)
+	}
+	for _, child := range yarnQueue.Queues {
+		queue.Queues = append(queue.Queues, c.fairQueue(child, queuePath, alloc))
+	}
+	return queue
+}
+
+// fairResources converts a resource value, a value that cannot be converted is reported and nil is returned.
+func (c *converter) fairResources(queuePath, setting, value string) map[string]string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	result, err := c.parseFairResources(value)
+	if err != nil {
+		c.report.add(queuePath, setting, value, err.Error()+", not converted")
+		return nil
+	}
+	return result
+}
+
+// parseFairResources parses the resource formats of the allocation file: "1024 mb, 4 vcores",
+// "memory-mb=1024, vcores=4", "50% memory, 25% cpu", "vcores=50%, memory-mb=25%" and "50%".
+// Percentages are of the cluster resources.
+func (c *converter) parseFairResources(value string) (map[string]string, error) {
+	// a single percentage applies to all resources
+	if percentage, found := strings.CutSuffix(value, "%"); found && !strings.ContainsAny(percentage, ",=% ") {
+		return c.percentage(percentage)
+	}
+	result := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var name, quantity string
+		if key, val, found := strings.Cut(part, "="); found {
+			name = resourceName(strings.TrimSpace(key))
+			quantity = strings.TrimSpace(val)
+		} else {
+			match := fairResourceRegExp.FindStringSubmatch(part)
+			if match == nil {
+				return nil, fmt.Errorf("invalid resource %s", part)
+			}
+			name = resourceName(match[3])
+			quantity = match[1] + match[2]
+		}
+		if percentage, found := strings.CutSuffix(quantity, "%"); found {
+			values, err := c.percentage(percentage, name)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := values[name]; !ok {
+				return nil, fmt.Errorf("resource %s is not set in the cluster resources", name)
+			}
+			result[name] = values[name]
+			continue
+		}
+		converted, err := absoluteValue(name, quantity)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = converted
+	}
+	return result, nil
+}
+
+// percentage returns the percentage of the cluster resources, limited to the names if given.
+func (c *converter) percentage(value string, names ...string) (map[string]string, error) {
+	percentage, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || percentage < 0 {
+		return nil, fmt.Errorf("invalid percentage %s", value)
+	}
+	result, ok := c.clusterFraction(percentage/100, names...)
+	if !ok {
+		return nil, fmt.Errorf("percentage of the cluster requires the cluster resources")
+	}
+	return result, nil
+}
+
+// fairLimits converts the per user settings into limits on the root queue.
+func (c *converter) fairLimits(alloc *fairAllocations) []configs.Limit {
+	var limits []configs.Limit
+	for _, user := range alloc.Users {
+		for _, other := range user.Other {
+			c.report.add("user "+user.Name, other.XMLName.Local, other.Value, "not supported, not converted")
+		}
+		if user.MaxRunningApps == nil {
+			continue
+		}
+		limits = append(limits, configs.Limit{
+			Limit:           "user " + user.Name,
+			Users:           []string{user.Name},
+			MaxApplications: *user.MaxRunningApps,
+		})
+	}
+	if alloc.UserMaxAppsDefault != nil {
+		limits = append(limits, configs.Limit{
+			Limit:           "default user limit",
+			Users:           []string{common.Wildcard},
+			MaxApplications: *alloc.UserMaxAppsDefault,
+		})
+	}
+	return limits
+}
+
+// fairRules converts the placement policy. Without a policy YARN places applications in the specified queue,
+// or in the default queue if none is specified.
+func (c *converter) fairRules(policy *fairPlacementPolicy) []configs.PlacementRule {
+	if policy == nil {
+		return []configs.PlacementRule{
+			{Name: types.Provided, Create: true},
+			{Name: types.Fixed, Value: common.DefaultPlacementQueue, Create: true},
+		}
+	}
+	var rules []configs.PlacementRule
+	for i, yarnRule := range policy.Rules {
+		if strings.EqualFold(yarnRule.Name, "reject") {
+			if i != len(policy.Rules)-1 {
+				c.report.add("", "queuePlacementPolicy", yarnRule.Name, "rules after the reject rule are never used, not converted")
+			}
+			// without a matching rule the application is rejected, unless the default queue exists
+			c.report.add("", "queuePlacementPolicy", yarnRule.Name, "applications that no rule places are rejected only if the root.default queue does not exist")
+			break
+		}
+		if rule := c.fairRule(yarnRule); rule != nil {
+			rules = append(rules, *rule)
+		}
+	}
+	return rules
+}
+
+// fairRule converts a single placement rule, nil is returned for rules that cannot be converted.
+func (c *converter) fairRule(yarnRule fairRule) *configs.PlacementRule {
+	// YARN creates queues by default
+	create := !strings.EqualFold(yarnRule.Create, "false")
+	switch yarnRule.Name {
+	case "specified":
+		return &configs.PlacementRule{Name: types.Provided, Create: create}
+	case "user":
+		return &configs.PlacementRule{Name: types.User, Create: create}
+	case "default":
+		queue := queueName(yarnRule.Queue)
+		if queue == "" {
+			queue = common.DefaultPlacementQueue
+		} else if !strings.HasPrefix(queue, configs.RootQueue+configs.DOT) {
+			queue = configs.RootQueue + configs.DOT + queue
+		}
+		return &configs.PlacementRule{Name: types.Fixed, Value: queue, Create: create}
+	case "nestedUserQueue":
+		if len(yarnRule.Rules) != 1 {
+			c.report.add("", "queuePlacementPolicy", yarnRule.Name, "rule must have exactly one nested rule, not converted")
+			return nil
+		}
+		parent := c.fairRule(yarnRule.Rules[0])
+		if parent == nil {
+			return nil
+		}
+		return &configs.PlacementRule{Name: types.User, Create: create, Parent: parent}
+	case "primaryGroup", "secondaryGroupExistingQueue":
+		c.report.add("", "queuePlacementPolicy", yarnRule.Name, "group based placement is not supported, not converted")
+	default:
+		c.report.add("", "queuePlacementPolicy", yarnRule.Name, "unknown rule, not converted")
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package yarn
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+)
+
+const fairAllocationFile = `<?xml version="1.0"?>
+<allocations>
+  <queue name="analytics">
+    <minResources>10000 mb,4vcores</minResources>
+    <maxResources>memory-mb=20000, vcores=8</maxResources>
+    <maxRunningApps>50</maxRunningApps>
+    <maxAMShare>0.1</maxAMShare>
+    <weight>2.0</weight>
+    <schedulingPolicy>drf</schedulingPolicy>
+    <aclSubmitApps>alice,bob analysts</aclSubmitApps>
+    <queue name="Reports">
+      <minResources>50%</minResources>
+    </queue>
+  </queue>
+  <queue name="users" type="parent">
+    <maxChildResources>1024 mb, 1 vcores</maxChildResources>
+  </queue>
+  <queue name="default">
+    <schedulingPolicy>fifo</schedulingPolicy>
+  </queue>
+  <user name="bob">
+    <maxRunningApps>5</maxRunningApps>
+  </user>
+  <userMaxAppsDefault>10</userMaxAppsDefault>
+  <queueMaxAMShareDefault>0.5</queueMaxAMShareDefault>
+  <queuePlacementPolicy>
+    <rule name="specified" create="false"/>
+    <rule name="primaryGroup"/>
+    <rule name="nestedUserQueue">
+      <rule name="default" queue="users"/>
+    </rule>
+    <rule name="reject"/>
+    <rule name="default"/>
+  </queuePlacementPolicy>
+</allocations>
+`
+
+func TestConvertFairScheduler(t *testing.T) {
+	_, _, err := ConvertFairScheduler([]byte("<allocations>"), Options{})
+	assert.ErrorContains(t, err, "failed to parse")
+	_, _, err = ConvertFairScheduler([]byte(fairAllocationFile), Options{ClusterResources: map[string]string{"memory": "x"}})
+	assert.ErrorContains(t, err, "invalid cluster resources")
+
+	conf, report, err := ConvertFairScheduler([]byte(fairAllocationFile), Options{})
+	assert.NilError(t, err, "conversion failed")
+	root := conf.Partitions[0].Queues[0]
+	assert.Equal(t, root.Name, configs.RootQueue)
+	assert.Equal(t, root.SubmitACL, "*")
+	assert.Equal(t, len(root.Queues), 3)
+	analytics := root.Queues[0]
+	assert.Equal(t, analytics.Name, "analytics")
+	assert.DeepEqual(t, analytics.Resources.Guaranteed, map[string]string{"memory": "10000Mi", "vcore": "4"})
+	assert.DeepEqual(t, analytics.Resources.Max, map[string]string{"memory": "20000Mi", "vcore": "8"})
+	assert.Equal(t, analytics.MaxApplications, uint64(50))
+	assert.Equal(t, analytics.SubmitACL, "alice,bob analysts")
+	assert.Equal(t, analytics.Properties[configs.ApplicationSortPolicy], "drf")
+	assert.Equal(t, analytics.Properties[configs.QueueWeight], "2")
+	assert.Assert(t, analytics.Parent, "queue with children should be a parent")
+	reports := analytics.Queues[0]
+	assert.Equal(t, reports.Name, "reports")
+	assert.Assert(t, reports.Resources.Guaranteed == nil, "percentage should not be converted without cluster resources")
+	assert.Equal(t, reports.MaxApplications, uint64(50), "max applications should be inherited")
+	users := root.Queues[1]
+	assert.Assert(t, users.Parent, "parent type should be converted")
+	assert.DeepEqual(t, users.ChildTemplate.Resources.Max, map[string]string{"memory": "1024Mi", "vcore": "1"})
+	assert.Equal(t, root.Queues[2].Properties[configs.ApplicationSortPolicy], "fifo")
+	assert.DeepEqual(t, root.Limits, []configs.Limit{
+		{Limit: "user bob", Users: []string{"bob"}, MaxApplications: 5},
+		{Limit: "default user limit", Users: []string{"*"}, MaxApplications: 10},
+	})
+	rules := conf.Partitions[0].PlacementRules
+	assert.Equal(t, len(rules), 2, "unexpected rules: %v", rules)
+	assert.DeepEqual(t, rules[0], configs.PlacementRule{Name: "provided"})
+	assert.Equal(t, rules[1].Name, "user")
+	assert.Assert(t, rules[1].Create)
+	assert.DeepEqual(t, rules[1].Parent, &configs.PlacementRule{Name: "fixed", Value: "root.users", Create: true})
+
+	settings := make(map[string]bool)
+	for _, entry := range report.Entries {
+		settings[entry.Queue+":"+entry.Setting+"="+entry.Value] = true
+	}
+	for _, expected := range []string{
+		":queueMaxAMShareDefault=0.5",
+		"root.analytics:maxAMShare=0.1",
+		"root.analytics.reports:minResources=50%",
+		"root:aclSubmitApps=",
+		":queuePlacementPolicy=primaryGroup",
+		":queuePlacementPolicy=reject",
+	} {
+		assert.Assert(t, settings[expected], "missing report entry %s in %v", expected, report.Entries)
+	}
+
+	// the converted config must pass validation
+	content, err := yaml.Marshal(conf)
+	assert.NilError(t, err)
+	_, err = configs.LoadSchedulerConfigFromByteArray(content)
+	assert.NilError(t, err, "converted config is not valid")
+
+	// percentages of the cluster
+	conf, _, err = ConvertFairScheduler([]byte(fairAllocationFile), Options{ClusterResources: map[string]string{"memory": "16Gi", "vcore": "8"}})
+	assert.NilError(t, err, "conversion failed")
+	assert.DeepEqual(t, conf.Partitions[0].Queues[0].Queues[0].Queues[0].Resources.Guaranteed, map[string]string{"memory": "8589934592", "vcore": "4000m"})
+}
+
+func TestConvertFairSchedulerDefaults(t *testing.T) {
+	conf, report, err := ConvertFairScheduler([]byte(`<allocations><queue name="root"><aclSubmitApps> </aclSubmitApps><aclAdministerApps>admin</aclAdministerApps></queue></allocations>`), Options{})
+	assert.NilError(t, err, "conversion failed")
+	assert.Equal(t, len(report.Entries), 0, "unexpected report: %v", report.Entries)
+	root := conf.Partitions[0].Queues[0]
+	assert.Equal(t, root.SubmitACL, "", "blank ACL allows nobody")
+	assert.Equal(t, root.AdminACL, "admin")
+	assert.DeepEqual(t, conf.Partitions[0].PlacementRules, []configs.PlacementRule{
+		{Name: "provided", Create: true},
+		{Name: "fixed", Value: "root.default", Create: true},
+	})
+}
+
+func TestParseFairResources(t *testing.T) {
+	c, err := newConverter(Options{ClusterResources: map[string]string{"memory": "1000", "vcore": "10", "nvidia.com/gpu": "4"}})
+	assert.NilError(t, err)
+	tests := []struct {
+		value    string
+		expected map[string]string
+		err      string
+	}{
+		{"1024 mb, 2 vcores", map[string]string{"memory": "1024Mi", "vcore": "2"}, ""},
+		{"1.5 mb,0.5vcores", map[string]string{"memory": "1572864", "vcore": "500m"}, ""},
+		{"memory-mb=512, vcores=1, nvidia.com/gpu=2", map[string]string{"memory": "512Mi", "vcore": "1", "nvidia.com/gpu": "2"}, ""},
+		{"50% memory, 10% cpu", map[string]string{"memory": "500", "vcore": "1000m"}, ""},
+		{"vcores=50%", map[string]string{"vcore": "5000m"}, ""},
+		{"25%", map[string]string{"memory": "250", "vcore": "2500m", "nvidia.com/gpu": "1"}, ""},
+		{"lots of memory", nil, "invalid resource"},
+		{"memory-mb=x", nil, "invalid quantity"},
+		{"10% disk", nil, "not set in the cluster resources"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			result, err := c.parseFairResources(tt.value)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, result, tt.expected)
+		})
+	}
+	c, err = newConverter(Options{})
+	assert.NilError(t, err)
+	_, err = c.parseFairResources("10%")
+	assert.ErrorContains(t, err, "requires the cluster resources")
+}
@@ -29,4 +29,28 @@ const (
 	RecoveryQueue         = "@recovery@"
 	RecoveryQueueFull     = "root." + RecoveryQueue
 	DefaultPlacementQueue = "root.default"
+
+	// AppTagDeadline is the time, in RFC 3339 format, by which all requests of the application should be allocated
+	AppTagDeadline = "application.deadline"
+	// AppTagMaxWait is the maximum time after submission, as a duration, before all requests should be allocated
+	AppTagMaxWait = "application.maxwait"
+	// AppTagMaxRuntime is the maximum wall-clock time, as a duration, the application may run before it is terminated
+	AppTagMaxRuntime = "application.maxruntime"
+	// AppTagDependencies is a comma separated list of application IDs, in the same partition, that must complete before the application can run
+	AppTagDependencies = "application.dependencies"
+	// AppTagGangMinMembers is the number of requests that must all be allocated in one scheduling pass before any request of the application is allocated
+	AppTagGangMinMembers = "application.gang.minmembers"
+
+	// AskTagTTL is the maximum time, as a duration, a request may be pending before it is removed
+	AskTagTTL = "ask.ttl"
+	// The affinity rules only select the asks of the same application: asks of a task group are selected using the
+	// AskSelectorTaskGroup key, rules between the asks of different applications are left to the RM predicates.
+	// AskTagAffinity selects, using the node selector syntax, the asks of the same application the ask must be co-located with
+	AskTagAffinity = "ask.affinity"
+	// AskTagAntiAffinity selects, using the node selector syntax, the asks of the same application the ask must not be co-located with
+	AskTagAntiAffinity = "ask.antiaffinity"
+	// AskTagTopologyKey is the node attribute that defines the co-location domain of the affinity rules, the default is the node
+	AskTagTopologyKey = "ask.topologykey"
+	// AskSelectorTaskGroup is the key that selects asks on their task group name in the affinity rules
+	AskSelectorTaskGroup = "taskgroup"
 )
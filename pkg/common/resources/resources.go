@@ -616,6 +616,59 @@ func CompUsageRatioSeparately(leftAllocated, leftGuaranteed, leftFairMax, rightA
 	}
 }
 
+// GetWeightedShares returns the weighted share of each resource quantity when compared to the total resources.
+// The share of a resource is multiplied by the weight of the resource, a resource without a weight has a weight of 1.
+// Resources that are not part of the total, or have a zero total, cannot be allocated and are skipped.
+func GetWeightedShares(res, total *Resource, weights map[string]float64) map[string]float64 {
+	shares := make(map[string]float64)
+	if res == nil || total == nil {
+		return shares
+	}
+	for k, v := range res.Resources {
+		if v == 0 || total.Resources[k] == 0 {
+			continue
+		}
+		weight, ok := weights[k]
+		if !ok {
+			weight = 1
+		}
+		shares[k] = weight * float64(v) / float64(total.Resources[k])
+	}
+	return shares
+}
+
+// GetDominantShare returns the largest weighted share of the resource when compared to the total resources.
+// See GetWeightedShares for the calculation of the weighted shares.
+func GetDominantShare(res, total *Resource, weights map[string]float64) float64 {
+	dominant := float64(0)
+	for _, share := range GetWeightedShares(res, total, weights) {
+		if share > dominant {
+			dominant = share
+		}
+	}
+	return dominant
+}
+
+// Compare the weighted shares of left of total and right of total.
+// The dominant shares are compared first, the next largest shares on a tie.
+// This returns the same value as compareShares does:
+// 0 for equal shares
+// 1 if the left share is larger
+// -1 if the right share is larger
+func CompDominantShare(left, right, total *Resource, weights map[string]float64) int {
+	return compareShares(sortedShares(GetWeightedShares(left, total, weights)), sortedShares(GetWeightedShares(right, total, weights)))
+}
+
+// sortedShares returns the shares in increasing order as used by compareShares.
+func sortedShares(shares map[string]float64) []float64 {
+	sorted := make([]float64, 0, len(shares))
+	for _, share := range shares {
+		sorted = append(sorted, share)
+	}
+	sort.Float64s(sorted)
+	return sorted
+}
+
 // Get fairness ratio calculated by:
 // highest share for left resource from total divided by
 // highest share for right resource from total.
@@ -1659,6 +1659,40 @@ func TestCompUsageRatio(t *testing.T) {
 	}
 }
 
+func TestGetWeightedShares(t *testing.T) {
+	total := NewResourceFromMap(map[string]Quantity{"memory": 1000, "vcore": 100, "gpu": 10})
+	shares := GetWeightedShares(nil, total, nil)
+	assert.Equal(t, len(shares), 0, "nil resource should not have shares")
+	shares = GetWeightedShares(NewResourceFromMap(map[string]Quantity{"memory": 100}), nil, nil)
+	assert.Equal(t, len(shares), 0, "nil total should not have shares")
+
+	res := NewResourceFromMap(map[string]Quantity{"memory": 500, "vcore": 10, "gpu": 1, "unknown": 5, "zero": 0})
+	shares = GetWeightedShares(res, total, nil)
+	assert.DeepEqual(t, shares, map[string]float64{"memory": 0.5, "vcore": 0.1, "gpu": 0.1})
+	assert.Equal(t, GetDominantShare(res, total, nil), 0.5)
+
+	weights := map[string]float64{"gpu": 10, "memory": 0.5}
+	shares = GetWeightedShares(res, total, weights)
+	assert.DeepEqual(t, shares, map[string]float64{"memory": 0.25, "vcore": 0.1, "gpu": 1})
+	assert.Equal(t, GetDominantShare(res, total, weights), 1.0)
+	assert.Equal(t, GetDominantShare(nil, total, weights), 0.0)
+}
+
+func TestCompDominantShare(t *testing.T) {
+	total := NewResourceFromMap(map[string]Quantity{"memory": 1000, "gpu": 10})
+	memory := NewResourceFromMap(map[string]Quantity{"memory": 400})
+	gpu := NewResourceFromMap(map[string]Quantity{"gpu": 2})
+	assert.Equal(t, CompDominantShare(nil, nil, total, nil), 0)
+	assert.Equal(t, CompDominantShare(memory, gpu, total, nil), 1, "memory share 0.4 should be larger than gpu share 0.2")
+	weights := map[string]float64{"gpu": 5}
+	assert.Equal(t, CompDominantShare(memory, gpu, total, weights), -1, "weighted gpu share 1.0 should be larger than memory share 0.4")
+	assert.Equal(t, CompDominantShare(gpu, memory, total, weights), 1, "weighted gpu share 1.0 should be larger than memory share 0.4")
+	// tie on the dominant share: next share decides
+	both := NewResourceFromMap(map[string]Quantity{"memory": 400, "gpu": 1})
+	assert.Equal(t, CompDominantShare(memory, both, total, nil), -1, "same dominant share, second share should decide")
+	assert.Equal(t, CompDominantShare(memory, memory, total, weights), 0)
+}
+
 func TestCompareShares(t *testing.T) {
 	tests := []struct {
 		left     []float64
@@ -28,6 +28,7 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -49,10 +50,18 @@ func NewNonBlockingGRPCServer() NonBlockingGRPCServer {
 	return &nonBlockingGRPCServer{}
 }
 
+// Create a server that uses TLS, or mutual TLS when a client CA is configured, for all connections.
+func NewNonBlockingGRPCServerWithTLS(tlsConfig *ServerTLSConfig) NonBlockingGRPCServer {
+	return &nonBlockingGRPCServer{
+		tlsConfig: tlsConfig,
+	}
+}
+
 // NonBlocking server
 type nonBlockingGRPCServer struct {
-	wg     sync.WaitGroup
-	server *grpc.Server
+	wg        sync.WaitGroup
+	server    *grpc.Server
+	tlsConfig *ServerTLSConfig
 }
 
 func (s *nonBlockingGRPCServer) Start(endpoint string, ss si.SchedulerServer) {
@@ -104,6 +113,27 @@ func withServerUnaryInterceptor() grpc.ServerOption {
 	return grpc.UnaryInterceptor(logGRPC)
 }
 
+// Returns the server options for the TLS configuration: the transport credentials and, for mutual TLS, the
+// interceptors that check the RM identity.
+func (s *nonBlockingGRPCServer) tlsServerOptions() ([]grpc.ServerOption, error) {
+	if !s.tlsConfig.Enabled() {
+		return []grpc.ServerOption{withServerUnaryInterceptor()}, nil
+	}
+	reloader, err := newCertReloader(s.tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	options := []grpc.ServerOption{grpc.Creds(credentials.NewTLS(reloader.tlsConfig()))}
+	if s.tlsConfig.MutualTLS() {
+		options = append(options,
+			grpc.ChainUnaryInterceptor(logGRPC, checkRMIdentityUnary),
+			grpc.StreamInterceptor(checkRMIdentityStream))
+	} else {
+		options = append(options, withServerUnaryInterceptor())
+	}
+	return options, nil
+}
+
 func (s *nonBlockingGRPCServer) serve(endpoint string, ss si.SchedulerServer) {
 	proto, addr, err := ParseEndpoint(endpoint)
 	if err != nil {
@@ -126,7 +156,12 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ss si.SchedulerServer) {
 			zap.Error(err))
 	}
 
-	server := grpc.NewServer(withServerUnaryInterceptor())
+	options, err := s.tlsServerOptions()
+	if err != nil {
+		log.Log(log.RPC).Fatal("failed to configure TLS",
+			zap.Error(err))
+	}
+	server := grpc.NewServer(options...)
 	s.server = server
 
 	if ss != nil {
@@ -134,7 +169,9 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ss si.SchedulerServer) {
 	}
 
 	log.Log(log.RPC).Info("listening for connections",
-		zap.Stringer("address", listener.Addr()))
+		zap.Stringer("address", listener.Addr()),
+		zap.Bool("tls", s.tlsConfig.Enabled()),
+		zap.Bool("mutualTLS", s.tlsConfig.MutualTLS()))
 
 	if err = server.Serve(listener); err != nil {
 		log.Log(log.RPC).Fatal("failed to serve", zap.Error(err))
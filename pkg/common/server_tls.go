@@ -0,0 +1,254 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+)
+
+// ServerTLSConfig defines the TLS settings for the gRPC server.
+// TLS is used when the certificate and key file are set. Setting the client CA file turns on mutual TLS: each client
+// must present a certificate signed by the CA and the identity in that certificate must match the RM ID used in the
+// requests. Changes to the files on disk are picked up on the next connection.
+type ServerTLSConfig struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+// Enabled returns true if the server should use TLS
+func (c *ServerTLSConfig) Enabled() bool {
+	return c != nil && c.CertFile != "" && c.KeyFile != ""
+}
+
+// MutualTLS returns true if the server should require and verify client certificates
+func (c *ServerTLSConfig) MutualTLS() bool {
+	return c.Enabled() && c.ClientCAFile != ""
+}
+
+// certReloader keeps the server certificate and client CA pool in memory and reloads them when the modification
+// time of one of the files changes. A failed reload keeps the previously loaded files in use.
+type certReloader struct {
+	config      ServerTLSConfig
+	cert        *tls.Certificate
+	clientCAs   *x509.CertPool
+	certModTime time.Time
+	keyModTime  time.Time
+	caModTime   time.Time
+	clientAuth  tls.ClientAuthType
+
+	locking.Mutex
+}
+
+func newCertReloader(config *ServerTLSConfig) (*certReloader, error) {
+	if !config.Enabled() {
+		return nil, fmt.Errorf("TLS certificate and key file must both be set")
+	}
+	r := &certReloader{config: *config, clientAuth: tls.RequireAndVerifyClientCert}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// load reads all files and replaces the cached certificate and CA pool. Should be called holding the lock.
+func (r *certReloader) load() error {
+	certModTime, keyModTime, caModTime, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.config.ClientCAFile != "" {
+		var pem []byte
+		pem, err = os.ReadFile(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no valid certificates found in client CA file %s", r.config.ClientCAFile)
+		}
+	}
+	r.cert = &cert
+	r.clientCAs = pool
+	r.certModTime = certModTime
+	r.keyModTime = keyModTime
+	r.caModTime = caModTime
+	return nil
+}
+
+func (r *certReloader) modTimes() (time.Time, time.Time, time.Time, error) {
+	var times [3]time.Time
+	for i, file := range []string{r.config.CertFile, r.config.KeyFile, r.config.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, time.Time{}, time.Time{}, fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		times[i] = info.ModTime()
+	}
+	return times[0], times[1], times[2], nil
+}
+
+// reloadIfChanged reloads the files if one of them was modified since the last load.
+func (r *certReloader) reloadIfChanged() {
+	r.Lock()
+	defer r.Unlock()
+	certModTime, keyModTime, caModTime, err := r.modTimes()
+	if err == nil && certModTime.Equal(r.certModTime) && keyModTime.Equal(r.keyModTime) && caModTime.Equal(r.caModTime) {
+		return
+	}
+	if err == nil {
+		err = r.load()
+	}
+	if err != nil {
+		log.Log(log.RPC).Warn("failed to reload TLS files, using previously loaded files",
+			zap.Error(err))
+		return
+	}
+	log.Log(log.RPC).Info("reloaded TLS files",
+		zap.String("certFile", r.config.CertFile),
+		zap.String("clientCAFile", r.config.ClientCAFile))
+}
+
+// getConfigForClient returns the TLS config for a new connection using the latest certificate and CA pool.
+func (r *certReloader) getConfigForClient(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+	r.reloadIfChanged()
+	r.Lock()
+	defer r.Unlock()
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*r.cert},
+	}
+	if r.clientCAs != nil {
+		config.ClientCAs = r.clientCAs
+		config.ClientAuth = r.clientAuth
+	}
+	return config, nil
+}
+
+func (r *certReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: r.getConfigForClient,
+	}
+}
+
+// NewReloadingTLSConfig returns a server TLS config that picks up changes to the certificate, key and client CA files
+// on the next connection. The client authentication type is only used when a client CA file is set.
+func NewReloadingTLSConfig(config *ServerTLSConfig, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	r, err := newCertReloader(config)
+	if err != nil {
+		return nil, err
+	}
+	r.clientAuth = clientAuth
+	return r.tlsConfig(), nil
+}
+
+// rmRequest is implemented by all requests of the scheduler interface that carry the RM ID
+type rmRequest interface {
+	GetRmID() string
+}
+
+// clientIdentities returns the common name and DNS names of the verified client certificate of the connection.
+func clientIdentities(ctx context.Context) ([]string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no peer information for the connection")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil, fmt.Errorf("no verified client certificate for the connection")
+	}
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	identities := make([]string, 0, len(cert.DNSNames)+1)
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	return append(identities, cert.DNSNames...), nil
+}
+
+// checkRMIdentity verifies that the RM ID of the request matches the identity in the client certificate.
+// Requests that do not carry an RM ID are not checked.
+func checkRMIdentity(ctx context.Context, req interface{}) error {
+	r, ok := req.(rmRequest)
+	if !ok {
+		return nil
+	}
+	identities, err := clientIdentities(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	rmID := r.GetRmID()
+	for _, id := range identities {
+		if id == rmID {
+			return nil
+		}
+	}
+	log.Log(log.RPC).Warn("RM ID does not match client certificate",
+		zap.String("rmID", rmID),
+		zap.Strings("identities", identities))
+	return status.Errorf(codes.PermissionDenied, "client certificate is not valid for RM %q", rmID)
+}
+
+// Unary interceptor that rejects requests for an RM that the client certificate does not belong to
+func checkRMIdentityUnary(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := checkRMIdentity(ctx, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// identityCheckedStream checks the RM ID of every message received on the stream
+type identityCheckedStream struct {
+	grpc.ServerStream
+}
+
+func (s *identityCheckedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkRMIdentity(s.Context(), m)
+}
+
+// Stream interceptor that rejects messages for an RM that the client certificate does not belong to
+func checkRMIdentityStream(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &identityCheckedStream{ServerStream: ss})
+}
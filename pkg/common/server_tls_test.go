@@ -0,0 +1,168 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// createCert creates a certificate and key signed by the parent, a nil parent creates a self-signed CA.
+func createCert(t *testing.T, commonName string, dnsNames []string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err, "key generation failed")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent = template
+		parentKey = key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NilError(t, err, "certificate creation failed")
+	cert, err := x509.ParseCertificate(der)
+	assert.NilError(t, err, "certificate parsing failed")
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NilError(t, err, "key marshalling failed")
+	return cert, key,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, content []byte, modTime time.Time) {
+	assert.NilError(t, os.WriteFile(path, content, 0o600), "failed to write file")
+	assert.NilError(t, os.Chtimes(path, modTime, modTime), "failed to set file time")
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	var config *ServerTLSConfig
+	assert.Assert(t, !config.Enabled(), "nil config should not enable TLS")
+	assert.Assert(t, !config.MutualTLS(), "nil config should not enable mutual TLS")
+	config = &ServerTLSConfig{CertFile: "cert.pem"}
+	assert.Assert(t, !config.Enabled(), "TLS should need the key file")
+	config.KeyFile = "key.pem"
+	assert.Assert(t, config.Enabled(), "TLS should be enabled")
+	assert.Assert(t, !config.MutualTLS(), "mutual TLS should need a client CA")
+	config.ClientCAFile = "ca.pem"
+	assert.Assert(t, config.MutualTLS(), "mutual TLS should be enabled")
+
+	_, err := newCertReloader(&ServerTLSConfig{})
+	assert.ErrorContains(t, err, "must both be set")
+	_, err = newCertReloader(&ServerTLSConfig{CertFile: "unknown.pem", KeyFile: "unknown.pem"})
+	assert.ErrorContains(t, err, "failed to read TLS file")
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	config := &ServerTLSConfig{
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server-key.pem"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	}
+	ca, caKey, caPEM, _ := createCert(t, "test-ca", nil, nil, nil)
+	_, _, certPEM, keyPEM := createCert(t, "server-1", nil, ca, caKey)
+	start := time.Now().Add(-time.Minute)
+	writeFile(t, config.CertFile, certPEM, start)
+	writeFile(t, config.KeyFile, keyPEM, start)
+	writeFile(t, config.ClientCAFile, caPEM, start)
+
+	reloader, err := newCertReloader(config)
+	assert.NilError(t, err, "reloader creation failed")
+	tlsConf, err := reloader.getConfigForClient(nil)
+	assert.NilError(t, err, "config for client failed")
+	assert.Equal(t, tlsConf.ClientAuth, tls.RequireAndVerifyClientCert, "client certificates should be required")
+	leaf, err := x509.ParseCertificate(tlsConf.Certificates[0].Certificate[0])
+	assert.NilError(t, err, "certificate parsing failed")
+	assert.Equal(t, leaf.Subject.CommonName, "server-1")
+
+	// replace the certificate: must be picked up on the next connection
+	_, _, certPEM, keyPEM = createCert(t, "server-2", nil, ca, caKey)
+	writeFile(t, config.CertFile, certPEM, start.Add(time.Second))
+	writeFile(t, config.KeyFile, keyPEM, start.Add(time.Second))
+	tlsConf, err = reloader.getConfigForClient(nil)
+	assert.NilError(t, err, "config for client failed")
+	leaf, err = x509.ParseCertificate(tlsConf.Certificates[0].Certificate[0])
+	assert.NilError(t, err, "certificate parsing failed")
+	assert.Equal(t, leaf.Subject.CommonName, "server-2", "certificate was not reloaded")
+
+	// broken certificate: previous one must stay in use
+	writeFile(t, config.CertFile, []byte("not a certificate"), start.Add(2*time.Second))
+	tlsConf, err = reloader.getConfigForClient(nil)
+	assert.NilError(t, err, "config for client failed")
+	leaf, err = x509.ParseCertificate(tlsConf.Certificates[0].Certificate[0])
+	assert.NilError(t, err, "certificate parsing failed")
+	assert.Equal(t, leaf.Subject.CommonName, "server-2", "broken certificate should not replace the loaded one")
+}
+
+func TestCheckRMIdentity(t *testing.T) {
+	ca, caKey, _, _ := createCert(t, "test-ca", nil, nil, nil)
+	client, _, _, _ := createCert(t, "rm-1", []string{"rm-alias"}, ca, caKey)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{client, ca}}},
+		},
+	})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  interface{}
+		code codes.Code
+	}{
+		{"common name", ctx, &si.RegisterResourceManagerRequest{RmID: "rm-1"}, codes.OK},
+		{"dns name", ctx, &si.NodeRequest{RmID: "rm-alias"}, codes.OK},
+		{"other rm", ctx, &si.AllocationRequest{RmID: "rm-2"}, codes.PermissionDenied},
+		{"no rm id", ctx, &si.PredicatesArgs{}, codes.OK},
+		{"no peer", context.Background(), &si.ApplicationRequest{RmID: "rm-1"}, codes.Unauthenticated},
+		{"no certificate", peer.NewContext(context.Background(), &peer.Peer{}), &si.ApplicationRequest{RmID: "rm-1"}, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkRMIdentity(tt.ctx, tt.req)
+			assert.Equal(t, status.Code(err), tt.code, "unexpected result: %v", err)
+		})
+	}
+}
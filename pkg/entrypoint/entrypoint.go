@@ -21,6 +21,7 @@ package entrypoint
 import (
 	"go.uber.org/zap"
 
+	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/handler"
 	"github.com/apache/yunikorn-core/pkg/log"
@@ -28,6 +29,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/metrics/history"
 	"github.com/apache/yunikorn-core/pkg/rmproxy"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
 	"github.com/apache/yunikorn-core/pkg/webservice"
 )
 
@@ -36,6 +38,7 @@ type startupOptions struct {
 	manualScheduleFlag bool
 	startWebAppFlag    bool
 	metricsHistorySize int
+	webTLSConfig       *common.ServerTLSConfig
 }
 
 func StartAllServices() *ServiceContext {
@@ -48,6 +51,18 @@ func StartAllServices() *ServiceContext {
 		})
 }
 
+// StartAllServicesWithWebTLS starts all services with the web app serving HTTPS using the TLS config.
+func StartAllServicesWithWebTLS(tlsConfig *common.ServerTLSConfig) *ServiceContext {
+	log.Log(log.Entrypoint).Info("ServiceContext start all services (web app TLS)")
+	return startAllServicesWithParameters(
+		startupOptions{
+			manualScheduleFlag: false,
+			startWebAppFlag:    true,
+			metricsHistorySize: 1440,
+			webTLSConfig:       tlsConfig,
+		})
+}
+
 // VisibleForTesting
 func StartAllServicesWithParams(manualSchedule, withWebapp bool) *ServiceContext {
 	log.Log(log.Entrypoint).Info("ServiceContext start all services")
@@ -79,6 +94,9 @@ func startAllServicesWithParameters(opts startupOptions) *ServiceContext {
 	log.Log(log.Entrypoint).Info("Starting event system")
 	events.GetEventSystem().StartService()
 
+	log.Log(log.Entrypoint).Info("Starting usage accounting")
+	accounting.GetTracker().StartService()
+
 	sched := scheduler.NewScheduler()
 	proxy := rmproxy.NewRMProxy(sched)
 	eventHandler := handler.EventHandlers{
@@ -108,7 +126,7 @@ func startAllServicesWithParameters(opts startupOptions) *ServiceContext {
 	if opts.startWebAppFlag {
 		log.Log(log.Entrypoint).Info("ServiceContext start web application service")
 		webapp := webservice.NewWebApp(sched.GetClusterContext(), imHistory)
-		webapp.StartWebApp()
+		webapp.StartWebAppWithTLS(opts.webTLSConfig)
 		context.WebApp = webapp
 	}
 
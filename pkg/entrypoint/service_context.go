@@ -19,12 +19,15 @@
 package entrypoint
 
 import (
+	"time"
+
 	"go.uber.org/zap"
 
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-core/pkg/metrics"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
 	"github.com/apache/yunikorn-core/pkg/webservice"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 )
@@ -34,6 +37,17 @@ type ServiceContext struct {
 	Scheduler        *scheduler.Scheduler
 	WebApp           *webservice.WebService
 	MetricsCollector metrics.InternalMetricsCollector
+	ConfigWatcher    *scheduler.ConfigFileWatcher
+}
+
+// WatchConfigFiles starts watching a local scheduler config file and config map file for standalone deployments.
+// Changes to the files are applied after an RM has registered. An empty file name is not watched.
+func (s *ServiceContext) WatchConfigFiles(queuesFile, configMapFile string, interval time.Duration) {
+	if s.ConfigWatcher != nil || (queuesFile == "" && configMapFile == "") {
+		return
+	}
+	s.ConfigWatcher = scheduler.NewConfigFileWatcher(s.Scheduler.GetClusterContext(), queuesFile, configMapFile, interval)
+	s.ConfigWatcher.Start()
 }
 
 func (s *ServiceContext) StopAll() {
@@ -47,7 +61,11 @@ func (s *ServiceContext) StopAll() {
 	if s.MetricsCollector != nil {
 		s.MetricsCollector.Stop()
 	}
+	if s.ConfigWatcher != nil {
+		s.ConfigWatcher.Stop()
+	}
 	s.Scheduler.Stop()
 	s.RMProxy.Stop()
 	events.GetEventSystem().Stop()
+	accounting.GetTracker().Stop()
 }
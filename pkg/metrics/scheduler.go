@@ -39,6 +39,12 @@ const (
 	NodeActive         = "active"
 	NodeDraining       = "draining"
 	NodeDecommissioned = "decommissioned"
+
+	ConfigReloadAccepted = "accepted"
+	ConfigReloadRejected = "rejected"
+
+	DeadlineMet    = "met"
+	DeadlineMissed = "missed"
 )
 
 var resourceUsageRangeBuckets = []string{
@@ -65,6 +71,9 @@ type SchedulerMetrics struct {
 	sortingLatency        *prometheus.HistogramVec
 	tryNodeLatency        prometheus.Histogram
 	tryPreemptionLatency  prometheus.Histogram
+	configReload          *prometheus.CounterVec
+	applicationDeadline   *prometheus.CounterVec
+	expiredAsk            *prometheus.CounterVec
 	lock                  locking.RWMutex
 }
 
@@ -97,7 +106,7 @@ func InitSchedulerMetrics() *SchedulerMetrics {
 			Namespace: Namespace,
 			Subsystem: SchedulerSubsystem,
 			Name:      "application_total",
-			Help:      "Total number of applications. State of the application includes `running`, `resuming`, `failing`, `completing`, `completed` and `failed`.",
+			Help:      "Total number of applications. State of the application includes `running`, `resuming`, `suspended`, `failing`, `completing`, `completed` and `failed`.",
 		}, []string{"state"})
 
 	s.node = prometheus.NewGaugeVec(
@@ -146,6 +155,30 @@ func InitSchedulerMetrics() *SchedulerMetrics {
 		},
 	)
 
+	s.configReload = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: SchedulerSubsystem,
+			Name:      "config_reload_total",
+			Help:      "Total number of scheduler config reloads from a file. Result of the reload includes `accepted` and `rejected`.",
+		}, []string{"result"})
+
+	s.applicationDeadline = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: SchedulerSubsystem,
+			Name:      "application_deadline_total",
+			Help:      "Total number of application deadlines that passed. Result of the deadline includes `met` and `missed`.",
+		}, []string{"result"})
+
+	s.expiredAsk = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: SchedulerSubsystem,
+			Name:      "expired_ask_total",
+			Help:      "Total number of pending asks removed after their time-to-live passed, by partition and queue.",
+		}, []string{"partition", "queue"})
+
 	// Register the metrics
 	var metricsList = []prometheus.Collector{
 		s.containerAllocation,
@@ -156,6 +189,9 @@ func InitSchedulerMetrics() *SchedulerMetrics {
 		s.sortingLatency,
 		s.tryNodeLatency,
 		s.tryPreemptionLatency,
+		s.configReload,
+		s.applicationDeadline,
+		s.expiredAsk,
 	}
 	for _, metric := range metricsList {
 		if err := prometheus.Register(metric); err != nil {
@@ -172,6 +208,9 @@ func (m *SchedulerMetrics) Reset() {
 	m.application.Reset()
 	m.applicationSubmission.Reset()
 	m.containerAllocation.Reset()
+	m.configReload.Reset()
+	m.applicationDeadline.Reset()
+	m.expiredAsk.Reset()
 }
 
 func SinceInSeconds(start time.Time) float64 {
@@ -241,6 +280,71 @@ func (m *SchedulerMetrics) GetSchedulingErrors() (int, error) {
 	return -1, err
 }
 
+func (m *SchedulerMetrics) IncConfigReloadAccepted() {
+	m.configReload.WithLabelValues(ConfigReloadAccepted).Inc()
+}
+
+func (m *SchedulerMetrics) GetConfigReloadAccepted() (int, error) {
+	metricDto := &dto.Metric{}
+	err := m.configReload.WithLabelValues(ConfigReloadAccepted).Write(metricDto)
+	if err == nil {
+		return int(*metricDto.Counter.Value), nil
+	}
+	return -1, err
+}
+
+func (m *SchedulerMetrics) IncConfigReloadRejected() {
+	m.configReload.WithLabelValues(ConfigReloadRejected).Inc()
+}
+
+func (m *SchedulerMetrics) GetConfigReloadRejected() (int, error) {
+	metricDto := &dto.Metric{}
+	err := m.configReload.WithLabelValues(ConfigReloadRejected).Write(metricDto)
+	if err == nil {
+		return int(*metricDto.Counter.Value), nil
+	}
+	return -1, err
+}
+
+func (m *SchedulerMetrics) IncApplicationDeadlineMet() {
+	m.applicationDeadline.WithLabelValues(DeadlineMet).Inc()
+}
+
+func (m *SchedulerMetrics) GetApplicationDeadlineMet() (int, error) {
+	metricDto := &dto.Metric{}
+	err := m.applicationDeadline.WithLabelValues(DeadlineMet).Write(metricDto)
+	if err == nil {
+		return int(*metricDto.Counter.Value), nil
+	}
+	return -1, err
+}
+
+func (m *SchedulerMetrics) IncApplicationDeadlineMissed() {
+	m.applicationDeadline.WithLabelValues(DeadlineMissed).Inc()
+}
+
+func (m *SchedulerMetrics) GetApplicationDeadlineMissed() (int, error) {
+	metricDto := &dto.Metric{}
+	err := m.applicationDeadline.WithLabelValues(DeadlineMissed).Write(metricDto)
+	if err == nil {
+		return int(*metricDto.Counter.Value), nil
+	}
+	return -1, err
+}
+
+func (m *SchedulerMetrics) AddExpiredAsks(partition, queue string, value int) {
+	m.expiredAsk.WithLabelValues(partition, queue).Add(float64(value))
+}
+
+func (m *SchedulerMetrics) GetExpiredAsks(partition, queue string) (int, error) {
+	metricDto := &dto.Metric{}
+	err := m.expiredAsk.WithLabelValues(partition, queue).Write(metricDto)
+	if err == nil {
+		return int(*metricDto.Counter.Value), nil
+	}
+	return -1, err
+}
+
 func (m *SchedulerMetrics) IncTotalApplicationsNew() {
 	m.applicationSubmission.WithLabelValues(AppNew).Inc()
 }
@@ -334,6 +438,23 @@ func (m *SchedulerMetrics) GetTotalApplicationsResuming() (int, error) {
 	return -1, err
 }
 
+func (m *SchedulerMetrics) IncTotalApplicationsSuspended() {
+	m.application.WithLabelValues(AppSuspended).Inc()
+}
+
+func (m *SchedulerMetrics) DecTotalApplicationsSuspended() {
+	m.application.WithLabelValues(AppSuspended).Dec()
+}
+
+func (m *SchedulerMetrics) GetTotalApplicationsSuspended() (int, error) {
+	metricDto := &dto.Metric{}
+	err := m.application.WithLabelValues(AppSuspended).Write(metricDto)
+	if err == nil {
+		return int(*metricDto.Gauge.Value), nil
+	}
+	return -1, err
+}
+
 func (m *SchedulerMetrics) IncTotalApplicationsCompleted() {
 	m.application.WithLabelValues(AppCompleted).Inc()
 }
@@ -60,6 +60,59 @@ func TestTryPreemptionLatency(t *testing.T) {
 	verifyHistogram(t, "trypreemption_latency_milliseconds", 60, 1)
 }
 
+func TestConfigReload(t *testing.T) {
+	sm = getSchedulerMetrics(t)
+	defer unregisterMetrics()
+
+	sm.IncConfigReloadRejected()
+	sm.IncConfigReloadRejected()
+	verifyMetric(t, 2, "rejected", "yunikorn_scheduler_config_reload_total", dto.MetricType_COUNTER, "result")
+	sm.IncConfigReloadAccepted()
+
+	curr, err := sm.GetConfigReloadRejected()
+	assert.NilError(t, err)
+	assert.Equal(t, curr, 2)
+	curr, err = sm.GetConfigReloadAccepted()
+	assert.NilError(t, err)
+	assert.Equal(t, curr, 1)
+}
+
+func TestApplicationDeadline(t *testing.T) {
+	sm = getSchedulerMetrics(t)
+	defer unregisterMetrics()
+
+	sm.IncApplicationDeadlineMissed()
+	verifyMetric(t, 1, "missed", "yunikorn_scheduler_application_deadline_total", dto.MetricType_COUNTER, "result")
+	sm.IncApplicationDeadlineMet()
+	sm.IncApplicationDeadlineMet()
+
+	curr, err := sm.GetApplicationDeadlineMissed()
+	assert.NilError(t, err)
+	assert.Equal(t, curr, 1)
+	curr, err = sm.GetApplicationDeadlineMet()
+	assert.NilError(t, err)
+	assert.Equal(t, curr, 2)
+}
+
+func TestExpiredAsks(t *testing.T) {
+	sm = getSchedulerMetrics(t)
+	defer unregisterMetrics()
+
+	sm.AddExpiredAsks("default", "root.a", 2)
+	sm.AddExpiredAsks("default", "root.a", 1)
+	sm.AddExpiredAsks("default", "root.b", 1)
+
+	curr, err := sm.GetExpiredAsks("default", "root.a")
+	assert.NilError(t, err)
+	assert.Equal(t, curr, 3)
+	curr, err = sm.GetExpiredAsks("default", "root.b")
+	assert.NilError(t, err)
+	assert.Equal(t, curr, 1)
+	curr, err = sm.GetExpiredAsks("other", "root.a")
+	assert.NilError(t, err)
+	assert.Equal(t, curr, 0)
+}
+
 func TestSchedulerApplicationsNew(t *testing.T) {
 	sm = getSchedulerMetrics(t)
 	defer unregisterMetrics()
@@ -137,6 +190,21 @@ func TestSchedulerApplicationsResuming(t *testing.T) {
 	verifyMetric(t, 0, "resuming", "yunikorn_scheduler_application_total", dto.MetricType_GAUGE, "state")
 }
 
+func TestSchedulerApplicationsSuspended(t *testing.T) {
+	sm = getSchedulerMetrics(t)
+	defer unregisterMetrics()
+
+	sm.IncTotalApplicationsSuspended()
+	verifyMetric(t, 1, "suspended", "yunikorn_scheduler_application_total", dto.MetricType_GAUGE, "state")
+
+	curr, err := sm.GetTotalApplicationsSuspended()
+	assert.NilError(t, err)
+	assert.Equal(t, curr, 1)
+
+	sm.DecTotalApplicationsSuspended()
+	verifyMetric(t, 0, "suspended", "yunikorn_scheduler_application_total", dto.MetricType_GAUGE, "state")
+}
+
 func TestSchedulerApplicationsFailing(t *testing.T) {
 	sm = getSchedulerMetrics(t)
 	defer unregisterMetrics()
@@ -226,4 +294,7 @@ func unregisterMetrics() {
 	prometheus.Unregister(sm.sortingLatency)
 	prometheus.Unregister(sm.tryNodeLatency)
 	prometheus.Unregister(sm.tryPreemptionLatency)
+	prometheus.Unregister(sm.configReload)
+	prometheus.Unregister(sm.applicationDeadline)
+	prometheus.Unregister(sm.expiredAsk)
 }
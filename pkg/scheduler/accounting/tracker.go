@@ -0,0 +1,552 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+const (
+	Hourly = "hourly"
+	Daily  = "daily"
+
+	// interval at which the usage of running allocations is accounted and completed periods are exported
+	accountingInterval = time.Minute
+)
+
+var once sync.Once
+var tracker *Tracker
+
+// Attribution identifies who the resource usage of an allocation is charged to.
+type Attribution struct {
+	Partition     string
+	Queue         string
+	ApplicationID string
+	User          string
+	Group         string
+	Tags          map[string]string
+}
+
+// Tracker accounts the resource usage of all allocations and rolls it up into hourly and daily records.
+// The usage of running allocations is accounted periodically: a record is complete when its period has ended.
+// Completed records are written to the export files, if configured, and kept in memory for the retention period.
+// Records that could not be written are retried on the next export until they are older than the retention period.
+type Tracker struct {
+	allocations map[string]*allocationUsage // running allocations keyed by partition and allocation key
+	rollups     []*rollup
+	tags        []string
+	retention   time.Duration
+	writer      *writer
+	unexported  map[string][]*dao.AccountingRecordDAOInfo // records not written to the export files yet, keyed by period
+	id          string
+	stop        chan struct{}
+	exportLock  locking.Mutex // serialises writing the export files
+
+	locking.RWMutex
+}
+
+type allocationUsage struct {
+	attribution Attribution
+	resource    *resources.Resource
+	accounted   time.Time // the usage has been accounted up to this time
+}
+
+// GetTracker returns the accounting tracker instance. Initialization happens during the first call.
+func GetTracker() *Tracker {
+	once.Do(func() {
+		tracker = newTracker(time.Now())
+	})
+	return tracker
+}
+
+func newTracker(now time.Time) *Tracker {
+	return &Tracker{
+		allocations: make(map[string]*allocationUsage),
+		rollups:     []*rollup{newRollup(Hourly, time.Hour, now), newRollup(Daily, 24*time.Hour, now)},
+		retention:   configs.DefaultAccountingRetention,
+		unexported:  make(map[string][]*dao.AccountingRecordDAOInfo),
+		id:          fmt.Sprintf("accounting-%d", now.Unix()),
+	}
+}
+
+// StartService reads the configuration and starts the periodic accounting in the background.
+func (t *Tracker) StartService() {
+	t.Lock()
+	defer t.Unlock()
+	if t.stop != nil {
+		return
+	}
+	configs.AddConfigMapCallback(t.id, func() {
+		t.reloadConfig(configs.GetConfigMap())
+	})
+	t.updateConfig(configs.GetConfigMap())
+	stop := make(chan struct{})
+	t.stop = stop
+	log.Log(log.SchedAccounting).Info("Starting usage accounting", zap.Duration("interval", accountingInterval))
+	go func() {
+		ticker := time.NewTicker(accountingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				t.account(now)
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic accounting. The usage up to now of the periods that have not ended is exported as
+// records that end now. Usage after the stop is not exported.
+func (t *Tracker) Stop() {
+	t.stopAt(time.Now())
+}
+
+func (t *Tracker) stopAt(now time.Time) {
+	t.Lock()
+	configs.RemoveConfigMapCallback(t.id)
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+	for _, usage := range t.allocations {
+		t.accountUsage(usage, now)
+	}
+	for _, r := range t.rollups {
+		t.addUnexported(r.period, r.complete(now))
+		t.addUnexported(r.period, r.flush(now))
+	}
+	t.Unlock()
+	t.export()
+}
+
+func (t *Tracker) reloadConfig(configMap map[string]string) {
+	t.Lock()
+	defer t.Unlock()
+	t.updateConfig(configMap)
+}
+
+// updateConfig applies the accounting settings from the config map.
+// Changed tags only apply to allocations added after the change.
+// No locking must be called while holding the lock
+func (t *Tracker) updateConfig(configMap map[string]string) {
+	t.writer = nil
+	if directory := configMap[configs.CMAccountingDirectory]; directory != "" {
+		format := configMap[configs.CMAccountingFormat]
+		if format != csvFormat && format != jsonlFormat {
+			if format != "" {
+				log.Log(log.SchedAccounting).Warn("Unknown accounting export format, using default",
+					zap.String("format", format),
+					zap.String("default", configs.DefaultAccountingFormat))
+			}
+			format = configs.DefaultAccountingFormat
+		}
+		t.writer = &writer{directory: directory, format: format}
+	} else {
+		// records are only kept for the export if there is a place to write them to
+		t.unexported = make(map[string][]*dao.AccountingRecordDAOInfo)
+	}
+	t.tags = nil
+	for _, tag := range strings.Split(configMap[configs.CMAccountingTags], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			t.tags = append(t.tags, tag)
+		}
+	}
+	t.retention = configs.DefaultAccountingRetention
+	if value, ok := configMap[configs.CMAccountingRetention]; ok {
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention <= 0 {
+			log.Log(log.SchedAccounting).Warn("Failed to parse accounting retention, using default",
+				zap.String("value", value),
+				zap.Duration("default", configs.DefaultAccountingRetention))
+		} else {
+			t.retention = retention
+		}
+	}
+}
+
+// AddAllocation starts accounting the resource usage of an allocation from the time it was bound.
+// Only the configured tags of the attribution are kept.
+func (t *Tracker) AddAllocation(allocationKey string, attribution Attribution, resource *resources.Resource, bindTime time.Time) {
+	if t == nil || resources.IsZero(resource) {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	if bindTime.IsZero() {
+		bindTime = time.Now()
+	}
+	tags := make(map[string]string)
+	for _, tag := range t.tags {
+		if value, ok := attribution.Tags[tag]; ok {
+			tags[tag] = value
+		}
+	}
+	attribution.Tags = tags
+	t.allocations[allocationID(attribution.Partition, allocationKey)] = &allocationUsage{
+		attribution: attribution,
+		resource:    resource.Clone(),
+		accounted:   bindTime,
+	}
+}
+
+// RemoveAllocation accounts the resource usage of the allocation up to now and stops tracking it.
+func (t *Tracker) RemoveAllocation(partition, allocationKey string) {
+	if t == nil {
+		return
+	}
+	t.removeAllocation(partition, allocationKey, time.Now())
+}
+
+func (t *Tracker) removeAllocation(partition, allocationKey string, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+	id := allocationID(partition, allocationKey)
+	if usage, ok := t.allocations[id]; ok {
+		t.accountUsage(usage, now)
+		delete(t.allocations, id)
+	}
+}
+
+// MoveApplication charges the resource usage of the allocations of the application to the new queue from now on.
+func (t *Tracker) MoveApplication(partition, applicationID, queuePath string) {
+	if t == nil {
+		return
+	}
+	t.moveApplication(partition, applicationID, queuePath, time.Now())
+}
+
+func (t *Tracker) moveApplication(partition, applicationID, queuePath string, now time.Time) {
+	t.Lock()
+	defer t.Unlock()
+	for _, usage := range t.allocations {
+		if usage.attribution.Partition == partition && usage.attribution.ApplicationID == applicationID {
+			t.accountUsage(usage, now)
+			usage.attribution.Queue = queuePath
+		}
+	}
+}
+
+// GetRecords returns the records of the period type that start in the time range, ordered by start time.
+// The records of periods that have not ended yet include the usage of the running allocations up to now.
+func (t *Tracker) GetRecords(period string, start, end time.Time) ([]*dao.AccountingRecordDAOInfo, error) {
+	return t.getRecords(period, start, end, time.Now())
+}
+
+func (t *Tracker) getRecords(period string, start, end, now time.Time) ([]*dao.AccountingRecordDAOInfo, error) {
+	t.Lock()
+	defer t.Unlock()
+	for _, usage := range t.allocations {
+		t.accountUsage(usage, now)
+	}
+	for _, r := range t.rollups {
+		if r.period == period {
+			return r.records(func(b *bucket) bool {
+				return !b.start.Before(start) && b.start.Before(end)
+			}), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown accounting period '%s', expected %s or %s", period, Hourly, Daily)
+}
+
+// account accounts the usage of all running allocations up to now, exports the records of the periods that
+// have ended and removes the records that are older than the retention period.
+func (t *Tracker) account(now time.Time) {
+	t.Lock()
+	for _, usage := range t.allocations {
+		t.accountUsage(usage, now)
+	}
+	cutoff := now.Add(-t.retention)
+	for _, r := range t.rollups {
+		t.addUnexported(r.period, r.complete(now))
+		r.expire(cutoff)
+	}
+	t.expireUnexported(cutoff)
+	t.Unlock()
+	t.export()
+}
+
+// addUnexported queues the records for the export, if an export is configured.
+// No locking must be called while holding the lock
+func (t *Tracker) addUnexported(period string, records []*dao.AccountingRecordDAOInfo) {
+	if t.writer == nil || len(records) == 0 {
+		return
+	}
+	t.unexported[period] = append(t.unexported[period], records...)
+}
+
+// expireUnexported drops the records that could not be exported before they became older than the retention period.
+// No locking must be called while holding the lock
+func (t *Tracker) expireUnexported(cutoff time.Time) {
+	for period, records := range t.unexported {
+		kept := records[:0]
+		for _, record := range records {
+			if record.End >= cutoff.UnixNano() {
+				kept = append(kept, record)
+			}
+		}
+		if dropped := len(records) - len(kept); dropped != 0 {
+			log.Log(log.SchedAccounting).Warn("Dropped accounting records that could not be exported within the retention period",
+				zap.String("period", period),
+				zap.Int("records", dropped))
+		}
+		t.unexported[period] = kept
+	}
+}
+
+// export writes the queued records to the export files. Records that could not be written stay queued.
+// Writing the files must not block the scheduler: the tracker lock is not held while writing.
+func (t *Tracker) export() {
+	t.exportLock.Lock()
+	defer t.exportLock.Unlock()
+	t.RLock()
+	exporter := t.writer
+	pending := make(map[string][]*dao.AccountingRecordDAOInfo, len(t.unexported))
+	for period, records := range t.unexported {
+		pending[period] = records[:len(records):len(records)]
+	}
+	t.RUnlock()
+	if exporter == nil {
+		return
+	}
+	for period, records := range pending {
+		if len(records) == 0 {
+			continue
+		}
+		failed, err := exporter.write(period, records)
+		if err != nil {
+			log.Log(log.SchedAccounting).Error("Failed to export accounting records, retrying on the next export",
+				zap.String("period", period),
+				zap.Int("records", len(failed)),
+				zap.Error(err))
+		}
+		t.Lock()
+		// records might have been added or expired while writing
+		queued := t.unexported[period]
+		remaining := make([]*dao.AccountingRecordDAOInfo, 0, len(failed))
+		for _, record := range queued {
+			if !slices.Contains(records, record) || slices.Contains(failed, record) {
+				remaining = append(remaining, record)
+			}
+		}
+		t.unexported[period] = remaining
+		t.Unlock()
+	}
+}
+
+// accountUsage adds the usage of the allocation since it was last accounted to all rollups.
+// No locking must be called while holding the lock
+func (t *Tracker) accountUsage(usage *allocationUsage, now time.Time) {
+	if !now.After(usage.accounted) {
+		return
+	}
+	for _, r := range t.rollups {
+		r.add(usage.attribution, usage.resource, usage.accounted, now)
+	}
+	usage.accounted = now
+}
+
+func allocationID(partition, allocationKey string) string {
+	return partition + "/" + allocationKey
+}
+
+// rollup aggregates resource usage in buckets of a fixed length aligned to UTC.
+type rollup struct {
+	period  string
+	length  time.Duration
+	buckets map[bucketKey]*bucket
+	// all buckets that start before this time have ended and have been exported
+	completed time.Time
+}
+
+type bucketKey struct {
+	start         int64
+	partition     string
+	queue         string
+	applicationID string
+	user          string
+	group         string
+	tags          string
+}
+
+type bucket struct {
+	start       time.Time
+	attribution Attribution
+	usage       map[string]float64
+}
+
+func newRollup(period string, length time.Duration, now time.Time) *rollup {
+	return &rollup{
+		period:    period,
+		length:    length,
+		buckets:   make(map[bucketKey]*bucket),
+		completed: now.UTC().Truncate(length),
+	}
+}
+
+// add splits the usage of the resource between from and to over the buckets it falls in.
+// Usage in buckets that have already been exported is not tracked.
+func (r *rollup) add(attribution Attribution, resource *resources.Resource, from, to time.Time) {
+	if from.Before(r.completed) {
+		from = r.completed
+	}
+	from, to = from.UTC(), to.UTC()
+	for start := from.Truncate(r.length); start.Before(to); start = start.Add(r.length) {
+		key := bucketKey{
+			start:         start.UnixNano(),
+			partition:     attribution.Partition,
+			queue:         attribution.Queue,
+			applicationID: attribution.ApplicationID,
+			user:          attribution.User,
+			group:         attribution.Group,
+			tags:          tagString(attribution.Tags),
+		}
+		b, ok := r.buckets[key]
+		if !ok {
+			b = &bucket{start: start, attribution: attribution, usage: make(map[string]float64)}
+			r.buckets[key] = b
+		}
+		seconds := minTime(to, start.Add(r.length)).Sub(maxTime(from, start)).Seconds()
+		for name, quantity := range resource.Resources {
+			b.usage[name] += float64(quantity) * seconds
+		}
+	}
+}
+
+// flush returns the records of the buckets that have not ended, with the usage up to now. The records end now and
+// the buckets are not returned again.
+func (r *rollup) flush(now time.Time) []*dao.AccountingRecordDAOInfo {
+	previous := r.completed
+	now = now.UTC()
+	if !now.After(previous) {
+		return nil
+	}
+	r.completed = now
+	records := r.records(func(b *bucket) bool {
+		return !b.start.Before(previous) && b.start.Before(now)
+	})
+	for _, record := range records {
+		record.End = min(record.End, now.UnixNano())
+	}
+	return records
+}
+
+// complete returns the records of the buckets that have ended since the last call.
+func (r *rollup) complete(now time.Time) []*dao.AccountingRecordDAOInfo {
+	previous := r.completed
+	current := now.UTC().Truncate(r.length)
+	if !current.After(previous) {
+		return nil
+	}
+	r.completed = current
+	return r.records(func(b *bucket) bool {
+		return !b.start.Before(previous) && b.start.Before(current)
+	})
+}
+
+// expire removes the buckets that ended before the cutoff.
+func (r *rollup) expire(cutoff time.Time) {
+	for key, b := range r.buckets {
+		if b.start.Add(r.length).Before(cutoff) {
+			delete(r.buckets, key)
+		}
+	}
+}
+
+func (r *rollup) records(filter func(b *bucket) bool) []*dao.AccountingRecordDAOInfo {
+	selected := make([]*bucket, 0)
+	for _, b := range r.buckets {
+		if filter(b) {
+			selected = append(selected, b)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		left, right := selected[i], selected[j]
+		if !left.start.Equal(right.start) {
+			return left.start.Before(right.start)
+		}
+		return left.attribution.sortKey() < right.attribution.sortKey()
+	})
+	result := make([]*dao.AccountingRecordDAOInfo, len(selected))
+	for i, b := range selected {
+		usage := make(map[string]int64, len(b.usage))
+		for name, value := range b.usage {
+			usage[name] = int64(value + 0.5)
+		}
+		tags := make(map[string]string, len(b.attribution.Tags))
+		for k, v := range b.attribution.Tags {
+			tags[k] = v
+		}
+		result[i] = &dao.AccountingRecordDAOInfo{
+			Period:          r.period,
+			Start:           b.start.UnixNano(),
+			End:             b.start.Add(r.length).UnixNano(),
+			Partition:       b.attribution.Partition,
+			QueuePath:       b.attribution.Queue,
+			ApplicationID:   b.attribution.ApplicationID,
+			User:            b.attribution.User,
+			Group:           b.attribution.Group,
+			Tags:            tags,
+			ResourceSeconds: usage,
+		}
+	}
+	return result
+}
+
+func (a Attribution) sortKey() string {
+	return strings.Join([]string{a.Partition, a.Queue, a.ApplicationID, a.User, a.Group, tagString(a.Tags)}, "\x00")
+}
+
+// tagString returns the tags as a sorted list of key=value pairs separated by a semicolon.
+func tagString(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
+func minTime(left, right time.Time) time.Time {
+	if left.Before(right) {
+		return left
+	}
+	return right
+}
+
+func maxTime(left, right time.Time) time.Time {
+	if left.After(right) {
+		return left
+	}
+	return right
+}
@@ -0,0 +1,262 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+var start = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestTrackerRollup(t *testing.T) {
+	tracker := newTracker(start)
+	tracker.updateConfig(map[string]string{configs.CMAccountingTags: "team, cost-center"})
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 2, "memory": 10})
+	attribution := Attribution{
+		Partition:     "default",
+		Queue:         "root.a",
+		ApplicationID: "app-1",
+		User:          "alice",
+		Group:         "dev",
+		Tags:          map[string]string{"team": "blue", "other": "ignored"},
+	}
+	tracker.AddAllocation("alloc-1", attribution, res, start.Add(30*time.Minute))
+	assert.Assert(t, attribution.Tags["other"] == "ignored", "attribution passed in must not change")
+	// zero allocations are not tracked
+	tracker.AddAllocation("alloc-2", attribution, resources.NewResource(), start)
+	assert.Equal(t, len(tracker.allocations), 1)
+
+	tracker.removeAllocation("default", "alloc-1", start.Add(2*time.Hour+15*time.Minute))
+	assert.Equal(t, len(tracker.allocations), 0)
+	records, err := tracker.getRecords(Hourly, start, start.Add(24*time.Hour), start.Add(3*time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 3)
+	assert.DeepEqual(t, records[0].ResourceSeconds, map[string]int64{"vcore": 3600, "memory": 18000})
+	assert.DeepEqual(t, records[1].ResourceSeconds, map[string]int64{"vcore": 7200, "memory": 36000})
+	assert.DeepEqual(t, records[2].ResourceSeconds, map[string]int64{"vcore": 1800, "memory": 9000})
+	assert.Equal(t, records[1].Start, start.Add(time.Hour).UnixNano())
+	assert.Equal(t, records[1].End, start.Add(2*time.Hour).UnixNano())
+	assert.DeepEqual(t, records[0].Tags, map[string]string{"team": "blue"})
+	assert.Equal(t, records[0].Group, "dev")
+
+	records, err = tracker.getRecords(Daily, start.Truncate(24*time.Hour), start.Add(time.Hour), start.Add(3*time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 1)
+	assert.DeepEqual(t, records[0].ResourceSeconds, map[string]int64{"vcore": 12600, "memory": 63000})
+	// the range filters on the start of the record
+	records, err = tracker.getRecords(Hourly, start.Add(time.Hour), start.Add(2*time.Hour), start.Add(3*time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 1)
+
+	_, err = tracker.getRecords("monthly", start, start.Add(time.Hour), start)
+	assert.ErrorContains(t, err, "unknown accounting period")
+}
+
+func TestTrackerRunningAllocations(t *testing.T) {
+	tracker := newTracker(start)
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	tracker.AddAllocation("alloc-1", Attribution{Partition: "default", Queue: "root.a", ApplicationID: "app-1", User: "alice"}, res, start)
+	tracker.AddAllocation("alloc-2", Attribution{Partition: "default", Queue: "root.a", ApplicationID: "app-2", User: "bob"}, res, start)
+
+	// running allocations are accounted up to now when the records are retrieved
+	records, err := tracker.getRecords(Hourly, start, start.Add(time.Hour), start.Add(30*time.Minute))
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0].ApplicationID, "app-1")
+	assert.DeepEqual(t, records[0].ResourceSeconds, map[string]int64{"vcore": 1800})
+
+	// usage after the move is charged to the new queue
+	tracker.moveApplication("default", "app-1", "root.b", start.Add(45*time.Minute))
+	tracker.removeAllocation("default", "alloc-1", start.Add(time.Hour))
+	records, err = tracker.getRecords(Hourly, start, start.Add(time.Hour), start.Add(time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 3)
+	assert.Equal(t, records[0].QueuePath, "root.a")
+	assert.Equal(t, records[0].User, "alice")
+	assert.DeepEqual(t, records[0].ResourceSeconds, map[string]int64{"vcore": 2700})
+	assert.Equal(t, records[1].QueuePath, "root.a")
+	assert.Equal(t, records[1].User, "bob")
+	assert.Equal(t, records[2].QueuePath, "root.b")
+	assert.DeepEqual(t, records[2].ResourceSeconds, map[string]int64{"vcore": 900})
+
+	// usage before the tracker started is not accounted
+	tracker.AddAllocation("alloc-3", Attribution{Partition: "default", Queue: "root.a", ApplicationID: "app-3", User: "carol"}, res, start.Add(-time.Hour))
+	records, err = tracker.getRecords(Hourly, start.Add(-time.Hour), start, start.Add(time.Hour))
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 0)
+}
+
+func TestTrackerExport(t *testing.T) {
+	for _, format := range []string{csvFormat, jsonlFormat} {
+		t.Run(format, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "accounting")
+			tracker := newTracker(start)
+			tracker.updateConfig(map[string]string{
+				configs.CMAccountingDirectory: dir,
+				configs.CMAccountingFormat:    format,
+				configs.CMAccountingRetention: "2h",
+			})
+			res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1, "memory": 4})
+			tracker.AddAllocation("alloc-1", Attribution{Partition: "default", Queue: "root.a", ApplicationID: "app-1", User: "alice"}, res, start)
+
+			// nothing is exported before the end of the first hour
+			tracker.account(start.Add(30 * time.Minute))
+			_, err := os.Stat(dir)
+			assert.Assert(t, os.IsNotExist(err), "directory should not be created without records")
+			tracker.account(start.Add(time.Hour + time.Minute))
+			tracker.account(start.Add(2*time.Hour + time.Minute))
+			hourly := readLines(t, filepath.Join(dir, "hourly-2024-01-01."+format))
+			if format == csvFormat {
+				assert.Equal(t, len(hourly), 5)
+				assert.Equal(t, hourly[0], strings.Join(csvHeader, ","))
+				assert.Equal(t, hourly[1], "hourly,2024-01-01T10:00:00Z,2024-01-01T11:00:00Z,default,root.a,app-1,alice,,,memory,14400")
+				assert.Equal(t, hourly[2], "hourly,2024-01-01T10:00:00Z,2024-01-01T11:00:00Z,default,root.a,app-1,alice,,,vcore,3600")
+			} else {
+				assert.Equal(t, len(hourly), 2)
+				var record dao.AccountingRecordDAOInfo
+				assert.NilError(t, json.Unmarshal([]byte(hourly[1]), &record))
+				assert.Equal(t, record.Start, start.Add(time.Hour).UnixNano())
+				assert.DeepEqual(t, record.ResourceSeconds, map[string]int64{"vcore": 3600, "memory": 14400})
+			}
+			// daily records are exported after the end of the day
+			_, err = os.Stat(filepath.Join(dir, "daily-2024-01."+format))
+			assert.Assert(t, os.IsNotExist(err), "daily file should not exist yet")
+			tracker.removeAllocation("default", "alloc-1", start.Add(3*time.Hour))
+			tracker.account(start.Add(14 * time.Hour))
+			daily := readLines(t, filepath.Join(dir, "daily-2024-01."+format))
+			if format == csvFormat {
+				assert.Equal(t, len(daily), 3)
+				assert.Equal(t, daily[2], "daily,2024-01-01T00:00:00Z,2024-01-02T00:00:00Z,default,root.a,app-1,alice,,,vcore,10800")
+			} else {
+				assert.Equal(t, len(daily), 1)
+			}
+			// records older than the retention are removed
+			records, err := tracker.getRecords(Hourly, start, start.Add(24*time.Hour), start.Add(14*time.Hour))
+			assert.NilError(t, err)
+			assert.Equal(t, len(records), 0)
+		})
+	}
+}
+
+func TestTrackerExportRetry(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "accounting")
+	tracker := newTracker(start)
+	tracker.updateConfig(map[string]string{
+		configs.CMAccountingDirectory: dir,
+		configs.CMAccountingFormat:    csvFormat,
+		configs.CMAccountingRetention: "3h",
+	})
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	tracker.AddAllocation("alloc-1", Attribution{Partition: "default", Queue: "root.a", ApplicationID: "app-1", User: "alice"}, res, start)
+
+	// block the export directory: the records must be kept for the next export
+	assert.NilError(t, os.WriteFile(dir, []byte{}, 0o600))
+	tracker.account(start.Add(time.Hour + time.Minute))
+	assert.Equal(t, len(tracker.unexported[Hourly]), 1)
+	assert.NilError(t, os.Remove(dir))
+	tracker.account(start.Add(2*time.Hour + time.Minute))
+	assert.Equal(t, len(tracker.unexported[Hourly]), 0)
+	hourly := readLines(t, filepath.Join(dir, "hourly-2024-01-01.csv"))
+	assert.Equal(t, len(hourly), 3)
+	assert.Equal(t, hourly[1], "hourly,2024-01-01T10:00:00Z,2024-01-01T11:00:00Z,default,root.a,app-1,alice,,,vcore,3600")
+	assert.Equal(t, hourly[2], "hourly,2024-01-01T11:00:00Z,2024-01-01T12:00:00Z,default,root.a,app-1,alice,,,vcore,3600")
+
+	// records that cannot be exported within the retention are dropped
+	assert.NilError(t, os.RemoveAll(dir))
+	assert.NilError(t, os.WriteFile(dir, []byte{}, 0o600))
+	tracker.account(start.Add(3*time.Hour + time.Minute))
+	assert.Equal(t, len(tracker.unexported[Hourly]), 1)
+	tracker.account(start.Add(7 * time.Hour))
+	assert.Equal(t, len(tracker.unexported[Hourly]), 4)
+	assert.Equal(t, tracker.unexported[Hourly][0].Start, start.Add(3*time.Hour).UnixNano())
+	tracker.account(start.Add(9 * time.Hour))
+	assert.Equal(t, len(tracker.unexported[Hourly]), 4)
+	assert.Equal(t, tracker.unexported[Hourly][0].Start, start.Add(5*time.Hour).UnixNano())
+
+	// removing the export drops the pending records
+	tracker.updateConfig(map[string]string{})
+	assert.Equal(t, len(tracker.unexported), 0)
+}
+
+func TestTrackerStop(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "accounting")
+	tracker := newTracker(start)
+	tracker.updateConfig(map[string]string{
+		configs.CMAccountingDirectory: dir,
+		configs.CMAccountingFormat:    csvFormat,
+	})
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	tracker.AddAllocation("alloc-1", Attribution{Partition: "default", Queue: "root.a", ApplicationID: "app-1", User: "alice"}, res, start)
+	tracker.account(start.Add(30 * time.Minute))
+
+	// the unfinished hour and day are exported up to the stop
+	tracker.stopAt(start.Add(90 * time.Minute))
+	hourly := readLines(t, filepath.Join(dir, "hourly-2024-01-01.csv"))
+	assert.Equal(t, len(hourly), 3)
+	assert.Equal(t, hourly[1], "hourly,2024-01-01T10:00:00Z,2024-01-01T11:00:00Z,default,root.a,app-1,alice,,,vcore,3600")
+	assert.Equal(t, hourly[2], "hourly,2024-01-01T11:00:00Z,2024-01-01T11:30:00Z,default,root.a,app-1,alice,,,vcore,1800")
+	daily := readLines(t, filepath.Join(dir, "daily-2024-01.csv"))
+	assert.Equal(t, len(daily), 2)
+	assert.Equal(t, daily[1], "daily,2024-01-01T00:00:00Z,2024-01-01T11:30:00Z,default,root.a,app-1,alice,,,vcore,5400")
+	assert.Equal(t, len(tracker.unexported[Hourly]), 0)
+}
+
+func TestTrackerConfig(t *testing.T) {
+	tracker := newTracker(start)
+	tracker.updateConfig(map[string]string{})
+	assert.Assert(t, tracker.writer == nil, "no export without a directory")
+	assert.Equal(t, tracker.retention, configs.DefaultAccountingRetention)
+	assert.Equal(t, len(tracker.tags), 0)
+	tracker.updateConfig(map[string]string{
+		configs.CMAccountingDirectory: "/tmp/accounting",
+		configs.CMAccountingFormat:    "xml",
+		configs.CMAccountingRetention: "-1h",
+		configs.CMAccountingTags:      ",namespace,",
+	})
+	assert.Equal(t, tracker.writer.format, configs.DefaultAccountingFormat)
+	assert.Equal(t, tracker.retention, configs.DefaultAccountingRetention)
+	assert.DeepEqual(t, tracker.tags, []string{"namespace"})
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	file, err := os.Open(path)
+	assert.NilError(t, err)
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	assert.NilError(t, scanner.Err())
+	return lines
+}
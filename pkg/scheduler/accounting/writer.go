@@ -0,0 +1,126 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+const (
+	csvFormat   = "csv"
+	jsonlFormat = "jsonl"
+)
+
+var csvHeader = []string{"period", "start", "end", "partition", "queue", "application", "user", "group", "tags", "resource", "resourceSeconds"}
+
+// writer appends accounting records to export files in the directory.
+// Hourly records are written to a file per day, daily records to a file per month.
+// CSV files have a row per resource type, JSON Lines files an object per record.
+type writer struct {
+	directory string
+	format    string
+}
+
+// write appends the records to the export files. The records of the files that could not be written are returned.
+func (w *writer) write(period string, records []*dao.AccountingRecordDAOInfo) ([]*dao.AccountingRecordDAOInfo, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(w.directory, 0o750); err != nil {
+		return records, err
+	}
+	files := make(map[string][]*dao.AccountingRecordDAOInfo)
+	for _, record := range records {
+		name := w.fileName(period, time.Unix(0, record.Start).UTC())
+		files[name] = append(files[name], record)
+	}
+	var errs []error
+	var failed []*dao.AccountingRecordDAOInfo
+	for name, fileRecords := range files {
+		if err := w.writeFile(filepath.Join(w.directory, name), fileRecords); err != nil {
+			errs = append(errs, err)
+			failed = append(failed, fileRecords...)
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+func (w *writer) fileName(period string, start time.Time) string {
+	if period == Daily {
+		return period + "-" + start.Format("2006-01") + "." + w.format
+	}
+	return period + "-" + start.Format("2006-01-02") + "." + w.format
+}
+
+func (w *writer) writeFile(path string, records []*dao.AccountingRecordDAOInfo) (err error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+	if w.format == jsonlFormat {
+		encoder := json.NewEncoder(file)
+		for _, record := range records {
+			if err = encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	csvWriter := csv.NewWriter(file)
+	if info.Size() == 0 {
+		if err = csvWriter.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	for _, record := range records {
+		start := time.Unix(0, record.Start).UTC().Format(time.RFC3339)
+		end := time.Unix(0, record.End).UTC().Format(time.RFC3339)
+		tags := tagString(record.Tags)
+		names := make([]string, 0, len(record.ResourceSeconds))
+		for name := range record.ResourceSeconds {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			row := []string{record.Period, start, end, record.Partition, record.QueuePath, record.ApplicationID,
+				record.User, record.Group, tags, name, strconv.FormatInt(record.ResourceSeconds[name], 10)}
+			if err = csvWriter.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
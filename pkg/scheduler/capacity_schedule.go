@@ -0,0 +1,109 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+)
+
+// maxCapacityTransitionSteps limits the search for the next transition to the number of minutes in a week.
+const maxCapacityTransitionSteps = 7 * 24 * 60
+
+// capacitySchedule is a capacity schedule from the partition configuration with the window parsed.
+// A window starts at each time matching the cron expression and lasts for the duration.
+type capacitySchedule struct {
+	conf     configs.CapacitySchedule
+	start    *configs.CronSchedule
+	duration time.Duration
+}
+
+func newCapacitySchedules(conf []configs.CapacitySchedule) ([]*capacitySchedule, error) {
+	schedules := make([]*capacitySchedule, 0, len(conf))
+	for _, scheduleConf := range conf {
+		start, err := configs.ParseCronSchedule(scheduleConf.Start)
+		if err != nil {
+			return nil, err
+		}
+		duration, err := configs.ParseCapacityWindow(scheduleConf.Duration)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, &capacitySchedule{
+			conf:     scheduleConf,
+			start:    start,
+			duration: duration,
+		})
+	}
+	return schedules, nil
+}
+
+// windowEnd returns the end of the latest window that contains the time, a zero time if no window contains it.
+func (cs *capacitySchedule) windowEnd(now time.Time) time.Time {
+	start := cs.start.Previous(now)
+	if start.IsZero() || !start.After(now.Add(-cs.duration)) {
+		return time.Time{}
+	}
+	return start.Add(cs.duration)
+}
+
+// activeCapacitySchedule returns the schedule that is active at the time. If windows of multiple schedules
+// overlap the first schedule in the configuration wins. Returns nil if no schedule is active.
+func activeCapacitySchedule(schedules []*capacitySchedule, now time.Time) *capacitySchedule {
+	for _, schedule := range schedules {
+		if !schedule.windowEnd(now).IsZero() {
+			return schedule
+		}
+	}
+	return nil
+}
+
+// nextCapacityTransition returns the first time after the passed in time at which the active schedule changes.
+// A zero time is returned if the active schedule does not change within a year. Each step of the search moves at
+// least a minute and the number of steps is limited: a transition within a week is always found.
+func nextCapacityTransition(schedules []*capacitySchedule, now time.Time) time.Time {
+	if len(schedules) == 0 {
+		return time.Time{}
+	}
+	current := activeCapacitySchedule(schedules, now)
+	limit := now.AddDate(1, 0, 0)
+	for step := 0; step < maxCapacityTransitionSteps && now.Before(limit); step++ {
+		// the earliest end of an active window or start of an inactive schedule is the next candidate:
+		// a window that starts while the schedule is active does not change the active schedule
+		var next time.Time
+		for _, schedule := range schedules {
+			candidate := schedule.windowEnd(now)
+			if candidate.IsZero() {
+				candidate = schedule.start.Next(now)
+			}
+			if !candidate.IsZero() && (next.IsZero() || candidate.Before(next)) {
+				next = candidate
+			}
+		}
+		if next.IsZero() {
+			return next
+		}
+		if activeCapacitySchedule(schedules, next) != current {
+			return next
+		}
+		now = next
+	}
+	return time.Time{}
+}
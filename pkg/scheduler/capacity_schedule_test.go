@@ -0,0 +1,145 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+func TestCapacityScheduleTransitions(t *testing.T) {
+	_, err := newCapacitySchedules([]configs.CapacitySchedule{{Name: "invalid", Start: "* *", Duration: "1h"}})
+	assert.ErrorContains(t, err, "must have 5 fields")
+	_, err = newCapacitySchedules([]configs.CapacitySchedule{{Name: "invalid", Start: "* * * * *", Duration: "1s"}})
+	assert.ErrorContains(t, err, "must be between")
+
+	schedules, err := newCapacitySchedules([]configs.CapacitySchedule{
+		{Name: "daytime", Start: "0 8 * * mon-fri", Duration: "10h"},
+		{Name: "night", Start: "0 18 * * *", Duration: "14h"},
+	})
+	assert.NilError(t, err)
+	assert.Assert(t, nextCapacityTransition(nil, time.Now()).IsZero(), "no schedules should not have a transition")
+	// 2024-01-01 is a Monday
+	monday := time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC)
+	assert.Equal(t, activeCapacitySchedule(schedules, monday).conf.Name, "daytime")
+	assert.Equal(t, nextCapacityTransition(schedules, monday), time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC))
+	evening := monday.Add(10 * time.Hour)
+	assert.Equal(t, activeCapacitySchedule(schedules, evening).conf.Name, "night")
+	assert.Equal(t, nextCapacityTransition(schedules, evening), time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC))
+	// the window end is not part of the window
+	assert.Equal(t, activeCapacitySchedule(schedules, time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC)).conf.Name, "night")
+	// the night window on friday runs into saturday, after that there is no schedule until sunday night
+	friday := time.Date(2024, 1, 5, 20, 0, 0, 0, time.UTC)
+	assert.Equal(t, nextCapacityTransition(schedules, friday), time.Date(2024, 1, 6, 8, 0, 0, 0, time.UTC))
+	saturday := time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC)
+	assert.Assert(t, activeCapacitySchedule(schedules, saturday) == nil, "no schedule should be active")
+	assert.Equal(t, nextCapacityTransition(schedules, saturday), time.Date(2024, 1, 6, 18, 0, 0, 0, time.UTC))
+	// windows of the same schedule that follow each other are not a transition
+	schedules, err = newCapacitySchedules([]configs.CapacitySchedule{{Name: "always", Start: "0 * * * *", Duration: "1h"}})
+	assert.NilError(t, err)
+	assert.Assert(t, nextCapacityTransition(schedules, monday).IsZero(), "always active schedule should not have a transition")
+	// the search for a schedule with a window every minute is limited
+	schedules, err = newCapacitySchedules([]configs.CapacitySchedule{{Name: "always", Start: "* * * * *", Duration: "1m"}})
+	assert.NilError(t, err)
+	start := time.Now()
+	assert.Assert(t, nextCapacityTransition(schedules, monday).IsZero(), "always active schedule should not have a transition")
+	assert.Assert(t, time.Since(start) < 5*time.Second, "search for the transition took too long: %s", time.Since(start))
+	// windows that overlap the end of the active window extend it
+	schedules, err = newCapacitySchedules([]configs.CapacitySchedule{
+		{Name: "first", Start: "0 8 1 * *", Duration: "1h"},
+		{Name: "overlap", Start: "*/10 * * * *", Duration: "15m"},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, activeCapacitySchedule(schedules, monday).conf.Name, "overlap")
+	assert.Equal(t, nextCapacityTransition(schedules, time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC)), time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC))
+}
+
+func TestPartitionCapacitySchedules(t *testing.T) {
+	conf := configs.PartitionConfig{
+		Name: "test",
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues: []configs.QueueConfig{
+					{Name: "interactive", MaxApplications: 5, Resources: configs.Resources{Guaranteed: map[string]string{"memory": "10"}}},
+					{Name: "batch", Resources: configs.Resources{Guaranteed: map[string]string{"memory": "50"}}},
+				},
+			},
+		},
+		CapacitySchedules: []configs.CapacitySchedule{
+			{
+				Name:     "daytime",
+				Start:    "0 8 * * *",
+				Duration: "10h",
+				Queues: []configs.QueueCapacity{
+					{Name: "root.interactive", Resources: configs.Resources{Guaranteed: map[string]string{"memory": "50"}}, MaxApplications: 20},
+					{Name: "root.batch", Resources: configs.Resources{Guaranteed: map[string]string{"memory": "10"}}},
+				},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, rmID, nil, true)
+	assert.NilError(t, err, "partition create failed")
+	interactive := partition.GetQueue("root.interactive")
+	batch := partition.GetQueue("root.batch")
+
+	morning := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	next := partition.applyCapacitySchedules(morning)
+	assert.Equal(t, next, time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC))
+	assert.Assert(t, resources.Equals(interactive.GetGuaranteedResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 50})))
+	assert.Equal(t, interactive.GetMaxApps(), uint64(20))
+	assert.Assert(t, resources.Equals(batch.GetGuaranteedResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})))
+	queueInfo := interactive.GetPartitionQueueDAOInfo(false)
+	assert.Equal(t, queueInfo.CapacityProfile, "daytime")
+	assert.Equal(t, queueInfo.NextCapacityTransition, next.UnixNano())
+	assert.Equal(t, partition.root.GetCapacityProfile(), "", "root is not part of the schedule")
+
+	evening := time.Date(2024, 1, 1, 19, 0, 0, 0, time.UTC)
+	next = partition.applyCapacitySchedules(evening)
+	assert.Equal(t, next, time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC))
+	assert.Assert(t, resources.Equals(interactive.GetGuaranteedResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})))
+	assert.Equal(t, interactive.GetMaxApps(), uint64(5))
+	assert.Assert(t, resources.Equals(batch.GetGuaranteedResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 50})))
+	queueInfo = interactive.GetPartitionQueueDAOInfo(false)
+	assert.Equal(t, queueInfo.CapacityProfile, "")
+	assert.Equal(t, queueInfo.NextCapacityTransition, next.UnixNano())
+
+	// a schedule that is always active is applied on a config change
+	conf.CapacitySchedules[0].Start = "* * * * *"
+	conf.CapacitySchedules[0].Duration = "1h"
+	conf.Queues[0].Queues[0].MaxApplications = 8
+	err = partition.updatePartitionDetails(conf)
+	assert.NilError(t, err, "config update failed")
+	assert.Equal(t, interactive.GetCapacityProfile(), "daytime")
+	assert.Equal(t, interactive.GetMaxApps(), uint64(20))
+	// removing the schedules restores the configured values
+	conf.CapacitySchedules = nil
+	err = partition.updatePartitionDetails(conf)
+	assert.NilError(t, err, "config update failed")
+	assert.Equal(t, interactive.GetCapacityProfile(), "")
+	assert.Equal(t, interactive.GetMaxApps(), uint64(8))
+	assert.Assert(t, interactive.GetPartitionQueueDAOInfo(false).NextCapacityTransition == 0)
+}
@@ -0,0 +1,292 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+// rmID used for the partitions created to check a configuration that does not exist in the scheduler yet
+const dryRunRMID = "dry-run"
+
+// DryRunConfig reports the impact the configuration would have if it replaced the current configuration.
+// Nothing is changed in the scheduler. The configuration passed in must have passed the standard validation.
+// An error is returned if the configuration would be rejected when it is applied.
+func (cc *ClusterContext) DryRunConfig(conf *configs.SchedulerConfig) ([]*dao.PartitionDryRunDAOInfo, error) {
+	cc.RLock()
+	defer cc.RUnlock()
+	live := make(map[string]*PartitionContext, len(cc.partitions))
+	for _, part := range cc.partitions {
+		live[common.GetPartitionNameWithoutClusterID(part.Name)] = part
+	}
+	result := make([]*dao.PartitionDryRunDAOInfo, 0, len(conf.Partitions)+len(live))
+	for _, p := range conf.Partitions {
+		part := live[p.Name]
+		delete(live, p.Name)
+		rmID := dryRunRMID
+		if part != nil {
+			rmID = part.RmID
+		}
+		// the same check that is performed before a partition is updated
+		shadow, err := newPartitionContext(p, rmID, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		// the new rules are only checked when an existing partition is updated
+		if err = shadow.getPlacementManager().UpdateRules(p.PlacementRules); err != nil {
+			return nil, err
+		}
+		result = append(result, dryRunPartition(p, part, shadow))
+	}
+	// partitions that are not in the new config are removed with everything in them
+	for name, part := range live {
+		info := &dao.PartitionDryRunDAOInfo{
+			PartitionName: name,
+			Removed:       true,
+		}
+		managed := make(map[string]bool)
+		collectManagedQueues(part.GetQueue(configs.RootQueue), managed)
+		info.QueuesRemoved = sortedKeys(managed)
+		info.QueuesDrained = drainedQueues(part, info.QueuesRemoved)
+		result = append(result, info)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].PartitionName < result[j].PartitionName
+	})
+	return result, nil
+}
+
+// dryRunPartition compares the shadow partition built from the new config with the live partition.
+// The live partition is nil if the partition does not exist yet.
+func dryRunPartition(conf configs.PartitionConfig, part, shadow *PartitionContext) *dao.PartitionDryRunDAOInfo {
+	info := &dao.PartitionDryRunDAOInfo{
+		PartitionName: conf.Name,
+	}
+	newQueues := make(map[string]bool)
+	collectManagedQueues(shadow.GetQueue(configs.RootQueue), newQueues)
+	if part == nil {
+		info.Added = true
+		info.QueuesAdded = sortedKeys(newQueues)
+		return info
+	}
+	currentQueues := make(map[string]bool)
+	collectManagedQueues(part.GetQueue(configs.RootQueue), currentQueues)
+	for queuePath := range newQueues {
+		if !currentQueues[queuePath] {
+			info.QueuesAdded = append(info.QueuesAdded, queuePath)
+		}
+	}
+	sort.Strings(info.QueuesAdded)
+	for queuePath := range currentQueues {
+		if !newQueues[queuePath] {
+			info.QueuesRemoved = append(info.QueuesRemoved, queuePath)
+		}
+	}
+	sort.Strings(info.QueuesRemoved)
+	info.QueuesDrained = drainedQueues(part, info.QueuesRemoved)
+	info.LimitsExceeded = exceededLimits(conf.Queues, "")
+	info.PlacementChanges = placementChanges(part, shadow)
+	return info
+}
+
+// collectManagedQueues adds the path of the queue and all managed queues below it to the paths map.
+func collectManagedQueues(queue *objects.Queue, paths map[string]bool) {
+	if queue == nil || !queue.IsManaged() {
+		return
+	}
+	paths[queue.QueuePath] = true
+	for _, child := range queue.GetCopyOfChildren() {
+		collectManagedQueues(child, paths)
+	}
+}
+
+// drainedQueues returns the removed queues that still have applications running in them or in a queue below them.
+func drainedQueues(part *PartitionContext, removed []string) []*dao.QueueDrainedDAOInfo {
+	if len(removed) == 0 {
+		return nil
+	}
+	apps := part.GetApplications()
+	var drained []*dao.QueueDrainedDAOInfo
+	for _, queuePath := range removed {
+		var appIDs []string
+		for _, app := range apps {
+			appQueue := app.GetQueuePath()
+			if appQueue == queuePath || strings.HasPrefix(appQueue, queuePath+configs.DOT) {
+				appIDs = append(appIDs, app.ApplicationID)
+			}
+		}
+		if len(appIDs) > 0 {
+			sort.Strings(appIDs)
+			drained = append(drained, &dao.QueueDrainedDAOInfo{
+				QueuePath:    queuePath,
+				Applications: appIDs,
+			})
+		}
+	}
+	return drained
+}
+
+// exceededLimits walks the queue config and returns all user and group limits that the current tracked usage
+// already exceeds.
+func exceededLimits(queues []configs.QueueConfig, parentPath string) []*dao.LimitExceededDAOInfo {
+	var exceeded []*dao.LimitExceededDAOInfo
+	manager := ugm.GetUserManager()
+	for _, queue := range queues {
+		queuePath := strings.ToLower(queue.Name)
+		if parentPath != "" {
+			queuePath = parentPath + configs.DOT + queuePath
+		}
+		// wildcard limits only apply to users and groups without their own limit on the queue
+		named := make(map[string]bool)
+		for _, limit := range queue.Limits {
+			for _, name := range limit.Users {
+				named["u:"+name] = true
+			}
+			for _, name := range limit.Groups {
+				named["g:"+name] = true
+			}
+		}
+		for _, limit := range queue.Limits {
+			for _, user := range limit.Users {
+				for _, tracker := range manager.GetUserTrackers() {
+					info := tracker.GetResourceUsageDAOInfo()
+					if (user == common.Wildcard && !named["u:"+info.UserName]) || user == info.UserName {
+						if check := checkLimit(limit, queuePath, info.Queues); check != nil {
+							check.User = info.UserName
+							exceeded = append(exceeded, check)
+						}
+					}
+				}
+			}
+			for _, group := range limit.Groups {
+				for _, tracker := range manager.GetGroupTrackers() {
+					info := tracker.GetResourceUsageDAOInfo()
+					if (group == common.Wildcard && !named["g:"+info.GroupName]) || group == info.GroupName {
+						if check := checkLimit(limit, queuePath, info.Queues); check != nil {
+							check.Group = info.GroupName
+							exceeded = append(exceeded, check)
+						}
+					}
+				}
+			}
+		}
+		exceeded = append(exceeded, exceededLimits(queue.Queues, queuePath)...)
+	}
+	return exceeded
+}
+
+// checkLimit returns the details of the limit if the tracked usage for the queue exceeds it, nil otherwise.
+func checkLimit(limit configs.Limit, queuePath string, usage *dao.ResourceUsageDAOInfo) *dao.LimitExceededDAOInfo {
+	usage = findQueueUsage(usage, queuePath)
+	if usage == nil {
+		return nil
+	}
+	// the config passed validation: the resources are correct
+	maxResources, err := resources.NewResourceFromConf(limit.MaxResources)
+	if err != nil {
+		return nil
+	}
+	maxMap := maxResources.DAOMap()
+	over := limit.MaxApplications > 0 && uint64(len(usage.RunningApplications)) > limit.MaxApplications
+	for name, value := range maxMap {
+		if usage.ResourceUsage[name] > value {
+			over = true
+		}
+	}
+	if !over {
+		return nil
+	}
+	return &dao.LimitExceededDAOInfo{
+		QueuePath:           queuePath,
+		MaxResources:        maxMap,
+		MaxApplications:     limit.MaxApplications,
+		ResourceUsage:       usage.ResourceUsage,
+		RunningApplications: uint64(len(usage.RunningApplications)),
+	}
+}
+
+// findQueueUsage returns the usage tracked for the queue path in the usage tree, nil if the queue is not tracked.
+func findQueueUsage(usage *dao.ResourceUsageDAOInfo, queuePath string) *dao.ResourceUsageDAOInfo {
+	if usage == nil {
+		return nil
+	}
+	if usage.QueuePath == queuePath {
+		return usage
+	}
+	if !strings.HasPrefix(queuePath, usage.QueuePath+configs.DOT) {
+		return nil
+	}
+	for _, child := range usage.Children {
+		if found := findQueueUsage(child, queuePath); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// placementChanges runs the placement rules of the shadow partition for all applications of the live partition.
+// Applications that would be placed in a different queue, or rejected, are returned.
+func placementChanges(part, shadow *PartitionContext) []*dao.PlacementChangeDAOInfo {
+	var changes []*dao.PlacementChangeDAOInfo
+	placementManager := shadow.getPlacementManager()
+	for _, app := range part.GetApplications() {
+		currentQueue := app.GetQueuePath()
+		// applications in the recovery queue were never placed by the rules
+		if currentQueue == common.RecoveryQueueFull {
+			continue
+		}
+		placed := app.NewPlacementCopy()
+		if err := placementManager.PlaceApplication(placed); err != nil {
+			changes = append(changes, &dao.PlacementChangeDAOInfo{
+				ApplicationID: app.ApplicationID,
+				CurrentQueue:  currentQueue,
+				Reason:        err.Error(),
+			})
+			continue
+		}
+		if placed.GetQueuePath() != currentQueue {
+			changes = append(changes, &dao.PlacementChangeDAOInfo{
+				ApplicationID: app.ApplicationID,
+				CurrentQueue:  currentQueue,
+				NewQueue:      placed.GetQueuePath(),
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ApplicationID < changes[j].ApplicationID
+	})
+	return changes
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,130 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
+)
+
+const dryRunCurrent = `
+partitions:
+  - name: default
+    placementrules:
+      - name: provided
+    queues:
+      - name: root
+        submitacl: "*"
+        queues:
+          - name: a
+          - name: b
+            queues:
+              - name: b1
+  - name: gpu
+    queues:
+      - name: root
+        queues:
+          - name: train
+`
+
+const dryRunNew = `
+partitions:
+  - name: default
+    placementrules:
+      - name: fixed
+        value: root.c
+    queues:
+      - name: root
+        submitacl: "*"
+        queues:
+          - name: a
+            limits:
+              - limit: user limit
+                users:
+                  - testuser
+                maxresources: {memory: 5}
+              - limit: wildcard limit
+                users:
+                  - "*"
+                maxapplications: 1
+          - name: c
+  - name: batch
+    queues:
+      - name: root
+`
+
+func TestDryRunConfig(t *testing.T) {
+	context, err := NewClusterContext(rmID, "dry-run", []byte(dryRunCurrent))
+	assert.NilError(t, err, "failed to create context")
+	defer ugm.GetUserManager().ClearUserTrackers()
+	defer ugm.GetUserManager().ClearGroupTrackers()
+	part := context.GetPartition(common.GetNormalizedPartitionName("default", rmID))
+	user := security.UserGroup{User: "testuser", Groups: []string{"testgroup"}}
+	assert.NilError(t, part.AddApplication(newApplicationWithUser("app-a", part.Name, "root.a", user)))
+	assert.NilError(t, part.AddApplication(newApplicationWithUser("app-b", part.Name, "root.b.b1", user)))
+	ugm.GetUserManager().IncreaseTrackedResource("root.a", "app-a", resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10}), user)
+
+	conf, err := configs.LoadSchedulerConfigFromByteArray([]byte(dryRunNew))
+	assert.NilError(t, err, "new config should be valid")
+	result, err := context.DryRunConfig(conf)
+	assert.NilError(t, err, "dry run failed")
+	assert.Equal(t, len(result), 3, "unexpected partition count")
+
+	batch := result[0]
+	assert.Equal(t, batch.PartitionName, "batch")
+	assert.Assert(t, batch.Added, "batch partition should be added")
+	assert.DeepEqual(t, batch.QueuesAdded, []string{"root"})
+
+	current := result[1]
+	assert.Equal(t, current.PartitionName, "default")
+	assert.DeepEqual(t, current.QueuesAdded, []string{"root.c"})
+	assert.DeepEqual(t, current.QueuesRemoved, []string{"root.b", "root.b.b1"})
+	assert.Equal(t, len(current.QueuesDrained), 2, "both removed queues have an app below them")
+	assert.DeepEqual(t, current.QueuesDrained[0].Applications, []string{"app-b"})
+	// only the named user limit applies, the wildcard is not used for a user with its own limit
+	assert.Equal(t, len(current.LimitsExceeded), 1, "unexpected limits exceeded")
+	assert.Equal(t, current.LimitsExceeded[0].User, "testuser")
+	assert.Equal(t, current.LimitsExceeded[0].QueuePath, "root.a")
+	assert.DeepEqual(t, current.LimitsExceeded[0].ResourceUsage, map[string]int64{"memory": 10})
+	assert.Equal(t, len(current.PlacementChanges), 2, "unexpected placement changes")
+	assert.Equal(t, current.PlacementChanges[0].ApplicationID, "app-a")
+	assert.Equal(t, current.PlacementChanges[0].NewQueue, "root.c")
+
+	gpu := result[2]
+	assert.Equal(t, gpu.PartitionName, "gpu")
+	assert.Assert(t, gpu.Removed, "gpu partition should be removed")
+	assert.DeepEqual(t, gpu.QueuesRemoved, []string{"root", "root.train"})
+
+	// nothing changed in the scheduler
+	assert.Assert(t, part.GetQueue("root.b").IsRunning(), "dry run should not change queues")
+	assert.Assert(t, part.GetQueue("root.c") == nil, "dry run should not add queues")
+	assert.Equal(t, part.GetApplication("app-a").GetQueuePath(), "root.a")
+
+	// a config that cannot be applied is rejected
+	conf.Partitions[0].PlacementRules = []configs.PlacementRule{{Name: "unknown"}}
+	_, err = context.DryRunConfig(conf)
+	assert.ErrorContains(t, err, "unknown")
+}
@@ -0,0 +1,212 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/events"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/metrics"
+	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
+)
+
+const DefaultConfigFileCheckInterval = 10 * time.Second
+
+// ConfigFileWatcher polls a local scheduler config file (queues.yaml) and a key/value file with the config map
+// settings, and applies the content when it changes. It is meant for standalone and test deployments that do not
+// receive the config from an RM. An RM that updates the config overrides the file content until the file changes.
+// Invalid changes are rejected: the running config is left as is, an event and a metric record the rejection.
+type ConfigFileWatcher struct {
+	context       *ClusterContext
+	queuesFile    string
+	configMapFile string
+	interval      time.Duration
+	queueEvents   *schedEvt.QueueEvents
+
+	// mutable values require locking
+	queuesChecksum    [32]byte
+	configMapChecksum [32]byte
+	stopChan          chan struct{}
+
+	locking.Mutex
+}
+
+// NewConfigFileWatcher creates a watcher for the files, an empty file name is not watched.
+func NewConfigFileWatcher(context *ClusterContext, queuesFile, configMapFile string, interval time.Duration) *ConfigFileWatcher {
+	if interval <= 0 {
+		interval = DefaultConfigFileCheckInterval
+	}
+	return &ConfigFileWatcher{
+		context:       context,
+		queuesFile:    queuesFile,
+		configMapFile: configMapFile,
+		interval:      interval,
+		queueEvents:   schedEvt.NewQueueEvents(events.GetEventSystem()),
+	}
+}
+
+// Start checks the files and keeps checking them in the background until stopped.
+func (w *ConfigFileWatcher) Start() {
+	w.Lock()
+	defer w.Unlock()
+	if w.stopChan != nil {
+		return
+	}
+	stopChan := make(chan struct{})
+	w.stopChan = stopChan
+	log.Log(log.SchedContext).Info("Starting config file watcher",
+		zap.String("queuesFile", w.queuesFile),
+		zap.String("configMapFile", w.configMapFile),
+		zap.Duration("interval", w.interval))
+	go func() {
+		w.runOnce()
+		ticker := time.NewTicker(w.interval)
+		for {
+			select {
+			case <-stopChan:
+				ticker.Stop()
+				return
+			case <-ticker.C:
+				w.runOnce()
+			}
+		}
+	}()
+}
+
+func (w *ConfigFileWatcher) Stop() {
+	w.Lock()
+	defer w.Unlock()
+	if w.stopChan != nil {
+		log.Log(log.SchedContext).Info("Stopping config file watcher")
+		close(w.stopChan)
+		w.stopChan = nil
+	}
+}
+
+// runOnce checks both files and applies the content of the files that changed since the last check.
+// The config map is applied first: settings in it can influence how the scheduler config is applied.
+func (w *ConfigFileWatcher) runOnce() {
+	w.Lock()
+	defer w.Unlock()
+	// the registration of the RM sets the config and config map: files are applied after that, retry on the next check
+	rmID := w.context.getRMID()
+	if rmID == "" {
+		log.Log(log.SchedContext).Debug("No RM registered, config files not applied yet")
+		return
+	}
+	if w.configMapFile != "" {
+		if content, changed := w.readChanged(w.configMapFile, &w.configMapChecksum); changed {
+			w.applyConfigMap(content)
+		}
+	}
+	if w.queuesFile != "" {
+		if content, changed := w.readChanged(w.queuesFile, &w.queuesChecksum); changed {
+			w.applySchedulerConfig(rmID, content)
+		}
+	}
+}
+
+// readChanged reads the file and returns the content if it changed since the last time the checksum was set.
+// A file that cannot be read is logged and treated as unchanged: the running config is kept.
+func (w *ConfigFileWatcher) readChanged(file string, checksum *[32]byte) ([]byte, bool) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		log.Log(log.SchedContext).Warn("Failed to read config file",
+			zap.String("file", file),
+			zap.Error(err))
+		return nil, false
+	}
+	sum := sha256.Sum256(content)
+	if sum == *checksum {
+		return nil, false
+	}
+	*checksum = sum
+	return content, true
+}
+
+func (w *ConfigFileWatcher) applySchedulerConfig(rmID string, content []byte) {
+	if _, err := configs.LoadSchedulerConfigFromByteArray(content); err != nil {
+		w.reject(w.queuesFile, err)
+		return
+	}
+	if err := w.context.UpdateRMSchedulerConfig(rmID, content, configSourceFile+w.queuesFile); err != nil {
+		w.reject(w.queuesFile, err)
+		return
+	}
+	metrics.GetSchedulerMetrics().IncConfigReloadAccepted()
+	log.Log(log.SchedContext).Info("Scheduler config reloaded from file",
+		zap.String("file", w.queuesFile))
+}
+
+func (w *ConfigFileWatcher) applyConfigMap(content []byte) {
+	configMap, err := parseConfigMap(content)
+	if err != nil {
+		w.reject(w.configMapFile, err)
+		return
+	}
+	// this also runs the config map callbacks
+	configs.SetConfigMap(configMap)
+	metrics.GetSchedulerMetrics().IncConfigReloadAccepted()
+	log.Log(log.SchedContext).Info("Config map reloaded from file",
+		zap.String("file", w.configMapFile),
+		zap.Int("entries", len(configMap)))
+}
+
+func (w *ConfigFileWatcher) reject(file string, err error) {
+	log.Log(log.SchedContext).Warn("Config file change rejected, keeping the running config",
+		zap.String("file", file),
+		zap.Error(err))
+	metrics.GetSchedulerMetrics().IncConfigReloadRejected()
+	w.queueEvents.SendConfigRejectedEvent(configs.RootQueue, fmt.Sprintf("config file %s rejected: %v", file, err))
+}
+
+// parseConfigMap parses "key=value" lines. Empty lines and lines starting with # are ignored.
+func parseConfigMap(content []byte) (map[string]string, error) {
+	configMap := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("line %d: expected key=value", lineNumber)
+		}
+		configMap[key] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return configMap, nil
+}
@@ -0,0 +1,122 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/events/mock"
+	"github.com/apache/yunikorn-core/pkg/metrics"
+	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const watchedConfig = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        queues:
+          - name: watched
+`
+
+func writeWatchedFile(t *testing.T, file, content string) {
+	assert.NilError(t, os.WriteFile(file, []byte(content), 0o600), "failed to write watched file")
+}
+
+func TestConfigFileWatcher(t *testing.T) {
+	defer configs.SetConfigMap(nil)
+	metrics.GetSchedulerMetrics().Reset()
+	dir := t.TempDir()
+	queuesFile := filepath.Join(dir, "queues.yaml")
+	configMapFile := filepath.Join(dir, "configmap")
+	writeWatchedFile(t, queuesFile, watchedConfig)
+	writeWatchedFile(t, configMapFile, "# comment\n\nkey = value\n")
+
+	// nothing is applied before the RM registers
+	watcher := NewConfigFileWatcher(newClusterContext(), queuesFile, configMapFile, 0)
+	assert.Equal(t, watcher.interval, DefaultConfigFileCheckInterval)
+	watcher.runOnce()
+	_, ok := configs.GetConfigMap()["key"]
+	assert.Assert(t, !ok, "config map should not be applied without an RM")
+
+	context, err := NewClusterContext(rmID, "watcher", []byte(configDefault))
+	assert.NilError(t, err, "failed to create context")
+	partitionName := common.GetNormalizedPartitionName("default", rmID)
+	eventSystem := mock.NewEventSystem()
+	watcher = NewConfigFileWatcher(context, queuesFile, configMapFile, 0)
+	watcher.queueEvents = schedEvt.NewQueueEvents(eventSystem)
+	watcher.runOnce()
+	assert.Equal(t, configs.GetConfigMap()["key"], "value")
+	assert.Assert(t, context.GetQueue("root.watched", partitionName).IsRunning(), "queue from the file should be added")
+	history := configs.ConfigContext.GetHistory("watcher")
+	assert.Equal(t, history[len(history)-1].Source, "file:"+queuesFile, "file reload should be recorded with the file as source")
+	accepted, err := metrics.GetSchedulerMetrics().GetConfigReloadAccepted()
+	assert.NilError(t, err)
+	assert.Equal(t, accepted, 2)
+
+	// unchanged files are not applied again
+	checksum := configs.ConfigContext.Get("watcher").Checksum
+	watcher.runOnce()
+	accepted, err = metrics.GetSchedulerMetrics().GetConfigReloadAccepted()
+	assert.NilError(t, err)
+	assert.Equal(t, accepted, 2)
+
+	// invalid edits are rejected and the running config is kept
+	writeWatchedFile(t, queuesFile, "partitions:\n  - name: default\n    nodesortpolicy:\n      type: invalid\n    queues:\n      - name: root\n")
+	writeWatchedFile(t, configMapFile, "novalue\n")
+	watcher.runOnce()
+	assert.Equal(t, configs.ConfigContext.Get("watcher").Checksum, checksum, "config should not change")
+	assert.Assert(t, context.GetQueue("root.watched", partitionName).IsRunning(), "queue should not change")
+	assert.Equal(t, configs.GetConfigMap()["key"], "value", "config map should not change")
+	rejected, err := metrics.GetSchedulerMetrics().GetConfigReloadRejected()
+	assert.NilError(t, err)
+	assert.Equal(t, rejected, 2)
+	assert.Equal(t, len(eventSystem.Events), 2, "rejections should generate events")
+	assert.Equal(t, eventSystem.Events[0].EventChangeDetail, si.EventRecord_QUEUE_CONFIG)
+	assert.Equal(t, eventSystem.Events[0].ObjectID, configs.RootQueue)
+
+	// a missing file keeps the running config
+	assert.NilError(t, os.Remove(queuesFile))
+	watcher.runOnce()
+	assert.Assert(t, context.GetQueue("root.watched", partitionName).IsRunning(), "queue should not change")
+
+	watcher.Start()
+	watcher.Stop()
+	watcher.Stop()
+}
+
+func TestParseConfigMap(t *testing.T) {
+	configMap, err := parseConfigMap([]byte(""))
+	assert.NilError(t, err)
+	assert.Equal(t, len(configMap), 0)
+	configMap, err = parseConfigMap([]byte("# comment\na=1\n b = two words \nempty=\nc=x=y\n"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, configMap, map[string]string{"a": "1", "b": "two words", "empty": "", "c": "x=y"})
+	_, err = parseConfigMap([]byte("a=1\nbroken\n"))
+	assert.ErrorContains(t, err, "line 2")
+	_, err = parseConfigMap([]byte("=value\n"))
+	assert.ErrorContains(t, err, "line 1")
+}
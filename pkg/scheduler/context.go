@@ -22,9 +22,11 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 
 	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/common/configs"
@@ -60,6 +62,13 @@ type ClusterContext struct {
 	lastHealthCheckResult *dao.SchedulerHealthDAOInfo
 }
 
+// config history sources for changes that do not come from an RM
+const (
+	configSourceREST     = "rest"
+	configSourceRollback = "rollback to version"
+	configSourceFile     = "file:"
+)
+
 type RMInformation struct {
 	RMBuildInformation map[string]string
 }
@@ -90,7 +99,7 @@ func NewClusterContext(rmID, policyGroup string, config []byte) (*ClusterContext
 		return nil, err
 	}
 	// update the global config
-	configs.ConfigContext.Set(policyGroup, conf)
+	configs.ConfigContext.SetWithSource(policyGroup, conf, rmID)
 	return cc, nil
 }
 
@@ -146,7 +155,11 @@ func (cc *ClusterContext) schedule() bool {
 				// communicate the removal to the RM
 				cc.notifyRMAllocationReleased(psc.RmID, psc.Name, []*objects.Allocation{result.Request.GetRelease()}, si.TerminationType_PLACEHOLDER_REPLACED, "replacing allocationKey: "+result.Request.GetAllocationKey())
 			} else {
-				cc.notifyRMNewAllocation(psc.RmID, result.Request)
+				allocs := []*objects.Allocation{result.Request}
+				for _, member := range result.Members {
+					allocs = append(allocs, member.Request)
+				}
+				cc.notifyRMNewAllocation(psc.RmID, allocs...)
 			}
 			activity = true
 		}
@@ -189,7 +202,7 @@ func (cc *ClusterContext) processRMRegistrationEvent(event *rmevent.RMRegistrati
 
 	// update global scheduler configs, set the policyGroup for this cluster
 	cc.policyGroup = policyGroup
-	configs.ConfigContext.Set(policyGroup, conf)
+	configs.ConfigContext.SetWithSource(policyGroup, conf, rmID)
 
 	// store the build information of RM
 	cc.SetRMInfo(rmID, event.Registration.BuildInfo)
@@ -246,7 +259,7 @@ func (cc *ClusterContext) processRMConfigUpdateEvent(event *rmevent.RMConfigUpda
 		Succeeded: true,
 	}
 	// update global scheduler configs
-	configs.ConfigContext.Set(cc.policyGroup, conf)
+	configs.ConfigContext.SetWithSource(cc.policyGroup, conf, rmID)
 }
 
 func (cc *ClusterContext) handleRMUpdateNodeEvent(event *rmevent.RMUpdateNodeEvent) {
@@ -306,20 +319,19 @@ func (cc *ClusterContext) processNodes(request *si.NodeRequest) {
 // Called when a RM re-registers. This triggers a full clean up.
 // Registration expects everything to be clean.
 func (cc *ClusterContext) removePartitionsByRMID(event *rmevent.RMPartitionsRemoveEvent) {
-	cc.Lock()
-	defer cc.Unlock()
-	partitionToRemove := make(map[string]bool)
-
-	// Just remove corresponding partitions
-	for k, partition := range cc.partitions {
+	// collect the partitions first: stopping the partition manager removes the partition from the context,
+	// which needs the context lock.
+	var partitionToRemove []*PartitionContext
+	cc.RLock()
+	for _, partition := range cc.partitions {
 		if partition.RmID == event.RmID {
-			partition.partitionManager.Stop()
-			partitionToRemove[k] = true
+			partitionToRemove = append(partitionToRemove, partition)
 		}
 	}
+	cc.RUnlock()
 
-	for partitionName := range partitionToRemove {
-		delete(cc.partitions, partitionName)
+	for _, partition := range partitionToRemove {
+		partition.partitionManager.Stop()
 	}
 	// Done, notify channel
 	event.Channel <- &rmevent.Result{
@@ -329,8 +341,9 @@ func (cc *ClusterContext) removePartitionsByRMID(event *rmevent.RMPartitionsRemo
 
 // Locked version of the configuration update called outside of event system.
 // Updates the current config via the config loader.
-// Used in test only, normal updates use the internal call
-func (cc *ClusterContext) UpdateRMSchedulerConfig(rmID string, config []byte) error {
+// Used by the config file watcher and in tests, normal updates use the internal call.
+// The source is recorded in the config history.
+func (cc *ClusterContext) UpdateRMSchedulerConfig(rmID string, config []byte, source string) error {
 	cc.Lock()
 	defer cc.Unlock()
 	if len(cc.partitions) == 0 {
@@ -346,7 +359,214 @@ func (cc *ClusterContext) UpdateRMSchedulerConfig(rmID string, config []byte) er
 		return err
 	}
 	// update global scheduler configs
-	configs.ConfigContext.Set(cc.policyGroup, conf)
+	configs.ConfigContext.SetWithSource(cc.policyGroup, conf, source)
+	return nil
+}
+
+// UpdateQueue changes the resources, maximum applications and properties of a managed queue at runtime.
+// The change is applied to a copy of the current configuration, which must pass the same validation as a
+// configuration update, before the queue is changed. The stored configuration is replaced by the validated copy.
+// The next configuration update from the RM overwrites the change.
+func (cc *ClusterContext) UpdateQueue(partitionName, queuePath string, update *dao.QueueUpdateDAOInfo) error {
+	cc.Lock()
+	defer cc.Unlock()
+	partition := cc.partitions[partitionName]
+	if partition == nil {
+		return fmt.Errorf("partition %s not found", partitionName)
+	}
+	queue := partition.GetQueue(queuePath)
+	if queue == nil {
+		return fmt.Errorf("queue %s not found", queuePath)
+	}
+	if !queue.IsManaged() {
+		return fmt.Errorf("queue %s is not managed, only managed queues can be changed", queuePath)
+	}
+	if queue.QueuePath == configs.RootQueue && (update.GuaranteedResource != nil || update.MaxResource != nil || update.MaxApplications != nil) {
+		return fmt.Errorf("resources and maximum applications of the root queue cannot be changed")
+	}
+	if err := objects.CheckQueueProperties(update.Properties); err != nil {
+		return err
+	}
+	conf, err := copySchedulerConfig(configs.ConfigContext.Get(cc.policyGroup))
+	if err != nil {
+		return err
+	}
+	queueConf := findQueueConfig(conf, common.GetPartitionNameWithoutClusterID(partitionName), queuePath)
+	if queueConf == nil {
+		return fmt.Errorf("queue %s not found in the configuration", queuePath)
+	}
+	if update.GuaranteedResource != nil {
+		queueConf.Resources.Guaranteed = update.GuaranteedResource
+	}
+	if update.MaxResource != nil {
+		queueConf.Resources.Max = update.MaxResource
+	}
+	if update.MaxApplications != nil {
+		queueConf.MaxApplications = *update.MaxApplications
+	}
+	if len(update.Properties) != 0 && queueConf.Properties == nil {
+		queueConf.Properties = make(map[string]string)
+	}
+	for key, value := range update.Properties {
+		if value == "" {
+			delete(queueConf.Properties, key)
+		} else {
+			queueConf.Properties[key] = value
+		}
+	}
+	// validate the changed config as a whole, this also sets the checksum
+	var content []byte
+	if content, err = yaml.Marshal(conf); err != nil {
+		return err
+	}
+	if conf, err = configs.LoadSchedulerConfigFromByteArray(content); err != nil {
+		return err
+	}
+	if update.GuaranteedResource != nil || update.MaxResource != nil {
+		var guaranteed, maxResource *resources.Resource
+		if guaranteed, err = resources.NewResourceFromConf(queueConf.Resources.Guaranteed); err != nil {
+			return err
+		}
+		if maxResource, err = resources.NewResourceFromConf(queueConf.Resources.Max); err != nil {
+			return err
+		}
+		queue.SetResources(guaranteed, maxResource)
+	}
+	if update.MaxApplications != nil {
+		queue.UpdateMaxRunningApps(*update.MaxApplications)
+	}
+	queue.UpdateProperties(update.Properties)
+	if _, ok := update.Properties[configs.NodeSelector]; ok {
+		partition.resetNodePoolResources()
+	}
+	// capacity schedules are applied on top of the changed configuration
+	if rootConf := findQueueConfig(conf, common.GetPartitionNameWithoutClusterID(partitionName), configs.RootQueue); rootConf != nil {
+		partition.setQueueConf(*rootConf)
+	}
+	configs.ConfigContext.SetWithSource(cc.policyGroup, conf, configSourceREST)
+	return nil
+}
+
+// MoveApplication moves an application with all its requests and allocations to another leaf queue in the same
+// partition. Placement rules are not applied again: the application stays in the target queue.
+func (cc *ClusterContext) MoveApplication(partitionName, appID, queuePath string) error {
+	partition := cc.GetPartition(partitionName)
+	if partition == nil {
+		return fmt.Errorf("partition %s not found", partitionName)
+	}
+	return partition.MoveApplication(appID, queuePath)
+}
+
+// SuspendApplication suspends an application in the partition, releasing all its allocations.
+func (cc *ClusterContext) SuspendApplication(partitionName, appID string) error {
+	partition := cc.GetPartition(partitionName)
+	if partition == nil {
+		return fmt.Errorf("partition %s not found", partitionName)
+	}
+	return partition.SuspendApplication(appID)
+}
+
+// ResumeApplication resumes a suspended application in the partition.
+func (cc *ClusterContext) ResumeApplication(partitionName, appID string) error {
+	partition := cc.GetPartition(partitionName)
+	if partition == nil {
+		return fmt.Errorf("partition %s not found", partitionName)
+	}
+	return partition.ResumeApplication(appID)
+}
+
+// RollbackConfig re-applies an earlier version of the scheduler config from the config history.
+// The version is applied as a normal config update and is recorded in the history as a new version.
+func (cc *ClusterContext) RollbackConfig(version uint64) error {
+	cc.Lock()
+	defer cc.Unlock()
+	entry := configs.ConfigContext.GetVersion(cc.policyGroup, version)
+	if entry == nil {
+		return fmt.Errorf("config version %d not found in the history", version)
+	}
+	rmID := cc.getRMIDInternal()
+	if rmID == "" {
+		return fmt.Errorf("no active partitions, make sure the RM is registered")
+	}
+	// the partitions keep references to parts of the config they were created from: never reuse the stored config
+	conf, err := copySchedulerConfig(entry.Config)
+	if err != nil {
+		return err
+	}
+	var content []byte
+	if content, err = yaml.Marshal(conf); err != nil {
+		return err
+	}
+	if conf, err = configs.LoadSchedulerConfigFromByteArray(content); err != nil {
+		return err
+	}
+	// the content is that of the earlier version: keep its checksum to show that
+	conf.Checksum = entry.Checksum
+	if err = cc.updateSchedulerConfig(conf, rmID); err != nil {
+		return err
+	}
+	log.Log(log.SchedContext).Info("scheduler config rolled back",
+		zap.Uint64("version", version),
+		zap.String("checksum", entry.Checksum))
+	configs.ConfigContext.SetWithSource(cc.policyGroup, conf, fmt.Sprintf("%s %d", configSourceRollback, version))
+	return nil
+}
+
+// getRMID returns the ID of the RM the partitions are registered for, empty if no RM is registered.
+func (cc *ClusterContext) getRMID() string {
+	cc.RLock()
+	defer cc.RUnlock()
+	return cc.getRMIDInternal()
+}
+
+// unlocked call must only be called holding the ClusterContext lock
+func (cc *ClusterContext) getRMIDInternal() string {
+	for _, part := range cc.partitions {
+		return part.RmID
+	}
+	return ""
+}
+
+// copySchedulerConfig returns a deep copy of the config without the checksum
+func copySchedulerConfig(conf *configs.SchedulerConfig) (*configs.SchedulerConfig, error) {
+	if conf == nil {
+		return nil, fmt.Errorf("no scheduler configuration loaded")
+	}
+	content, err := yaml.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	confCopy := &configs.SchedulerConfig{}
+	if err = yaml.Unmarshal(content, confCopy); err != nil {
+		return nil, err
+	}
+	confCopy.Checksum = ""
+	return confCopy, nil
+}
+
+// findQueueConfig returns the config of the queue in the partition, nil if the queue is not part of the config
+func findQueueConfig(conf *configs.SchedulerConfig, partitionName, queuePath string) *configs.QueueConfig {
+	for i := range conf.Partitions {
+		if !strings.EqualFold(conf.Partitions[i].Name, partitionName) {
+			continue
+		}
+		queues := conf.Partitions[i].Queues
+		var queueConf *configs.QueueConfig
+		for _, name := range strings.Split(queuePath, configs.DOT) {
+			queueConf = nil
+			for j := range queues {
+				if strings.EqualFold(queues[j].Name, name) {
+					queueConf = &queues[j]
+					break
+				}
+			}
+			if queueConf == nil {
+				return nil
+			}
+			queues = queueConf.Queues
+		}
+		return queueConf
+	}
 	return nil
 }
 
@@ -659,7 +879,7 @@ func (cc *ClusterContext) updateNode(nodeInfo *si.NodeInfo) {
 	switch nodeInfo.Action {
 	case si.NodeInfo_UPDATE:
 		if sr := nodeInfo.SchedulableResource; sr != nil {
-			partition.updatePartitionResource(node.SetCapacity(resources.NewResourceFromProto(sr)))
+			partition.updateNodeCapacity(node, resources.NewResourceFromProto(sr))
 		}
 	case si.NodeInfo_DRAIN_NODE:
 		if node.IsSchedulable() {
@@ -789,11 +1009,15 @@ func (cc *ClusterContext) processAllocationReleases(releases []*si.AllocationRel
 
 // Create a RM update event to notify RM of new allocations
 // Lock free call, all updates occur via events.
-func (cc *ClusterContext) notifyRMNewAllocation(rmID string, alloc *objects.Allocation) {
+func (cc *ClusterContext) notifyRMNewAllocation(rmID string, allocs ...*objects.Allocation) {
+	siAllocs := make([]*si.Allocation, len(allocs))
+	for i, alloc := range allocs {
+		siAllocs[i] = alloc.NewSIFromAllocation()
+	}
 	c := make(chan *rmevent.Result)
-	// communicate the allocation to the RM synchronously
+	// communicate the allocations to the RM synchronously
 	cc.rmEventHandler.HandleEvent(&rmevent.RMNewAllocationsEvent{
-		Allocations: []*si.Allocation{alloc.NewSIFromAllocation()},
+		Allocations: siAllocs,
 		RmID:        rmID,
 		Channel:     c,
 	})
@@ -802,8 +1026,10 @@ func (cc *ClusterContext) notifyRMNewAllocation(rmID string, alloc *objects.Allo
 	if result.Succeeded {
 		log.Log(log.SchedContext).Debug("Successfully synced shim on new allocation. response: " + result.Reason)
 	} else {
-		log.Log(log.SchedContext).Info("failed to sync shim on new allocation",
-			zap.String("Allocation key: ", alloc.GetAllocationKey()))
+		for _, alloc := range allocs {
+			log.Log(log.SchedContext).Info("failed to sync shim on new allocation",
+				zap.String("Allocation key: ", alloc.GetAllocationKey()))
+		}
 	}
 }
 
@@ -26,10 +26,12 @@ import (
 	dto "github.com/prometheus/client_model/go"
 	"gotest.tools/v3/assert"
 
+	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/metrics"
 	"github.com/apache/yunikorn-core/pkg/rmproxy/rmevent"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
@@ -381,6 +383,27 @@ func TestContext_OnAllocationNotification(t *testing.T) {
 	assert.Assert(t, lastAllocEvent == nil, "unexpected allocation event")
 }
 
+func TestContext_RemovePartitionsByRMID(t *testing.T) {
+	context := createTestContext(t, pName)
+	partition := context.GetPartition(pName)
+	assert.Assert(t, partition != nil, "partition not found")
+	err := context.addNode(getNodeInfoForAddingNode(), true)
+	assert.NilError(t, err, "unexpected error returned from addNode")
+
+	// other RM: nothing is removed
+	c := make(chan *rmevent.Result, 1)
+	context.removePartitionsByRMID(&rmevent.RMPartitionsRemoveEvent{RmID: "other", Channel: c})
+	result := <-c
+	assert.Assert(t, result.Succeeded, "remove should have succeeded")
+	assert.Assert(t, context.GetPartition(pName) != nil, "partition of other RM should not be removed")
+
+	context.removePartitionsByRMID(&rmevent.RMPartitionsRemoveEvent{RmID: partition.RmID, Channel: c})
+	result = <-c
+	assert.Assert(t, result.Succeeded, "remove should have succeeded")
+	assert.Assert(t, context.GetPartition(pName) == nil, "partition should have been removed")
+	assert.Equal(t, partition.GetTotalNodeCount(), 0, "nodes should have been removed")
+}
+
 func getNodeInfoForAddingNode() *si.NodeInfo {
 	n := &si.NodeInfo{
 		NodeID:              "test-1",
@@ -430,3 +453,108 @@ outer:
 
 	assert.Assert(t, checked, "Failed to find metric")
 }
+
+func TestContext_UpdateQueue(t *testing.T) {
+	conf := `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        queues:
+          - name: parent
+            resources:
+              max: {memory: 100}
+            queues:
+              - name: leaf
+                resources:
+                  guaranteed: {memory: 10}
+                  max: {memory: 50}
+`
+	context, err := NewClusterContext("rm-1", "update-queue", []byte(conf))
+	assert.NilError(t, err, "failed to create context")
+	partitionName := common.GetNormalizedPartitionName("default", "rm-1")
+	leaf := context.GetQueue("root.parent.leaf", partitionName)
+	assert.Assert(t, leaf != nil, "leaf queue not found")
+
+	// unknown and unmanaged queues or changes to root are rejected
+	err = context.UpdateQueue(partitionName, "root.unknown", &dao.QueueUpdateDAOInfo{})
+	assert.ErrorContains(t, err, "not found")
+	maxApps := uint64(5)
+	err = context.UpdateQueue(partitionName, "root", &dao.QueueUpdateDAOInfo{MaxApplications: &maxApps})
+	assert.ErrorContains(t, err, "root queue cannot be changed")
+
+	// changes must pass the config validation
+	err = context.UpdateQueue(partitionName, "root.parent.leaf", &dao.QueueUpdateDAOInfo{MaxResource: map[string]string{"memory": "200"}})
+	assert.ErrorContains(t, err, "max resource of parent")
+	err = context.UpdateQueue(partitionName, "root.parent.leaf", &dao.QueueUpdateDAOInfo{Properties: map[string]string{configs.ApplicationSortPolicy: "unknown"}})
+	assert.ErrorContains(t, err, "invalid value for queue property")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 50})), "failed change should not update the queue")
+
+	oldChecksum := configs.ConfigContext.Get("update-queue").Checksum
+	err = context.UpdateQueue(partitionName, "root.parent.leaf", &dao.QueueUpdateDAOInfo{
+		MaxResource:     map[string]string{"memory": "80"},
+		MaxApplications: &maxApps,
+		Properties:      map[string]string{configs.ApplicationSortPolicy: "fair"},
+	})
+	assert.NilError(t, err, "valid update failed")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 80})), "max resource not updated")
+	assert.Assert(t, resources.Equals(leaf.GetGuaranteedResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})), "guaranteed resource should not change")
+	assert.Equal(t, leaf.GetMaxApps(), maxApps, "max apps not updated")
+	assert.Equal(t, leaf.GetPartitionQueueDAOInfo(false).SortingPolicy, "fair", "sort policy not updated")
+
+	// stored config reflects the change
+	stored := configs.ConfigContext.Get("update-queue")
+	assert.Assert(t, stored.Checksum != oldChecksum, "checksum should have changed")
+	queueConf := findQueueConfig(stored, "default", "root.parent.leaf")
+	assert.Assert(t, queueConf != nil, "queue not found in stored config")
+	assert.Equal(t, queueConf.Resources.Max["memory"], "80")
+	assert.Equal(t, queueConf.MaxApplications, maxApps)
+	assert.Equal(t, queueConf.Properties[configs.ApplicationSortPolicy], "fair")
+	// capacity schedules are applied on top of the changed config
+	partition := context.GetPartition(partitionName)
+	partition.RLock()
+	assert.Equal(t, partition.queueConf.Queues[0].Queues[0].Resources.Max["memory"], "80")
+	partition.RUnlock()
+
+	// a new update is validated against the changed config
+	err = context.UpdateQueue(partitionName, "root.parent", &dao.QueueUpdateDAOInfo{MaxResource: map[string]string{"memory": "60"}})
+	assert.ErrorContains(t, err, "max resource of parent")
+}
+
+func TestContext_RollbackConfig(t *testing.T) {
+	confA := `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        queues:
+          - name: a
+`
+	confB := `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        queues:
+          - name: b
+`
+	context, err := NewClusterContext("rm-1", "rollback", []byte(confA))
+	assert.NilError(t, err, "failed to create context")
+	partitionName := common.GetNormalizedPartitionName("default", "rm-1")
+	assert.NilError(t, context.UpdateRMSchedulerConfig("rm-1", []byte(confB), "rm-1"), "config update failed")
+	assert.Assert(t, context.GetQueue("root.a", partitionName).IsDraining(), "queue a should be draining")
+	history := configs.ConfigContext.GetHistory("rollback")
+	assert.Equal(t, len(history), 2, "both configs should be in the history")
+	assert.Equal(t, history[1].Source, "rm-1")
+	assert.Assert(t, strings.Contains(history[1].Diff, "+            - name: b"), "diff should show the new queue: %s", history[1].Diff)
+
+	assert.ErrorContains(t, context.RollbackConfig(10), "not found")
+	assert.NilError(t, context.RollbackConfig(1), "rollback failed")
+	assert.Assert(t, context.GetQueue("root.a", partitionName).IsRunning(), "queue a should be running again")
+	assert.Assert(t, context.GetQueue("root.b", partitionName).IsDraining(), "queue b should be draining")
+	history = configs.ConfigContext.GetHistory("rollback")
+	assert.Equal(t, len(history), 3, "rollback should be recorded")
+	assert.Equal(t, history[2].Source, "rollback to version 1")
+	assert.Equal(t, history[2].Checksum, history[0].Checksum, "rollback should keep the checksum of the version")
+	assert.Equal(t, configs.ConfigContext.Get("rollback").Checksum, history[0].Checksum)
+}
@@ -26,6 +26,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/locking"
@@ -50,6 +51,10 @@ type Allocation struct {
 	tags              map[string]string
 	foreign           bool
 	preemptable       bool
+	ttl               time.Duration             // pending time before the request is removed, 0 uses the queue default
+	affinity          *configs.NodePoolSelector // asks of the application this ask must be co-located with
+	antiAffinity      *configs.NodePoolSelector // asks of the application this ask must not be co-located with
+	topologyKey       string                    // node attribute defining the co-location domain, empty for the node
 
 	// Mutable fields which need protection
 	allocated            bool
@@ -120,6 +125,32 @@ func NewAllocationFromSI(alloc *si.Allocation) *Allocation {
 		}
 	}
 
+	var ttl time.Duration
+	if value, ok := alloc.AllocationTags[common.AskTagTTL]; ok {
+		if ttl, err = time.ParseDuration(value); err != nil || ttl <= 0 {
+			log.Log(log.SchedAllocation).Warn("Ask TTL tag has illegal value, using queue default",
+				zap.String("allocationKey", alloc.AllocationKey),
+				zap.String("value", value))
+			ttl = 0
+		}
+	}
+
+	var affinity, antiAffinity *configs.NodePoolSelector
+	if value, ok := alloc.AllocationTags[common.AskTagAffinity]; ok {
+		if affinity, err = configs.ParseNodePoolSelector(value); err != nil {
+			log.Log(log.SchedAllocation).Warn("Ask affinity tag has illegal value, ignoring affinity",
+				zap.String("allocationKey", alloc.AllocationKey),
+				zap.Error(err))
+		}
+	}
+	if value, ok := alloc.AllocationTags[common.AskTagAntiAffinity]; ok {
+		if antiAffinity, err = configs.ParseNodePoolSelector(value); err != nil {
+			log.Log(log.SchedAllocation).Warn("Ask anti-affinity tag has illegal value, ignoring anti-affinity",
+				zap.String("allocationKey", alloc.AllocationKey),
+				zap.Error(err))
+		}
+	}
+
 	var allocated bool
 	var nodeID string
 	var bindTime time.Time
@@ -149,6 +180,10 @@ func NewAllocationFromSI(alloc *si.Allocation) *Allocation {
 		bindTime:          bindTime,
 		foreign:           foreign,
 		preemptable:       preemptable,
+		ttl:               ttl,
+		affinity:          affinity,
+		antiAffinity:      antiAffinity,
+		topologyKey:       alloc.AllocationTags[common.AskTagTopologyKey],
 	}
 }
 
@@ -201,6 +236,31 @@ func (a *Allocation) GetCreateTime() time.Time {
 	return a.createTime
 }
 
+// GetTTL returns the pending time before this request is removed, 0 means the queue default is used.
+func (a *Allocation) GetTTL() time.Duration {
+	return a.ttl
+}
+
+// GetAffinity returns the selectors for the asks this ask must and must not be co-located with. A nil selector
+// means no rule is set.
+func (a *Allocation) GetAffinity() (*configs.NodePoolSelector, *configs.NodePoolSelector) {
+	return a.affinity, a.antiAffinity
+}
+
+// GetTopologyKey returns the node attribute that defines the co-location domain, empty means the node.
+func (a *Allocation) GetTopologyKey() string {
+	return a.topologyKey
+}
+
+// getSelectorAttribute returns the value of the tag, or the task group name, used to select this ask in the
+// affinity rules of other asks.
+func (a *Allocation) getSelectorAttribute(key string) string {
+	if key == common.AskSelectorTaskGroup {
+		return a.taskGroupName
+	}
+	return a.tags[key]
+}
+
 // GetBindTime returns the time this allocation was bound.
 func (a *Allocation) GetBindTime() time.Time {
 	a.RLock()
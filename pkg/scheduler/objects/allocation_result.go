@@ -41,6 +41,7 @@ type AllocationResult struct {
 	NodeID                string
 	ReservedNodeID        string
 	CancelledReservations int
+	Members               []*AllocationResult // the other members of a gang, allocated together with the request
 }
 
 func (ar *AllocationResult) String() string {
@@ -28,6 +28,7 @@ import (
 
 	"gotest.tools/v3/assert"
 
+	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/events/mock"
 	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
@@ -466,6 +467,62 @@ func TestNewAllocFromSI(t *testing.T) {
 	assert.Assert(t, !alloc.IsAllowPreemptOther(), "alloc should not have allow-preempt-other set")
 }
 
+func TestNewAllocTTLFromSI(t *testing.T) {
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1})
+	siAlloc := &si.Allocation{
+		AllocationKey:    "ask-1",
+		ApplicationID:    "app-1",
+		ResourcePerAlloc: res.ToProto(),
+	}
+	alloc := NewAllocationFromSI(siAlloc)
+	assert.Equal(t, alloc.GetTTL(), time.Duration(0), "no tag should use the queue default")
+
+	siAlloc.AllocationTags = map[string]string{common.AskTagTTL: "10m"}
+	alloc = NewAllocationFromSI(siAlloc)
+	assert.Equal(t, alloc.GetTTL(), 10*time.Minute)
+
+	// illegal values use the queue default
+	for _, value := range []string{"xyz", "-1m", "0s"} {
+		siAlloc.AllocationTags[common.AskTagTTL] = value
+		alloc = NewAllocationFromSI(siAlloc)
+		assert.Equal(t, alloc.GetTTL(), time.Duration(0), "illegal value %s should not be used", value)
+	}
+}
+
+func TestNewAllocAffinityFromSI(t *testing.T) {
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1})
+	siAlloc := &si.Allocation{
+		AllocationKey:    "ask-1",
+		ApplicationID:    "app-1",
+		ResourcePerAlloc: res.ToProto(),
+		TaskGroupName:    "tg-1",
+		AllocationTags:   map[string]string{"role": "kafka"},
+	}
+	alloc := NewAllocationFromSI(siAlloc)
+	affinity, antiAffinity := alloc.GetAffinity()
+	assert.Assert(t, affinity == nil && antiAffinity == nil, "no rules expected without tags")
+	assert.Equal(t, alloc.GetTopologyKey(), "")
+	assert.Equal(t, alloc.getSelectorAttribute("role"), "kafka")
+	assert.Equal(t, alloc.getSelectorAttribute(common.AskSelectorTaskGroup), "tg-1")
+	assert.Equal(t, alloc.getSelectorAttribute("unknown"), "")
+
+	siAlloc.AllocationTags[common.AskTagAffinity] = "taskgroup=tg-1"
+	siAlloc.AllocationTags[common.AskTagAntiAffinity] = "role=kafka"
+	siAlloc.AllocationTags[common.AskTagTopologyKey] = "zone"
+	alloc = NewAllocationFromSI(siAlloc)
+	affinity, antiAffinity = alloc.GetAffinity()
+	assert.Equal(t, affinity.String(), "taskgroup=tg-1")
+	assert.Equal(t, antiAffinity.String(), "role=kafka")
+	assert.Equal(t, alloc.GetTopologyKey(), "zone")
+
+	// illegal rules are ignored
+	siAlloc.AllocationTags[common.AskTagAffinity] = "role in kafka"
+	siAlloc.AllocationTags[common.AskTagAntiAffinity] = ""
+	alloc = NewAllocationFromSI(siAlloc)
+	affinity, antiAffinity = alloc.GetAffinity()
+	assert.Assert(t, affinity == nil && antiAffinity == nil, "illegal rules should be ignored")
+}
+
 func TestNewForeignAllocFromSI(t *testing.T) {
 	res := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"first": 1,
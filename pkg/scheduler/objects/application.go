@@ -20,8 +20,10 @@ package objects
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -40,6 +42,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-core/pkg/metrics"
 	"github.com/apache/yunikorn-core/pkg/rmproxy/rmevent"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
 	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
 	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
@@ -51,6 +54,9 @@ var (
 	completingTimeout         = 30 * time.Second
 	terminatedTimeout         = 3 * 24 * time.Hour
 	defaultPlaceholderTimeout = 15 * time.Minute
+	maxDeadlineRiskWindow     = 5 * time.Minute
+	minDeadlineWait           = time.Minute
+	maxRuntimeWarningWindow   = 5 * time.Minute
 )
 var initAppLogOnce sync.Once
 var rateLimitedAppLog *log.RateLimitedLogger
@@ -61,6 +67,23 @@ const (
 
 	NotEnoughUserQuota  = "Not enough user quota"
 	NotEnoughQueueQuota = "Not enough queue quota"
+	GangIncomplete      = "Not enough nodes for all gang members"
+	AffinityNotMet      = "Ask affinity not met"
+	AntiAffinityNotMet  = "Ask anti-affinity not met"
+
+	// MaxRuntimeExceeded is the reason for the release of all allocations of an application that ran too long
+	MaxRuntimeExceeded = "MaxRuntimeExceeded"
+	// DependencyFailed is the reason for the failure of an application that depends on an application that did not complete
+	DependencyFailed = "DependencyFailed"
+	// ApplicationSuspended is the reason for the release of all allocations of an application that was suspended
+	ApplicationSuspended = "ApplicationSuspended"
+	// AskExpired is the reason for the removal of a request that was pending longer than its time-to-live
+	AskExpired = "AskExpired"
+)
+
+var (
+	errAffinityNotMet     = errors.New(AffinityNotMet)
+	errAntiAffinityNotMet = errors.New(AntiAffinityNotMet)
 )
 
 type PlaceholderData struct {
@@ -114,10 +137,24 @@ type Application struct {
 	hasPlaceholderAlloc  bool                        // Whether there is at least one allocated placeholder
 	runnableInQueue      bool                        // whether the application is runnable/schedulable in the queue. Default is true.
 	runnableByUserLimit  bool                        // whether the application is runnable/schedulable based on user/group quota. Default is true.
+	deadline             time.Time                   // time by which all requests should be allocated. Default is zero: no deadline.
+	deadlineTimer        *time.Timer                 // timer for the deadline risk window and the deadline itself
+	deadlineAtRisk       bool                        // whether the deadline is close: the priority is raised until the deadline passes
+	deadlineStarted      bool                        // whether the deadline timer was started, it is started once on the first accept
+	pendingSince         time.Time                   // start of the wait for an allocation, used for priority aging. Zero if nothing is pending.
+	priorityAging        int32                       // priority increase due to aging, taken before the applications are sorted
+	maxRuntime           time.Duration               // max wall-clock runtime, set when the application first enters the running state. Zero if not limited.
+	runtimeStart         time.Time                   // the time the application first entered the running state, not reset when it moves back from completing
+	runtimeTimer         *time.Timer                 // timer for the max runtime warning and the max runtime itself
+	dependencies         []string                    // applications that must complete before the application is runnable, resolved dependencies are removed
+	gangMinMembers       int                         // number of requests that must be allocated together in one scheduling pass. Zero if not gang scheduled.
+	gangCommitted        bool                        // whether the minimum member set of the gang has been allocated
 
 	rmEventHandler        handler.EventHandler
 	rmID                  string
 	terminatedCallback    func(appID string)
+	reservationsCallback  func(num int)
+	nodeLookup            func(nodeID string) *Node // node lookup for the affinity rules of the asks
 	appEvents             *schedEvt.ApplicationEvents
 	sendStateChangeEvents bool // whether to send state-change events or not (simplifies testing)
 
@@ -167,9 +204,184 @@ func NewApplication(siApp *si.AddApplicationRequest, ugi security.UserGroup, eve
 	app.rmID = rmID
 	app.appEvents = schedEvt.NewApplicationEvents(events.GetEventSystem())
 	app.appEvents.SendNewApplicationEvent(app.ApplicationID)
+	app.deadline = getDeadline(app.ApplicationID, app.tags, app.SubmissionTime)
+	app.gangMinMembers = getGangMinMembers(app.ApplicationID, app.tags, app.placeholderAsk)
+	app.dependencies = getDependencies(app.ApplicationID, app.tags)
+	if len(app.dependencies) != 0 {
+		log.Log(log.SchedApplication).Info("Application is not runnable until its dependencies complete",
+			zap.String("appID", app.ApplicationID),
+			zap.Strings("dependencies", app.dependencies))
+		app.appEvents.SendAppWaitingOnDependenciesEvent(app.ApplicationID, app.dependencies)
+	}
 	return app
 }
 
+// getGangMinMembers returns the size of the minimum member set of the gang set in the tags, zero if not set.
+// Placeholder based gang scheduling and the minimum member set cannot be combined: the tag is ignored if the
+// application has a placeholder request. Invalid values are logged and ignored.
+func getGangMinMembers(appID string, tags map[string]string, placeholderAsk *resources.Resource) int {
+	value := tags[common.AppTagGangMinMembers]
+	if value == "" {
+		return 0
+	}
+	minMembers, err := strconv.Atoi(value)
+	if err != nil || minMembers <= 0 {
+		log.Log(log.SchedApplication).Warn("invalid gang minimum member set, ignored",
+			zap.String("appID", appID),
+			zap.String("minMembers", value),
+			zap.Error(err))
+		return 0
+	}
+	if !resources.IsZero(placeholderAsk) {
+		log.Log(log.SchedApplication).Warn("gang minimum member set ignored for application with task groups",
+			zap.String("appID", appID),
+			zap.String("minMembers", value))
+		return 0
+	}
+	return minMembers
+}
+
+// getDependencies returns the unique application IDs set in the dependencies tag.
+// An application that depends on itself would never run: the application's own ID is ignored.
+func getDependencies(appID string, tags map[string]string) []string {
+	var dependencies []string
+	for _, dependency := range strings.Split(tags[common.AppTagDependencies], common.Separator) {
+		dependency = strings.TrimSpace(dependency)
+		if dependency == "" || dependency == appID || slices.Contains(dependencies, dependency) {
+			continue
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies
+}
+
+// getDeadline returns the deadline set in the tags: an absolute deadline or the max wait after submission.
+// If both are set the earliest of the two is used. Invalid values are logged and ignored, this includes deadlines
+// less than minDeadlineWait after submission.
+func getDeadline(appID string, tags map[string]string, submissionTime time.Time) time.Time {
+	var deadline time.Time
+	if value := tags[common.AppTagDeadline]; value != "" {
+		var err error
+		if deadline, err = time.Parse(time.RFC3339, value); err != nil || deadline.Before(submissionTime.Add(minDeadlineWait)) {
+			log.Log(log.SchedApplication).Warn("invalid application deadline, ignored",
+				zap.String("appID", appID),
+				zap.String("deadline", value),
+				zap.Duration("minWait", minDeadlineWait),
+				zap.Error(err))
+			deadline = time.Time{}
+		}
+	}
+	if value := tags[common.AppTagMaxWait]; value != "" {
+		maxWait, err := time.ParseDuration(value)
+		if err != nil || maxWait < minDeadlineWait {
+			log.Log(log.SchedApplication).Warn("invalid application max wait, ignored",
+				zap.String("appID", appID),
+				zap.String("maxWait", value),
+				zap.Duration("minWait", minDeadlineWait),
+				zap.Error(err))
+		} else if waitDeadline := submissionTime.Add(maxWait); deadline.IsZero() || waitDeadline.Before(deadline) {
+			deadline = waitDeadline
+		}
+	}
+	return deadline
+}
+
+// getDeadlineRiskWindow returns the time before the deadline at which the deadline is considered at risk:
+// ten percent of the time between submission and the deadline, limited to maxDeadlineRiskWindow.
+func getDeadlineRiskWindow(submissionTime, deadline time.Time) time.Duration {
+	return min(deadline.Sub(submissionTime)/10, maxDeadlineRiskWindow)
+}
+
+// initDeadlineTimer starts the timer that raises the priority of the application when the deadline comes close.
+// The timer is started when the application is accepted for the first time: a rejected application never tracks
+// its deadline.
+// Lock free call, must be called holding the application lock.
+func (sa *Application) initDeadlineTimer() {
+	if sa.deadline.IsZero() || sa.deadlineStarted {
+		return
+	}
+	sa.deadlineStarted = true
+	riskAfter := time.Until(sa.deadline.Add(-getDeadlineRiskWindow(sa.SubmissionTime, sa.deadline)))
+	if riskAfter > 0 {
+		sa.deadlineTimer = time.AfterFunc(riskAfter, sa.deadlineRiskReached)
+		return
+	}
+	sa.deadlineAtRisk = true
+	sa.deadlineTimer = time.AfterFunc(time.Until(sa.deadline), sa.deadlineReached)
+}
+
+// deadlineRiskReached raises the priority of the application and starts the timer for the deadline itself.
+func (sa *Application) deadlineRiskReached() {
+	sa.Lock()
+	defer sa.Unlock()
+	// timer was cleared while we were waiting for the lock
+	if sa.deadlineTimer == nil {
+		return
+	}
+	log.Log(log.SchedApplication).Info("application deadline at risk, raising priority",
+		zap.String("appID", sa.ApplicationID),
+		zap.Time("deadline", sa.deadline))
+	sa.deadlineAtRisk = true
+	if sa.queue != nil && sa.askMaxPriority != configs.MinPriority {
+		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getSchedulingPriority())
+	}
+	sa.deadlineTimer = time.AfterFunc(time.Until(sa.deadline), sa.deadlineReached)
+}
+
+// deadlineReached records whether the application met its deadline: a deadline is missed if requests are still
+// pending when it passes. The priority boost ends with the deadline.
+func (sa *Application) deadlineReached() {
+	sa.Lock()
+	defer sa.Unlock()
+	if sa.deadlineTimer == nil {
+		return
+	}
+	sa.deadlineTimer = nil
+	sa.deadlineAtRisk = false
+	if sa.queue != nil && sa.askMaxPriority != configs.MinPriority {
+		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getSchedulingPriority())
+	}
+	sa.recordDeadline(resources.IsZero(sa.pending))
+}
+
+// finishDeadline stops tracking the deadline of an application that terminates before the deadline passed and
+// records whether the deadline was met. Nothing is recorded if the deadline is not tracked or already passed.
+// Lock free call, must be called holding the application lock.
+func (sa *Application) finishDeadline(met bool) {
+	if sa.deadlineTimer == nil {
+		return
+	}
+	sa.clearDeadlineTimer()
+	sa.recordDeadline(met)
+}
+
+// recordDeadline updates the deadline metrics and sends the event for a missed deadline.
+// Lock free call, must be called holding the application lock.
+func (sa *Application) recordDeadline(met bool) {
+	if met {
+		metrics.GetSchedulerMetrics().IncApplicationDeadlineMet()
+		return
+	}
+	log.Log(log.SchedApplication).Warn("application missed its deadline",
+		zap.String("appID", sa.ApplicationID),
+		zap.Time("deadline", sa.deadline),
+		zap.Stringer("pending", sa.pending))
+	metrics.GetSchedulerMetrics().IncApplicationDeadlineMissed()
+	sa.appEvents.SendDeadlineMissedEvent(sa.ApplicationID, sa.deadline, sa.pending)
+}
+
+func (sa *Application) clearDeadlineTimer() {
+	if sa == nil || sa.deadlineTimer == nil {
+		return
+	}
+	sa.deadlineTimer.Stop()
+	sa.deadlineTimer = nil
+	sa.deadlineAtRisk = false
+	log.Log(log.SchedApplication).Debug("Application deadline timer cleared",
+		zap.String("AppID", sa.ApplicationID),
+		zap.Time("deadline", sa.deadline))
+}
+
 func (sa *Application) String() string {
 	if sa == nil {
 		return "application is nil"
@@ -250,6 +462,10 @@ func (sa *Application) IsResuming() bool {
 	return sa.stateMachine.Is(Resuming.String())
 }
 
+func (sa *Application) IsSuspended() bool {
+	return sa.stateMachine.Is(Suspended.String())
+}
+
 // HandleApplicationEvent handles the state event for the application.
 // The application lock is expected to be held.
 func (sa *Application) HandleApplicationEvent(event applicationEvent) error {
@@ -277,6 +493,7 @@ func (sa *Application) HandleApplicationEventWithInfo(event applicationEvent, ev
 // The only state that does not generate an event is Rejected.
 func (sa *Application) OnStateChange(event *fsm.Event, eventInfo string) {
 	sa.recordState(event.Dst)
+	sa.trackRuntime(event.Dst)
 	if event.Dst == Rejected.String() || sa.rmEventHandler == nil {
 		return
 	}
@@ -300,6 +517,302 @@ func (sa *Application) OnStateChange(event *fsm.Event, eventInfo string) {
 		})
 }
 
+// trackRuntime starts tracking the runtime when the application enters the running state for the first time and
+// stops tracking when the application terminates. Suspending the application does not stop the runtime.
+// Lock free call, must be called holding the application lock.
+func (sa *Application) trackRuntime(state string) {
+	switch state {
+	case Running.String():
+		if sa.runtimeStart.IsZero() {
+			sa.runtimeStart = time.Now()
+			sa.maxRuntime = sa.getMaxRuntime()
+			sa.initRuntimeTimer()
+		}
+	case Accepted.String(), Completing.String(), Suspended.String():
+		// the application can still move back to running
+	default:
+		sa.clearRuntimeTimer()
+	}
+}
+
+// getMaxRuntime returns the lowest max runtime set on the application tags and the queue, zero if not limited.
+func (sa *Application) getMaxRuntime() time.Duration {
+	maxRuntime := sa.queue.GetMaxRuntime()
+	if value := sa.tags[common.AppTagMaxRuntime]; value != "" {
+		tagRuntime, err := configs.ParseMaxRuntime(value)
+		if err != nil {
+			log.Log(log.SchedApplication).Warn("invalid application max runtime, ignored",
+				zap.String("applicationID", sa.ApplicationID),
+				zap.String("maxRuntime", value),
+				zap.Error(err))
+		} else if maxRuntime == 0 || tagRuntime < maxRuntime {
+			maxRuntime = tagRuntime
+		}
+	}
+	return maxRuntime
+}
+
+// getRuntimeWarningWindow returns the time before the max runtime is reached at which the warning is sent:
+// ten percent of the max runtime, limited to maxRuntimeWarningWindow.
+func getRuntimeWarningWindow(maxRuntime time.Duration) time.Duration {
+	return min(maxRuntime/10, maxRuntimeWarningWindow)
+}
+
+// initRuntimeTimer starts the timer that warns about, and then enforces, the max runtime of the application.
+// Lock free call, must be called holding the application lock.
+func (sa *Application) initRuntimeTimer() {
+	if sa.maxRuntime == 0 {
+		return
+	}
+	terminateAt := sa.runtimeStart.Add(sa.maxRuntime)
+	if warnAfter := time.Until(terminateAt.Add(-getRuntimeWarningWindow(sa.maxRuntime))); warnAfter > 0 {
+		sa.runtimeTimer = time.AfterFunc(warnAfter, sa.runtimeWarningReached)
+		return
+	}
+	sa.runtimeTimer = time.AfterFunc(time.Until(terminateAt), sa.maxRuntimeReached)
+}
+
+// runtimeWarningReached sends the warning that the max runtime comes close and starts the timer for the max runtime.
+func (sa *Application) runtimeWarningReached() {
+	sa.Lock()
+	defer sa.Unlock()
+	// timer was cleared while waiting for the lock
+	if sa.runtimeTimer == nil {
+		return
+	}
+	terminateAt := sa.runtimeStart.Add(sa.maxRuntime)
+	log.Log(log.SchedApplication).Info("application close to its max runtime",
+		zap.String("applicationID", sa.ApplicationID),
+		zap.Stringer("maxRuntime", sa.maxRuntime),
+		zap.Time("terminateAt", terminateAt))
+	sa.appEvents.SendMaxRuntimeWarningEvent(sa.ApplicationID, sa.maxRuntime, terminateAt)
+	sa.runtimeTimer = time.AfterFunc(time.Until(terminateAt), sa.maxRuntimeReached)
+}
+
+// maxRuntimeReached releases all allocations and pending requests of the application and fails the application.
+func (sa *Application) maxRuntimeReached() {
+	sa.Lock()
+	defer sa.Unlock()
+	// timer was cleared while waiting for the lock
+	if sa.runtimeTimer == nil {
+		return
+	}
+	sa.runtimeTimer = nil
+	var toRelease, pendingRelease []*Allocation
+	for _, alloc := range sa.allocations {
+		// skip over the allocations that are already marked for release
+		if alloc.IsReleased() {
+			continue
+		}
+		alloc.SetReleased(true)
+		toRelease = append(toRelease, alloc)
+	}
+	for _, alloc := range sa.requests {
+		if !alloc.IsAllocated() {
+			alloc.SetReleased(true)
+			pendingRelease = append(pendingRelease, alloc)
+		}
+	}
+	log.Log(log.SchedApplication).Warn("application exceeded its max runtime, releasing all allocations",
+		zap.String("applicationID", sa.ApplicationID),
+		zap.Stringer("maxRuntime", sa.maxRuntime),
+		zap.Int("releasing allocations", len(toRelease)),
+		zap.Int("pending requests", len(pendingRelease)))
+	sa.appEvents.SendMaxRuntimeExceededEvent(sa.ApplicationID, sa.maxRuntime, sa.allocatedResource)
+	sa.executeReservationsCallback(sa.removeAsksInternal("", si.EventRecord_REQUEST_TIMEOUT))
+	// trigger the release of the allocations: accounting updates when the release is done
+	sa.notifyRMAllocationReleased(toRelease, si.TerminationType_TIMEOUT, MaxRuntimeExceeded)
+	// trigger the release of the pending requests: accounting has been done
+	sa.notifyRMAllocationReleased(pendingRelease, si.TerminationType_TIMEOUT, MaxRuntimeExceeded)
+	if sa.IsRunning() || sa.IsSuspended() {
+		if err := sa.HandleApplicationEventWithInfo(FailApplication, MaxRuntimeExceeded); err != nil {
+			log.Log(log.SchedApplication).Debug("Application state change failed when max runtime was exceeded",
+				zap.String("AppID", sa.ApplicationID),
+				zap.String("currentState", sa.CurrentState()),
+				zap.Error(err))
+		}
+	}
+}
+
+func (sa *Application) clearRuntimeTimer() {
+	if sa == nil || sa.runtimeTimer == nil {
+		return
+	}
+	sa.runtimeTimer.Stop()
+	sa.runtimeTimer = nil
+	log.Log(log.SchedApplication).Debug("Application runtime timer cleared",
+		zap.String("AppID", sa.ApplicationID),
+		zap.Stringer("maxRuntime", sa.maxRuntime))
+}
+
+// GetDependencies returns the applications that must still complete before the application is runnable.
+func (sa *Application) GetDependencies() []string {
+	sa.RLock()
+	defer sa.RUnlock()
+	return slices.Clone(sa.dependencies)
+}
+
+// isWaitingOnDependencies returns true if not all applications the application depends on have completed.
+func (sa *Application) isWaitingOnDependencies() bool {
+	sa.RLock()
+	defer sa.RUnlock()
+	return len(sa.dependencies) != 0
+}
+
+// ResolveDependency removes the completed application from the dependencies. The application becomes runnable
+// when the last dependency is resolved.
+func (sa *Application) ResolveDependency(dependency string) {
+	sa.Lock()
+	defer sa.Unlock()
+	index := slices.Index(sa.dependencies, dependency)
+	if index == -1 {
+		return
+	}
+	sa.dependencies = slices.Delete(sa.dependencies, index, index+1)
+	if len(sa.dependencies) == 0 {
+		log.Log(log.SchedApplication).Info("Application is now runnable: all dependencies completed",
+			zap.String("appID", sa.ApplicationID),
+			zap.String("dependency", dependency),
+			zap.String("queue", sa.queuePath))
+		sa.appEvents.SendAppDependenciesResolvedEvent(sa.ApplicationID)
+	}
+}
+
+// FailDependency fails the application as the application it depends on failed or was rejected.
+// The application has not been runnable and has no allocations: all pending requests are released and the
+// application moves straight to the failed state.
+func (sa *Application) FailDependency(dependency string) {
+	sa.Lock()
+	defer sa.Unlock()
+	if !slices.Contains(sa.dependencies, dependency) {
+		return
+	}
+	log.Log(log.SchedApplication).Warn("application dependency did not complete, failing application",
+		zap.String("appID", sa.ApplicationID),
+		zap.String("dependency", dependency))
+	if err := sa.HandleApplicationEventWithInfo(FailApplication, DependencyFailed); err != nil {
+		log.Log(log.SchedApplication).Debug("Application state change failed when dependency failed",
+			zap.String("appID", sa.ApplicationID),
+			zap.String("currentState", sa.CurrentState()),
+			zap.Error(err))
+		return
+	}
+	sa.dependencies = nil
+	sa.appEvents.SendAppDependencyFailedEvent(sa.ApplicationID, dependency)
+	var pendingRelease []*Allocation
+	for _, alloc := range sa.requests {
+		if !alloc.IsAllocated() {
+			alloc.SetReleased(true)
+			pendingRelease = append(pendingRelease, alloc)
+		}
+	}
+	// removing the asks while failing does not complete the application
+	sa.removeAsksInternal("", si.EventRecord_REQUEST_CANCEL)
+	// trigger the release of the pending requests: accounting has been done
+	sa.notifyRMAllocationReleased(pendingRelease, si.TerminationType_UNKNOWN_TERMINATION_TYPE, DependencyFailed)
+	sa.clearPlaceholderTimer()
+	// there are no allocations to wait for: move straight on to failed
+	if err := sa.HandleApplicationEventWithInfo(FailApplication, DependencyFailed); err != nil {
+		log.Log(log.SchedApplication).Warn("Application state not changed to Failed when dependency failed",
+			zap.String("appID", sa.ApplicationID),
+			zap.String("currentState", sa.CurrentState()),
+			zap.Error(err))
+	}
+}
+
+// Suspend releases all allocations of the application and stops scheduling the pending requests until the
+// application is resumed. The pending requests and the submission time are kept. The allocations are released
+// as preempted by the scheduler with the ApplicationSuspended message. Returns the number of reservations
+// that were removed.
+func (sa *Application) Suspend() (int, error) {
+	sa.Lock()
+	defer sa.Unlock()
+	if !sa.IsAccepted() && !sa.IsRunning() {
+		return 0, fmt.Errorf("application %s cannot be suspended in state %s", sa.ApplicationID, sa.CurrentState())
+	}
+	if err := sa.HandleApplicationEventWithInfo(SuspendApplication, ApplicationSuspended); err != nil {
+		return 0, err
+	}
+	// the suspended requests must not block nodes
+	var reservations int
+	for _, reserve := range sa.reservations {
+		reservations += sa.unReserveInternal(reserve)
+	}
+	sa.queue.UnReserve(sa.ApplicationID, reservations)
+	var toRelease []*Allocation
+	released := resources.NewResource()
+	for _, alloc := range sa.allocations {
+		// skip over the allocations that are already marked for release
+		if alloc.IsReleased() {
+			continue
+		}
+		alloc.SetReleased(true)
+		toRelease = append(toRelease, alloc)
+		released.AddTo(alloc.GetAllocatedResource())
+	}
+	// a gang must be placed as a whole again after the application is resumed
+	sa.gangCommitted = false
+	log.Log(log.SchedApplication).Info("application suspended, releasing all allocations",
+		zap.String("applicationID", sa.ApplicationID),
+		zap.Int("releasing allocations", len(toRelease)),
+		zap.Int("reservations removed", reservations))
+	sa.appEvents.SendAppSuspendedEvent(sa.ApplicationID, released)
+	// trigger the release of the allocations: accounting updates when the release is done
+	sa.notifyRMAllocationReleased(toRelease, si.TerminationType_PREEMPTED_BY_SCHEDULER, ApplicationSuspended)
+	return reservations, nil
+}
+
+// Resume makes a suspended application schedulable again. The application is accepted and is sorted using its
+// original submission time.
+func (sa *Application) Resume() error {
+	sa.Lock()
+	defer sa.Unlock()
+	if !sa.IsSuspended() {
+		return fmt.Errorf("application %s is not suspended, current state %s", sa.ApplicationID, sa.CurrentState())
+	}
+	if err := sa.HandleApplicationEvent(UnsuspendApplication); err != nil {
+		return err
+	}
+	log.Log(log.SchedApplication).Info("application resumed",
+		zap.String("applicationID", sa.ApplicationID))
+	sa.appEvents.SendAppUnsuspendedEvent(sa.ApplicationID)
+	return nil
+}
+
+// ExpireAsks removes the requests that are pending longer than their time-to-live. The ask tag overrides the
+// default of the queue. Placeholders are not expired: they have their own timeout. The RM is notified of the
+// removal through a rejected allocation. Returns the number of expired requests and removed reservations.
+func (sa *Application) ExpireAsks(now time.Time) (int, int) {
+	sa.Lock()
+	defer sa.Unlock()
+	queueTTL := sa.queue.getAskTTL()
+	var expired []*Allocation
+	for _, ask := range sa.requests {
+		if ask.IsAllocated() || ask.IsPlaceholder() {
+			continue
+		}
+		ttl := ask.GetTTL()
+		if ttl == 0 {
+			ttl = queueTTL
+		}
+		if ttl > 0 && now.Sub(ask.GetCreateTime()) >= ttl {
+			expired = append(expired, ask)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, 0
+	}
+	var reservations int
+	for _, ask := range expired {
+		reservations += sa.removeAsksInternal(ask.GetAllocationKey(), si.EventRecord_REQUEST_TIMEOUT)
+	}
+	log.Log(log.SchedApplication).Info("pending requests expired",
+		zap.String("applicationID", sa.ApplicationID),
+		zap.Int("expired requests", len(expired)))
+	sa.notifyRMAllocationRejected(expired, AskExpired)
+	return len(expired), reservations
+}
+
 // Set the state timer to make sure the application will not get stuck in a time-sensitive state too long.
 // This prevents an app from not progressing to the next state if a timeout is required.
 // Used for placeholder timeout and completion handling.
@@ -533,13 +1046,14 @@ func (sa *Application) removeAsksInternal(allocKey string, detail si.EventRecord
 		// Cleanup total pending resource
 		deltaPendingResource = sa.pending
 		sa.pending = resources.NewResource()
+		sa.updatePendingSince()
 		for _, ask := range sa.requests {
 			sa.appEvents.SendRemoveAskEvent(sa.ApplicationID, ask.allocationKey, ask.GetAllocatedResource(), detail)
 		}
 		sa.requests = make(map[string]*Allocation)
 		sa.sortedRequests = sortedRequests{}
 		sa.askMaxPriority = configs.MinPriority
-		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.askMaxPriority)
+		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getSchedulingPriority())
 	} else {
 		// cleanup the reservation for this allocation
 		if reserve, ok := sa.reservations[allocKey]; ok {
@@ -553,6 +1067,7 @@ func (sa *Application) removeAsksInternal(allocKey string, detail si.EventRecord
 				deltaPendingResource = ask.GetAllocatedResource()
 				sa.pending = resources.Sub(sa.pending, deltaPendingResource)
 				sa.pending.Prune()
+				sa.updatePendingSince()
 			}
 			delete(sa.requests, allocKey)
 			sa.sortedRequests.remove(ask)
@@ -570,7 +1085,7 @@ func (sa *Application) removeAsksInternal(allocKey string, detail si.EventRecord
 	// Change the state to completing.
 	// When the resource trackers are zero we should not expect anything to come in later.
 	hasPlaceHolderAllocations := len(sa.getPlaceholderAllocations()) > 0
-	if resources.IsZero(sa.pending) && resources.IsZero(sa.allocatedResource) && !sa.IsFailing() && !sa.IsCompleting() && !hasPlaceHolderAllocations {
+	if resources.IsZero(sa.pending) && resources.IsZero(sa.allocatedResource) && !sa.IsFailing() && !sa.IsCompleting() && !sa.IsSuspended() && !hasPlaceHolderAllocations {
 		if err := sa.HandleApplicationEvent(CompleteApplication); err != nil {
 			log.Log(log.SchedApplication).Warn("Application state not changed to Completing while updating ask(s)",
 				zap.String("currentState", sa.CurrentState()),
@@ -621,6 +1136,7 @@ func (sa *Application) AddAllocationAsk(ask *Allocation) error {
 	delta.SubFrom(oldAskResource)
 	sa.pending = resources.Add(sa.pending, delta)
 	sa.pending.Prune()
+	sa.updatePendingSince()
 	sa.queue.incPendingResource(delta)
 
 	log.Log(log.SchedApplication).Info("ask added successfully to application",
@@ -680,6 +1196,7 @@ func (sa *Application) UpdateAllocationResources(alloc *Allocation) error {
 		// update pending resources
 		sa.pending = resources.Add(sa.pending, delta)
 		sa.pending.Prune()
+		sa.updatePendingSince()
 		sa.queue.incPendingResource(delta)
 		log.Log(log.SchedApplication).Info("updated pending resources for application",
 			zap.String("appID", sa.ApplicationID),
@@ -724,7 +1241,7 @@ func (sa *Application) addAllocationAskInternal(ask *Allocation) {
 	priority := ask.GetPriority()
 	if !allocated && priority > sa.askMaxPriority {
 		sa.askMaxPriority = priority
-		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.askMaxPriority)
+		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getSchedulingPriority())
 	}
 
 	if ask.IsPlaceholder() {
@@ -754,6 +1271,9 @@ func (sa *Application) allocateAsk(ask *Allocation) (*resources.Resource, error)
 	if !ask.allocate() {
 		return nil, fmt.Errorf("unable to allocate previously allocated ask %s on app %s", ask.GetAllocationKey(), sa.ApplicationID)
 	}
+	// an allocation ends the wait: aging starts again for what is still pending
+	sa.pendingSince = time.Time{}
+	sa.priorityAging = 0
 
 	if ask.GetPriority() >= sa.askMaxPriority {
 		// recalculate downward
@@ -763,6 +1283,7 @@ func (sa *Application) allocateAsk(ask *Allocation) (*resources.Resource, error)
 	delta := ask.GetAllocatedResource()
 	sa.pending = resources.Sub(sa.pending, delta)
 	sa.pending.Prune()
+	sa.updatePendingSince()
 	// update the pending of the queue with the same delta
 	sa.queue.decPendingResource(delta)
 
@@ -778,11 +1299,12 @@ func (sa *Application) deallocateAsk(ask *Allocation) (*resources.Resource, erro
 	if askPriority > sa.askMaxPriority {
 		// increase app priority
 		sa.askMaxPriority = askPriority
-		sa.queue.UpdateApplicationPriority(sa.ApplicationID, askPriority)
+		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getSchedulingPriority())
 	}
 
 	delta := ask.GetAllocatedResource()
 	sa.pending = resources.Add(sa.pending, delta)
+	sa.updatePendingSince()
 	// update the pending of the queue with the same delta
 	sa.queue.incPendingResource(delta)
 
@@ -983,6 +1505,10 @@ func (sa *Application) tryAllocate(headRoom *resources.Resource, allowPreemption
 	}
 	// calculate the users' headroom, includes group check which requires the applicationID
 	userHeadroom := ugm.GetUserManager().Headroom(sa.queuePath, sa.ApplicationID, sa.user)
+	// nothing is allocated until the minimum member set of a gang is allocated in one go
+	if sa.gangMinMembers > 0 && !sa.gangCommitted {
+		return sa.tryGangAllocate(headRoom, userHeadroom, nodeIterator, getNodeFn)
+	}
 	// get all the requests from the app sorted in order
 	for _, request := range sa.sortedRequests {
 		if request.IsAllocated() {
@@ -1060,6 +1586,279 @@ func (sa *Application) tryAllocate(headRoom *resources.Resource, allowPreemption
 	return nil
 }
 
+// tryGangAllocate allocates the minimum member set of the gang in one scheduling pass. Each member is tentatively
+// placed on a node, taking into account the members already placed on that node. Only if every member has been
+// placed are the allocations committed: a partial placement is rolled back and nothing is allocated or reserved.
+// The first allocation is returned with the other members of the gang attached.
+func (sa *Application) tryGangAllocate(headRoom, userHeadroom *resources.Resource, nodeIterator func() NodeIterator, getNodeFn func(string) *Node) *AllocationResult {
+	members := make([]*Allocation, 0, sa.gangMinMembers)
+	total := resources.NewResource()
+	for _, request := range sa.sortedRequests {
+		if request.IsAllocated() || request.IsPlaceholder() {
+			continue
+		}
+		members = append(members, request)
+		total.AddTo(request.GetAllocatedResource())
+		if len(members) == sa.gangMinMembers {
+			break
+		}
+	}
+	// wait for the RM to submit all members
+	if len(members) < sa.gangMinMembers {
+		return nil
+	}
+	for _, member := range members {
+		member.SetSchedulingAttempted(true)
+	}
+	if !userHeadroom.FitInMaxUndef(total) {
+		members[0].LogAllocationFailure(NotEnoughUserQuota, true) // error message MUST be constant!
+		members[0].setUserQuotaCheckFailed(userHeadroom)
+		return nil
+	}
+	members[0].setUserQuotaCheckPassed()
+	if !headRoom.FitInMaxUndef(total) {
+		members[0].LogAllocationFailure(NotEnoughQueueQuota, true) // error message MUST be constant!
+		members[0].setHeadroomCheckFailed(headRoom, sa.queuePath)
+		return nil
+	}
+	members[0].setHeadroomCheckPassed(sa.queuePath)
+
+	var nodes []*Node
+	if iterator := nodeIterator(); iterator != nil {
+		iterator.ForEachNode(func(node *Node) bool {
+			nodes = append(nodes, node)
+			return true
+		})
+	}
+	// tentative placement: the resources of the members placed on each node, dropped if the gang does not fit
+	tentative := make(map[string]*resources.Resource)
+	placement := make([]*Node, len(members))
+	for i, member := range members {
+		// the members placed before this member count for the affinity rules
+		index := sa.newAffinityIndex(member)
+		for j := 0; j < i; j++ {
+			index.add(members[j], placement[j].NodeID)
+		}
+		candidates := nodes
+		if requiredNode := member.GetRequiredNode(); requiredNode != "" {
+			candidates = nil
+			if node := getNodeFn(requiredNode); node != nil {
+				candidates = []*Node{node}
+			}
+		}
+		for _, node := range candidates {
+			// gang members are never reserved: a reserved node is kept for the ask of another application, like the
+			// node iterator does for a regular allocation, also when the member requires the node
+			if !node.IsSchedulable() || node.IsReserved() {
+				continue
+			}
+			nodeTotal := resources.Add(tentative[node.NodeID], member.GetAllocatedResource())
+			if !node.preAllocateCheck(nodeTotal, member.GetAllocationKey()) || index.check(node) != nil || node.preAllocateConditions(member) != nil {
+				continue
+			}
+			tentative[node.NodeID] = nodeTotal
+			placement[i] = node
+			break
+		}
+		if placement[i] == nil {
+			member.LogAllocationFailure(GangIncomplete, true) // error message MUST be constant!
+			log.Log(log.SchedApplication).Debug("gang does not fit, tentative placement rolled back",
+				zap.String("appID", sa.ApplicationID),
+				zap.Int("minMembers", sa.gangMinMembers),
+				zap.Int("placed", i))
+			return nil
+		}
+	}
+
+	// commit: the nodes could have changed since the placement, roll back if a member does not fit anymore
+	for i, member := range members {
+		if !placement[i].TryAddAllocation(member) {
+			rollbackGangPlacement(members[:i], placement)
+			log.Log(log.SchedApplication).Debug("gang member did not fit on node, allocations rolled back",
+				zap.String("appID", sa.ApplicationID),
+				zap.String("allocationKey", member.GetAllocationKey()),
+				zap.String("nodeID", placement[i].NodeID))
+			return nil
+		}
+	}
+	if err := sa.queue.TryIncAllocatedResource(total); err != nil {
+		log.Log(log.SchedApplication).DPanic("queue update failed unexpectedly",
+			zap.Error(err))
+		rollbackGangPlacement(members, placement)
+		return nil
+	}
+	results := make([]*AllocationResult, len(members))
+	for i, member := range members {
+		if _, err := sa.allocateAsk(member); err != nil {
+			log.Log(log.SchedApplication).Warn("allocation of alloc failed unexpectedly",
+				zap.Error(err))
+		}
+		results[i] = newAllocatedAllocationResult(placement[i].NodeID, member)
+		sa.addAllocationInternal(results[i].ResultType, member)
+	}
+	sa.gangCommitted = true
+	log.Log(log.SchedApplication).Info("gang minimum member set allocated",
+		zap.String("appID", sa.ApplicationID),
+		zap.Int("minMembers", sa.gangMinMembers),
+		zap.Int("nodes", len(tentative)),
+		zap.Stringer("allocatedResource", total))
+	results[0].Members = results[1:]
+	return results[0]
+}
+
+// UnwindGangAllocation reverses the allocation of the minimum member set of a gang that could not be processed as a
+// whole by the partition: the members are removed from the application and the queue and are pending again.
+// Members already removed from the application, i.e. with the removal of the node, are only made pending again.
+// The caller must remove the members from the nodes.
+func (sa *Application) UnwindGangAllocation(members []*Allocation) {
+	sa.Lock()
+	defer sa.Unlock()
+	total := resources.NewResource()
+	for _, member := range members {
+		allocKey := member.GetAllocationKey()
+		if _, ok := sa.allocations[allocKey]; ok {
+			delete(sa.allocations, allocKey)
+			sa.allocatedResource = resources.Sub(sa.allocatedResource, member.GetAllocatedResource())
+			sa.decUserResourceUsage(member.GetAllocatedResource(), false)
+			accounting.GetTracker().RemoveAllocation(sa.Partition, allocKey)
+			total.AddTo(member.GetAllocatedResource())
+		}
+		if _, err := sa.deallocateAsk(member); err != nil {
+			log.Log(log.SchedApplication).Warn("failed to unwind gang member allocation",
+				zap.String("appID", sa.ApplicationID),
+				zap.String("allocationKey", allocKey),
+				zap.Error(err))
+		}
+	}
+	sa.allocatedResource.Prune()
+	if err := sa.queue.DecAllocatedResource(total); err != nil {
+		log.Log(log.SchedApplication).Warn("failed to unwind gang allocation on queue",
+			zap.String("appID", sa.ApplicationID),
+			zap.Stringer("allocatedResource", total),
+			zap.Error(err))
+	}
+	sa.gangCommitted = false
+}
+
+// rollbackGangPlacement removes the members of the gang from the nodes they were added to.
+func rollbackGangPlacement(members []*Allocation, placement []*Node) {
+	for i, member := range members {
+		placement[i].RemoveAllocation(member.GetAllocationKey())
+	}
+}
+
+// affinityIndex holds the co-location domains of the allocations that match the affinity and anti-affinity rules
+// of an ask. It is built once for the ask in a scheduling pass: checking a node does not iterate over the allocations.
+// The rules select the allocations of the application only. Asks of the same task group are selected using the
+// taskgroup key. Rules between asks of different applications are not supported.
+type affinityIndex struct {
+	affinity     *configs.NodePoolSelector
+	antiAffinity *configs.NodePoolSelector
+	topologyKey  string
+	nodeLookup   func(nodeID string) *Node
+	nodeDomains  map[string]string // co-location domain per node of the allocations, looked up once per node
+	affinityIn   map[string]bool   // domains with an allocation that matches the affinity rule
+	antiIn       map[string]bool   // domains with an allocation that matches the anti-affinity rule
+	matched      bool              // an allocation matches the affinity rule, in a domain or not
+}
+
+// newAffinityIndex indexes the allocations of the application for the affinity rules of the ask.
+// Returns nil if the ask has no affinity rules.
+// Lock free call, must be called holding the application lock.
+func (sa *Application) newAffinityIndex(ask *Allocation) *affinityIndex {
+	affinity, antiAffinity := ask.GetAffinity()
+	if affinity == nil && antiAffinity == nil {
+		return nil
+	}
+	index := &affinityIndex{
+		affinity:     affinity,
+		antiAffinity: antiAffinity,
+		topologyKey:  ask.GetTopologyKey(),
+		nodeLookup:   sa.nodeLookup,
+		nodeDomains:  make(map[string]string),
+		affinityIn:   make(map[string]bool),
+		antiIn:       make(map[string]bool),
+	}
+	for _, alloc := range sa.allocations {
+		// allocations that are being released do not count
+		if alloc.IsReleased() {
+			continue
+		}
+		index.add(alloc, alloc.GetNodeID())
+	}
+	return index
+}
+
+// add indexes the allocation placed on the node, used for the allocations of the application and for the asks
+// tentatively placed.
+func (ai *affinityIndex) add(alloc *Allocation, nodeID string) {
+	if ai == nil {
+		return
+	}
+	antiMatch := ai.antiAffinity != nil && ai.antiAffinity.Matches(alloc.getSelectorAttribute)
+	affinityMatch := ai.affinity != nil && ai.affinity.Matches(alloc.getSelectorAttribute)
+	if !antiMatch && !affinityMatch {
+		return
+	}
+	ai.matched = ai.matched || affinityMatch
+	domain := ai.domain(nodeID)
+	// a node without the topology key attribute is not part of any domain
+	if domain == "" {
+		return
+	}
+	if antiMatch {
+		ai.antiIn[domain] = true
+	}
+	if affinityMatch {
+		ai.affinityIn[domain] = true
+	}
+}
+
+// domain returns the co-location domain of the node with the ID: the node itself, or the value of the topology
+// key attribute of the node.
+func (ai *affinityIndex) domain(nodeID string) string {
+	if ai.topologyKey == "" {
+		return nodeID
+	}
+	domain, ok := ai.nodeDomains[nodeID]
+	if !ok {
+		if ai.nodeLookup != nil {
+			if node := ai.nodeLookup(nodeID); node != nil {
+				domain = node.GetAttribute(ai.topologyKey)
+			}
+		}
+		ai.nodeDomains[nodeID] = domain
+	}
+	return domain
+}
+
+// check checks the affinity and anti-affinity rules if the ask is placed on the node. The rules are checked in the
+// co-location domain of the node: the node itself, or all nodes with the same value for the topology key attribute.
+// An ask with an affinity rule can be placed on any node while no allocation matches the rule.
+func (ai *affinityIndex) check(node *Node) error {
+	if ai == nil {
+		return nil
+	}
+	domain := node.NodeID
+	if ai.topologyKey != "" {
+		domain = node.GetAttribute(ai.topologyKey)
+	}
+	inDomain := func(domains map[string]bool) bool {
+		return domain != "" && domains[domain]
+	}
+	if inDomain(ai.antiIn) {
+		return errAntiAffinityNotMet
+	}
+	if ai.matched && !inDomain(ai.affinityIn) {
+		return errAffinityNotMet
+	}
+	return nil
+}
+
+func isAffinityError(err error) bool {
+	return errors.Is(err, errAffinityNotMet) || errors.Is(err, errAntiAffinityNotMet)
+}
+
 // tryRequiredNode tries to place the allocation in the specific node that is set as the required node in the allocation.
 // The first time the allocation is seen it will try to make the allocation on the node. If that does not work it will
 // always trigger the reservation of the node.
@@ -1084,7 +1883,7 @@ func (sa *Application) tryRequiredNode(request *Allocation, getNodeFn func(strin
 	}
 	_, thisReserved := sa.reservations[allocationKey]
 	// now try the request, we don't care about predicate error messages here
-	result, _ := sa.tryNode(node, request) //nolint:errcheck
+	result, _ := sa.tryNode(node, request, sa.newAffinityIndex(request)) //nolint:errcheck
 	if result != nil {
 		result.CancelledReservations = num
 		// check if the node was reserved and we allocated after a release
@@ -1326,7 +2125,7 @@ func (sa *Application) tryReservedAllocate(headRoom *resources.Resource, nodeIte
 		}
 		// check allocation possibility
 		// we don't care about predicate error messages here
-		result, _ := sa.tryNode(reserve.node, ask) //nolint:errcheck
+		result, _ := sa.tryNode(reserve.node, ask, sa.newAffinityIndex(ask)) //nolint:errcheck
 
 		// allocation worked fix the resultType and return
 		if result != nil {
@@ -1406,6 +2205,7 @@ func (sa *Application) tryRequiredNodePreemption(reserve *reservation, ask *Allo
 // This should never result in a reservation as the allocation is already reserved
 func (sa *Application) tryNodesNoReserve(ask *Allocation, iterator NodeIterator, reservedNode string) *AllocationResult {
 	var allocResult *AllocationResult
+	index := sa.newAffinityIndex(ask)
 	iterator.ForEachNode(func(node *Node) bool {
 		if !node.IsSchedulable() {
 			log.Log(log.SchedApplication).Debug("skipping node for reserved ask as state is unschedulable",
@@ -1418,7 +2218,7 @@ func (sa *Application) tryNodesNoReserve(ask *Allocation, iterator NodeIterator,
 			return true
 		}
 		// we don't care about predicate error messages here
-		result, _ := sa.tryNode(node, ask) //nolint:errcheck
+		result, _ := sa.tryNode(node, ask, index) //nolint:errcheck
 		// allocation worked: update resultType and return
 		if result != nil {
 			result.ResultType = AllocatedReserved
@@ -1443,6 +2243,7 @@ func (sa *Application) tryNodes(ask *Allocation, iterator NodeIterator) *Allocat
 	reserved := sa.reservations[allocKey]
 	var allocResult *AllocationResult
 	var predicateErrors map[string]int
+	index := sa.newAffinityIndex(ask)
 	iterator.ForEachNode(func(node *Node) bool {
 		// skip the node if the node is not schedulable
 		if !node.IsSchedulable() {
@@ -1456,7 +2257,7 @@ func (sa *Application) tryNodes(ask *Allocation, iterator NodeIterator) *Allocat
 			return true
 		}
 		tryNodeStart := time.Now()
-		result, err := sa.tryNode(node, ask)
+		result, err := sa.tryNode(node, ask, index)
 		if err != nil {
 			if predicateErrors == nil {
 				predicateErrors = make(map[string]int)
@@ -1491,8 +2292,9 @@ func (sa *Application) tryNodes(ask *Allocation, iterator NodeIterator) *Allocat
 			return false
 		}
 		// nothing allocated should we look at a reservation?
+		// a node that the affinity rules do not allow is never reserved
 		askAge := time.Since(ask.GetCreateTime())
-		if reserved == nil && askAge > reservationDelay {
+		if reserved == nil && askAge > reservationDelay && !isAffinityError(err) {
 			log.Log(log.SchedApplication).Debug("app reservation check",
 				zap.String("allocationKey", allocKey),
 				zap.Time("createTime", ask.GetCreateTime()),
@@ -1535,8 +2337,8 @@ func (sa *Application) tryNodes(ask *Allocation, iterator NodeIterator) *Allocat
 	return nil
 }
 
-// tryNode tries allocating on one specific node
-func (sa *Application) tryNode(node *Node, ask *Allocation) (*AllocationResult, error) {
+// tryNode tries allocating on one specific node, the index holds the allocations for the affinity rules of the ask
+func (sa *Application) tryNode(node *Node, ask *Allocation, index *affinityIndex) (*AllocationResult, error) {
 	toAllocate := ask.GetAllocatedResource()
 	allocationKey := ask.GetAllocationKey()
 	// create the key for the reservation
@@ -1544,6 +2346,10 @@ func (sa *Application) tryNode(node *Node, ask *Allocation) (*AllocationResult,
 		// skip schedule onto node
 		return nil, nil
 	}
+	// skip the node if the affinity rules of the ask do not allow it
+	if err := index.check(node); err != nil {
+		return nil, err
+	}
 	// skip the node if conditions can not be satisfied
 	if err := node.preAllocateConditions(ask); err != nil {
 		return nil, err
@@ -1592,6 +2398,20 @@ func (sa *Application) SetQueuePath(queuePath string) {
 	sa.queuePath = queuePath
 }
 
+// NewPlacementCopy returns an application with just the details the placement rules use copied from this application.
+// The copy is not linked to a queue or partition: placing it does not change this application.
+func (sa *Application) NewPlacementCopy() *Application {
+	sa.RLock()
+	defer sa.RUnlock()
+	return &Application{
+		ApplicationID: sa.ApplicationID,
+		Partition:     sa.Partition,
+		queuePath:     sa.queuePath,
+		tags:          sa.tags,
+		user:          sa.user,
+	}
+}
+
 // Set the leaf queue the application runs in.
 func (sa *Application) SetQueue(queue *Queue) {
 	sa.Lock()
@@ -1603,6 +2423,82 @@ func (sa *Application) SetQueue(queue *Queue) {
 	metrics.GetSchedulerMetrics().IncTotalApplicationsNew()
 }
 
+// MoveToQueue moves the application with all its requests and allocations to another leaf queue in the same
+// partition. The user must be allowed to submit to the target queue and the application must fit in the limits of the
+// target queue and the user and group limits. Nothing is changed if a check fails.
+// The application lock is held while the usage moves: the usage of the application cannot change during the move.
+func (sa *Application) MoveToQueue(target *Queue) error {
+	sa.Lock()
+	defer sa.Unlock()
+	source := sa.queue
+	if source == nil {
+		return fmt.Errorf("application %s is not linked to a queue", sa.ApplicationID)
+	}
+	if target == source {
+		return fmt.Errorf("application %s is already in queue %s", sa.ApplicationID, target.QueuePath)
+	}
+	state := sa.stateMachine.Current()
+	if state != New.String() && state != Accepted.String() && state != Running.String() {
+		return fmt.Errorf("application %s cannot be moved in state %s", sa.ApplicationID, state)
+	}
+	if !target.IsLeafQueue() {
+		return fmt.Errorf("queue %s is not a leaf queue", target.QueuePath)
+	}
+	if !target.IsRunning() {
+		return fmt.Errorf("queue %s is not running, it is %s", target.QueuePath, target.CurrentState())
+	}
+	if !target.CheckSubmitAccess(sa.user) {
+		return fmt.Errorf("user %s has no submit access to queue %s", sa.user.User, target.QueuePath)
+	}
+	usage := &appQueueUsage{
+		allocated:          resources.Add(sa.allocatedResource, sa.allocatedPlaceholder),
+		pending:            sa.pending.Clone(),
+		preempting:         resources.NewResource(),
+		running:            state == Running.String(),
+		allocatingAccepted: source.isAllocatingAccepted(sa.ApplicationID),
+	}
+	for _, alloc := range sa.allocations {
+		if alloc.IsPreempted() {
+			usage.preempting.AddTo(alloc.GetAllocatedResource())
+		}
+	}
+	parent := commonParent(source, target)
+	if err := target.checkMoveLimits(parent, usage); err != nil {
+		return err
+	}
+	// the user and group usage of the application is removed before checking the limits: the parents shared with the
+	// source queue must not count the application twice
+	tracked := !resources.IsZero(usage.allocated)
+	if tracked {
+		sa.decUserResourceUsage(usage.allocated, true)
+		userManager := ugm.GetUserManager()
+		headroom := userManager.Headroom(target.QueuePath, sa.ApplicationID, sa.user)
+		if !userManager.CanRunApp(target.QueuePath, sa.ApplicationID, sa.user) || !headroom.FitInMaxUndef(usage.allocated) {
+			sa.incUserResourceUsage(usage.allocated)
+			return fmt.Errorf("application %s does not fit in the user or group limits of queue %s", sa.ApplicationID, target.QueuePath)
+		}
+	}
+	reservations := source.removeMovedApplication(sa.ApplicationID)
+	source.removeAppUsage(parent, sa.ApplicationID, usage)
+	target.addAppUsage(parent, sa.ApplicationID, usage)
+	target.addMovedApplication(sa, reservations, sa.getSchedulingPriority())
+	moveStateMetrics(state, source.QueuePath, target.QueuePath)
+	sa.queue = target
+	sa.queuePath = target.QueuePath
+	accounting.GetTracker().MoveApplication(sa.Partition, sa.ApplicationID, target.QueuePath)
+	if tracked {
+		sa.incUserResourceUsage(usage.allocated)
+	}
+	log.Log(log.SchedApplication).Info("application moved to a different queue",
+		zap.String("applicationID", sa.ApplicationID),
+		zap.String("fromQueue", source.QueuePath),
+		zap.String("toQueue", target.QueuePath),
+		zap.Stringer("allocated", usage.allocated),
+		zap.Stringer("pending", usage.pending))
+	sa.appEvents.SendApplicationMovedEvent(sa.ApplicationID, source.QueuePath, target.QueuePath)
+	return nil
+}
+
 // remove the leaf queue the application runs in, used when completing the app
 func (sa *Application) UnSetQueue() {
 	if sa.queue != nil {
@@ -1722,6 +2618,25 @@ func (sa *Application) addAllocationInternal(allocType AllocationResultType, all
 	}
 	sa.appEvents.SendNewAllocationEvent(sa.ApplicationID, alloc.allocationKey, alloc.GetAllocatedResource())
 	sa.allocations[alloc.GetAllocationKey()] = alloc
+	accounting.GetTracker().AddAllocation(alloc.GetAllocationKey(), sa.getAttribution(), alloc.GetAllocatedResource(), alloc.GetBindTime())
+}
+
+// getAttribution returns who the resource usage of the application is charged to.
+// The first group of the user is the group the usage is charged to.
+// No locking must be called while holding the lock
+func (sa *Application) getAttribution() accounting.Attribution {
+	var group string
+	if len(sa.user.Groups) > 0 {
+		group = sa.user.Groups[0]
+	}
+	return accounting.Attribution{
+		Partition:     sa.Partition,
+		Queue:         sa.queuePath,
+		ApplicationID: sa.ApplicationID,
+		User:          sa.user.User,
+		Group:         group,
+		Tags:          sa.tags,
+	}
 }
 
 // Increase user resource usage
@@ -1738,6 +2653,7 @@ func (sa *Application) decUserResourceUsage(resource *resources.Resource, remove
 
 // Track used and preempted resources
 func (sa *Application) trackCompletedResource(info *Allocation) {
+	accounting.GetTracker().RemoveAllocation(sa.Partition, info.GetAllocationKey())
 	switch {
 	case info.IsPreempted():
 		sa.updatePreemptedResource(info)
@@ -1845,7 +2761,7 @@ func (sa *Application) removeAllocationInternal(allocationKey string, releaseTyp
 		if resources.IsZero(sa.allocatedPlaceholder) {
 			sa.clearPlaceholderTimer()
 			sa.hasPlaceholderAlloc = false
-			if (sa.IsCompleting() && sa.stateTimer == nil) || sa.IsFailing() || sa.IsResuming() || sa.hasZeroAllocations() {
+			if (sa.IsCompleting() && sa.stateTimer == nil) || sa.IsFailing() || sa.IsResuming() || (sa.hasZeroAllocations() && !sa.IsSuspended()) {
 				removeApp = true
 				event = CompleteApplication
 				if sa.IsFailing() {
@@ -1870,7 +2786,8 @@ func (sa *Application) removeAllocationInternal(allocationKey string, releaseTyp
 		sa.trackCompletedResource(alloc)
 
 		// When the resource trackers are zero we should not expect anything to come in later.
-		if sa.hasZeroAllocations() {
+		// A suspended application keeps its requests and waits to be resumed.
+		if sa.hasZeroAllocations() && !sa.IsSuspended() {
 			removeApp = true
 			event = CompleteApplication
 			eventWarning = "Application state not changed to Completing while removing an allocation"
@@ -1899,7 +2816,7 @@ func (sa *Application) updateAskMaxPriority() {
 		value = max(value, v.GetPriority())
 	}
 	sa.askMaxPriority = value
-	sa.queue.UpdateApplicationPriority(sa.ApplicationID, value)
+	sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getSchedulingPriority())
 }
 
 func (sa *Application) hasZeroAllocations() bool {
@@ -1945,6 +2862,9 @@ func (sa *Application) RemoveAllAllocations() []*Allocation {
 	}
 	sa.clearPlaceholderTimer()
 	sa.clearStateTimer()
+	// a removed application met its deadline unless it was failing
+	sa.finishDeadline(!sa.IsFailing())
+	sa.clearRuntimeTimer()
 	return allocationsToRelease
 }
 
@@ -1995,12 +2915,34 @@ func (sa *Application) SetTerminatedCallback(callback func(appID string)) {
 	sa.terminatedCallback = callback
 }
 
+// SetReservationsCallback sets the function called with the number of reservations removed by the application
+// itself, outside a call from the partition, i.e. when the max runtime is reached.
+func (sa *Application) SetReservationsCallback(callback func(num int)) {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.reservationsCallback = callback
+}
+
+// SetNodeLookup sets the function used to find the nodes of the allocations when the affinity rules of an ask use
+// a topology key.
+func (sa *Application) SetNodeLookup(lookup func(nodeID string) *Node) {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.nodeLookup = lookup
+}
+
 func (sa *Application) executeTerminatedCallback() {
 	if sa.terminatedCallback != nil {
 		go sa.terminatedCallback(sa.ApplicationID)
 	}
 }
 
+func (sa *Application) executeReservationsCallback(num int) {
+	if num > 0 && sa.reservationsCallback != nil {
+		go sa.reservationsCallback(num)
+	}
+}
+
 // notifyRMAllocationReleased send an allocation release event to the RM to if the event handler is configured
 // and at least one allocation has been released.
 // No locking must be called while holding the lock
@@ -2034,6 +2976,25 @@ func (sa *Application) notifyRMAllocationReleased(released []*Allocation, termin
 	}
 }
 
+func (sa *Application) notifyRMAllocationRejected(rejected []*Allocation, message string) {
+	// only generate event if needed
+	if len(rejected) == 0 || sa.rmEventHandler == nil {
+		return
+	}
+	rejectEvent := &rmevent.RMRejectedAllocationEvent{
+		RejectedAllocations: make([]*si.RejectedAllocation, 0),
+		RmID:                sa.rmID,
+	}
+	for _, alloc := range rejected {
+		rejectEvent.RejectedAllocations = append(rejectEvent.RejectedAllocations, &si.RejectedAllocation{
+			ApplicationID: alloc.GetApplicationID(),
+			AllocationKey: alloc.GetAllocationKey(),
+			Reason:        message,
+		})
+	}
+	sa.rmEventHandler.HandleEvent(rejectEvent)
+}
+
 func (sa *Application) IsAllocationAssignedToApp(alloc *Allocation) bool {
 	sa.RLock()
 	defer sa.RUnlock()
@@ -2077,6 +3038,67 @@ func (sa *Application) GetAskMaxPriority() int32 {
 	return sa.askMaxPriority
 }
 
+// GetSchedulingPriority returns the priority of the application as used by the queue: the highest priority of the
+// outstanding asks including aging, raised by the deadline boost of the queue when the deadline of the application
+// is at risk. Only queues that sort by deadline (edf) boost the priority.
+func (sa *Application) GetSchedulingPriority() int32 {
+	sa.RLock()
+	defer sa.RUnlock()
+	return sa.getSchedulingPriority()
+}
+
+func (sa *Application) getSchedulingPriority() int32 {
+	if sa.askMaxPriority == configs.MinPriority {
+		return sa.askMaxPriority
+	}
+	if sa.deadlineAtRisk && sa.queue != nil {
+		return int32(min(int64(sa.getAgedPriority())+int64(sa.queue.getDeadlineBoost()), int64(configs.MaxPriority)))
+	}
+	return sa.getAgedPriority()
+}
+
+// getAgedPriority returns the ask priority increased by the aging taken at the last refresh. The priority does not
+// change over time between refreshes, which keeps it stable while the applications are sorted.
+func (sa *Application) getAgedPriority() int32 {
+	if sa.queue == nil || sa.priorityAging == 0 {
+		return sa.askMaxPriority
+	}
+	if interval, _ := sa.queue.getPriorityAging(); interval <= 0 {
+		return sa.askMaxPriority
+	}
+	return int32(min(int64(sa.askMaxPriority)+int64(sa.priorityAging), int64(configs.MaxPriority)))
+}
+
+// refreshAgedPriority updates the aging of the priority: increased by one for each aging interval of the queue the
+// application waited, at the time, without receiving an allocation, up to the maximum increase set on the queue.
+// Returns the scheduling priority after the update.
+func (sa *Application) refreshAgedPriority(now time.Time) int32 {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.priorityAging = 0
+	if sa.queue != nil && !sa.pendingSince.IsZero() {
+		if interval, maxAging := sa.queue.getPriorityAging(); interval > 0 {
+			sa.priorityAging = int32(min(int64(now.Sub(sa.pendingSince)/interval), int64(maxAging)))
+		}
+	}
+	return sa.getSchedulingPriority()
+}
+
+// updatePendingSince tracks the start of the wait for an allocation: it is reset if nothing is pending.
+func (sa *Application) updatePendingSince() {
+	if resources.IsZero(sa.pending) {
+		sa.pendingSince = time.Time{}
+		sa.priorityAging = 0
+	} else if sa.pendingSince.IsZero() {
+		sa.pendingSince = time.Now()
+	}
+}
+
+// GetDeadline returns the deadline of the application, zero if the application has no deadline.
+func (sa *Application) GetDeadline() time.Time {
+	return sa.deadline
+}
+
 func (sa *Application) cleanupAsks() {
 	sa.requests = make(map[string]*Allocation)
 	sa.sortedRequests = nil
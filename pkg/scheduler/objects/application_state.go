@@ -45,6 +45,8 @@ const (
 	FailApplication
 	ExpireApplication
 	ResumeApplication
+	SuspendApplication
+	UnsuspendApplication
 )
 
 const (
@@ -52,7 +54,7 @@ const (
 )
 
 func (ae applicationEvent) String() string {
-	return [...]string{"runApplication", "rejectApplication", "completeApplication", "failApplication", "expireApplication", "resumeApplication"}[ae]
+	return [...]string{"runApplication", "rejectApplication", "completeApplication", "failApplication", "expireApplication", "resumeApplication", "suspendApplication", "unsuspendApplication"}[ae]
 }
 
 // ----------------------------------
@@ -72,6 +74,7 @@ const (
 	Failed
 	Expired
 	Resuming
+	Suspended
 )
 
 var stateEvents = map[string]si.EventRecord_ChangeDetail{
@@ -85,10 +88,12 @@ var stateEvents = map[string]si.EventRecord_ChangeDetail{
 	Failed.String():     si.EventRecord_APP_FAILED,
 	Resuming.String():   si.EventRecord_APP_RESUMING,
 	Expired.String():    si.EventRecord_APP_EXPIRED,
+	// the interface has no suspended detail: the application can no longer run in the queue
+	Suspended.String(): si.EventRecord_APP_CANNOTRUN_QUEUE,
 }
 
 func (as applicationState) String() string {
-	return [...]string{"New", "Accepted", "Running", "Rejected", "Completing", "Completed", "Failing", "Failed", "Expired", "Resuming"}[as]
+	return [...]string{"New", "Accepted", "Running", "Rejected", "Completing", "Completed", "Failing", "Failed", "Expired", "Resuming", "Suspended"}[as]
 }
 
 func eventDesc() fsm.Events {
@@ -107,7 +112,7 @@ func eventDesc() fsm.Events {
 			Dst:  Running.String(),
 		}, {
 			Name: CompleteApplication.String(),
-			Src:  []string{Accepted.String(), Running.String()},
+			Src:  []string{Accepted.String(), Running.String(), Suspended.String()},
 			Dst:  Completing.String(),
 		}, {
 			Name: CompleteApplication.String(),
@@ -115,7 +120,7 @@ func eventDesc() fsm.Events {
 			Dst:  Completed.String(),
 		}, {
 			Name: FailApplication.String(),
-			Src:  []string{New.String(), Accepted.String(), Running.String()},
+			Src:  []string{New.String(), Accepted.String(), Running.String(), Suspended.String()},
 			Dst:  Failing.String(),
 		}, {
 			Name: FailApplication.String(),
@@ -125,6 +130,14 @@ func eventDesc() fsm.Events {
 			Name: ResumeApplication.String(),
 			Src:  []string{New.String(), Accepted.String()},
 			Dst:  Resuming.String(),
+		}, {
+			Name: SuspendApplication.String(),
+			Src:  []string{Accepted.String(), Running.String()},
+			Dst:  Suspended.String(),
+		}, {
+			Name: UnsuspendApplication.String(),
+			Src:  []string{Suspended.String()},
+			Dst:  Accepted.String(),
 		}, {
 			Name: ExpireApplication.String(),
 			Src:  []string{Completed.String(), Failed.String(), Rejected.String()},
@@ -179,6 +192,7 @@ func callbacks() fsm.Callbacks {
 			app := event.Args[0].(*Application) //nolint:errcheck
 			metrics.GetQueueMetrics(app.queuePath).IncQueueApplicationsAccepted()
 			metrics.GetSchedulerMetrics().IncTotalApplicationsAccepted()
+			app.initDeadlineTimer()
 		},
 		fmt.Sprintf("leave_%s", Accepted.String()): func(_ context.Context, event *fsm.Event) {
 			app := event.Args[0].(*Application) //nolint:errcheck
@@ -190,6 +204,7 @@ func callbacks() fsm.Callbacks {
 			metrics.GetQueueMetrics(app.queuePath).IncQueueApplicationsRejected()
 			metrics.GetSchedulerMetrics().IncTotalApplicationsRejected()
 			app.setStateTimer(terminatedTimeout, app.stateMachine.Current(), ExpireApplication)
+			app.clearDeadlineTimer()
 			app.finishedTime = time.Now()
 			app.cleanupTrackedResource()
 			// No rejected message when use app.HandleApplicationEvent(RejectApplication)
@@ -224,6 +239,18 @@ func callbacks() fsm.Callbacks {
 			metrics.GetQueueMetrics(app.queuePath).DecQueueApplicationsResuming()
 			metrics.GetSchedulerMetrics().DecTotalApplicationsResuming()
 		},
+		fmt.Sprintf("enter_%s", Suspended.String()): func(_ context.Context, event *fsm.Event) {
+			app := event.Args[0].(*Application) //nolint:errcheck
+			// a suspended application must not hold a running slot in the queue
+			app.queue.clearAllocatingAccepted(app.ApplicationID)
+			metrics.GetQueueMetrics(app.queuePath).IncQueueApplicationsSuspended()
+			metrics.GetSchedulerMetrics().IncTotalApplicationsSuspended()
+		},
+		fmt.Sprintf("leave_%s", Suspended.String()): func(_ context.Context, event *fsm.Event) {
+			app := event.Args[0].(*Application) //nolint:errcheck
+			metrics.GetQueueMetrics(app.queuePath).DecQueueApplicationsSuspended()
+			metrics.GetSchedulerMetrics().DecTotalApplicationsSuspended()
+		},
 		fmt.Sprintf("enter_%s", Failing.String()): func(_ context.Context, event *fsm.Event) {
 			app := event.Args[0].(*Application) //nolint:errcheck
 			metrics.GetQueueMetrics(app.queuePath).IncQueueApplicationsFailing()
@@ -250,6 +277,7 @@ func callbacks() fsm.Callbacks {
 			metrics.GetSchedulerMetrics().IncTotalApplicationsCompleted()
 			metrics.GetQueueMetrics(app.queuePath).IncQueueApplicationsCompleted()
 			app.setStateTimer(terminatedTimeout, app.stateMachine.Current(), ExpireApplication)
+			app.finishDeadline(true)
 			app.executeTerminatedCallback()
 			app.clearPlaceholderTimer()
 			app.cleanupAsks()
@@ -259,12 +287,34 @@ func callbacks() fsm.Callbacks {
 			metrics.GetSchedulerMetrics().IncTotalApplicationsFailed()
 			metrics.GetQueueMetrics(app.queuePath).IncQueueApplicationsFailed()
 			app.setStateTimer(terminatedTimeout, app.stateMachine.Current(), ExpireApplication)
+			app.finishDeadline(false)
 			app.executeTerminatedCallback()
 			app.cleanupAsks()
 		},
+		fmt.Sprintf("enter_%s", Expired.String()): func(_ context.Context, event *fsm.Event) {
+			event.Args[0].(*Application).clearDeadlineTimer() //nolint:errcheck
+		},
 	}
 }
 
 func NewAppState() *fsm.FSM {
 	return fsm.NewFSM(New.String(), eventDesc(), callbacks())
 }
+
+// moveStateMetrics moves the count of an application in the state from the metrics of one queue to another.
+// Only the states an application can be moved in are tracked.
+func moveStateMetrics(state, fromQueue, toQueue string) {
+	from := metrics.GetQueueMetrics(fromQueue)
+	to := metrics.GetQueueMetrics(toQueue)
+	switch state {
+	case New.String():
+		from.DecQueueApplicationsNew()
+		to.IncQueueApplicationsNew()
+	case Accepted.String():
+		from.DecQueueApplicationsAccepted()
+		to.IncQueueApplicationsAccepted()
+	case Running.String():
+		from.DecQueueApplicationsRunning()
+		to.IncQueueApplicationsRunning()
+	}
+}
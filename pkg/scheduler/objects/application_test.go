@@ -34,11 +34,13 @@ import (
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/events/mock"
 	"github.com/apache/yunikorn-core/pkg/handler"
+	"github.com/apache/yunikorn-core/pkg/metrics"
 	mockCommon "github.com/apache/yunikorn-core/pkg/mock"
 	"github.com/apache/yunikorn-core/pkg/plugins"
 	"github.com/apache/yunikorn-core/pkg/rmproxy"
 	"github.com/apache/yunikorn-core/pkg/rmproxy/rmevent"
 	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
+	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
 	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -3650,3 +3652,750 @@ func TestTryNodesNoReserve(t *testing.T) {
 	assert.Equal(t, result.ResultType, AllocatedReserved, "result type should be AllocatedReserved")
 	assert.Equal(t, result.ReservedNodeID, node1.NodeID, "reserved node should be node1")
 }
+
+func TestGetDeadline(t *testing.T) {
+	submission := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		expected time.Time
+	}{
+		{"no tags", nil, time.Time{}},
+		{"deadline", map[string]string{common.AppTagDeadline: "2024-01-01T13:00:00Z"}, submission.Add(time.Hour)},
+		{"max wait", map[string]string{common.AppTagMaxWait: "30m"}, submission.Add(30 * time.Minute)},
+		{"max wait earlier", map[string]string{common.AppTagDeadline: "2024-01-01T13:00:00Z", common.AppTagMaxWait: "30m"}, submission.Add(30 * time.Minute)},
+		{"deadline earlier", map[string]string{common.AppTagDeadline: "2024-01-01T12:10:00Z", common.AppTagMaxWait: "30m"}, submission.Add(10 * time.Minute)},
+		{"invalid deadline", map[string]string{common.AppTagDeadline: "tomorrow", common.AppTagMaxWait: "30m"}, submission.Add(30 * time.Minute)},
+		{"invalid max wait", map[string]string{common.AppTagMaxWait: "-1m"}, time.Time{}},
+		{"max wait too short", map[string]string{common.AppTagMaxWait: "1ms"}, time.Time{}},
+		{"past deadline", map[string]string{common.AppTagDeadline: "2024-01-01T11:00:00Z"}, time.Time{}},
+		{"deadline too close", map[string]string{common.AppTagDeadline: "2024-01-01T12:00:30Z", common.AppTagMaxWait: "30m"}, submission.Add(30 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Assert(t, getDeadline(appID1, tt.tags, submission).Equal(tt.expected))
+		})
+	}
+}
+
+func TestGetDeadlineRiskWindow(t *testing.T) {
+	submission := time.Now()
+	assert.Equal(t, getDeadlineRiskWindow(submission, submission.Add(10*time.Minute)), time.Minute)
+	assert.Equal(t, getDeadlineRiskWindow(submission, submission.Add(10*time.Hour)), maxDeadlineRiskWindow)
+}
+
+func TestApplicationDeadline(t *testing.T) {
+	defer func(wait time.Duration) { minDeadlineWait = wait }(minDeadlineWait)
+	minDeadlineWait = 100 * time.Millisecond
+	missed, err := metrics.GetSchedulerMetrics().GetApplicationDeadlineMissed()
+	assert.NilError(t, err)
+	// risk window is 30ms: priority is raised after 270ms
+	app := newApplicationWithTags(appID1, "default", "root.a", map[string]string{common.AppTagMaxWait: "300ms"})
+	assert.Assert(t, !app.GetDeadline().IsZero(), "deadline should be set")
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer should not be set before the application is accepted")
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueueWithProps(root, "a", false, nil, map[string]string{configs.ApplicationSortPolicy: policies.EdfSortPolicy.String(), configs.PriorityDeadlineBoost: "20"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	leaf.AddApplication(app)
+	app.queue = leaf
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	err = app.AddAllocationAsk(newAllocationAskPriority(aKey, appID1, res, 5))
+	assert.NilError(t, err, "ask should have been added to app")
+	assert.Assert(t, app.IsAccepted(), "application should have been accepted")
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer should be set")
+	assert.Equal(t, app.GetSchedulingPriority(), int32(5))
+	assert.Equal(t, leaf.GetCurrentPriority(), int32(5))
+
+	err = common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return leaf.GetCurrentPriority() == 25
+	})
+	assert.NilError(t, err, "priority of the queue was not raised")
+	assert.Equal(t, app.GetSchedulingPriority(), int32(25))
+	assert.Equal(t, app.GetAskMaxPriority(), int32(5), "ask priority should not change")
+	err = common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		current, _ := metrics.GetSchedulerMetrics().GetApplicationDeadlineMissed()
+		return current == missed+1
+	})
+	assert.NilError(t, err, "missed deadline was not recorded")
+	// the boost ends with the deadline
+	assert.Equal(t, app.GetSchedulingPriority(), int32(5))
+	assert.Equal(t, leaf.GetCurrentPriority(), int32(5))
+
+	// no pending asks: priority is not raised
+	_, err = app.AllocateAsk(aKey)
+	assert.NilError(t, err, "ask should have been allocated")
+	assert.Equal(t, app.GetSchedulingPriority(), configs.MinPriority)
+
+	// removing the app clears the timer and meets the deadline
+	met, err := metrics.GetSchedulerMetrics().GetApplicationDeadlineMet()
+	assert.NilError(t, err)
+	app = newApplicationWithTags(appID2, "default", "root.a", map[string]string{common.AppTagMaxWait: "1h"})
+	assert.NilError(t, app.HandleApplicationEvent(RunApplication), "application should have been accepted")
+	assert.Assert(t, app.deadlineTimer != nil, "deadline timer should be set")
+	app.RemoveAllAllocations()
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer should be cleared")
+	current, err := metrics.GetSchedulerMetrics().GetApplicationDeadlineMet()
+	assert.NilError(t, err)
+	assert.Equal(t, current, met+1, "removed application should meet its deadline")
+
+	// completing before the deadline meets it, failing misses it
+	app = newApplicationWithTags(appID2, "default", "root.a", map[string]string{common.AppTagMaxWait: "1h"})
+	app.queue = leaf
+	assert.NilError(t, app.HandleApplicationEvent(RunApplication), "application should have been accepted")
+	app.SetState(Completing.String())
+	assert.NilError(t, app.HandleApplicationEvent(CompleteApplication), "application should have completed")
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer should be cleared")
+	current, err = metrics.GetSchedulerMetrics().GetApplicationDeadlineMet()
+	assert.NilError(t, err)
+	assert.Equal(t, current, met+2, "completed application should meet its deadline")
+	app = newApplicationWithTags(appID3, "default", "root.a", map[string]string{common.AppTagMaxWait: "1h"})
+	app.queue = leaf
+	assert.NilError(t, app.HandleApplicationEvent(RunApplication), "application should have been accepted")
+	assert.NilError(t, app.FailApplication("failed"), "application should be failing")
+	assert.NilError(t, app.FailApplication("failed"), "application should have failed")
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer should be cleared")
+	current, err = metrics.GetSchedulerMetrics().GetApplicationDeadlineMissed()
+	assert.NilError(t, err)
+	assert.Equal(t, current, missed+2, "failed application should miss its deadline")
+
+	// a rejected application never tracked its deadline: nothing is recorded
+	app = newApplicationWithTags("app-4", "default", "root.a", map[string]string{common.AppTagMaxWait: "1h"})
+	assert.NilError(t, app.RejectApplication("rejected"), "application should have been rejected")
+	assert.Assert(t, app.deadlineTimer == nil, "deadline timer should not be set")
+	current, err = metrics.GetSchedulerMetrics().GetApplicationDeadlineMissed()
+	assert.NilError(t, err)
+	assert.Equal(t, current, missed+2, "rejected application should not miss its deadline")
+}
+
+func TestDeadlinePriorityBoost(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var fifo, edf *Queue
+	fifo, err = createManagedQueue(root, "fifo", false, nil)
+	assert.NilError(t, err, "failed to create fifo queue")
+	edf, err = createManagedQueueWithProps(root, "edf", false, nil, map[string]string{configs.ApplicationSortPolicy: policies.EdfSortPolicy.String(), configs.PriorityDeadlineBoost: "3"})
+	assert.NilError(t, err, "failed to create edf queue")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1})
+
+	for _, leaf := range []*Queue{fifo, edf} {
+		// a max wait below the minimum is ignored: the application never gets a boost
+		short := newApplicationWithTags(appID1, "default", leaf.QueuePath, map[string]string{common.AppTagMaxWait: "1ms"})
+		assert.Assert(t, short.GetDeadline().IsZero(), "short max wait should be ignored")
+		assert.Assert(t, short.deadlineTimer == nil, "no deadline timer expected")
+		high := newApplication(appID2, "default", leaf.QueuePath)
+		for _, app := range []*Application{short, high} {
+			app.SetQueue(leaf)
+			leaf.AddApplication(app)
+		}
+		err = short.AddAllocationAsk(newAllocationAskPriority(aKey, appID1, res, 1))
+		assert.NilError(t, err, "ask should have been added to app")
+		err = high.AddAllocationAsk(newAllocationAskPriority(aKey, appID2, res, 10))
+		assert.NilError(t, err, "ask should have been added to app")
+		assert.Equal(t, leaf.sortApplications(false)[0].ApplicationID, appID2, "higher priority app should be first in %s", leaf.QueuePath)
+
+		// a deadline at risk only raises the priority by the boost of an edf queue
+		short.Lock()
+		short.deadlineAtRisk = true
+		short.Unlock()
+		if leaf == edf {
+			assert.Equal(t, short.GetSchedulingPriority(), int32(4))
+		} else {
+			assert.Equal(t, short.GetSchedulingPriority(), int32(1))
+		}
+		assert.Equal(t, leaf.sortApplications(false)[0].ApplicationID, appID2, "higher priority app should be first in %s", leaf.QueuePath)
+	}
+}
+
+func TestMoveToQueue(t *testing.T) {
+	setupUGM()
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	root.submitACL, err = security.NewACL("*", false)
+	assert.NilError(t, err, "ACL create failed")
+	var parent, source, small, target, full *Queue
+	parent, err = createManagedQueue(root, "parent", true, nil)
+	assert.NilError(t, err, "failed to create parent queue")
+	source, err = createManagedQueue(parent, "source", false, nil)
+	assert.NilError(t, err, "failed to create source queue")
+	small, err = createManagedQueue(parent, "small", false, map[string]string{"first": "4"})
+	assert.NilError(t, err, "failed to create small queue")
+	full, err = createManagedQueueMaxApps(root, "full", false, nil, 1)
+	assert.NilError(t, err, "failed to create full queue")
+	full.runningApps = 1
+	target, err = createManagedQueue(root, "target", false, nil)
+	assert.NilError(t, err, "failed to create target queue")
+
+	app := newApplication(appID1, "default", "root.parent.source")
+	app.SetQueue(source)
+	source.AddApplication(app)
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	err = app.AddAllocationAsk(newAllocationAskPriority(aKey, appID1, res, 3))
+	assert.NilError(t, err, "ask should have been added to app")
+	app.AddAllocation(newAllocation(appID1, nodeID1, res))
+	source.IncAllocatedResource(res)
+	assert.Assert(t, app.IsRunning(), "application should be running")
+
+	// checks that fail do not change anything
+	err = app.MoveToQueue(source)
+	assert.ErrorContains(t, err, "already in queue")
+	err = app.MoveToQueue(parent)
+	assert.ErrorContains(t, err, "not a leaf queue")
+	err = app.MoveToQueue(small)
+	assert.ErrorContains(t, err, "do not fit in the max resources of queue root.parent.small")
+	err = app.MoveToQueue(full)
+	assert.ErrorContains(t, err, "maximum number of running applications")
+	target.submitACL = security.ACL{}
+	root.submitACL = security.ACL{}
+	err = app.MoveToQueue(target)
+	assert.ErrorContains(t, err, "no submit access")
+	root.submitACL, err = security.NewACL("*", false)
+	assert.NilError(t, err, "ACL create failed")
+	assert.Equal(t, app.GetQueuePath(), "root.parent.source")
+	assert.Assert(t, resources.Equals(source.GetAllocatedResource(), res), "source allocation should not change")
+	assert.Equal(t, source.runningApps, uint64(1))
+
+	err = app.MoveToQueue(target)
+	assert.NilError(t, err, "move should have succeeded")
+	assert.Equal(t, app.GetQueuePath(), "root.target")
+	assert.Equal(t, app.GetQueue(), target)
+	assert.Assert(t, source.GetApplication(appID1) == nil, "application should be removed from the source queue")
+	assert.Assert(t, target.GetApplication(appID1) != nil, "application should be added to the target queue")
+	for _, queue := range []*Queue{source, parent} {
+		assert.Assert(t, resources.IsZero(queue.GetAllocatedResource()), "allocation should be removed from %s", queue.QueuePath)
+		assert.Assert(t, resources.IsZero(queue.GetPendingResource()), "pending should be removed from %s", queue.QueuePath)
+		assert.Equal(t, queue.runningApps, uint64(0), "running apps should be removed from %s", queue.QueuePath)
+		assert.Equal(t, queue.GetCurrentPriority(), configs.MinPriority, "priority should be removed from %s", queue.QueuePath)
+	}
+	for _, queue := range []*Queue{target, root} {
+		assert.Assert(t, resources.Equals(queue.GetAllocatedResource(), res), "allocation should be tracked in %s", queue.QueuePath)
+		assert.Assert(t, resources.Equals(queue.GetPendingResource(), res), "pending should be tracked in %s", queue.QueuePath)
+		assert.Equal(t, queue.GetCurrentPriority(), int32(3), "priority should be tracked in %s", queue.QueuePath)
+	}
+	assert.Equal(t, target.runningApps, uint64(1))
+	assert.Equal(t, root.runningApps, uint64(1), "running apps of the common parent should not change")
+	// user usage moved to the target queue
+	assertUserGroupResource(t, getTestUserGroup(), res)
+	usage := ugm.GetUserManager().GetUserTracker("testuser").GetResourceUsageDAOInfo().Queues
+	for _, child := range usage.Children {
+		if child.QueuePath == "root.target" {
+			assert.DeepEqual(t, child.ResourceUsage, res.DAOMap())
+			assert.DeepEqual(t, child.RunningApplications, []string{appID1})
+		} else {
+			assert.Equal(t, len(child.RunningApplications), 0, "application should not be tracked in %s", child.QueuePath)
+		}
+	}
+}
+
+func TestGetMaxRuntime(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "a", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	app := newApplication(appID1, "default", "root.a")
+	app.queue = leaf
+	assert.Equal(t, app.getMaxRuntime(), time.Duration(0), "no limit expected")
+	root.maxRuntime = 2 * time.Hour
+	assert.Equal(t, app.getMaxRuntime(), 2*time.Hour, "limit of the parent should be used")
+	leaf.maxRuntime = 3 * time.Hour
+	assert.Equal(t, app.getMaxRuntime(), 2*time.Hour, "lowest limit of the queues should be used")
+	app.tags = map[string]string{common.AppTagMaxRuntime: "1h"}
+	assert.Equal(t, app.getMaxRuntime(), time.Hour, "tag lower than the queue should be used")
+	app.tags = map[string]string{common.AppTagMaxRuntime: "5h"}
+	assert.Equal(t, app.getMaxRuntime(), 2*time.Hour, "tag cannot raise the queue limit")
+	app.tags = map[string]string{common.AppTagMaxRuntime: "forever"}
+	assert.Equal(t, app.getMaxRuntime(), 2*time.Hour, "invalid tag should be ignored")
+
+	assert.Equal(t, getRuntimeWarningWindow(10*time.Minute), time.Minute)
+	assert.Equal(t, getRuntimeWarningWindow(10*time.Hour), maxRuntimeWarningWindow)
+}
+
+func TestMaxRuntime(t *testing.T) {
+	setupUGM()
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "a", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	// warning window is 20ms: warning after 180ms
+	app, testHandler := newApplicationWithHandler(appID1, "default", "root.a")
+	app.tags = map[string]string{common.AppTagMaxRuntime: "200ms"}
+	app.queue = leaf
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	err = app.AddAllocationAsk(newAllocationAsk(aKey, appID1, res))
+	assert.NilError(t, err, "ask should have been added to app")
+	assert.Assert(t, app.runtimeTimer == nil, "runtime should not be tracked before running")
+	app.AddAllocation(newAllocation(appID1, nodeID1, res))
+	assert.Assert(t, app.IsRunning(), "application should be running")
+	// the reservation of the pending request is handed back to the partition
+	err = app.Reserve(newNode(nodeID2, map[string]resources.Quantity{"first": 10}), app.GetAllocationAsk(aKey))
+	assert.NilError(t, err, "reservation should have been added")
+	removed := make(chan int, 1)
+	app.SetReservationsCallback(func(num int) {
+		removed <- num
+	})
+	app.RLock()
+	assert.Equal(t, app.maxRuntime, 200*time.Millisecond)
+	assert.Assert(t, app.runtimeTimer != nil, "runtime timer should be set")
+	app.RUnlock()
+
+	err = common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return app.IsFailing()
+	})
+	assert.NilError(t, err, "application did not fail after the max runtime")
+	app.RLock()
+	assert.Assert(t, app.runtimeTimer == nil, "runtime timer should be cleared")
+	app.RUnlock()
+	assert.Assert(t, resources.IsZero(app.GetPendingResource()), "pending requests should be removed")
+	assert.Assert(t, !app.HasReserved(), "reservation should be removed")
+	select {
+	case num := <-removed:
+		assert.Equal(t, num, 1, "removed reservation should be passed on")
+	case <-time.After(time.Second):
+		t.Fatal("removed reservation was not passed on")
+	}
+	// first the allocation then the pending request is released
+	var released []*si.AllocationRelease
+	for _, event := range testHandler.GetEvents() {
+		if allocRelease, ok := event.(*rmevent.RMReleaseAllocationEvent); ok {
+			released = append(released, allocRelease.ReleasedAllocations...)
+		}
+	}
+	assert.Equal(t, len(released), 2, "allocation and request should be released")
+	for _, release := range released {
+		assert.Equal(t, release.TerminationType, si.TerminationType_TIMEOUT)
+		assert.Equal(t, release.Message, MaxRuntimeExceeded)
+	}
+	assert.Equal(t, released[1].AllocationKey, aKey)
+
+	// removing the app clears the timer
+	app = newApplication(appID2, "default", "root.a")
+	app.tags = map[string]string{common.AppTagMaxRuntime: "1h"}
+	app.queue = leaf
+	app.SetState(Accepted.String())
+	err = app.HandleApplicationEvent(RunApplication)
+	assert.NilError(t, err, "application should be running")
+	assert.Assert(t, app.runtimeTimer != nil, "runtime timer should be set")
+	app.RemoveAllAllocations()
+	assert.Assert(t, app.runtimeTimer == nil, "runtime timer should be cleared")
+}
+
+func TestSuspendResume(t *testing.T) {
+	setupUGM()
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "a", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	leaf.maxRunningApps = 1
+	app, testHandler := newApplicationWithHandler(appID1, "default", "root.a")
+	app.queue = leaf
+	leaf.AddApplication(app)
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+
+	// a new application cannot be suspended
+	_, err = app.Suspend()
+	assert.ErrorContains(t, err, "cannot be suspended")
+	err = app.AddAllocationAsk(newAllocationAsk(aKey, appID1, res))
+	assert.NilError(t, err, "ask should have been added to app")
+	alloc := newAllocation(appID1, nodeID1, res)
+	app.AddAllocation(alloc)
+	assert.Assert(t, app.IsRunning(), "application should be running")
+	assert.Assert(t, !leaf.canRunApp(appID2), "running limit should be reached")
+
+	var reservations int
+	reservations, err = app.Suspend()
+	assert.NilError(t, err, "application should have been suspended")
+	assert.Equal(t, reservations, 0)
+	assert.Assert(t, app.IsSuspended(), "application should be suspended")
+	assert.Assert(t, leaf.canRunApp(appID2), "suspended application should not count as running")
+	assert.Assert(t, resources.Equals(app.GetPendingResource(), res), "pending request should be kept")
+	assert.Assert(t, alloc.IsReleased(), "allocation should be marked for release")
+	var released []*si.AllocationRelease
+	for _, event := range testHandler.GetEvents() {
+		if allocRelease, ok := event.(*rmevent.RMReleaseAllocationEvent); ok {
+			released = append(released, allocRelease.ReleasedAllocations...)
+		}
+	}
+	assert.Equal(t, len(released), 1, "only the allocation should be released")
+	assert.Equal(t, released[0].AllocationKey, alloc.GetAllocationKey())
+	assert.Equal(t, released[0].TerminationType, si.TerminationType_PREEMPTED_BY_SCHEDULER)
+	assert.Equal(t, released[0].Message, ApplicationSuspended)
+	_, err = app.Suspend()
+	assert.ErrorContains(t, err, "cannot be suspended")
+
+	// removing the last allocation does not complete the suspended application
+	app.RemoveAllocation(alloc.GetAllocationKey(), si.TerminationType_PREEMPTED_BY_SCHEDULER)
+	assert.Assert(t, app.IsSuspended(), "application should still be suspended: %s", app.CurrentState())
+
+	submitted := app.SubmissionTime
+	assert.NilError(t, app.Resume(), "application should have been resumed")
+	assert.Assert(t, app.IsAccepted(), "application should be accepted: %s", app.CurrentState())
+	assert.Equal(t, app.SubmissionTime, submitted, "submission time should not change")
+	assert.ErrorContains(t, app.Resume(), "is not suspended")
+}
+
+func TestSuspendedTerminate(t *testing.T) {
+	setupUGM()
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "a", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	queueMetrics := metrics.GetQueueMetrics("root.a")
+	suspended, err := queueMetrics.GetQueueApplicationsSuspended()
+	assert.NilError(t, err, "failed to get suspended metric")
+	suspendApp := func(app *Application) {
+		app.queue = leaf
+		leaf.AddApplication(app)
+		assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(aKey, app.ApplicationID, res)), "ask should have been added to app")
+		app.AddAllocation(newAllocation(app.ApplicationID, nodeID1, res))
+		_, err = app.Suspend()
+		assert.NilError(t, err, "application should have been suspended")
+		var count int
+		count, err = queueMetrics.GetQueueApplicationsSuspended()
+		assert.NilError(t, err, "failed to get suspended metric")
+		assert.Equal(t, count, suspended+1, "suspended application should be counted")
+	}
+	assertNotSuspended := func() {
+		count, err := queueMetrics.GetQueueApplicationsSuspended()
+		assert.NilError(t, err, "failed to get suspended metric")
+		assert.Equal(t, count, suspended, "terminated application should not be counted as suspended")
+	}
+
+	// removal by the RM completes the suspended application
+	app := newApplication(appID1, "default", "root.a")
+	suspendApp(app)
+	app.RemoveAllocationAsk("")
+	assert.Assert(t, app.IsSuspended(), "removing the asks should not complete the application")
+	app.RemoveAllAllocations()
+	assert.Assert(t, app.IsCompleting(), "application should be completing: %s", app.CurrentState())
+	assertNotSuspended()
+
+	// the max runtime fails the suspended application
+	app = newApplicationWithTags(appID2, "default", "root.a", map[string]string{common.AppTagMaxRuntime: "1h"})
+	suspendApp(app)
+	app.maxRuntimeReached()
+	assert.Assert(t, app.IsFailing(), "application should be failing: %s", app.CurrentState())
+	assertNotSuspended()
+
+	// a failed dependency fails the suspended application
+	app = newApplication(appID3, "default", "root.a")
+	suspendApp(app)
+	app.dependencies = []string{"app-4"}
+	app.FailDependency("app-4")
+	assert.Assert(t, app.IsFailed(), "application should have failed: %s", app.CurrentState())
+	assertNotSuspended()
+}
+
+func TestExpireAsks(t *testing.T) {
+	setupUGM()
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueueWithProps(root, "a", false, nil, map[string]string{configs.AskTTL: "10m"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, leaf.getAskTTL(), 10*time.Minute)
+	app, testHandler := newApplicationWithHandler(appID1, "default", "root.a")
+	app.queue = leaf
+	leaf.AddApplication(app)
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	err = app.AddAllocationAsk(newAllocationAsk(aKey, appID1, res))
+	assert.NilError(t, err, "ask should have been added to app")
+	// the ask tag overrides the queue default
+	long := NewAllocationFromSI(&si.Allocation{
+		AllocationKey:    aKey2,
+		ApplicationID:    appID1,
+		ResourcePerAlloc: res.ToProto(),
+		AllocationTags:   map[string]string{common.AskTagTTL: "1h"},
+	})
+	assert.NilError(t, app.AddAllocationAsk(long), "ask should have been added to app")
+	// placeholders have their own timeout
+	err = app.AddAllocationAsk(newAllocationAskTG(aKey3, appID1, "tg", res))
+	assert.NilError(t, err, "placeholder ask should have been added to app")
+	now := time.Now()
+
+	expired, reservations := app.ExpireAsks(now)
+	assert.Equal(t, expired, 0, "no asks should expire before the ttl")
+	assert.Equal(t, reservations, 0)
+	expired, _ = app.ExpireAsks(now.Add(20 * time.Minute))
+	assert.Equal(t, expired, 1, "ask using the queue default should expire")
+	assert.Assert(t, app.GetAllocationAsk(aKey) == nil, "expired ask should be removed")
+	assert.Assert(t, app.GetAllocationAsk(aKey2) != nil, "ask with a longer ttl should not be removed")
+	assert.Assert(t, app.GetAllocationAsk(aKey3) != nil, "placeholder ask should not be removed")
+	assert.Assert(t, resources.Equals(app.GetPendingResource(), resources.Multiply(res, 2)), "pending resources should be updated")
+	var rejected []*si.RejectedAllocation
+	for _, event := range testHandler.GetEvents() {
+		if allocReject, ok := event.(*rmevent.RMRejectedAllocationEvent); ok {
+			rejected = append(rejected, allocReject.RejectedAllocations...)
+		}
+	}
+	assert.Equal(t, len(rejected), 1, "RM should be notified of the expired ask")
+	assert.Equal(t, rejected[0].AllocationKey, aKey)
+	assert.Equal(t, rejected[0].ApplicationID, appID1)
+	assert.Equal(t, rejected[0].Reason, AskExpired)
+
+	expired, _ = app.ExpireAsks(now.Add(2 * time.Hour))
+	assert.Equal(t, expired, 1, "ask using the tag should expire")
+	assert.Assert(t, app.GetAllocationAsk(aKey2) == nil, "expired ask should be removed")
+
+	// no ttl set: nothing expires
+	leaf.properties = map[string]string{}
+	leaf.UpdateQueueProperties()
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(aKey, appID1, res)), "ask should have been added to app")
+	expired, _ = app.ExpireAsks(now.Add(24 * time.Hour))
+	assert.Equal(t, expired, 0, "asks should not expire without a ttl")
+}
+
+func TestAffinityIndex(t *testing.T) {
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1})
+	total := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 10})
+	nodes := map[string]*Node{
+		nodeID1:  NewNode(newProto(nodeID1, total, map[string]string{"zone": "a"})),
+		nodeID2:  NewNode(newProto(nodeID2, total, map[string]string{"zone": "b"})),
+		"node-3": NewNode(newProto("node-3", total, map[string]string{"zone": "a"})),
+		"node-4": NewNode(newProto("node-4", total, nil)),
+	}
+	newAlloc := func(key, nodeID, taskGroup string, tags map[string]string) *Allocation {
+		return NewAllocationFromSI(&si.Allocation{
+			AllocationKey:    key,
+			ApplicationID:    appID1,
+			NodeID:           nodeID,
+			ResourcePerAlloc: res.ToProto(),
+			TaskGroupName:    taskGroup,
+			AllocationTags:   tags,
+		})
+	}
+	app := newApplication(appID1, "default", "root.a")
+	lookups := 0
+	app.SetNodeLookup(func(nodeID string) *Node {
+		lookups++
+		return nodes[nodeID]
+	})
+	check := func(node *Node, ask *Allocation) error {
+		return app.newAffinityIndex(ask).check(node)
+	}
+	assert.Assert(t, app.newAffinityIndex(newAlloc("none", "", "", nil)) == nil, "no index without affinity rules")
+
+	// affinity without matching allocations allows all nodes
+	coLocate := newAlloc("colocate", "", "", map[string]string{common.AskTagAffinity: "taskgroup=tg-x"})
+	spread := newAlloc("spread", "", "", map[string]string{"role": "kafka", common.AskTagAntiAffinity: "role=kafka"})
+	for _, node := range nodes {
+		assert.NilError(t, check(node, coLocate))
+		assert.NilError(t, check(node, spread))
+	}
+
+	kafka := newAlloc("kafka-1", nodeID1, "", map[string]string{"role": "kafka"})
+	app.allocations[kafka.GetAllocationKey()] = kafka
+	group := newAlloc("group-1", nodeID2, "tg-x", nil)
+	app.allocations[group.GetAllocationKey()] = group
+	assert.Equal(t, check(nodes[nodeID1], spread), errAntiAffinityNotMet)
+	assert.NilError(t, check(nodes[nodeID2], spread))
+	assert.NilError(t, check(nodes["node-3"], spread))
+	assert.NilError(t, check(nodes[nodeID2], coLocate))
+	assert.Equal(t, check(nodes[nodeID1], coLocate), errAffinityNotMet)
+	assert.Assert(t, isAffinityError(check(nodes[nodeID1], coLocate)), "affinity error expected")
+
+	// the topology key extends the domain to all nodes with the same attribute value
+	spreadZone := newAlloc("spread-zone", "", "", map[string]string{common.AskTagAntiAffinity: "role=kafka", common.AskTagTopologyKey: "zone"})
+	assert.Equal(t, check(nodes["node-3"], spreadZone), errAntiAffinityNotMet)
+	assert.NilError(t, check(nodes[nodeID2], spreadZone))
+	assert.NilError(t, check(nodes["node-4"], spreadZone), "node without the attribute is not in a domain")
+	// the domain of a node is looked up once per index
+	lookups = 0
+	index := app.newAffinityIndex(spreadZone)
+	for _, node := range nodes {
+		_ = index.check(node) //nolint:errcheck
+	}
+	assert.Equal(t, lookups, 1, "only the node of the matching allocation should be looked up")
+	coLocateZone := newAlloc("colocate-zone", "", "", map[string]string{common.AskTagAffinity: "role=kafka", common.AskTagTopologyKey: "zone"})
+	assert.NilError(t, check(nodes["node-3"], coLocateZone))
+	assert.Equal(t, check(nodes["node-4"], coLocateZone), errAffinityNotMet)
+
+	// tentatively placed asks are checked, released allocations are not
+	index = app.newAffinityIndex(spread)
+	index.add(newAlloc("kafka-2", "", "", map[string]string{"role": "kafka"}), nodeID2)
+	assert.Equal(t, index.check(nodes[nodeID2]), errAntiAffinityNotMet)
+	kafka.SetReleased(true)
+	assert.NilError(t, check(nodes[nodeID1], spread))
+}
+
+func TestPriorityAging(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueueWithProps(root, "a", false, nil, map[string]string{configs.PriorityAgingInterval: "10m", configs.PriorityAgingMax: "3"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	app := newApplication(appID1, "default", "root.a")
+	leaf.AddApplication(app)
+	app.queue = leaf
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	err = app.AddAllocationAsk(newAllocationAskPriority(aKey, appID1, res, 5))
+	assert.NilError(t, err, "ask should have been added to app")
+	err = app.AddAllocationAsk(newAllocationAskPriority(aKey2, appID1, res, 1))
+	assert.NilError(t, err, "ask should have been added to app")
+	assert.Equal(t, app.GetSchedulingPriority(), int32(5), "no aging expected without waiting")
+
+	// waited for 25 minutes: two intervals, the aging only changes when refreshed
+	now := time.Now()
+	app.pendingSince = now.Add(-25 * time.Minute)
+	assert.Equal(t, app.GetSchedulingPriority(), int32(5), "aging should only change when refreshed")
+	assert.Equal(t, app.refreshAgedPriority(now), int32(7))
+	assert.Equal(t, app.GetSchedulingPriority(), int32(7))
+	assert.Equal(t, app.GetAskMaxPriority(), int32(5), "ask priority should not change")
+	assert.Equal(t, leaf.GetCurrentPriority(), int32(5), "queue priority should only change when refreshed")
+	leaf.sortApplications(false)
+	assert.Equal(t, leaf.GetCurrentPriority(), int32(7), "queue priority should include aging")
+	assert.Equal(t, root.GetCurrentPriority(), int32(7), "root priority should include aging")
+
+	// the increase is capped
+	assert.Equal(t, app.refreshAgedPriority(now.Add(5*time.Hour)), int32(8))
+	assert.Equal(t, app.GetSchedulingPriority(), int32(8))
+
+	// an allocation resets the wait
+	_, err = app.AllocateAsk(aKey)
+	assert.NilError(t, err, "ask should have been allocated")
+	assert.Equal(t, app.GetSchedulingPriority(), int32(1))
+	assert.Equal(t, app.refreshAgedPriority(time.Now()), int32(1), "aging should restart after an allocation")
+	assert.Assert(t, !app.pendingSince.IsZero(), "app still has pending requests")
+	// nothing pending: no wait
+	_, err = app.AllocateAsk(aKey2)
+	assert.NilError(t, err, "ask should have been allocated")
+	assert.Assert(t, app.pendingSince.IsZero(), "app has no pending requests")
+
+	// disabled aging
+	_, err = app.DeallocateAsk(aKey)
+	assert.NilError(t, err, "ask should have been deallocated")
+	leaf.properties = map[string]string{}
+	leaf.UpdateQueueProperties()
+	app.pendingSince = time.Now().Add(-5 * time.Hour)
+	assert.Equal(t, app.refreshAgedPriority(time.Now()), int32(5))
+	assert.Equal(t, app.GetSchedulingPriority(), int32(5))
+}
+
+func TestApplicationDependencies(t *testing.T) {
+	assert.Assert(t, getDependencies(appID1, nil) == nil, "no dependencies expected without tag")
+	assert.DeepEqual(t, getDependencies(appID1, map[string]string{common.AppTagDependencies: " app-2, app-3,,app-2, app-1 "}), []string{"app-2", "app-3"})
+
+	setupUGM()
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "a", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	app := newApplicationWithTags(appID1, "default", "root.a", map[string]string{common.AppTagDependencies: "app-2,app-3"})
+	app.queue = leaf
+	assert.Assert(t, app.isWaitingOnDependencies(), "application should wait on dependencies")
+	app.ResolveDependency("unknown")
+	app.ResolveDependency("app-2")
+	assert.DeepEqual(t, app.GetDependencies(), []string{"app-3"})
+	assert.Assert(t, app.isWaitingOnDependencies(), "application should still wait on a dependency")
+	app.ResolveDependency("app-3")
+	assert.Assert(t, !app.isWaitingOnDependencies(), "application should not wait on dependencies")
+	app.FailDependency("app-3")
+	assert.Assert(t, app.IsNew(), "resolved dependency should not fail the application")
+
+	// a failed dependency releases the pending requests and fails the application
+	var testHandler *rmproxy.MockedRMProxy
+	app, testHandler = newApplicationWithHandler(appID2, "default", "root.a")
+	app.dependencies = []string{"app-3"}
+	app.queue = leaf
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	err = app.AddAllocationAsk(newAllocationAsk(aKey, appID2, res))
+	assert.NilError(t, err, "ask should have been added to app")
+	assert.Assert(t, app.IsAccepted(), "application should be accepted")
+	app.FailDependency("app-3")
+	assert.Assert(t, app.IsFailed(), "application should have failed, state: %s", app.CurrentState())
+	assert.Assert(t, resources.IsZero(app.GetPendingResource()), "pending requests should be removed")
+	assert.Assert(t, app.GetDependencies() == nil, "failed application should not have dependencies")
+	var released []*si.AllocationRelease
+	for _, event := range testHandler.GetEvents() {
+		if allocRelease, ok := event.(*rmevent.RMReleaseAllocationEvent); ok {
+			released = append(released, allocRelease.ReleasedAllocations...)
+		}
+	}
+	assert.Equal(t, len(released), 1, "pending request should be released")
+	assert.Equal(t, released[0].AllocationKey, aKey)
+	assert.Equal(t, released[0].Message, DependencyFailed)
+}
+
+func TestGetGangMinMembers(t *testing.T) {
+	assert.Equal(t, getGangMinMembers(appID1, nil, nil), 0, "no gang expected without tag")
+	assert.Equal(t, getGangMinMembers(appID1, map[string]string{common.AppTagGangMinMembers: "3"}, nil), 3)
+	assert.Equal(t, getGangMinMembers(appID1, map[string]string{common.AppTagGangMinMembers: "0"}, nil), 0, "zero should be ignored")
+	assert.Equal(t, getGangMinMembers(appID1, map[string]string{common.AppTagGangMinMembers: "x"}, nil), 0, "invalid value should be ignored")
+	placeholderAsk := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1})
+	assert.Equal(t, getGangMinMembers(appID1, map[string]string{common.AppTagGangMinMembers: "3"}, placeholderAsk), 0, "task groups should disable the gang")
+}
+
+func TestTryGangAllocate(t *testing.T) {
+	setupUGM()
+	node1 := newNode(nodeID1, map[string]resources.Quantity{"first": 5})
+	node2 := newNode(nodeID2, map[string]resources.Quantity{"first": 5})
+	nodeMap := map[string]*Node{nodeID1: node1, nodeID2: node2}
+	iterator := getNodeIteratorFn(node1, node2)
+	getNode := func(nodeID string) *Node {
+		return nodeMap[nodeID]
+	}
+	root, err := createRootQueue(map[string]string{"first": "10"})
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "leaf", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	app := newApplicationWithTags(appID1, "default", "root.leaf", map[string]string{common.AppTagGangMinMembers: "3"})
+	app.SetQueue(leaf)
+	leaf.AddApplication(app)
+	headRoom := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 10})
+	preemptionAttemptsRemaining := 0
+
+	// nothing is allocated until all members are submitted
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 3})
+	for _, key := range []string{"alloc-1", "alloc-2"} {
+		assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(key, appID1, res)), "ask should have been added to app")
+	}
+	result := app.tryAllocate(headRoom, false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result == nil, "incomplete gang should not be allocated")
+
+	// three members of 3 do not fit on two nodes of 5: the placement is rolled back
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk("alloc-3", appID1, res)), "ask should have been added to app")
+	result = app.tryAllocate(headRoom, false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result == nil, "gang that does not fit should not be allocated")
+	assert.Assert(t, resources.IsZero(node1.GetAllocatedResource()) && resources.IsZero(node2.GetAllocatedResource()), "nothing should be allocated on the nodes")
+	assert.Assert(t, resources.IsZero(app.GetAllocatedResource()), "nothing should be allocated for the app")
+	assert.Assert(t, len(app.GetReservations()) == 0, "gang members should not be reserved")
+
+	// a node that fits the remaining member but is reserved for another application is not used
+	node3 := newNode("node-3", map[string]resources.Quantity{"first": 5})
+	nodeMap["node-3"] = node3
+	iterator = getNodeIteratorFn(node1, node2, node3)
+	app2 := newApplication(appID2, "default", "root.leaf")
+	reservedAsk := newAllocationAsk("alloc-reserved", appID2, res)
+	assert.NilError(t, node3.Reserve(app2, reservedAsk), "node should have been reserved")
+	result = app.tryAllocate(headRoom, false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result == nil, "gang should not be placed on a node reserved for another application")
+	assert.Assert(t, resources.IsZero(node3.GetAllocatedResource()), "nothing should be allocated on the reserved node")
+
+	// all members are allocated in one pass once the reservation is removed
+	assert.Equal(t, node3.unReserve(reservedAsk), 1, "reservation should have been removed")
+	result = app.tryAllocate(headRoom, false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result != nil && result.ResultType == Allocated, "gang should be allocated")
+	assert.Equal(t, len(result.Members), 2, "all members should be allocated together")
+	nodes := map[string]bool{result.NodeID: true}
+	for _, member := range result.Members {
+		assert.Equal(t, member.ResultType, Allocated)
+		nodes[member.NodeID] = true
+	}
+	assert.Equal(t, len(nodes), 3, "each member should be on a different node")
+	assert.Assert(t, resources.Equals(app.GetAllocatedResource(), resources.Multiply(res, 3)))
+	assert.Assert(t, resources.Equals(leaf.GetAllocatedResource(), resources.Multiply(res, 3)))
+	assert.Assert(t, app.IsRunning(), "application should be running")
+
+	// after the gang is allocated the requests are allocated one by one
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk("alloc-4", appID1, resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1}))), "ask should have been added to app")
+	result = app.tryAllocate(headRoom, false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result != nil && result.ResultType == Allocated, "request should be allocated")
+	assert.Equal(t, len(result.Members), 0, "single allocation expected")
+}
@@ -20,6 +20,8 @@ package events
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
@@ -143,6 +145,87 @@ func (ae *ApplicationEvents) SendAppRunnableQuotaEvent(appID string) {
 	ae.eventSystem.AddEvent(event)
 }
 
+func (ae *ApplicationEvents) SendDeadlineMissedEvent(appID string, deadline time.Time, pending *resources.Resource) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' missed its deadline '%s' with pending resources '%s'", appID, deadline.Format(time.RFC3339), pending)
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_NONE, si.EventRecord_DETAILS_NONE, pending)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendMaxRuntimeWarningEvent(appID string, maxRuntime time.Duration, terminateAt time.Time) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' will reach its max runtime '%s' at '%s', all allocations will be released", appID, maxRuntime, terminateAt.Format(time.RFC3339))
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_NONE, si.EventRecord_DETAILS_NONE, resources.NewResource())
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendMaxRuntimeExceededEvent(appID string, maxRuntime time.Duration, allocated *resources.Resource) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' exceeded its max runtime '%s', releasing allocated resources '%s'", appID, maxRuntime, allocated)
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_NONE, si.EventRecord_DETAILS_NONE, allocated)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendApplicationMovedEvent(appID, fromQueue, toQueue string) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' moved from queue '%s' to queue '%s'", appID, fromQueue, toQueue)
+	event := events.CreateAppEventRecord(appID, message, toQueue, si.EventRecord_SET, si.EventRecord_DETAILS_NONE, nil)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendAppWaitingOnDependenciesEvent(appID string, dependencies []string) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' is waiting for applications '%s' to complete", appID, strings.Join(dependencies, common.Separator))
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_NONE, si.EventRecord_DETAILS_NONE, nil)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendAppDependenciesResolvedEvent(appID string) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' is runnable: all applications it depends on have completed", appID)
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_NONE, si.EventRecord_DETAILS_NONE, nil)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendAppDependencyFailedEvent(appID, dependency string) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' failed: application '%s' it depends on did not complete", appID, dependency)
+	event := events.CreateAppEventRecord(appID, message, dependency, si.EventRecord_NONE, si.EventRecord_DETAILS_NONE, nil)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendAppSuspendedEvent(appID string, released *resources.Resource) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' suspended, releasing allocated resources '%s'", appID, released)
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_NONE, si.EventRecord_DETAILS_NONE, released)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendAppUnsuspendedEvent(appID string) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Application '%s' resumed after suspension", appID)
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_NONE, si.EventRecord_DETAILS_NONE, nil)
+	ae.eventSystem.AddEvent(event)
+}
+
 func NewApplicationEvents(es events.EventSystem) *ApplicationEvents {
 	return &ApplicationEvents{
 		eventSystem: es,
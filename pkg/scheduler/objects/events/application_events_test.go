@@ -20,6 +20,7 @@ package events
 
 import (
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -337,3 +338,114 @@ func TestSendAppRunnableByQuotaEvent(t *testing.T) {
 	assert.Equal(t, "", event.ReferenceID)
 	assert.Equal(t, "", event.Message)
 }
+
+func TestSendDeadlineMissedEvent(t *testing.T) {
+	deadline := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})
+	eventSystem := mock.NewEventSystemDisabled()
+	appEvents := NewApplicationEvents(eventSystem)
+	appEvents.SendDeadlineMissedEvent(appID, deadline, pending)
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	appEvents = NewApplicationEvents(eventSystem)
+	appEvents.SendDeadlineMissedEvent(appID, deadline, pending)
+	assert.Equal(t, 1, len(eventSystem.Events), "event was not generated")
+	assert.Equal(t, si.EventRecord_APP, eventSystem.Events[0].Type, "event type is not expected")
+	assert.Equal(t, si.EventRecord_NONE, eventSystem.Events[0].EventChangeType, "event change type is not expected")
+	assert.Equal(t, si.EventRecord_DETAILS_NONE, eventSystem.Events[0].EventChangeDetail, "event change detail is not expected")
+	assert.Equal(t, appID, eventSystem.Events[0].ObjectID, "event object id is not expected")
+	assert.Equal(t, "Application 'app-0' missed its deadline '2024-01-02T03:04:05Z' with pending resources 'map[memory:10]'", eventSystem.Events[0].Message, "message is not expected")
+}
+
+func TestSendMaxRuntimeEvents(t *testing.T) {
+	terminateAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	allocated := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})
+	eventSystem := mock.NewEventSystemDisabled()
+	appEvents := NewApplicationEvents(eventSystem)
+	appEvents.SendMaxRuntimeWarningEvent(appID, time.Hour, terminateAt)
+	appEvents.SendMaxRuntimeExceededEvent(appID, time.Hour, allocated)
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	appEvents = NewApplicationEvents(eventSystem)
+	appEvents.SendMaxRuntimeWarningEvent(appID, time.Hour, terminateAt)
+	appEvents.SendMaxRuntimeExceededEvent(appID, time.Hour, allocated)
+	assert.Equal(t, 2, len(eventSystem.Events), "events were not generated")
+	for _, event := range eventSystem.Events {
+		assert.Equal(t, si.EventRecord_APP, event.Type, "event type is not expected")
+		assert.Equal(t, si.EventRecord_NONE, event.EventChangeType, "event change type is not expected")
+		assert.Equal(t, si.EventRecord_DETAILS_NONE, event.EventChangeDetail, "event change detail is not expected")
+		assert.Equal(t, appID, event.ObjectID, "event object id is not expected")
+	}
+	assert.Equal(t, "Application 'app-0' will reach its max runtime '1h0m0s' at '2024-01-02T03:04:05Z', all allocations will be released", eventSystem.Events[0].Message, "message is not expected")
+	assert.Equal(t, "Application 'app-0' exceeded its max runtime '1h0m0s', releasing allocated resources 'map[memory:10]'", eventSystem.Events[1].Message, "message is not expected")
+}
+
+func TestSendApplicationMovedEvent(t *testing.T) {
+	eventSystem := mock.NewEventSystemDisabled()
+	appEvents := NewApplicationEvents(eventSystem)
+	appEvents.SendApplicationMovedEvent(appID, "root.a", "root.b")
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	appEvents = NewApplicationEvents(eventSystem)
+	appEvents.SendApplicationMovedEvent(appID, "root.a", "root.b")
+	assert.Equal(t, 1, len(eventSystem.Events), "event was not generated")
+	assert.Equal(t, si.EventRecord_APP, eventSystem.Events[0].Type, "event type is not expected")
+	assert.Equal(t, si.EventRecord_SET, eventSystem.Events[0].EventChangeType, "event change type is not expected")
+	assert.Equal(t, si.EventRecord_DETAILS_NONE, eventSystem.Events[0].EventChangeDetail, "event change detail is not expected")
+	assert.Equal(t, appID, eventSystem.Events[0].ObjectID, "event object id is not expected")
+	assert.Equal(t, "root.b", eventSystem.Events[0].ReferenceID, "event reference id is not expected")
+	assert.Equal(t, "Application 'app-0' moved from queue 'root.a' to queue 'root.b'", eventSystem.Events[0].Message, "message is not expected")
+}
+
+func TestSendAppDependencyEvents(t *testing.T) {
+	eventSystem := mock.NewEventSystemDisabled()
+	appEvents := NewApplicationEvents(eventSystem)
+	appEvents.SendAppWaitingOnDependenciesEvent(appID, []string{"app-1", "app-2"})
+	appEvents.SendAppDependenciesResolvedEvent(appID)
+	appEvents.SendAppDependencyFailedEvent(appID, "app-1")
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	appEvents = NewApplicationEvents(eventSystem)
+	appEvents.SendAppWaitingOnDependenciesEvent(appID, []string{"app-1", "app-2"})
+	appEvents.SendAppDependenciesResolvedEvent(appID)
+	appEvents.SendAppDependencyFailedEvent(appID, "app-1")
+	assert.Equal(t, 3, len(eventSystem.Events), "events were not generated")
+	for _, event := range eventSystem.Events {
+		assert.Equal(t, si.EventRecord_APP, event.Type, "event type is not expected")
+		assert.Equal(t, si.EventRecord_NONE, event.EventChangeType, "event change type is not expected")
+		assert.Equal(t, si.EventRecord_DETAILS_NONE, event.EventChangeDetail, "event change detail is not expected")
+		assert.Equal(t, appID, event.ObjectID, "event object id is not expected")
+	}
+	assert.Equal(t, "Application 'app-0' is waiting for applications 'app-1,app-2' to complete", eventSystem.Events[0].Message, "message is not expected")
+	assert.Equal(t, "Application 'app-0' is runnable: all applications it depends on have completed", eventSystem.Events[1].Message, "message is not expected")
+	assert.Equal(t, "Application 'app-0' failed: application 'app-1' it depends on did not complete", eventSystem.Events[2].Message, "message is not expected")
+	assert.Equal(t, "app-1", eventSystem.Events[2].ReferenceID, "event reference id is not expected")
+}
+
+func TestSendAppSuspendEvents(t *testing.T) {
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})
+	eventSystem := mock.NewEventSystemDisabled()
+	appEvents := NewApplicationEvents(eventSystem)
+	appEvents.SendAppSuspendedEvent(appID, res)
+	appEvents.SendAppUnsuspendedEvent(appID)
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	appEvents = NewApplicationEvents(eventSystem)
+	appEvents.SendAppSuspendedEvent(appID, res)
+	appEvents.SendAppUnsuspendedEvent(appID)
+	assert.Equal(t, 2, len(eventSystem.Events), "events were not generated")
+	for _, event := range eventSystem.Events {
+		assert.Equal(t, si.EventRecord_APP, event.Type, "event type is not expected")
+		assert.Equal(t, si.EventRecord_NONE, event.EventChangeType, "event change type is not expected")
+		assert.Equal(t, si.EventRecord_DETAILS_NONE, event.EventChangeDetail, "event change detail is not expected")
+		assert.Equal(t, appID, event.ObjectID, "event object id is not expected")
+	}
+	assert.Equal(t, "Application 'app-0' suspended, releasing allocated resources 'map[memory:10]'", eventSystem.Events[0].Message, "message is not expected")
+	assert.Equal(t, int64(10), eventSystem.Events[0].Resource.Resources["memory"].Value, "resource is not expected")
+	assert.Equal(t, "Application 'app-0' resumed after suspension", eventSystem.Events[1].Message, "message is not expected")
+}
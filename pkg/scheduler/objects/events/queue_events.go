@@ -104,6 +104,24 @@ func (q *QueueEvents) SendTypeChangedEvent(queuePath string, isLeaf bool) {
 	q.eventSystem.AddEvent(event)
 }
 
+func (q *QueueEvents) SendConfigUpdatedEvent(queuePath, message string) {
+	if !q.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	event := events.CreateQueueEventRecord(queuePath, message, common.Empty, si.EventRecord_SET,
+		si.EventRecord_QUEUE_CONFIG, nil)
+	q.eventSystem.AddEvent(event)
+}
+
+func (q *QueueEvents) SendConfigRejectedEvent(queuePath, message string) {
+	if !q.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	event := events.CreateQueueEventRecord(queuePath, message, common.Empty, si.EventRecord_NONE,
+		si.EventRecord_QUEUE_CONFIG, nil)
+	q.eventSystem.AddEvent(event)
+}
+
 func NewQueueEvents(evt events.EventSystem) *QueueEvents {
 	return &QueueEvents{
 		eventSystem: evt,
@@ -201,3 +201,41 @@ func TestSendGuaranteedResourceChangedEvent(t *testing.T) {
 	protoRes := resources.NewResourceFromProto(event.Resource)
 	assert.DeepEqual(t, guaranteed, protoRes)
 }
+
+func TestSendConfigUpdatedEvent(t *testing.T) {
+	eventSystem := mock.NewEventSystemDisabled()
+	nq := NewQueueEvents(eventSystem)
+	nq.SendConfigUpdatedEvent(testQueuePath, "maxApplications: 10")
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	nq = NewQueueEvents(eventSystem)
+	nq.SendConfigUpdatedEvent(testQueuePath, "maxApplications: 10")
+	assert.Equal(t, 1, len(eventSystem.Events), "event was not generated")
+	event := eventSystem.Events[0]
+	assert.Equal(t, si.EventRecord_QUEUE, event.Type)
+	assert.Equal(t, testQueuePath, event.ObjectID)
+	assert.Equal(t, common.Empty, event.ReferenceID)
+	assert.Equal(t, "maxApplications: 10", event.Message)
+	assert.Equal(t, si.EventRecord_SET, event.EventChangeType)
+	assert.Equal(t, si.EventRecord_QUEUE_CONFIG, event.EventChangeDetail)
+	assert.Equal(t, 0, len(event.Resource.Resources))
+}
+
+func TestSendConfigRejectedEvent(t *testing.T) {
+	eventSystem := mock.NewEventSystemDisabled()
+	nq := NewQueueEvents(eventSystem)
+	nq.SendConfigRejectedEvent(testQueuePath, "invalid config")
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	nq = NewQueueEvents(eventSystem)
+	nq.SendConfigRejectedEvent(testQueuePath, "invalid config")
+	assert.Equal(t, 1, len(eventSystem.Events), "event was not generated")
+	event := eventSystem.Events[0]
+	assert.Equal(t, si.EventRecord_QUEUE, event.Type)
+	assert.Equal(t, testQueuePath, event.ObjectID)
+	assert.Equal(t, "invalid config", event.Message)
+	assert.Equal(t, si.EventRecord_NONE, event.EventChangeType)
+	assert.Equal(t, si.EventRecord_QUEUE_CONFIG, event.EventChangeDetail)
+}
@@ -53,3 +53,41 @@ func NewTreeIterator(accept func(*Node) bool, getTree func() *btree.BTree) *tree
 	}
 	return ti
 }
+
+// filteredIterator skips the nodes that are not accepted by the filter.
+type filteredIterator struct {
+	iterator NodeIterator
+	accept   func(*Node) bool
+}
+
+// ForEachNode Calls the provided "f" function on the nodes of the wrapped iterator that are accepted by the filter.
+func (fi *filteredIterator) ForEachNode(f func(*Node) bool) {
+	fi.iterator.ForEachNode(func(node *Node) bool {
+		if !fi.accept(node) {
+			return true
+		}
+		return f(node)
+	})
+}
+
+// filterNodeIterator wraps the iterator function so that the returned iterators only return accepted nodes.
+func filterNodeIterator(iterator func() NodeIterator, accept func(*Node) bool) func() NodeIterator {
+	return func() NodeIterator {
+		nodes := iterator()
+		if nodes == nil {
+			return nil
+		}
+		return &filteredIterator{iterator: nodes, accept: accept}
+	}
+}
+
+// filterGetNode wraps the node lookup so that nodes which are not accepted are not found.
+func filterGetNode(getNode func(string) *Node, accept func(*Node) bool) func(string) *Node {
+	return func(nodeID string) *Node {
+		node := getNode(nodeID)
+		if node == nil || !accept(node) {
+			return nil
+		}
+		return node
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -69,6 +71,12 @@ type Queue struct {
 	priorityOffset      int32                     // priority offset for this queue relative to others
 	preemptionPolicy    policies.PreemptionPolicy // preemption policy
 	preemptionDelay     time.Duration             // time before preemption is considered
+	weight              float64                   // share of the parent fair max relative to the siblings
+	drfWeights          map[string]float64        // resource weights used by the drf sort policy
+	agingInterval       time.Duration             // pending time for each priority increase of an application, 0 is disabled
+	agingMax            int32                     // maximum priority increase of an application through aging
+	deadlineBoost       int32                     // priority increase of an application with its deadline at risk (edf only)
+	askTTL              time.Duration             // default pending time before a request is removed, 0 is disabled
 	currentPriority     int32                     // the current scheduling priority of this queue
 
 	// The queue properties should be treated as immutable the value is a merge of the
@@ -87,6 +95,11 @@ type Queue struct {
 	runningApps            uint64
 	allocatingAcceptedApps map[string]bool
 	template               *template.Template
+	maxRuntime             time.Duration             // max wall-clock runtime of applications in the queue, zero if not set
+	capacityProfile        string                    // name of the active capacity schedule that overrides the queue capacity
+	nextCapacityTransition time.Time                 // next change of the active capacity schedule, zero if not scheduled
+	nodeSelector           *configs.NodePoolSelector // nodes the queue subtree is restricted to, nil if not restricted
+	nodePoolResource       *resources.Resource       // total capacity of the selected nodes, nil if the queue has no node pool
 	queueEvents            *schedEvt.QueueEvents
 
 	locking.RWMutex
@@ -110,6 +123,9 @@ func newBlankQueue() *Queue {
 		prioritySortEnabled:    true,
 		preemptionDelay:        configs.DefaultPreemptionDelay,
 		preemptionPolicy:       policies.DefaultPreemptionPolicy,
+		weight:                 configs.DefaultQueueWeight,
+		agingMax:               configs.DefaultPriorityAgingMax,
+		deadlineBoost:          configs.DefaultPriorityDeadlineBoost,
 	}
 }
 
@@ -225,6 +241,7 @@ func (sq *Queue) applyTemplate(childTemplate *template.Template) {
 	// the resources in template are already checked
 	sq.guaranteedResource = childTemplate.GetGuaranteedResource()
 	sq.maxResource = childTemplate.GetMaxResource()
+	sq.maxRuntime = childTemplate.GetMaxRuntime()
 	// update metrics for guaranteed and max resource
 	sq.updateGuaranteedResourceMetrics()
 	sq.updateMaxResourceMetrics()
@@ -273,6 +290,82 @@ func preemptionDelay(value string) (time.Duration, error) {
 	return result, nil
 }
 
+func priorityAgingInterval(value string) (time.Duration, error) {
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if result < 0 {
+		return 0, fmt.Errorf("%s must not be negative: %s", configs.PriorityAgingInterval, value)
+	}
+	return result, nil
+}
+
+func askTTL(value string) (time.Duration, error) {
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if result < 0 {
+		return 0, fmt.Errorf("%s must not be negative: %s", configs.AskTTL, value)
+	}
+	return result, nil
+}
+
+func priorityAgingMax(value string) (int32, error) {
+	intValue, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return configs.DefaultPriorityAgingMax, err
+	}
+	if intValue < 0 {
+		return configs.DefaultPriorityAgingMax, fmt.Errorf("%s must not be negative: %s", configs.PriorityAgingMax, value)
+	}
+	return int32(intValue), nil
+}
+
+func priorityDeadlineBoost(value string) (int32, error) {
+	intValue, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return configs.DefaultPriorityDeadlineBoost, err
+	}
+	if intValue < 0 {
+		return configs.DefaultPriorityDeadlineBoost, fmt.Errorf("%s must not be negative: %s", configs.PriorityDeadlineBoost, value)
+	}
+	return int32(intValue), nil
+}
+
+func queueWeight(value string) (float64, error) {
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return configs.DefaultQueueWeight, err
+	}
+	if result <= 0 || math.IsInf(result, 0) || math.IsNaN(result) {
+		return configs.DefaultQueueWeight, fmt.Errorf("%s must be a positive number: %s", configs.QueueWeight, value)
+	}
+	return result, nil
+}
+
+// drfResourceWeights parses the resource weights in the "name=weight,name=weight" format.
+func drfResourceWeights(value string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(value, ",") {
+		name, weight, found := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("%s expects name=weight: %s", configs.DRFResourceWeights, part)
+		}
+		result, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil {
+			return nil, err
+		}
+		if result < 0 || math.IsInf(result, 0) || math.IsNaN(result) {
+			return nil, fmt.Errorf("%s must be zero or a positive number: %s", configs.DRFResourceWeights, part)
+		}
+		weights[name] = result
+	}
+	return weights, nil
+}
+
 func priorityOffset(value string) (int32, error) {
 	intValue, err := strconv.ParseInt(value, 10, 32)
 	if err != nil {
@@ -292,6 +385,47 @@ func applicationSortPriorityEnabled(value string) (bool, error) {
 	}
 }
 
+// CheckQueueProperties validates the values of the properties that are converted into queue policies.
+// Other properties are not checked: they are ignored by the queue. An empty value is not checked.
+func CheckQueueProperties(props map[string]string) error {
+	for key, value := range props {
+		if value == "" {
+			continue
+		}
+		var err error
+		switch key {
+		case configs.ApplicationSortPolicy:
+			_, err = policies.SortPolicyFromString(value)
+		case configs.ApplicationSortPriority:
+			_, err = applicationSortPriorityEnabled(value)
+		case configs.PriorityOffset:
+			_, err = priorityOffset(value)
+		case configs.PriorityPolicy:
+			_, err = policies.PriorityPolicyFromString(value)
+		case configs.PreemptionPolicy:
+			_, err = policies.PreemptionPolicyFromString(value)
+		case configs.PreemptionDelay:
+			_, err = preemptionDelay(value)
+		case configs.QueueWeight:
+			_, err = queueWeight(value)
+		case configs.DRFResourceWeights:
+			_, err = drfResourceWeights(value)
+		case configs.PriorityAgingInterval:
+			_, err = priorityAgingInterval(value)
+		case configs.PriorityAgingMax:
+			_, err = priorityAgingMax(value)
+		case configs.PriorityDeadlineBoost:
+			_, err = priorityDeadlineBoost(value)
+		case configs.AskTTL:
+			_, err = askTTL(value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value for queue property %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
 // filterParentProperty modifies values from parent queues where necessary
 func filterParentProperty(key string, value string) string {
 	switch key {
@@ -301,6 +435,9 @@ func filterParentProperty(key string, value string) string {
 	case configs.PriorityOffset:
 		// priority offsets are not inherited as they are additive
 		return "0"
+	case configs.QueueWeight:
+		// weights are relative to the siblings and not inherited
+		return strconv.FormatFloat(configs.DefaultQueueWeight, 'f', -1, 64)
 	case configs.PreemptionPolicy:
 		// only 'disabled' should be allowed to propagate
 		if pol, err := policies.PreemptionPolicyFromString(value); err != nil || pol != policies.DisabledPreemptionPolicy {
@@ -376,6 +513,16 @@ func (sq *Queue) applyConf(conf configs.QueueConfig, silence bool) error {
 		sq.updateMaxRunningAppsMetrics()
 	}
 
+	sq.maxRuntime = 0
+	if conf.MaxRuntime != "" {
+		if sq.maxRuntime, err = configs.ParseMaxRuntime(conf.MaxRuntime); err != nil {
+			log.Log(log.SchedQueue).Error("parsing failed on max runtime this should not happen",
+				zap.String("queue", sq.QueuePath),
+				zap.Error(err))
+			return err
+		}
+	}
+
 	sq.properties = conf.Properties
 	return nil
 }
@@ -473,6 +620,113 @@ func (sq *Queue) SetMaxRunningApps(maxApps uint64) {
 	sq.updateMaxRunningAppsMetrics()
 }
 
+// UpdateMaxRunningApps changes the maximum running apps on a queue at runtime, a change is recorded as a queue event.
+func (sq *Queue) UpdateMaxRunningApps(maxApps uint64) {
+	sq.Lock()
+	defer sq.Unlock()
+	if sq.maxRunningApps == maxApps {
+		return
+	}
+	log.Log(log.SchedQueue).Info("updating max running apps",
+		zap.String("queue", sq.QueuePath),
+		zap.Uint64("current", sq.maxRunningApps),
+		zap.Uint64("new", maxApps))
+	sq.maxRunningApps = maxApps
+	sq.updateMaxRunningAppsMetrics()
+	if sq.queueEvents != nil {
+		sq.queueEvents.SendConfigUpdatedEvent(sq.QueuePath, fmt.Sprintf("maxApplications: %d", maxApps))
+	}
+}
+
+// UpdateProperties changes the properties of a queue at runtime. The changes are merged into the existing properties,
+// a property with an empty value is removed. The properties are converted into policies and the change is recorded
+// as a queue event.
+func (sq *Queue) UpdateProperties(changes map[string]string) {
+	if len(changes) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	messages := make([]string, 0, len(keys))
+	sq.Lock()
+	props := make(map[string]string, len(sq.properties)+len(changes))
+	for key, value := range sq.properties {
+		props[key] = value
+	}
+	for _, key := range keys {
+		if value := changes[key]; value != "" {
+			props[key] = value
+			messages = append(messages, key+"="+value)
+		} else {
+			delete(props, key)
+			messages = append(messages, key+" removed")
+		}
+	}
+	sq.properties = props
+	sq.Unlock()
+	sq.UpdateQueueProperties()
+	log.Log(log.SchedQueue).Info("updated queue properties",
+		zap.String("queue", sq.QueuePath),
+		zap.Any("changes", changes))
+	if sq.queueEvents != nil {
+		sq.queueEvents.SendConfigUpdatedEvent(sq.QueuePath, "properties: "+strings.Join(messages, ", "))
+	}
+}
+
+// ChangeState moves a managed queue into the requested state using the queue state machine:
+// Active restarts a stopped or draining queue, reversing a drain also for all children.
+// Stopped skips the queue, and all its children, during scheduling.
+// Draining marks the queue and all its children for removal: a drained queue is removed when it is empty.
+// The state change is recorded as a queue event.
+func (sq *Queue) ChangeState(state ObjectState) error {
+	if !sq.IsManaged() {
+		return fmt.Errorf("state of unmanaged queue %s cannot be changed", sq.QueuePath)
+	}
+	current := sq.CurrentState()
+	switch state {
+	case Active:
+		if err := sq.startQueue(); err != nil {
+			return err
+		}
+	case Stopped:
+		sq.Lock()
+		err := sq.handleQueueEvent(Stop)
+		sq.Unlock()
+		if err != nil {
+			return err
+		}
+	case Draining:
+		sq.MarkQueueForRemoval()
+	default:
+		return fmt.Errorf("unknown queue state %d", state)
+	}
+	if current != state.String() && sq.queueEvents != nil {
+		sq.queueEvents.SendConfigUpdatedEvent(sq.QueuePath, "state: "+state.String())
+	}
+	return nil
+}
+
+// startQueue moves the queue back to active, children that were drained together with the queue are started too.
+func (sq *Queue) startQueue() error {
+	sq.Lock()
+	err := sq.handleQueueEvent(Start)
+	sq.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, child := range sq.GetCopyOfChildren() {
+		if child.IsManaged() && child.IsDraining() {
+			if err = child.startQueue(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // setTemplate sets the template on the queue based on the config.
 // lock free call, must be called holding the queue lock or during create only
 func (sq *Queue) setTemplate(conf configs.ChildTemplate) error {
@@ -497,6 +751,14 @@ func (sq *Queue) UpdateQueueProperties() {
 		// set the sorting type for parent queues
 		sq.sortType = policies.FairSortPolicy
 	}
+	// a weight removed from the config must not be kept
+	sq.weight = configs.DefaultQueueWeight
+	sq.drfWeights = nil
+	sq.agingInterval = 0
+	sq.agingMax = configs.DefaultPriorityAgingMax
+	sq.deadlineBoost = configs.DefaultPriorityDeadlineBoost
+	sq.askTTL = 0
+	sq.nodeSelector = nil
 	// walk over all properties and process
 	var err error
 	for key, value := range sq.properties {
@@ -512,6 +774,9 @@ func (sq *Queue) UpdateQueueProperties() {
 				if sq.sortType == policies.Undefined {
 					sq.sortType = policies.FifoSortPolicy
 				}
+			} else if value == policies.DrfSortPolicy.String() {
+				// parent queues only support drf as an alternative to fair
+				sq.sortType = policies.DrfSortPolicy
 			}
 		case configs.ApplicationSortPriority:
 			sq.prioritySortEnabled, err = applicationSortPriorityEnabled(value)
@@ -537,6 +802,49 @@ func (sq *Queue) UpdateQueueProperties() {
 				log.Log(log.SchedQueue).Debug("queue preemption policy configuration error",
 					zap.Error(err))
 			}
+		case configs.PriorityAgingInterval:
+			sq.agingInterval, err = priorityAgingInterval(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("queue priority aging interval configuration error",
+					zap.Error(err))
+			}
+		case configs.PriorityAgingMax:
+			sq.agingMax, err = priorityAgingMax(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("queue priority aging max configuration error",
+					zap.Error(err))
+			}
+		case configs.PriorityDeadlineBoost:
+			sq.deadlineBoost, err = priorityDeadlineBoost(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("queue priority deadline boost configuration error",
+					zap.Error(err))
+			}
+		case configs.AskTTL:
+			sq.askTTL, err = askTTL(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("queue ask ttl configuration error",
+					zap.Error(err))
+			}
+		case configs.DRFResourceWeights:
+			sq.drfWeights, err = drfResourceWeights(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("queue drf resource weights configuration error",
+					zap.Error(err))
+			}
+		case configs.QueueWeight:
+			sq.weight, err = queueWeight(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("queue weight configuration error",
+					zap.Error(err))
+			}
+		case configs.NodeSelector:
+			sq.nodeSelector, err = configs.ParseNodePoolSelector(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Warn("queue node selector configuration error",
+					zap.String("queue", sq.QueuePath),
+					zap.Error(err))
+			}
 		case configs.PreemptionDelay:
 			if sq.isLeaf {
 				sq.preemptionDelay, err = preemptionDelay(value)
@@ -628,6 +936,120 @@ func (sq *Queue) GetMaxApps() uint64 {
 	return sq.maxRunningApps
 }
 
+// GetMaxRuntime returns the lowest max runtime set on the queue or any of its parents, zero if none is set.
+func (sq *Queue) GetMaxRuntime() time.Duration {
+	if sq == nil {
+		return 0
+	}
+	parentRuntime := sq.parent.GetMaxRuntime()
+	sq.RLock()
+	defer sq.RUnlock()
+	if sq.maxRuntime == 0 || (parentRuntime != 0 && parentRuntime < sq.maxRuntime) {
+		return parentRuntime
+	}
+	return sq.maxRuntime
+}
+
+// SetCapacityProfile sets the active capacity schedule for the queue and the time the active schedule changes next.
+// An empty profile means the configured capacity is used, a zero time means the queue is not part of a schedule.
+func (sq *Queue) SetCapacityProfile(profile string, next time.Time) {
+	sq.Lock()
+	defer sq.Unlock()
+	sq.capacityProfile = profile
+	sq.nextCapacityTransition = next
+}
+
+// GetCapacityProfile returns the name of the active capacity schedule for the queue, empty if none is active.
+func (sq *Queue) GetCapacityProfile() string {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.capacityProfile
+}
+
+// HasNodePool returns true if the queue restricts its subtree to a different set of nodes than its parent does.
+func (sq *Queue) HasNodePool() bool {
+	selector := sq.getNodeSelector()
+	if selector == nil {
+		return false
+	}
+	return sq.parent == nil || sq.parent.getNodeSelector().String() != selector.String()
+}
+
+func (sq *Queue) getNodeSelector() *configs.NodePoolSelector {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.nodeSelector
+}
+
+// MatchesNode returns true if the node matches the node selectors of the queue and all its parents.
+func (sq *Queue) MatchesNode(node *Node) bool {
+	for queue := sq; queue != nil; queue = queue.parent {
+		if !queue.getNodeSelector().Matches(node.GetAttribute) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeFilter returns a filter for the nodes the queue is restricted to, nil if the queue can use all nodes.
+func (sq *Queue) nodeFilter() func(*Node) bool {
+	var selectors []*configs.NodePoolSelector
+	for queue := sq; queue != nil; queue = queue.parent {
+		if selector := queue.getNodeSelector(); selector != nil {
+			selectors = append(selectors, selector)
+		}
+	}
+	if len(selectors) == 0 {
+		return nil
+	}
+	return func(node *Node) bool {
+		for _, selector := range selectors {
+			if !selector.Matches(node.GetAttribute) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// SetNodePoolResource sets the total capacity of the nodes the queue is restricted to.
+// A nil resource removes the node pool limit from the queue.
+func (sq *Queue) SetNodePoolResource(capacity *resources.Resource) {
+	sq.Lock()
+	defer sq.Unlock()
+	sq.nodePoolResource = capacity.Clone()
+}
+
+// AddNodePoolResource adds the delta to the node pool resource of the queue. A removal or decrease MUST be negative.
+func (sq *Queue) AddNodePoolResource(delta *resources.Resource) {
+	sq.Lock()
+	defer sq.Unlock()
+	if sq.nodePoolResource == nil {
+		sq.nodePoolResource = resources.NewResource()
+	}
+	sq.nodePoolResource.AddTo(delta)
+	sq.nodePoolResource.Prune()
+}
+
+// GetNodePoolResource returns the total capacity of the nodes the queue is restricted to, nil if the queue has no node pool.
+func (sq *Queue) GetNodePoolResource() *resources.Resource {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.nodePoolResource.Clone()
+}
+
+// getLimit returns the max resource of the queue limited by the size of the node pool.
+// No locking must be called while holding the lock
+func (sq *Queue) getLimit() *resources.Resource {
+	if sq.nodePoolResource == nil || sq.maxResource == nil {
+		if sq.nodePoolResource != nil {
+			return sq.nodePoolResource
+		}
+		return sq.maxResource
+	}
+	return resources.ComponentWiseMin(sq.maxResource, sq.nodePoolResource)
+}
+
 // GetActualGuaranteedResources returns the actual (including parent) guaranteed resources for the queue.
 func (sq *Queue) GetActualGuaranteedResource() *resources.Resource {
 	if sq == nil {
@@ -674,6 +1096,11 @@ func (sq *Queue) CheckAdminAccess(user security.UserGroup) bool {
 	return allow
 }
 
+// GetParent returns the parent of the queue, nil for the root queue.
+func (sq *Queue) GetParent() *Queue {
+	return sq.parent
+}
+
 // GetPartitionQueueDAOInfo returns the queue hierarchy as an object for a REST call.
 // Include is false, which means that returns the specified queue object, but does not return the children of the specified queue.
 func (sq *Queue) GetPartitionQueueDAOInfo(include bool) dao.PartitionQueueDAOInfo {
@@ -687,6 +1114,16 @@ func (sq *Queue) GetPartitionQueueDAOInfo(include bool) dao.PartitionQueueDAOInf
 	}
 	// we have held the read lock so following method should not take lock again.
 	queueInfo.HeadRoom = sq.getHeadRoom().DAOMap()
+	// show the weighted usage if the queue is ranked using drf, use the weights the parent ranks the queue with
+	if sq.parent != nil && sq.parent.getSortType() == policies.DrfSortPolicy {
+		weights := sq.parent.getDRFWeights()
+		capacity := sq.getPartitionCapacity()
+		queueInfo.WeightedUsage = resources.GetWeightedShares(sq.GetAllocatedResource(), capacity, weights)
+		queueInfo.DominantShare = resources.GetDominantShare(sq.GetAllocatedResource(), capacity, weights)
+	}
+	if maxRuntime := sq.GetMaxRuntime(); maxRuntime > 0 {
+		queueInfo.MaxRuntime = maxRuntime.String()
+	}
 	sq.RLock()
 	defer sq.RUnlock()
 
@@ -723,6 +1160,11 @@ func (sq *Queue) GetPartitionQueueDAOInfo(include bool) dao.PartitionQueueDAOInf
 	}
 	queueInfo.MaxRunningApps = sq.maxRunningApps
 	queueInfo.RunningApps = sq.runningApps
+	queueInfo.CapacityProfile = sq.capacityProfile
+	if !sq.nextCapacityTransition.IsZero() {
+		queueInfo.NextCapacityTransition = sq.nextCapacityTransition.UnixNano()
+	}
+	queueInfo.NodePoolResource = sq.nodePoolResource.DAOMap()
 	queueInfo.AllocatingAcceptedApps = make([]string, 0)
 	for appID, result := range sq.allocatingAcceptedApps {
 		if result {
@@ -849,6 +1291,140 @@ func (sq *Queue) RemoveApplication(app *Application) {
 		zap.String("applicationID", appID))
 }
 
+// appQueueUsage is the usage of an application that is tracked by the queue it runs in and all its parents.
+type appQueueUsage struct {
+	allocated          *resources.Resource
+	pending            *resources.Resource
+	preempting         *resources.Resource
+	running            bool // counts as a running application
+	allocatingAccepted bool // accepted application with allocated placeholders
+}
+
+// commonParent returns the lowest queue that is the same as, or a parent of, both queues.
+func commonParent(left, right *Queue) *Queue {
+	for l := left; l != nil; l = l.parent {
+		for r := right; r != nil; r = r.parent {
+			if l == r {
+				return l
+			}
+		}
+	}
+	return nil
+}
+
+// queuesBelow returns the queue and its parents up to, but not including, the stop queue. The queue is first.
+func (sq *Queue) queuesBelow(stop *Queue) []*Queue {
+	var queues []*Queue
+	for queue := sq; queue != nil && queue != stop; queue = queue.parent {
+		queues = append(queues, queue)
+	}
+	return queues
+}
+
+// checkMoveLimits checks if the usage of an application fits in the max resources and the max running applications
+// of this queue and its parents up to, but not including, the stop queue. Queues from the stop queue upwards already
+// track the usage of the application.
+func (sq *Queue) checkMoveLimits(stop *Queue, usage *appQueueUsage) error {
+	for _, queue := range sq.queuesBelow(stop) {
+		if !queue.allocatedResFits(usage.allocated) {
+			return fmt.Errorf("allocated resources %s of the application do not fit in the max resources of queue %s", usage.allocated, queue.QueuePath)
+		}
+		if usage.running && !queue.canRunMovedApp() {
+			return fmt.Errorf("queue %s has reached its maximum number of running applications", queue.QueuePath)
+		}
+	}
+	return nil
+}
+
+// canRunMovedApp returns true if one more application can run in this queue, not including the parents.
+func (sq *Queue) canRunMovedApp() bool {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.maxRunningApps == 0 || sq.runningApps+uint64(len(sq.allocatingAcceptedApps)+1) <= sq.maxRunningApps
+}
+
+// isAllocatingAccepted returns true if the accepted application has allocated placeholders in this queue.
+func (sq *Queue) isAllocatingAccepted(appID string) bool {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.allocatingAcceptedApps[appID]
+}
+
+// addAppUsage adds the usage of a moved application to this queue and its parents up to, but not including, the
+// stop queue. No limits are checked.
+func (sq *Queue) addAppUsage(stop *Queue, appID string, usage *appQueueUsage) {
+	for _, queue := range sq.queuesBelow(stop) {
+		queue.Lock()
+		queue.allocatedResource = resources.Add(queue.allocatedResource, usage.allocated)
+		queue.pending = resources.Add(queue.pending, usage.pending)
+		queue.preemptingResource = resources.Add(queue.preemptingResource, usage.preempting)
+		if usage.running {
+			queue.runningApps++
+		}
+		if usage.allocatingAccepted {
+			queue.allocatingAcceptedApps[appID] = true
+		}
+		queue.updateAllocatedResourceMetrics()
+		queue.updatePendingResourceMetrics()
+		queue.updatePreemptingResourceMetrics()
+		queue.Unlock()
+	}
+}
+
+// removeAppUsage removes the usage of a moved application from this queue and its parents up to, but not including,
+// the stop queue. Guarded against going negative.
+func (sq *Queue) removeAppUsage(stop *Queue, appID string, usage *appQueueUsage) {
+	for _, queue := range sq.queuesBelow(stop) {
+		queue.Lock()
+		queue.allocatedResource = resources.SubEliminateNegative(queue.allocatedResource, usage.allocated)
+		queue.pending = resources.SubEliminateNegative(queue.pending, usage.pending)
+		queue.preemptingResource = resources.SubEliminateNegative(queue.preemptingResource, usage.preempting)
+		if usage.running && queue.runningApps > 0 {
+			queue.runningApps--
+		}
+		delete(queue.allocatingAcceptedApps, appID)
+		// update the metrics before pruning the resources, see DecAllocatedResource
+		queue.updateAllocatedResourceMetrics()
+		queue.updatePendingResourceMetrics()
+		queue.updatePreemptingResourceMetrics()
+		queue.allocatedResource.Prune()
+		queue.pending.Prune()
+		queue.preemptingResource.Prune()
+		queue.Unlock()
+	}
+}
+
+// addMovedApplication adds an application that is moved from another queue, with its reservations, to this leaf queue.
+// The usage of the application is updated separately.
+func (sq *Queue) addMovedApplication(app *Application, reservations int, priority int32) {
+	sq.Lock()
+	appID := app.ApplicationID
+	sq.applications[appID] = app
+	if reservations > 0 {
+		sq.reservedApps[appID] = reservations
+	}
+	sq.appPriorities[appID] = priority
+	value := sq.recalculatePriority()
+	sq.Unlock()
+	sq.parent.UpdateQueuePriority(sq.Name, value)
+	sq.queueEvents.SendNewApplicationEvent(sq.QueuePath, appID)
+}
+
+// removeMovedApplication removes an application that is moved to another queue from this leaf queue.
+// It returns the number of reservations of the application. The usage of the application is updated separately.
+func (sq *Queue) removeMovedApplication(appID string) int {
+	sq.Lock()
+	reservations := sq.reservedApps[appID]
+	delete(sq.applications, appID)
+	delete(sq.appPriorities, appID)
+	delete(sq.reservedApps, appID)
+	value := sq.recalculatePriority()
+	sq.Unlock()
+	sq.parent.UpdateQueuePriority(sq.Name, value)
+	sq.queueEvents.SendRemoveApplicationEvent(sq.QueuePath, appID)
+	return reservations
+}
+
 func (sq *Queue) appExists(appID string) bool {
 	sq.RLock()
 	defer sq.RUnlock()
@@ -1198,8 +1774,13 @@ func (sq *Queue) sortApplications(withPlaceholdersOnly bool) []*Application {
 		return nil
 	}
 
+	sq.refreshAgedPriorities(apps)
 	// sort applications based on the sorting policy
-	return sortApplications(apps, sq.getSortType(), sq.IsPrioritySortEnabled(), sq.GetGuaranteedResource())
+	sortType := sq.getSortType()
+	if sortType == policies.DrfSortPolicy {
+		return sortApplications(apps, sortType, sq.IsPrioritySortEnabled(), sq.getPartitionCapacity(), sq.getDRFWeights())
+	}
+	return sortApplications(apps, sortType, sq.IsPrioritySortEnabled(), sq.GetGuaranteedResource(), nil)
 }
 
 // sortQueues returns a sorted shallow copy of the queues for this parent queue.
@@ -1210,6 +1791,20 @@ func (sq *Queue) sortQueues() []*Queue {
 	if sq.IsLeafQueue() {
 		return nil
 	}
+	sortType := sq.getSortType()
+	// drf compares all queues against the partition capacity
+	var capacity *resources.Resource
+	var weights map[string]float64
+	// the fair max of the children is derived from the fair max and child weights of this queue: calculate once
+	var fairMax *resources.Resource
+	var childWeights float64
+	if sortType == policies.DrfSortPolicy {
+		capacity = sq.getPartitionCapacity()
+		weights = sq.getDRFWeights()
+	} else {
+		fairMax = sq.GetFairMaxResource()
+		childWeights = sq.getChildWeights()
+	}
 	// Create a list of the queues with pending resources
 	sortedQueues := make([]*Queue, 0)
 	sortedMaxFairResources := make([]*resources.Resource, 0)
@@ -1221,11 +1816,15 @@ func (sq *Queue) sortQueues() []*Queue {
 		// queue must have pending resources to be considered for scheduling
 		if resources.StrictlyGreaterThanZero(child.GetPendingResource()) {
 			sortedQueues = append(sortedQueues, child)
-			sortedMaxFairResources = append(sortedMaxFairResources, child.GetFairMaxResource())
+			if sortType == policies.DrfSortPolicy {
+				sortedMaxFairResources = append(sortedMaxFairResources, capacity)
+			} else {
+				sortedMaxFairResources = append(sortedMaxFairResources, child.getFairMaxResource(fairMax, childWeights))
+			}
 		}
 	}
 	// Sort the queues
-	sortQueue(sortedQueues, sortedMaxFairResources, sq.getSortType(), sq.IsPrioritySortEnabled())
+	sortQueue(sortedQueues, sortedMaxFairResources, sortType, sq.IsPrioritySortEnabled(), weights)
 
 	return sortedQueues
 }
@@ -1239,11 +1838,11 @@ func (sq *Queue) getHeadRoom() *resources.Resource {
 	if sq.parent != nil {
 		parentHeadRoom = sq.parent.getHeadRoom()
 	}
-	return sq.internalHeadRoom(parentHeadRoom)
+	return sq.internalHeadRoom(parentHeadRoom, true)
 }
 
 // getMaxHeadRoom returns the maximum headRoom of a queue. The cluster size, which defines the root limit,
-// is not relevant for this call. Contrary to the getHeadRoom call. This will return nil unless a limit is set.
+// and the size of node pools are not relevant for this call. Contrary to the getHeadRoom call. This will return nil unless a limit is set.
 // Used during scheduling in an auto-scaling cluster.
 // NOTE: if a resource quantity is missing and a limit is defined the missing quantity will be seen as no limit.
 func (sq *Queue) getMaxHeadRoom() *resources.Resource {
@@ -1253,14 +1852,17 @@ func (sq *Queue) getMaxHeadRoom() *resources.Resource {
 	} else {
 		return nil
 	}
-	return sq.internalHeadRoom(parentHeadRoom)
+	return sq.internalHeadRoom(parentHeadRoom, false)
 }
 
-// internalHeadRoom does the real headroom calculation.
-func (sq *Queue) internalHeadRoom(parentHeadRoom *resources.Resource) *resources.Resource {
+// internalHeadRoom does the real headroom calculation. The node pool size limits the headroom if requested.
+func (sq *Queue) internalHeadRoom(parentHeadRoom *resources.Resource, withNodePool bool) *resources.Resource {
 	sq.RLock()
 	defer sq.RUnlock()
 	headRoom := sq.maxResource
+	if withNodePool {
+		headRoom = sq.getLimit()
+	}
 
 	// if we have no max set headroom is always the same as the parent
 	if headRoom == nil {
@@ -1292,35 +1894,75 @@ func (sq *Queue) GetMaxResource() *resources.Resource {
 	if sq.parent != nil {
 		limit = sq.parent.GetMaxResource()
 	}
-	return sq.internalGetMax(limit)
+	return sq.internalGetMax(limit, true)
 }
 
 // GetFairMaxResource computes the fair max resources for a given queue.
 // Starting with the root, descend down to the target queue allowing children to override Resource values .
 // If the root includes an explicit 0 value for a Resource, do not include it in the accumulator and treat it as missing.
 // If no children provide a maximum capacity override, the resulting value will be the value found on the Root.
+// If weights are set on the queue or its siblings the value inherited from the parent is the weighted share of the
+// parent value: weight / sum of the weights of all siblings that are not stopped.
 // It is useful for fair-scheduling to allow a ratio to be produced representing the rough utilization % of a given queue.
 func (sq *Queue) GetFairMaxResource() *resources.Resource {
-	var limit *resources.Resource
 	if sq.parent == nil {
 		return sq.GetMaxResource().Clone()
 	}
+	return sq.getFairMaxResource(sq.parent.GetFairMaxResource(), sq.parent.getChildWeights())
+}
+
+// getChildWeights returns the sum of the weights of all children that are not stopped.
+// If no weights are set each child can use the full parent value: 0 is returned.
+// Lock free call all locks are taken when needed in called functions
+func (sq *Queue) getChildWeights() float64 {
+	total := 0.0
+	weighted := false
+	for _, child := range sq.GetCopyOfChildren() {
+		if child.IsStopped() {
+			continue
+		}
+		weight := child.GetWeight()
+		weighted = weighted || weight != configs.DefaultQueueWeight
+		total += weight
+	}
+	if !weighted || total <= 0 {
+		return 0
+	}
+	return total
+}
 
-	limit = sq.parent.GetFairMaxResource()
+// getFairMaxResource computes the fair max resources of the queue from the fair max resources of the parent and the
+// sum of the weights of the queue and its siblings as returned by getChildWeights on the parent.
+// Lock free call all locks are taken when needed in called functions
+func (sq *Queue) getFairMaxResource(parentFairMax *resources.Resource, childWeights float64) *resources.Resource {
+	limit := parentFairMax
+	if childWeights > 0 {
+		if share := sq.GetWeight() / childWeights; share < 1 {
+			limit = resources.MultiplyBy(limit, share)
+		}
+	}
 	return sq.internalGetFairMaxResource(limit)
 }
 
+// GetWeight returns the weight of the queue relative to its siblings.
+func (sq *Queue) GetWeight() float64 {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.weight
+}
+
 func (sq *Queue) internalGetFairMaxResource(limit *resources.Resource) *resources.Resource {
 	sq.RLock()
 	defer sq.RUnlock()
 
 	out := limit.Clone()
-	if sq.maxResource.IsEmpty() || out.IsEmpty() {
+	maxResource := sq.getLimit()
+	if maxResource.IsEmpty() || out.IsEmpty() {
 		return out
 	}
 
 	// perform merge. child wins every resources collision
-	for k, v := range sq.maxResource.Resources {
+	for k, v := range maxResource.Resources {
 		out.Resources[k] = v
 	}
 
@@ -1328,7 +1970,8 @@ func (sq *Queue) internalGetFairMaxResource(limit *resources.Resource) *resource
 }
 
 // GetMaxQueueSet returns the max resource for the queue. The max resource should never be larger than the
-// max resource of the parent. The cluster size, which defines the root limit, is not relevant for this call.
+// max resource of the parent. The cluster size, which defines the root limit, and the size of node pools are not
+// relevant for this call.
 // Contrary to the GetMaxResource call. This will return nil unless a limit is set.
 // Used during scheduling in an auto-scaling cluster.
 // NOTE: if a resource quantity is missing and a limit is defined the missing quantity will be seen as a limit of 0.
@@ -1338,23 +1981,27 @@ func (sq *Queue) GetMaxQueueSet() *resources.Resource {
 	if sq.parent == nil {
 		return nil
 	}
-	return sq.internalGetMax(sq.parent.GetMaxQueueSet())
+	return sq.internalGetMax(sq.parent.GetMaxQueueSet(), false)
 }
 
-// internalGetMax does the real max calculation.
-func (sq *Queue) internalGetMax(parentLimit *resources.Resource) *resources.Resource {
+// internalGetMax does the real max calculation. The node pool size limits the max if requested.
+func (sq *Queue) internalGetMax(parentLimit *resources.Resource, withNodePool bool) *resources.Resource {
 	sq.RLock()
 	defer sq.RUnlock()
+	maxResource := sq.maxResource
+	if withNodePool {
+		maxResource = sq.getLimit()
+	}
 	// no parent queue limit set, not even for root
 	if parentLimit == nil {
-		return sq.maxResource.Clone()
+		return maxResource.Clone()
 	}
 	// parent limit set, no queue limit return parent
-	if sq.maxResource == nil {
+	if maxResource == nil {
 		return parentLimit
 	}
 	// calculate the smallest value for each type
-	return resources.ComponentWiseMin(parentLimit, sq.maxResource)
+	return resources.ComponentWiseMin(parentLimit, maxResource)
 }
 
 // SetMaxResource sets the max resource for the root queue. Called as part of adding or removing a node.
@@ -1429,6 +2076,12 @@ func (sq *Queue) canRunApp(appID string) bool {
 // Lock free call this all locks are taken when needed in called functions
 func (sq *Queue) TryAllocate(iterator func() NodeIterator, fullIterator func() NodeIterator, getnode func(string) *Node, allowPreemption bool) *AllocationResult {
 	if sq.IsLeafQueue() {
+		// only the nodes of the node pool can be used
+		if accept := sq.nodeFilter(); accept != nil {
+			iterator = filterNodeIterator(iterator, accept)
+			fullIterator = filterNodeIterator(fullIterator, accept)
+			getnode = filterGetNode(getnode, accept)
+		}
 		// get the headroom
 		headRoom := sq.getHeadRoom()
 		preemptionDelay := sq.GetPreemptionDelay()
@@ -1436,6 +2089,14 @@ func (sq *Queue) TryAllocate(iterator func() NodeIterator, fullIterator func() N
 
 		// process the apps (filters out app without pending requests)
 		for _, app := range sq.sortApplications(false) {
+			// applications waiting on other applications to complete are never runnable
+			if app.isWaitingOnDependencies() {
+				continue
+			}
+			// suspended applications keep their requests but are not scheduled until resumed
+			if app.IsSuspended() {
+				continue
+			}
 			runnableInQueue := sq.canRunApp(app.ApplicationID)
 			runnableByUserLimit := ugm.GetUserManager().CanRunApp(sq.QueuePath, app.ApplicationID, app.user)
 			app.updateRunnableStatus(runnableInQueue, runnableByUserLimit)
@@ -1477,6 +2138,11 @@ func (sq *Queue) TryAllocate(iterator func() NodeIterator, fullIterator func() N
 // Lock free call this all locks are taken when needed in called functions
 func (sq *Queue) TryPlaceholderAllocate(iterator func() NodeIterator, getnode func(string) *Node) *AllocationResult {
 	if sq.IsLeafQueue() {
+		// only the nodes of the node pool can be used
+		if accept := sq.nodeFilter(); accept != nil {
+			iterator = filterNodeIterator(iterator, accept)
+			getnode = filterGetNode(getnode, accept)
+		}
 		// process the apps (filters out app without pending requests)
 		for _, app := range sq.sortApplications(true) {
 			result := app.tryPlaceholderAllocate(iterator, getnode)
@@ -1508,6 +2174,11 @@ func (sq *Queue) GetQueueOutstandingRequests(total *[]*Allocation) {
 		// while calculating outstanding requests, we calculate all the requests that can fit into the queue's headroom,
 		// all these requests are qualified to trigger the up scaling.
 		for _, app := range sq.sortApplications(false) {
+			// applications waiting on other applications to complete and suspended applications must not
+			// trigger up scaling
+			if app.isWaitingOnDependencies() || app.IsSuspended() {
+				continue
+			}
 			// calculate the users' headroom
 			userHeadroom := ugm.GetUserManager().Headroom(app.queuePath, app.ApplicationID, app.user)
 			app.getOutstandingRequests(headRoom, userHeadroom, total)
@@ -1530,6 +2201,10 @@ func (sq *Queue) TryReservedAllocate(iterator func() NodeIterator) *AllocationRe
 		// skip if it has no reservations
 		reservedCopy := sq.GetReservedApps()
 		if len(reservedCopy) != 0 {
+			// only the nodes of the node pool can be used
+			if accept := sq.nodeFilter(); accept != nil {
+				iterator = filterNodeIterator(iterator, accept)
+			}
 			// get the headroom
 			headRoom := sq.getHeadRoom()
 			// process the apps
@@ -1661,6 +2336,23 @@ func (sq *Queue) getSortType() policies.SortPolicy {
 	return sq.sortType
 }
 
+// getDRFWeights returns the resource weights used by the drf sort policy.
+func (sq *Queue) getDRFWeights() map[string]float64 {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.drfWeights
+}
+
+// getPartitionCapacity returns the capacity of the partition: the max resource of the root queue.
+// Lock free call all locks are taken when needed in called functions
+func (sq *Queue) getPartitionCapacity() *resources.Resource {
+	root := sq
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root.GetMaxResource()
+}
+
 // SupportTaskGroup returns true if the queue supports task groups.
 // FIFO policy is required to support this.
 // NOTE: this call does not make sense for a parent queue, and always returns false
@@ -1787,6 +2479,20 @@ func (sq *Queue) setAllocatingAccepted(appID string) {
 	sq.allocatingAcceptedApps[appID] = true
 }
 
+// clearAllocatingAccepted removes the application from the accepted applications that are allocating.
+// For this queue (recursively).
+func (sq *Queue) clearAllocatingAccepted(appID string) {
+	if sq == nil {
+		return
+	}
+	if sq.parent != nil {
+		sq.parent.clearAllocatingAccepted(appID)
+	}
+	sq.Lock()
+	defer sq.Unlock()
+	delete(sq.allocatingAcceptedApps, appID)
+}
+
 func (sq *Queue) GetPreemptionPolicy() policies.PreemptionPolicy {
 	sq.RLock()
 	defer sq.RUnlock()
@@ -2044,6 +2750,56 @@ func (sq *Queue) updateQueuePriorityInternal(queueName string, priority int32) i
 	return sq.recalculatePriority()
 }
 
+// getPriorityAging returns the pending time for each priority increase and the maximum increase.
+// An interval of 0 means aging is disabled.
+func (sq *Queue) getPriorityAging() (time.Duration, int32) {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.agingInterval, sq.agingMax
+}
+
+// getDeadlineBoost returns the priority increase of an application with its deadline at risk. Deadlines are only
+// used to order applications in a queue with the edf sort policy, other queues never boost the priority.
+func (sq *Queue) getDeadlineBoost() int32 {
+	sq.RLock()
+	defer sq.RUnlock()
+	if sq.sortType != policies.EdfSortPolicy {
+		return 0
+	}
+	return sq.deadlineBoost
+}
+
+// getAskTTL returns the default pending time before a request of an application in the queue is removed.
+// A value of 0 means requests do not expire.
+func (sq *Queue) getAskTTL() time.Duration {
+	if sq == nil {
+		return 0
+	}
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.askTTL
+}
+
+// refreshAgedPriorities updates the priorities of the applications that changed due to aging. The aging is taken at
+// one point in time for all applications before they are sorted. The queue priority is recalculated, and propagated
+// up the hierarchy, for each change.
+// Lock free call all locks are taken when needed in called functions
+func (sq *Queue) refreshAgedPriorities(apps map[string]*Application) {
+	if interval, _ := sq.getPriorityAging(); interval == 0 {
+		return
+	}
+	now := time.Now()
+	for appID, app := range apps {
+		priority := app.refreshAgedPriority(now)
+		sq.RLock()
+		current, ok := sq.appPriorities[appID]
+		sq.RUnlock()
+		if ok && current != priority {
+			sq.UpdateApplicationPriority(appID, priority)
+		}
+	}
+}
+
 func (sq *Queue) recalculatePriority() int32 {
 	var items map[string]int32
 	if sq.isLeaf {
@@ -1310,6 +1310,29 @@ func testOutstanding(t *testing.T, allocMap, usedMap map[string]string) {
 	assert.Equal(t, len(rootTotal), 5)
 }
 
+func TestGetOutstandingDependencies(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "failed to create root queue")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "leaf", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	app := newApplication(appID1, "default", "root.leaf")
+	app.dependencies = []string{appID2}
+	app.queue = leaf
+	leaf.AddApplication(app)
+	ask := newAllocationAsk(aKey, appID1, resources.NewResourceFromMap(map[string]resources.Quantity{"cpu": 1}))
+	ask.SetSchedulingAttempted(true)
+	assert.NilError(t, app.AddAllocationAsk(ask), "failed to add allocation ask")
+
+	// an application waiting on its dependencies must not trigger up scaling
+	total := make([]*Allocation, 0)
+	root.GetQueueOutstandingRequests(&total)
+	assert.Equal(t, len(total), 0)
+	app.ResolveDependency(appID2)
+	root.GetQueueOutstandingRequests(&total)
+	assert.Equal(t, len(total), 1)
+}
+
 func TestGetOutstandingOnlyUntracked(t *testing.T) {
 	// all outstanding pods use only an unlimited resource type
 	// max is set for a different resource type and fully allocated
@@ -2877,3 +2900,261 @@ func TestQueue_allocatedResFits_Other(t *testing.T) {
 		})
 	}
 }
+
+func TestQueueChangeState(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "failed to create root queue")
+	parent, err := createManagedQueue(root, "parent", true, nil)
+	assert.NilError(t, err, "failed to create parent queue")
+	leaf, err := createManagedQueue(parent, "leaf", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	dynamic, err := createDynamicQueue(parent, "dynamic", false)
+	assert.NilError(t, err, "failed to create dynamic queue")
+
+	assert.ErrorContains(t, dynamic.ChangeState(Stopped), "unmanaged queue")
+
+	assert.NilError(t, parent.ChangeState(Stopped), "stop should not fail")
+	assert.Assert(t, parent.IsStopped(), "parent should be stopped")
+	assert.Assert(t, leaf.IsRunning(), "stop should not change the children")
+	assert.NilError(t, parent.ChangeState(Active), "start should not fail")
+	assert.Assert(t, parent.IsRunning(), "parent should be active")
+
+	// drain marks the children too, a restart reverses that
+	assert.NilError(t, parent.ChangeState(Draining), "drain should not fail")
+	assert.Assert(t, parent.IsDraining(), "parent should be draining")
+	assert.Assert(t, leaf.IsDraining(), "leaf should be draining")
+	assert.Assert(t, parent.ChangeState(Stopped) != nil, "stopping a draining queue should fail")
+	assert.NilError(t, parent.ChangeState(Active), "start should not fail")
+	assert.Assert(t, parent.IsRunning(), "parent should be active")
+	assert.Assert(t, leaf.IsRunning(), "leaf should be active")
+}
+
+func TestQueueUpdateProperties(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "failed to create root queue")
+	leaf, err := createManagedQueueWithProps(root, "leaf", false, nil, map[string]string{configs.ApplicationSortPolicy: "fifo", "custom": "value"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, leaf.getSortType(), policies.FifoSortPolicy)
+
+	leaf.UpdateProperties(map[string]string{configs.ApplicationSortPolicy: "fair", configs.PreemptionPolicy: "disabled", "custom": ""})
+	props := leaf.getProperties()
+	assert.Equal(t, len(props), 2, "unexpected properties: %v", props)
+	assert.Equal(t, props[configs.PreemptionPolicy], "disabled")
+	assert.Equal(t, leaf.getSortType(), policies.FairSortPolicy)
+	assert.Equal(t, leaf.GetPreemptionPolicy(), policies.DisabledPreemptionPolicy)
+
+	leaf.UpdateMaxRunningApps(3)
+	assert.Equal(t, leaf.GetMaxApps(), uint64(3))
+}
+
+func TestGetFairMaxResourceWeighted(t *testing.T) {
+	root, err := createRootQueue(map[string]string{"memory": "1200", "vcore": "1200m"})
+	assert.NilError(t, err, "queue create failed")
+	var heavy, light, capped, stopped *Queue
+	heavy, err = createManagedQueueWithProps(root, "heavy", false, nil, map[string]string{configs.QueueWeight: "2"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	light, err = createManagedQueue(root, "light", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, heavy.GetWeight(), 2.0)
+	assert.Equal(t, light.GetWeight(), configs.DefaultQueueWeight)
+	assert.Equal(t, root.getChildWeights(), 3.0)
+	// weight 2 against 1: two third and one third of the parent
+	assert.DeepEqual(t, heavy.GetFairMaxResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 800, "vcore": 800}))
+	assert.DeepEqual(t, light.GetFairMaxResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 400, "vcore": 400}))
+
+	// a max on the queue overrides the weighted share, the weight still counts for the siblings
+	capped, err = createManagedQueueWithProps(root, "capped", false, map[string]string{"memory": "100"}, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.DeepEqual(t, capped.GetFairMaxResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 100, "vcore": 300}))
+	assert.DeepEqual(t, heavy.GetFairMaxResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 600, "vcore": 600}))
+
+	// stopped queues are not part of the share
+	stopped, err = createManagedQueueWithProps(root, "stopped", false, nil, map[string]string{configs.QueueWeight: "10"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	stopped.stateMachine.SetState(Stopped.String())
+	assert.DeepEqual(t, heavy.GetFairMaxResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 600, "vcore": 600}))
+
+	// removing the weight resets it: no weights means no change to the parent value
+	heavy.properties = map[string]string{}
+	heavy.UpdateQueueProperties()
+	assert.Equal(t, heavy.GetWeight(), configs.DefaultQueueWeight)
+	stopped.properties = map[string]string{}
+	stopped.UpdateQueueProperties()
+	assert.Equal(t, root.getChildWeights(), 0.0, "no weights set")
+	assert.DeepEqual(t, heavy.GetFairMaxResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 1200, "vcore": 1200}))
+
+	// weights are not inherited
+	var parent, child *Queue
+	parent, err = createManagedQueueWithProps(root, "parent", true, nil, map[string]string{configs.QueueWeight: "3"})
+	assert.NilError(t, err, "failed to create parent queue")
+	child, err = createManagedQueue(parent, "child", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, parent.GetWeight(), 3.0)
+	assert.Equal(t, child.GetWeight(), configs.DefaultQueueWeight)
+}
+
+func TestDRFSortPolicy(t *testing.T) {
+	root, err := createRootQueue(map[string]string{"memory": "1000", "gpu": "10"})
+	assert.NilError(t, err, "queue create failed")
+	var parent, leaf0, leaf1 *Queue
+	props := map[string]string{configs.ApplicationSortPolicy: "drf", configs.DRFResourceWeights: "gpu=5"}
+	parent, err = createManagedQueueWithProps(root, "parent", true, nil, props)
+	assert.NilError(t, err, "failed to create parent queue")
+	assert.Equal(t, parent.getSortType(), policies.DrfSortPolicy)
+	assert.DeepEqual(t, parent.getDRFWeights(), map[string]float64{"gpu": 5})
+	// policy and weights are inherited
+	leaf0, err = createManagedQueue(parent, "leaf0", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, leaf0.getSortType(), policies.DrfSortPolicy)
+	assert.DeepEqual(t, leaf0.getDRFWeights(), map[string]float64{"gpu": 5})
+	leaf1, err = createManagedQueue(parent, "leaf1", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.DeepEqual(t, leaf1.getPartitionCapacity(), root.GetMaxResource())
+
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})
+	leaf0.incPendingResource(res)
+	leaf0.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 500})
+	leaf1.incPendingResource(res)
+	leaf1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"gpu": 2})
+	// gpu share 0.2 weighted 1.0 is larger than the memory share 0.5
+	assert.Equal(t, queueNames(parent.sortQueues()), queueNames([]*Queue{leaf0, leaf1}))
+
+	info := leaf1.GetPartitionQueueDAOInfo(false)
+	assert.Equal(t, info.SortingPolicy, "drf")
+	assert.DeepEqual(t, info.WeightedUsage, map[string]float64{"gpu": 1})
+	assert.Equal(t, info.DominantShare, 1.0)
+	info = parent.GetPartitionQueueDAOInfo(false)
+	assert.Assert(t, info.WeightedUsage == nil, "root does not use drf, no weighted usage expected")
+
+	// only drf is supported on a parent queue
+	parent.properties = map[string]string{configs.ApplicationSortPolicy: "fifo"}
+	parent.UpdateQueueProperties()
+	assert.Equal(t, parent.getSortType(), policies.FairSortPolicy)
+	assert.Assert(t, parent.getDRFWeights() == nil, "weights should be removed")
+}
+
+func TestDRFResourceWeights(t *testing.T) {
+	weights, err := drfResourceWeights("memory=1, nvidia.com/gpu = 10,vcore=0")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, weights, map[string]float64{"memory": 1, "nvidia.com/gpu": 10, "vcore": 0})
+	for _, value := range []string{"memory", "=1", "memory=x", "memory=-1", "memory=NaN", "memory=1,"} {
+		_, err = drfResourceWeights(value)
+		assert.Assert(t, err != nil, "expected error for weights %s", value)
+	}
+}
+
+func TestQueueWeight(t *testing.T) {
+	weight, err := queueWeight("1.5")
+	assert.NilError(t, err)
+	assert.Equal(t, weight, 1.5)
+	for _, value := range []string{"x", "0", "-1", "NaN", "+Inf"} {
+		weight, err = queueWeight(value)
+		assert.Assert(t, err != nil, "expected error for weight %s", value)
+		assert.Equal(t, weight, configs.DefaultQueueWeight)
+	}
+}
+
+func TestQueueMaxRuntime(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var parent *Queue
+	parent, err = NewConfiguredQueue(configs.QueueConfig{
+		Name:          "parent",
+		Parent:        true,
+		MaxRuntime:    "4h",
+		ChildTemplate: configs.ChildTemplate{MaxRuntime: "1h"},
+	}, root, false)
+	assert.NilError(t, err, "failed to create parent queue")
+	assert.Equal(t, parent.GetMaxRuntime(), 4*time.Hour)
+	assert.Equal(t, parent.GetPartitionQueueDAOInfo(false).MaxRuntime, "4h0m0s")
+	var leaf *Queue
+	leaf, err = NewDynamicQueue("leaf", true, parent)
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, leaf.GetMaxRuntime(), time.Hour, "template should set the max runtime")
+	// a lower limit on the parent applies to the child
+	err = parent.ApplyConf(configs.QueueConfig{Name: "parent", Parent: true, MaxRuntime: "30m"})
+	assert.NilError(t, err, "failed to update parent queue")
+	assert.Equal(t, leaf.GetMaxRuntime(), 30*time.Minute)
+	err = parent.ApplyConf(configs.QueueConfig{Name: "parent", Parent: true})
+	assert.NilError(t, err, "failed to update parent queue")
+	assert.Equal(t, parent.GetMaxRuntime(), time.Duration(0), "max runtime should be removed")
+}
+
+func TestQueueCapacityProfile(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "leaf", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	queueInfo := leaf.GetPartitionQueueDAOInfo(false)
+	assert.Equal(t, queueInfo.CapacityProfile, "")
+	assert.Equal(t, queueInfo.NextCapacityTransition, int64(0))
+
+	next := time.Now().Add(time.Hour)
+	leaf.SetCapacityProfile("daytime", next)
+	assert.Equal(t, leaf.GetCapacityProfile(), "daytime")
+	queueInfo = leaf.GetPartitionQueueDAOInfo(false)
+	assert.Equal(t, queueInfo.CapacityProfile, "daytime")
+	assert.Equal(t, queueInfo.NextCapacityTransition, next.UnixNano())
+}
+
+func TestCheckQueueProperties(t *testing.T) {
+	assert.NilError(t, CheckQueueProperties(nil))
+	assert.NilError(t, CheckQueueProperties(map[string]string{
+		configs.ApplicationSortPolicy: "fair",
+		configs.PreemptionDelay:       "10s",
+		configs.PriorityOffset:        "",
+		"unknown":                     "anything",
+	}))
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.ApplicationSortPolicy: "random"}), configs.ApplicationSortPolicy)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.PreemptionDelay: "-1s"}), configs.PreemptionDelay)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.PriorityOffset: "x"}), configs.PriorityOffset)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.PreemptionPolicy: "x"}), configs.PreemptionPolicy)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.QueueWeight: "0"}), configs.QueueWeight)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.DRFResourceWeights: "gpu"}), configs.DRFResourceWeights)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.PriorityAgingInterval: "-1m"}), configs.PriorityAgingInterval)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.PriorityAgingMax: "-1"}), configs.PriorityAgingMax)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.PriorityAgingMax: "x"}), configs.PriorityAgingMax)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.PriorityDeadlineBoost: "-1"}), configs.PriorityDeadlineBoost)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.PriorityDeadlineBoost: "x"}), configs.PriorityDeadlineBoost)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.AskTTL: "-1m"}), configs.AskTTL)
+	assert.ErrorContains(t, CheckQueueProperties(map[string]string{configs.AskTTL: "x"}), "invalid value")
+}
+
+func TestQueueNodePool(t *testing.T) {
+	root, err := createRootQueue(map[string]string{"memory": "100"})
+	assert.NilError(t, err, "failed to create root queue")
+	parent, err := createManagedQueueWithProps(root, "parent", true, map[string]string{"memory": "60"}, map[string]string{configs.NodeSelector: "pool=gpu"})
+	assert.NilError(t, err, "failed to create parent queue")
+	leaf, err := createManagedQueue(parent, "leaf", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	restricted, err := createManagedQueueWithProps(parent, "restricted", false, nil, map[string]string{configs.NodeSelector: "zone in (east)"})
+	assert.NilError(t, err, "failed to create restricted queue")
+	assert.Assert(t, !root.HasNodePool(), "root should not have a node pool")
+	assert.Assert(t, parent.HasNodePool(), "parent should have a node pool")
+	assert.Assert(t, !leaf.HasNodePool(), "leaf inherits the parent node pool")
+	assert.Assert(t, restricted.HasNodePool(), "restricted queue should have a node pool")
+
+	gpuEast := NewNode(&si.NodeInfo{NodeID: "node-1", Attributes: map[string]string{"pool": "gpu", "zone": "east"}})
+	gpuWest := NewNode(&si.NodeInfo{NodeID: "node-2", Attributes: map[string]string{"pool": "gpu", "zone": "west"}})
+	cpu := NewNode(&si.NodeInfo{NodeID: "node-3", Attributes: map[string]string{"pool": "cpu", "zone": "east"}})
+	assert.Assert(t, root.MatchesNode(cpu) && root.nodeFilter() == nil, "root should use all nodes")
+	assert.Assert(t, leaf.MatchesNode(gpuEast) && leaf.MatchesNode(gpuWest) && !leaf.MatchesNode(cpu), "leaf should only use gpu nodes")
+	assert.Assert(t, restricted.MatchesNode(gpuEast) && !restricted.MatchesNode(gpuWest) && !restricted.MatchesNode(cpu), "restricted queue should only use gpu nodes in east")
+	filter := restricted.nodeFilter()
+	assert.Assert(t, filter(gpuEast) && !filter(gpuWest) && !filter(cpu), "filter should match the queue and its parents")
+
+	// the pool limits the headroom and max but not the values used for auto scaling
+	parent.SetNodePoolResource(resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 40}))
+	pool := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 40})
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), pool), "max should be limited by the node pool: %s", leaf.GetMaxResource())
+	assert.Assert(t, resources.Equals(leaf.getHeadRoom(), pool), "headroom should be limited by the node pool: %s", leaf.getHeadRoom())
+	maxRes := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 60})
+	assert.Assert(t, resources.Equals(leaf.GetMaxQueueSet(), maxRes), "max queue set should not be limited by the node pool: %s", leaf.GetMaxQueueSet())
+	assert.Assert(t, resources.Equals(leaf.getMaxHeadRoom(), maxRes), "max headroom should not be limited by the node pool: %s", leaf.getMaxHeadRoom())
+	parent.AddNodePoolResource(resources.NewResourceFromMap(map[string]resources.Quantity{"memory": -10}))
+	assert.DeepEqual(t, parent.GetPartitionQueueDAOInfo(false).NodePoolResource, map[string]int64{"memory": 30})
+	parent.SetNodePoolResource(nil)
+	assert.Assert(t, parent.GetNodePoolResource() == nil, "node pool should have been removed")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), maxRes), "max should not be limited without a node pool: %s", leaf.GetMaxResource())
+}
@@ -27,15 +27,24 @@ import (
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
 )
 
-func sortQueue(queues []*Queue, fairMaxResources []*resources.Resource, sortType policies.SortPolicy, considerPriority bool) {
+// sortQueue sorts the queues based on the sort type. For the fair policy the fair max resources are used as the
+// denominator of the usage share, for the drf policy the partition capacity combined with the resource weights.
+func sortQueue(queues []*Queue, fairMaxResources []*resources.Resource, sortType policies.SortPolicy, considerPriority bool, weights map[string]float64) {
 	sortingStart := time.Now()
-	if sortType == policies.FairSortPolicy {
+	switch sortType {
+	case policies.FairSortPolicy:
 		if considerPriority {
 			sortQueuesByPriorityAndFairness(queues, fairMaxResources)
 		} else {
 			sortQueuesByFairnessAndPriority(queues, fairMaxResources)
 		}
-	} else {
+	case policies.DrfSortPolicy:
+		if considerPriority {
+			sortQueuesByPriorityAndDominantShare(queues, fairMaxResources, weights)
+		} else {
+			sortQueuesByDominantShareAndPriority(queues, fairMaxResources, weights)
+		}
+	default:
 		if considerPriority {
 			sortQueuesByPriority(queues)
 		}
@@ -98,7 +107,50 @@ func sortQueuesByFairnessAndPriority(queues []*Queue, fairMaxResources []*resour
 	})
 }
 
-func sortApplications(apps map[string]*Application, sortType policies.SortPolicy, considerPriority bool, globalResource *resources.Resource) []*Application {
+func sortQueuesByPriorityAndDominantShare(queues []*Queue, capacities []*resources.Resource, weights map[string]float64) {
+	sort.SliceStable(queues, func(i, j int) bool {
+		l := queues[i]
+		r := queues[j]
+		lPriority := l.GetCurrentPriority()
+		rPriority := r.GetCurrentPriority()
+		if lPriority > rPriority {
+			return true
+		}
+		if lPriority < rPriority {
+			return false
+		}
+		comp := resources.CompDominantShare(l.GetAllocatedResource(), r.GetAllocatedResource(), capacities[i], weights)
+		if comp == 0 {
+			return resources.StrictlyGreaterThan(resources.Sub(l.GetPendingResource(), r.GetPendingResource()), resources.Zero)
+		}
+		return comp < 0
+	})
+}
+
+func sortQueuesByDominantShareAndPriority(queues []*Queue, capacities []*resources.Resource, weights map[string]float64) {
+	sort.SliceStable(queues, func(i, j int) bool {
+		l := queues[i]
+		r := queues[j]
+		comp := resources.CompDominantShare(l.GetAllocatedResource(), r.GetAllocatedResource(), capacities[i], weights)
+		if comp == 0 {
+			lPriority := l.GetCurrentPriority()
+			rPriority := r.GetCurrentPriority()
+			if lPriority > rPriority {
+				return true
+			}
+			if lPriority < rPriority {
+				return false
+			}
+			return resources.StrictlyGreaterThan(resources.Sub(l.GetPendingResource(), r.GetPendingResource()), resources.Zero)
+		}
+		return comp < 0
+	})
+}
+
+// sortApplications returns the applications with a pending request sorted based on the sort type. For the fair
+// policy the global resource is the denominator of the usage share, for the drf policy the partition capacity is
+// passed in as the global resource, combined with the resource weights.
+func sortApplications(apps map[string]*Application, sortType policies.SortPolicy, considerPriority bool, globalResource *resources.Resource, weights map[string]float64) []*Application {
 	sortingStart := time.Now()
 	sortedApps := filterOnPendingResources(apps)
 	switch sortType {
@@ -108,6 +160,18 @@ func sortApplications(apps map[string]*Application, sortType policies.SortPolicy
 		} else {
 			sortApplicationsByFairnessAndPriority(sortedApps, globalResource)
 		}
+	case policies.DrfSortPolicy:
+		if considerPriority {
+			sortApplicationsByPriorityAndDominantShare(sortedApps, globalResource, weights)
+		} else {
+			sortApplicationsByDominantShareAndPriority(sortedApps, globalResource, weights)
+		}
+	case policies.EdfSortPolicy:
+		if considerPriority {
+			sortApplicationsByPriorityAndDeadline(sortedApps)
+		} else {
+			sortApplicationsByDeadlineAndPriority(sortedApps)
+		}
 	case policies.FifoSortPolicy:
 		if considerPriority {
 			sortApplicationsByPriorityAndSubmissionTime(sortedApps)
@@ -126,7 +190,7 @@ func sortApplicationsByFairnessAndPriority(sortedApps []*Application, globalReso
 		if comp := resources.CompUsageRatio(l.GetAllocatedResource(), r.GetAllocatedResource(), globalResource); comp != 0 {
 			return comp < 0
 		}
-		return l.GetAskMaxPriority() > r.GetAskMaxPriority()
+		return l.GetSchedulingPriority() > r.GetSchedulingPriority()
 	})
 }
 
@@ -134,8 +198,8 @@ func sortApplicationsByPriorityAndFairness(sortedApps []*Application, globalReso
 	sort.SliceStable(sortedApps, func(i, j int) bool {
 		l := sortedApps[i]
 		r := sortedApps[j]
-		leftPriority := l.GetAskMaxPriority()
-		rightPriority := r.GetAskMaxPriority()
+		leftPriority := l.GetSchedulingPriority()
+		rightPriority := r.GetSchedulingPriority()
 		if leftPriority > rightPriority {
 			return true
 		}
@@ -146,6 +210,85 @@ func sortApplicationsByPriorityAndFairness(sortedApps []*Application, globalReso
 	})
 }
 
+func sortApplicationsByDominantShareAndPriority(sortedApps []*Application, capacity *resources.Resource, weights map[string]float64) {
+	sort.SliceStable(sortedApps, func(i, j int) bool {
+		l := sortedApps[i]
+		r := sortedApps[j]
+		if comp := resources.CompDominantShare(l.GetAllocatedResource(), r.GetAllocatedResource(), capacity, weights); comp != 0 {
+			return comp < 0
+		}
+		return l.GetSchedulingPriority() > r.GetSchedulingPriority()
+	})
+}
+
+func sortApplicationsByPriorityAndDominantShare(sortedApps []*Application, capacity *resources.Resource, weights map[string]float64) {
+	sort.SliceStable(sortedApps, func(i, j int) bool {
+		l := sortedApps[i]
+		r := sortedApps[j]
+		leftPriority := l.GetSchedulingPriority()
+		rightPriority := r.GetSchedulingPriority()
+		if leftPriority > rightPriority {
+			return true
+		}
+		if leftPriority < rightPriority {
+			return false
+		}
+		return resources.CompDominantShare(l.GetAllocatedResource(), r.GetAllocatedResource(), capacity, weights) < 0
+	})
+}
+
+// compareDeadline returns true as the first value if the left application has less slack: an earlier deadline.
+// Applications without a deadline always have more slack than those with one. The second value is false if the
+// deadlines are the same and the comparison is undecided.
+func compareDeadline(l, r *Application) (bool, bool) {
+	lDeadline := l.GetDeadline()
+	rDeadline := r.GetDeadline()
+	switch {
+	case lDeadline.Equal(rDeadline):
+		return false, false
+	case rDeadline.IsZero():
+		return true, true
+	case lDeadline.IsZero():
+		return false, true
+	default:
+		return lDeadline.Before(rDeadline), true
+	}
+}
+
+func sortApplicationsByDeadlineAndPriority(sortedApps []*Application) {
+	sort.SliceStable(sortedApps, func(i, j int) bool {
+		l := sortedApps[i]
+		r := sortedApps[j]
+		if less, decided := compareDeadline(l, r); decided {
+			return less
+		}
+		leftPriority := l.GetSchedulingPriority()
+		rightPriority := r.GetSchedulingPriority()
+		if leftPriority != rightPriority {
+			return leftPriority > rightPriority
+		}
+		return l.SubmissionTime.Before(r.SubmissionTime)
+	})
+}
+
+// sortApplicationsByPriorityAndDeadline uses the scheduling priority: an application at risk of missing its
+// deadline is never sorted behind applications with a higher ask priority.
+func sortApplicationsByPriorityAndDeadline(sortedApps []*Application) {
+	sort.SliceStable(sortedApps, func(i, j int) bool {
+		l := sortedApps[i]
+		r := sortedApps[j]
+		leftPriority := l.GetSchedulingPriority()
+		rightPriority := r.GetSchedulingPriority()
+		if leftPriority != rightPriority {
+			return leftPriority > rightPriority
+		}
+		if less, decided := compareDeadline(l, r); decided {
+			return less
+		}
+		return l.SubmissionTime.Before(r.SubmissionTime)
+	})
+}
+
 func sortApplicationsBySubmissionTimeAndPriority(sortedApps []*Application) {
 	sort.SliceStable(sortedApps, func(i, j int) bool {
 		l := sortedApps[i]
@@ -156,7 +299,7 @@ func sortApplicationsBySubmissionTimeAndPriority(sortedApps []*Application) {
 		if r.SubmissionTime.Before(l.SubmissionTime) {
 			return false
 		}
-		return l.GetAskMaxPriority() > r.GetAskMaxPriority()
+		return l.GetSchedulingPriority() > r.GetSchedulingPriority()
 	})
 }
 
@@ -164,8 +307,8 @@ func sortApplicationsByPriorityAndSubmissionTime(sortedApps []*Application) {
 	sort.SliceStable(sortedApps, func(i, j int) bool {
 		l := sortedApps[i]
 		r := sortedApps[j]
-		leftPriority := l.GetAskMaxPriority()
-		rightPriority := r.GetAskMaxPriority()
+		leftPriority := l.GetSchedulingPriority()
+		rightPriority := r.GetSchedulingPriority()
 		if leftPriority > rightPriority {
 			return true
 		}
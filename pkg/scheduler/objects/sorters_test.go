@@ -26,6 +26,7 @@ import (
 
 	"gotest.tools/v3/assert"
 
+	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
 )
@@ -70,49 +71,49 @@ func TestSortQueuesWithGuarantees(t *testing.T) {
 	// fifo
 	queues = []*Queue{q0, q1, q2, q3}
 
-	sortQueue(queues, fairMaxResources, policies.FifoSortPolicy, false)
+	sortQueue(queues, fairMaxResources, policies.FifoSortPolicy, false, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q0, q1, q2, q3}), "fifo first")
 
 	queues = []*Queue{q0, q1, q2, q3}
-	sortQueue(queues, fairMaxResources, policies.FifoSortPolicy, true)
+	sortQueue(queues, fairMaxResources, policies.FifoSortPolicy, true, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q0, q1, q2}), "fifo first - priority")
 
 	// fifo - different starting order
 	queues = []*Queue{q1, q3, q0, q2}
-	sortQueue(queues, fairMaxResources, policies.FifoSortPolicy, false)
+	sortQueue(queues, fairMaxResources, policies.FifoSortPolicy, false, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q1, q3, q0, q2}), "fifo second")
 
 	queues = []*Queue{q1, q3, q0, q2}
-	sortQueue(queues, fairMaxResources, policies.FifoSortPolicy, true)
+	sortQueue(queues, fairMaxResources, policies.FifoSortPolicy, true, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q1, q0, q2}), "fifo second - priority")
 
 	// fairness ratios: q0:300/500=0.6, q1:200/300=0.67, q2:100/200=0.5, q3:100/200=0.5
 	queues = []*Queue{q0, q1, q2, q3}
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q2, q0, q1}), "fair first")
 
 	queues = []*Queue{q0, q1, q2, q3}
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q2, q0, q1}), "fair first - priority")
 
 	// fairness ratios: q0:200/500=0.4, q1:300/300=1, q2:100/200=0.5, q3:100/200=0.5
 	q0.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 200, "vcore": 200})
 	q1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 300, "vcore": 300})
 	queues = []*Queue{q0, q1, q2, q3}
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q0, q3, q2, q1}), "fair second")
 	queues = []*Queue{q0, q1, q2, q3}
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q0, q2, q1}), "fair second - priority")
 
 	// fairness ratios: q0:150/500=0.3, q1:120/300=0.4, q2:100/200=0.5, q3:100/200=0.5
 	q0.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 150, "vcore": 150})
 	q1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 120, "vcore": 120})
 	queues = []*Queue{q0, q1, q2, q3}
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q0, q1, q3, q2}), "fair third")
 	queues = []*Queue{q0, q1, q2, q3}
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q0, q1, q2}), "fair third - priority")
 
 	// fairness ratios: q0:400/800=0.5, q1:200/400= 0.5, q2:100/200=0.5, q3:100/200=0.5
@@ -121,7 +122,7 @@ func TestSortQueuesWithGuarantees(t *testing.T) {
 	q1.guaranteedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 400, "vcore": 300})
 	q1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 200, "vcore": 150})
 	queues = []*Queue{q0, q1, q2, q3}
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q0, q1, q2}), "fair - pending resource")
 }
 
@@ -157,22 +158,71 @@ func TestSortQueuesNoGuarantees(t *testing.T) {
 		resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 1000, "vcore": 1000}),
 		resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 1000, "vcore": 1000}),
 	}
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q2, q1, q0}), "fair no gaurantees first")
 
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q2, q0, q1}), "fair no gaurantees first - priority")
 
 	q0.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 200, "vcore": 200})
 	q1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 300, "vcore": 300})
 
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, false, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q2, q0, q1}), "fair no gaurantees second")
 
-	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true)
+	sortQueue(queues, fairMaxResources, policies.FairSortPolicy, true, nil)
 	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q3, q2, q0, q1}), "fair no limit second - priority")
 }
 
+func TestSortQueuesWeighted(t *testing.T) {
+	root, err := createRootQueue(map[string]string{"memory": "900"})
+	assert.NilError(t, err, "queue create failed")
+
+	var q0, q1 *Queue
+	q0, err = createManagedQueueWithProps(root, "q0", false, nil, map[string]string{configs.QueueWeight: "2"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	q0.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 400})
+	q1, err = createManagedQueue(root, "q1", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 250})
+
+	// q0 uses more but deserves twice the share: 400/600 against 250/300
+	queues := []*Queue{q1, q0}
+	sortQueue(queues, []*resources.Resource{q1.GetFairMaxResource(), q0.GetFairMaxResource()}, policies.FairSortPolicy, false, nil)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q0, q1}), "weighted fair share")
+
+	// without weights the usage decides
+	q0.properties = map[string]string{}
+	q0.UpdateQueueProperties()
+	queues = []*Queue{q0, q1}
+	sortQueue(queues, []*resources.Resource{q0.GetFairMaxResource(), q1.GetFairMaxResource()}, policies.FairSortPolicy, false, nil)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q1, q0}), "unweighted fair share")
+}
+
+func TestSortQueuesDominantShare(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var q0, q1 *Queue
+	q0, err = createManagedQueue(root, "q0", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q0.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 400})
+	q1, err = createManagedQueue(root, "q1", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"gpu": 3})
+
+	capacity := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 1000, "gpu": 10})
+	capacities := []*resources.Resource{capacity, capacity}
+	queues := []*Queue{q0, q1}
+	sortQueue(queues, capacities, policies.DrfSortPolicy, false, nil)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q1, q0}), "unweighted dominant share")
+	sortQueue(queues, capacities, policies.DrfSortPolicy, false, map[string]float64{"gpu": 2})
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q0, q1}), "weighted dominant share")
+
+	q1.currentPriority = 10
+	sortQueue(queues, capacities, policies.DrfSortPolicy, true, map[string]float64{"gpu": 2})
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q1, q0}), "priority before dominant share")
+}
+
 func TestSortAppsNoPending(t *testing.T) {
 	var list []*Application
 
@@ -188,27 +238,27 @@ func TestSortAppsNoPending(t *testing.T) {
 	}
 
 	// no apps with pending resources should come back empty
-	list = sortApplications(input, policies.FairSortPolicy, false, nil)
+	list = sortApplications(input, policies.FairSortPolicy, false, nil, nil)
 	assertAppListLength(t, list, []string{}, "fair no pending")
-	list = sortApplications(input, policies.FairSortPolicy, true, nil)
+	list = sortApplications(input, policies.FairSortPolicy, true, nil, nil)
 	assertAppListLength(t, list, []string{}, "fair no pending - priority")
 
-	list = sortApplications(input, policies.FifoSortPolicy, false, nil)
+	list = sortApplications(input, policies.FifoSortPolicy, false, nil, nil)
 	assertAppListLength(t, list, []string{}, "fifo no pending")
-	list = sortApplications(input, policies.FifoSortPolicy, true, nil)
+	list = sortApplications(input, policies.FifoSortPolicy, true, nil, nil)
 	assertAppListLength(t, list, []string{}, "fifo no pending - priority")
 
 	// set one app with pending
 	appID := "app-1"
 	input[appID].pending = res
-	list = sortApplications(input, policies.FairSortPolicy, false, nil)
+	list = sortApplications(input, policies.FairSortPolicy, false, nil, nil)
 	assertAppListLength(t, list, []string{appID}, "fair one pending")
-	list = sortApplications(input, policies.FairSortPolicy, true, nil)
+	list = sortApplications(input, policies.FairSortPolicy, true, nil, nil)
 	assertAppListLength(t, list, []string{appID}, "fair one pending - priority")
 
-	list = sortApplications(input, policies.FifoSortPolicy, false, nil)
+	list = sortApplications(input, policies.FifoSortPolicy, false, nil, nil)
 	assertAppListLength(t, list, []string{appID}, "fifo one pending")
-	list = sortApplications(input, policies.FifoSortPolicy, true, nil)
+	list = sortApplications(input, policies.FifoSortPolicy, true, nil, nil)
 	assertAppListLength(t, list, []string{appID}, "fifo one pending - priority")
 }
 
@@ -231,14 +281,14 @@ func TestSortAppsFifo(t *testing.T) {
 	}
 
 	// fifo - apps should come back in order created 0, 1, 2, 3
-	list = sortApplications(input, policies.FifoSortPolicy, false, nil)
+	list = sortApplications(input, policies.FifoSortPolicy, false, nil, nil)
 	assertAppList(t, list, []int{0, 1, 2, 3}, "fifo simple")
 
 	input["app-1"].askMaxPriority = 3
 	input["app-3"].askMaxPriority = 5
 	input["app-2"].SubmissionTime = input["app-3"].SubmissionTime
 	input["app-1"].SubmissionTime = input["app-3"].SubmissionTime
-	list = sortApplications(input, policies.FifoSortPolicy, false, nil)
+	list = sortApplications(input, policies.FifoSortPolicy, false, nil, nil)
 	/*
 	* apps order: 0, 3, 1, 2
 	* the resultType of app index is [0, 2, 3, 1]
@@ -270,7 +320,7 @@ func TestSortAppsPriorityFifo(t *testing.T) {
 	input["app-3"].askMaxPriority = 4
 
 	// priority - apps should come back in order 1, 3, 0, 2
-	list = sortApplications(input, policies.FifoSortPolicy, true, nil)
+	list = sortApplications(input, policies.FifoSortPolicy, true, nil, nil)
 	assertAppList(t, list, []int{2, 0, 3, 1}, "fifo simple")
 }
 
@@ -290,27 +340,27 @@ func TestSortAppsFair(t *testing.T) {
 	}
 	// nil resource: usage based sorting
 	// apps should come back in order: 0, 1, 2, 3
-	list := sortApplications(input, policies.FairSortPolicy, false, nil)
+	list := sortApplications(input, policies.FairSortPolicy, false, nil, nil)
 	assertAppList(t, list, []int{0, 1, 2, 3}, "nil total")
 
 	// apps should come back in order: 0, 1, 2, 3
-	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 0))
+	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 0), nil)
 	assertAppList(t, list, []int{0, 1, 2, 3}, "zero total")
 
 	// apps should come back in order: 0, 1, 2, 3
-	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 5))
+	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 5), nil)
 	assertAppList(t, list, []int{0, 1, 2, 3}, "no alloc, set total")
 
 	// update allocated resource for app-1
 	input["app-1"].allocatedResource = resources.Multiply(res, 10)
 	// apps should come back in order: 0, 2, 3, 1
-	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 5))
+	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 5), nil)
 	assertAppList(t, list, []int{0, 3, 1, 2}, "app-1 allocated")
 
 	// update allocated resource for app-3 to negative (move to head of the list)
 	input["app-3"].allocatedResource = resources.Multiply(res, -10)
 	// apps should come back in order: 3, 0, 2, 1
-	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 5))
+	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 5), nil)
 	assertAppList(t, list, []int{1, 3, 2, 0}, "app-1 & app-3 allocated")
 
 	// update allocated resource for app-3 & app-1 where priority of app-3 is higher
@@ -318,7 +368,7 @@ func TestSortAppsFair(t *testing.T) {
 	input["app-1"].askMaxPriority = 2
 	input["app-3"].allocatedResource = resources.Multiply(res, 10)
 	input["app-3"].askMaxPriority = 3
-	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 5))
+	list = sortApplications(input, policies.FairSortPolicy, false, resources.Multiply(res, 5), nil)
 	/*
 	*  expected apps order: 0, 2, 3, 1 means
 	*  So resultType of apps indexs is [0, 3, 1, 2]
@@ -327,6 +377,67 @@ func TestSortAppsFair(t *testing.T) {
 	assertAppList(t, list, []int{0, 3, 1, 2}, "app-1 & app-3 allocated, app-3 high priority")
 }
 
+func TestSortAppsDominantShare(t *testing.T) {
+	capacity := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 1000, "gpu": 10})
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 1})
+	input := make(map[string]*Application, 3)
+	for i, alloc := range []map[string]resources.Quantity{{"memory": 500}, {"gpu": 2}, {"memory": 100, "gpu": 1}} {
+		appID := "app-" + strconv.Itoa(i)
+		app := newApplication(appID, "partition", "queue")
+		app.allocatedResource = resources.NewResourceFromMap(alloc)
+		app.pending = pending
+		input[appID] = app
+	}
+	// dominant shares: app-0 memory 0.5, app-1 gpu 0.2, app-2 gpu 0.1
+	list := sortApplications(input, policies.DrfSortPolicy, false, capacity, nil)
+	assertAppListLength(t, list, []string{"app-2", "app-1", "app-0"}, "unweighted dominant share")
+
+	// gpu weighted: app-0 memory 0.5, app-1 gpu 1.0, app-2 gpu 0.5 with memory 0.1 as the tie breaker
+	weights := map[string]float64{"gpu": 5}
+	list = sortApplications(input, policies.DrfSortPolicy, false, capacity, weights)
+	assertAppListLength(t, list, []string{"app-0", "app-2", "app-1"}, "weighted dominant share")
+
+	// priority first
+	input["app-1"].askMaxPriority = 1
+	list = sortApplications(input, policies.DrfSortPolicy, true, capacity, weights)
+	assertAppListLength(t, list, []string{"app-1", "app-0", "app-2"}, "weighted dominant share with priority")
+}
+
+func TestSortAppsDeadline(t *testing.T) {
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	now := time.Now()
+	input := make(map[string]*Application, 4)
+	// app-0 has no deadline, app-1 the latest, app-2 and app-3 share the earliest deadline
+	for i, deadline := range []time.Time{{}, now.Add(time.Hour), now.Add(time.Minute), now.Add(time.Minute)} {
+		appID := "app-" + strconv.Itoa(i)
+		app := newApplication(appID, "partition", "queue")
+		app.pending = res
+		app.deadline = deadline
+		app.SubmissionTime = now.Add(-time.Duration(i) * time.Second)
+		input[appID] = app
+	}
+	// same deadline: app-3 was submitted first
+	list := sortApplications(input, policies.EdfSortPolicy, false, nil, nil)
+	assertAppListLength(t, list, []string{"app-3", "app-2", "app-1", "app-0"}, "earliest deadline first")
+
+	// priority breaks the tie on the deadline only
+	input["app-2"].askMaxPriority = 5
+	input["app-0"].askMaxPriority = 10
+	list = sortApplications(input, policies.EdfSortPolicy, false, nil, nil)
+	assertAppListLength(t, list, []string{"app-2", "app-3", "app-1", "app-0"}, "deadline before priority")
+	list = sortApplications(input, policies.EdfSortPolicy, true, nil, nil)
+	assertAppListLength(t, list, []string{"app-0", "app-2", "app-3", "app-1"}, "priority before deadline")
+
+	// an application at risk gets the deadline boost of the edf queue
+	queue, err := createManagedQueueWithProps(nil, "root", false, nil, map[string]string{configs.ApplicationSortPolicy: policies.EdfSortPolicy.String()})
+	assert.NilError(t, err, "failed to create queue")
+	input["app-1"].askMaxPriority = 1
+	input["app-1"].deadlineAtRisk = true
+	input["app-1"].queue = queue
+	list = sortApplications(input, policies.EdfSortPolicy, true, nil, nil)
+	assertAppListLength(t, list, []string{"app-1", "app-0", "app-2", "app-3"}, "deadline at risk")
+}
+
 func TestSortAppsPriorityFair(t *testing.T) {
 	// stable sort is used so equal values stay where they were
 	res := resources.NewResourceFromMap(map[string]resources.Quantity{
@@ -347,27 +458,27 @@ func TestSortAppsPriorityFair(t *testing.T) {
 
 	// nil resource: priority then usage based sorting
 	// apps should come back in order: 1, 0, 2, 3
-	list := sortApplications(input, policies.FairSortPolicy, true, nil)
+	list := sortApplications(input, policies.FairSortPolicy, true, nil, nil)
 	assertAppList(t, list, []int{1, 0, 2, 3}, "nil total")
 
 	// apps should come back in order: 1, 0, 2, 3
-	list = sortApplications(input, policies.FairSortPolicy, true, resources.Multiply(res, 0))
+	list = sortApplications(input, policies.FairSortPolicy, true, resources.Multiply(res, 0), nil)
 	assertAppList(t, list, []int{1, 0, 2, 3}, "zero total")
 
 	// apps should come back in order: 1, 0, 2, 3
-	list = sortApplications(input, policies.FairSortPolicy, true, resources.Multiply(res, 5))
+	list = sortApplications(input, policies.FairSortPolicy, true, resources.Multiply(res, 5), nil)
 	assertAppList(t, list, []int{1, 0, 2, 3}, "no alloc, set total")
 
 	// update allocated resource for app-2
 	input["app-2"].allocatedResource = resources.Multiply(res, 10)
 	// apps should come back in order: 1, 0, 3, 2
-	list = sortApplications(input, policies.FairSortPolicy, true, resources.Multiply(res, 5))
+	list = sortApplications(input, policies.FairSortPolicy, true, resources.Multiply(res, 5), nil)
 	assertAppList(t, list, []int{1, 0, 3, 2}, "app-1 allocated")
 
 	// update allocated resource for app-3 to negative (move to head of the list within priority 0)
 	input["app-3"].allocatedResource = resources.Multiply(res, -10)
 	// apps should come back in order: 1, 3, 0, 2
-	list = sortApplications(input, policies.FairSortPolicy, true, resources.Multiply(res, 5))
+	list = sortApplications(input, policies.FairSortPolicy, true, resources.Multiply(res, 5), nil)
 	assertAppList(t, list, []int{2, 0, 3, 1}, "app-1 & app-3 allocated")
 }
 
@@ -414,6 +525,6 @@ func TestSortBySubmissionTime(t *testing.T) {
 		input[appID] = app
 	}
 
-	list = sortApplications(input, policies.FifoSortPolicy, true, nil)
+	list = sortApplications(input, policies.FifoSortPolicy, true, nil, nil)
 	assertAppList(t, list, []int{3, 2, 1, 0}, "sort by submission time")
 }
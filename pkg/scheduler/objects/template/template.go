@@ -19,6 +19,8 @@
 package template
 
 import (
+	"time"
+
 	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/webservice/dao"
@@ -29,6 +31,7 @@ type Template struct {
 	properties         map[string]string
 	maxResource        *resources.Resource
 	guaranteedResource *resources.Resource
+	maxRuntime         time.Duration
 }
 
 // FromConf converts the configs.ChildTemplate to a Template.
@@ -49,14 +52,23 @@ func FromConf(template *configs.ChildTemplate) (*Template, error) {
 		return nil, err
 	}
 
-	return newTemplate(template.MaxApplications, template.Properties, maxResource, guaranteedResource), nil
+	var maxRuntime time.Duration
+	if template.MaxRuntime != "" {
+		maxRuntime, err = configs.ParseMaxRuntime(template.MaxRuntime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newTemplate(template.MaxApplications, template.Properties, maxResource, guaranteedResource, maxRuntime), nil
 }
 
 func isChildTemplateEmpty(template *configs.ChildTemplate) bool {
 	return template.MaxApplications == 0 &&
 		isMapEmpty(template.Properties) &&
 		isMapEmpty(template.Resources.Guaranteed) &&
-		isMapEmpty(template.Resources.Max)
+		isMapEmpty(template.Resources.Max) &&
+		template.MaxRuntime == ""
 }
 
 // A non-empty list of empty property values is also empty
@@ -69,12 +81,13 @@ func isMapEmpty(m map[string]string) bool {
 	return true
 }
 
-func newTemplate(maxApplications uint64, properties map[string]string, maxResource *resources.Resource, guaranteedResource *resources.Resource) *Template {
+func newTemplate(maxApplications uint64, properties map[string]string, maxResource *resources.Resource, guaranteedResource *resources.Resource, maxRuntime time.Duration) *Template {
 	template := &Template{
 		maxApplications:    maxApplications,
 		properties:         make(map[string]string),
 		maxResource:        nil,
 		guaranteedResource: nil,
+		maxRuntime:         maxRuntime,
 	}
 
 	if resources.StrictlyGreaterThanZero(maxResource) {
@@ -123,6 +136,14 @@ func (t *Template) GetGuaranteedResource() *resources.Resource {
 	return t.guaranteedResource.Clone()
 }
 
+// GetMaxRuntime returns the max runtime of applications, zero if not set
+func (t *Template) GetMaxRuntime() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return t.maxRuntime
+}
+
 // GetTemplateInfo converts this to a TemplateInfo
 func (t *Template) GetTemplateInfo() *dao.TemplateInfo {
 	if t == nil {
@@ -133,5 +154,14 @@ func (t *Template) GetTemplateInfo() *dao.TemplateInfo {
 		Properties:         t.GetProperties(),
 		MaxResource:        t.maxResource.DAOMap(),
 		GuaranteedResource: t.guaranteedResource.DAOMap(),
+		MaxRuntime:         formatMaxRuntime(t.maxRuntime),
+	}
+}
+
+// formatMaxRuntime returns the max runtime as a string, empty if not set
+func formatMaxRuntime(maxRuntime time.Duration) string {
+	if maxRuntime == 0 {
+		return ""
 	}
+	return maxRuntime.String()
 }
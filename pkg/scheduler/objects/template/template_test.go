@@ -22,6 +22,7 @@ import (
 	"math/rand"
 	"strconv"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -49,9 +50,10 @@ func getResource(t *testing.T) *resources.Resource {
 	return r
 }
 
-func checkMembers(t *testing.T, template *Template, maxApplications uint64, properties map[string]string, maxResource *resources.Resource, guaranteedResource *resources.Resource) {
+func checkMembers(t *testing.T, template *Template, maxApplications uint64, properties map[string]string, maxResource *resources.Resource, guaranteedResource *resources.Resource, maxRuntime time.Duration) {
 	// test inner members
 	assert.Equal(t, template.maxApplications, maxApplications)
+	assert.Equal(t, template.maxRuntime, maxRuntime)
 	assert.DeepEqual(t, template.properties, properties)
 	assert.DeepEqual(t, template.maxResource, maxResource)
 	assert.DeepEqual(t, template.guaranteedResource, guaranteedResource)
@@ -61,12 +63,14 @@ func checkMembers(t *testing.T, template *Template, maxApplications uint64, prop
 	assert.DeepEqual(t, template.GetProperties(), properties)
 	assert.DeepEqual(t, template.GetMaxResource(), maxResource)
 	assert.DeepEqual(t, template.GetGuaranteedResource(), guaranteedResource)
+	assert.Equal(t, template.GetMaxRuntime(), maxRuntime)
 
 	assert.DeepEqual(t, template.GetTemplateInfo(), &dao.TemplateInfo{
 		MaxApplications:    template.GetMaxApplications(),
 		Properties:         template.GetProperties(),
 		MaxResource:        template.maxResource.DAOMap(),
 		GuaranteedResource: template.guaranteedResource.DAOMap(),
+		MaxRuntime:         formatMaxRuntime(maxRuntime),
 	})
 }
 
@@ -75,6 +79,7 @@ func checkNilTemplate(t *testing.T, template *Template) {
 	assert.Assert(t, template.GetMaxResource() == nil)
 	assert.Assert(t, template.GetGuaranteedResource() == nil)
 	assert.Assert(t, template.GetTemplateInfo() == nil)
+	assert.Equal(t, template.GetMaxRuntime(), time.Duration(0))
 }
 
 func TestNewTemplate(t *testing.T) {
@@ -83,7 +88,9 @@ func TestNewTemplate(t *testing.T) {
 	maxResource := getResource(t)
 	maxApplications := uint64(1)
 
-	checkMembers(t, newTemplate(maxApplications, properties, maxResource, guaranteedResource), maxApplications, properties, maxResource, guaranteedResource)
+	maxRuntime := time.Hour
+
+	checkMembers(t, newTemplate(maxApplications, properties, maxResource, guaranteedResource, maxRuntime), maxApplications, properties, maxResource, guaranteedResource, maxRuntime)
 }
 
 func TestFromConf(t *testing.T) {
@@ -107,7 +114,7 @@ func TestFromConf(t *testing.T) {
 	assert.NilError(t, err, "failed to parse resource: %v", err)
 	guaranteedResource, err := resources.NewResourceFromConf(guaranteedResourceConf)
 	assert.NilError(t, err, "failed to parse resource: %v", err)
-	checkMembers(t, template, maxApplications, properties, maxResource, guaranteedResource)
+	checkMembers(t, template, maxApplications, properties, maxResource, guaranteedResource, 0)
 
 	// case 1: empty map produces nil template
 	template, err = FromConf(&configs.ChildTemplate{
@@ -171,4 +178,15 @@ func TestFromConf(t *testing.T) {
 	})
 	assert.Assert(t, err != nil)
 	checkNilTemplate(t, template)
+
+	// case 6: max runtime only produces template
+	template, err = FromConf(&configs.ChildTemplate{MaxRuntime: "2h"})
+	assert.NilError(t, err)
+	assert.Equal(t, template.GetMaxRuntime(), 2*time.Hour)
+	assert.Equal(t, template.GetTemplateInfo().MaxRuntime, "2h0m0s")
+
+	// case 7: invalid max runtime
+	template, err = FromConf(&configs.ChildTemplate{MaxRuntime: "0s"})
+	assert.ErrorContains(t, err, "max runtime must be larger than zero")
+	checkNilTemplate(t, template)
 }
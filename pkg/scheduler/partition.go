@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -66,6 +67,14 @@ type PartitionContext struct {
 	placeholderAllocations int                             // number of placeholder allocations
 	preemptionEnabled      bool                            // whether preemption is enabled or not
 	foreignAllocs          map[string]*objects.Allocation  // foreign (non-Yunikorn) allocations
+	queueConf              configs.QueueConfig             // configured root queue without capacity schedules applied
+	capacitySchedules      []*capacitySchedule             // capacity schedules in configuration order
+	capacityProfile        string                          // name of the active capacity schedule, empty if none
+	dependents             map[string][]string             // applications waiting on an application to terminate, keyed by the application waited on
+
+	// nodePoolLock serialises the changes to the node list and node capacity with the node pool resource
+	// calculation: a node must never be counted twice in, or removed twice from, a node pool resource.
+	nodePoolLock locking.Mutex
 
 	// The partition write lock must not be held while manipulating an application.
 	// Scheduling is running continuously as a lock free background task. Scheduling an application
@@ -94,6 +103,7 @@ func newPartitionContext(conf configs.PartitionConfig, rmID string, cc *ClusterC
 		stateTime:             time.Now(),
 		applications:          make(map[string]*objects.Application),
 		completedApplications: make(map[string]*objects.Application),
+		dependents:            make(map[string][]string),
 		nodes:                 objects.NewNodeCollection(conf.Name),
 		foreignAllocs:         make(map[string]*objects.Allocation),
 	}
@@ -112,10 +122,16 @@ func (pc *PartitionContext) initialPartitionFromConfig(conf configs.PartitionCon
 		return fmt.Errorf("partition cannot be created without root queue")
 	}
 
+	var err error
+	if pc.capacitySchedules, err = newCapacitySchedules(conf.CapacitySchedules); err != nil {
+		return err
+	}
+	pc.queueConf = conf.Queues[0]
 	// Setup the queue structure: root first it should be the only queue at this level
 	// Add the rest of the queue structure recursively
-	queueConf := conf.Queues[0]
-	var err error
+	now := time.Now()
+	queueConf := pc.capacityQueueConf(now)
+	next := nextCapacityTransition(pc.capacitySchedules, now)
 	if pc.root, err = objects.NewConfiguredQueue(queueConf, nil, silence); err != nil {
 		return err
 	}
@@ -123,6 +139,8 @@ func (pc *PartitionContext) initialPartitionFromConfig(conf configs.PartitionCon
 	if err = pc.addQueue(queueConf.Queues, pc.root, silence); err != nil {
 		return err
 	}
+	pc.updateCapacityProfiles(next)
+	pc.resetNodePoolResources()
 
 	if !silence {
 		log.Log(log.SchedPartition).Info("root queue added",
@@ -140,7 +158,7 @@ func (pc *PartitionContext) initialPartitionFromConfig(conf configs.PartitionCon
 
 	// update limit settings: start at the root
 	if !silence {
-		return ugm.GetUserManager().UpdateConfig(queueConf, conf.Queues[0].Name)
+		return ugm.GetUserManager().UpdateConfig(queueConf, queueConf.Name)
 	}
 	return nil
 }
@@ -179,12 +197,34 @@ func (pc *PartitionContext) updatePartitionDetails(conf configs.PartitionConfig)
 		return err
 	}
 	pc.updateNodeSortingPolicy(conf, false)
+	schedules, err := newCapacitySchedules(conf.CapacitySchedules)
+	if err != nil {
+		return err
+	}
+	// searching for the transition can take a while: do not hold the lock
+	now := time.Now()
+	next := nextCapacityTransition(schedules, now)
 
 	pc.Lock()
 	defer pc.Unlock()
 	pc.updatePreemption(conf)
-	// start at the root: there is only one queue
-	queueConf := conf.Queues[0]
+	// start at the root: there is only one queue, use the active capacity schedule if there is one
+	pc.queueConf = conf.Queues[0]
+	pc.capacitySchedules = schedules
+	queueConf := pc.capacityQueueConf(now)
+	if err = pc.updateQueueTree(queueConf); err != nil {
+		return err
+	}
+	pc.updateCapacityProfiles(next)
+	// the windows might have changed: the manager must recalculate the next transition
+	pc.partitionManager.capacitySchedulesChanged()
+	// update limit settings: start at the root
+	return ugm.GetUserManager().UpdateConfig(queueConf, queueConf.Name)
+}
+
+// Update the root queue and the queues below it from the configuration.
+// NOTE: this is a lock free call. It should only be called holding the PartitionContext lock.
+func (pc *PartitionContext) updateQueueTree(queueConf configs.QueueConfig) error {
 	root := pc.root
 	// update the root queue
 	if err := root.ApplyConf(queueConf); err != nil {
@@ -195,8 +235,93 @@ func (pc *PartitionContext) updatePartitionDetails(conf configs.PartitionConfig)
 	if err := pc.updateQueues(queueConf.Queues, root); err != nil {
 		return err
 	}
-	// update limit settings: start at the root
-	return ugm.GetUserManager().UpdateConfig(queueConf, conf.Queues[0].Name)
+	// node selectors might have changed
+	pc.resetNodePoolResources()
+	return nil
+}
+
+// Get the root queue configuration with the capacities of the active capacity schedule applied.
+// The name of the active schedule is updated.
+// NOTE: this is a lock free call. It should only be called holding the PartitionContext lock.
+func (pc *PartitionContext) capacityQueueConf(now time.Time) configs.QueueConfig {
+	queueConf := pc.queueConf
+	pc.capacityProfile = ""
+	if active := activeCapacitySchedule(pc.capacitySchedules, now); active != nil {
+		queueConf = configs.ApplyCapacitySchedule(queueConf, active.conf)
+		pc.capacityProfile = active.conf.Name
+	}
+	return queueConf
+}
+
+// Store the configured root queue that the capacity schedules are applied to. Called when the configuration of a
+// queue is changed without reloading the partition configuration.
+func (pc *PartitionContext) setQueueConf(queueConf configs.QueueConfig) {
+	pc.Lock()
+	defer pc.Unlock()
+	pc.queueConf = queueConf
+}
+
+func (pc *PartitionContext) getCapacitySchedules() []*capacitySchedule {
+	pc.RLock()
+	defer pc.RUnlock()
+	return pc.capacitySchedules
+}
+
+// Apply the capacity schedule that is active at the time if it differs from the schedule currently applied.
+// The queues are updated in the same way as on a configuration change. Returns the time of the next transition.
+// The transition is searched for without holding the lock: a configuration change that replaces the schedules in the
+// meantime triggers a new check.
+func (pc *PartitionContext) applyCapacitySchedules(now time.Time) time.Time {
+	next := nextCapacityTransition(pc.getCapacitySchedules(), now)
+	pc.Lock()
+	defer pc.Unlock()
+	previous := pc.capacityProfile
+	queueConf := pc.capacityQueueConf(now)
+	if previous != pc.capacityProfile {
+		log.Log(log.SchedPartition).Info("capacity schedule changed",
+			zap.String("partitionName", pc.Name),
+			zap.String("previous", previous),
+			zap.String("active", pc.capacityProfile),
+			zap.Time("nextTransition", next))
+		if err := pc.updateQueueTree(queueConf); err != nil {
+			log.Log(log.SchedPartition).Error("failed to apply capacity schedule",
+				zap.String("partitionName", pc.Name),
+				zap.String("active", pc.capacityProfile),
+				zap.Error(err))
+		}
+	}
+	pc.updateCapacityProfiles(next)
+	return next
+}
+
+// Set the active capacity schedule and the next transition on the queues that are part of a capacity schedule.
+// NOTE: this is a lock free call. It should only be called holding the PartitionContext lock.
+func (pc *PartitionContext) updateCapacityProfiles(next time.Time) {
+	scheduled := make(map[string]string)
+	for _, schedule := range pc.capacitySchedules {
+		active := schedule.conf.Name == pc.capacityProfile
+		for _, capacity := range schedule.conf.Queues {
+			path := strings.ToLower(capacity.Name)
+			if active {
+				scheduled[path] = pc.capacityProfile
+			} else if _, ok := scheduled[path]; !ok {
+				scheduled[path] = ""
+			}
+		}
+	}
+	queues := []*objects.Queue{pc.root}
+	for len(queues) > 0 {
+		queue := queues[0]
+		queues = queues[1:]
+		if profile, ok := scheduled[queue.QueuePath]; ok {
+			queue.SetCapacityProfile(profile, next)
+		} else {
+			queue.SetCapacityProfile("", time.Time{})
+		}
+		for _, child := range queue.GetCopyOfChildren() {
+			queues = append(queues, child)
+		}
+	}
 }
 
 // Process the config structure and create a queue info tree for this partition.
@@ -322,11 +447,22 @@ func (pc *PartitionContext) AddApplication(app *objects.Application) error {
 		return fmt.Errorf("failed to place application %s: %v", appID, err)
 	}
 	queueName := app.GetQueuePath()
+	dependencies := app.GetDependencies()
+
+	// dependencies that have already terminated must be processed after the partition lock is released:
+	// the deferred call runs after the deferred unlock
+	var terminated map[string]bool
+	defer func() {
+		pc.processTerminatedDependencies(app, terminated)
+	}()
 
 	// lock the partition and make the last change: we need to do this before creating the queues.
 	// queue cleanup might otherwise remove the queue again before we can add the application
 	pc.Lock()
 	defer pc.Unlock()
+	if dependency := pc.findDependencyCycle(appID, dependencies); dependency != "" {
+		return fmt.Errorf("failed to add application %s: dependency on %s is cyclic", appID, dependency)
+	}
 	// we have a queue name either from placement or direct, get the queue
 	queue := pc.getQueueInternal(queueName)
 
@@ -387,12 +523,124 @@ func (pc *PartitionContext) AddApplication(app *objects.Application) error {
 	// all is OK update the app and add it to the partition
 	app.SetQueue(queue)
 	app.SetTerminatedCallback(pc.moveTerminatedApp)
+	app.SetReservationsCallback(pc.decReservationCount)
+	app.SetNodeLookup(pc.GetNode)
 	queue.AddApplication(app)
 	pc.applications[appID] = app
+	terminated = pc.trackDependencies(appID, dependencies)
 
 	return nil
 }
 
+// trackDependencies tracks the dependencies of the application that have not terminated yet. The dependencies
+// that have terminated are returned with true for a completed and false for a failed or rejected application.
+// An unknown application might still be submitted and is tracked.
+// NOTE: this is a lock free call. It must only be called holding the PartitionContext lock.
+func (pc *PartitionContext) trackDependencies(appID string, dependencies []string) map[string]bool {
+	var terminated map[string]bool
+	for _, dependency := range dependencies {
+		if completed, ok := pc.getTerminatedState(dependency); ok {
+			if terminated == nil {
+				terminated = make(map[string]bool)
+			}
+			terminated[dependency] = completed
+			continue
+		}
+		pc.dependents[dependency] = append(pc.dependents[dependency], appID)
+	}
+	return terminated
+}
+
+// findDependencyCycle returns the dependency that waits, directly or through other applications, on the
+// application. An empty string is returned if the dependencies do not form a cycle.
+// NOTE: this is a lock free call. It must only be called holding the PartitionContext lock.
+func (pc *PartitionContext) findDependencyCycle(appID string, dependencies []string) string {
+	if len(dependencies) == 0 {
+		return ""
+	}
+	waiting := map[string]bool{appID: true}
+	queue := []string{appID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range pc.dependents[current] {
+			if !waiting[dependent] {
+				waiting[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	for _, dependency := range dependencies {
+		if waiting[dependency] {
+			return dependency
+		}
+	}
+	return ""
+}
+
+// getTerminatedState returns true as the second value if the application has terminated. The first value is true
+// if the application completed, false if it failed or was rejected. Expired applications are not known anymore.
+// NOTE: this is a lock free call. It must only be called holding the PartitionContext lock.
+func (pc *PartitionContext) getTerminatedState(appID string) (bool, bool) {
+	// a terminated application is moved to the completed applications asynchronously
+	if app, ok := pc.applications[appID]; ok {
+		return app.IsCompleted(), app.IsCompleted() || app.IsFailed()
+	}
+	if _, ok := pc.rejectedApplications[appID]; ok {
+		return false, true
+	}
+	for _, app := range pc.completedApplications {
+		if app.ApplicationID == appID && (app.IsCompleted() || app.IsFailed()) {
+			return app.IsCompleted(), true
+		}
+	}
+	return false, false
+}
+
+// processTerminatedDependencies resolves the completed dependencies of the application, or fails the application
+// if one of the dependencies failed or was rejected.
+// NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
+func (pc *PartitionContext) processTerminatedDependencies(app *objects.Application, terminated map[string]bool) {
+	for dependency, completed := range terminated {
+		if !completed {
+			app.FailDependency(dependency)
+			return
+		}
+	}
+	for dependency := range terminated {
+		app.ResolveDependency(dependency)
+	}
+}
+
+// notifyDependents resolves the dependency for the applications waiting on the terminated application if it
+// completed, or fails the waiting applications if it did not complete.
+// NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
+func (pc *PartitionContext) notifyDependents(appID string, completed bool) {
+	pc.Lock()
+	dependents := pc.dependents[appID]
+	delete(pc.dependents, appID)
+	// the terminated application no longer waits on anything
+	for dependency, waiting := range pc.dependents {
+		if waiting = slices.DeleteFunc(waiting, func(id string) bool { return id == appID }); len(waiting) == 0 {
+			delete(pc.dependents, dependency)
+		} else {
+			pc.dependents[dependency] = waiting
+		}
+	}
+	pc.Unlock()
+	for _, dependentID := range dependents {
+		dependent := pc.getApplication(dependentID)
+		if dependent == nil {
+			continue
+		}
+		if completed {
+			dependent.ResolveDependency(appID)
+		} else {
+			dependent.FailDependency(appID)
+		}
+	}
+}
+
 // Remove the application from the partition.
 // This does not fail and handles missing app/queue/node/allocations internally
 func (pc *PartitionContext) removeApplication(appID string) []*objects.Allocation {
@@ -409,6 +657,8 @@ func (pc *PartitionContext) removeApplication(appID string) []*objects.Allocatio
 	}
 	// Remove all allocations
 	allocations := app.RemoveAllAllocations()
+	// an application that is not completing after the removal fails the applications waiting on it
+	pc.notifyDependents(appID, app.IsCompleted() || app.IsCompleting())
 	// Remove all allocations from node(s) (queues have been updated already)
 	if len(allocations) != 0 {
 		// track the number of allocations
@@ -448,6 +698,62 @@ func (pc *PartitionContext) removeAppInternal(appID string) *objects.Application
 	return app
 }
 
+// MoveApplication moves an application with all its requests and allocations to another leaf queue in the partition.
+// The partition lock is not held during the move: a terminating application takes the partition lock while it holds
+// the application lock.
+func (pc *PartitionContext) MoveApplication(appID, queuePath string) error {
+	app := pc.getApplication(appID)
+	if app == nil {
+		return fmt.Errorf("application %s not found in partition %s", appID, pc.Name)
+	}
+	queue := pc.GetQueue(queuePath)
+	if queue == nil {
+		return fmt.Errorf("queue %s not found in partition %s", queuePath, pc.Name)
+	}
+	return app.MoveToQueue(queue)
+}
+
+// SuspendApplication suspends the application: all allocations are released and the pending requests are not
+// scheduled until the application is resumed.
+func (pc *PartitionContext) SuspendApplication(appID string) error {
+	app := pc.getApplication(appID)
+	if app == nil {
+		return fmt.Errorf("application %s not found in partition %s", appID, pc.Name)
+	}
+	reservations, err := app.Suspend()
+	if err != nil {
+		return err
+	}
+	if reservations > 0 {
+		pc.decReservationCount(reservations)
+	}
+	return nil
+}
+
+// ResumeApplication makes a suspended application schedulable again.
+func (pc *PartitionContext) ResumeApplication(appID string) error {
+	app := pc.getApplication(appID)
+	if app == nil {
+		return fmt.Errorf("application %s not found in partition %s", appID, pc.Name)
+	}
+	return app.Resume()
+}
+
+// expireAsks removes the pending requests of all applications that are waiting longer than their time-to-live.
+// NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
+func (pc *PartitionContext) expireAsks(now time.Time) {
+	for _, app := range pc.GetApplications() {
+		expired, reservations := app.ExpireAsks(now)
+		if expired == 0 {
+			continue
+		}
+		if reservations > 0 {
+			pc.decReservationCount(reservations)
+		}
+		metrics.GetSchedulerMetrics().AddExpiredAsks(pc.Name, app.GetQueuePath(), expired)
+	}
+}
+
 func (pc *PartitionContext) GetApplication(appID string) *objects.Application {
 	return pc.getApplication(appID)
 }
@@ -553,6 +859,12 @@ func (pc *PartitionContext) createQueue(name string, user security.UserGroup) (*
 				zap.Error(err))
 			return nil, err
 		}
+		// a template can set a node selector
+		if queue.HasNodePool() {
+			pc.nodePoolLock.Lock()
+			pc.setNodePoolResource(queue, pc.GetNodes())
+			pc.nodePoolLock.Unlock()
+		}
 	}
 	return queue, nil
 }
@@ -600,14 +912,96 @@ func (pc *PartitionContext) updatePartitionResource(delta *resources.Resource) {
 	}
 }
 
+// updateNodePoolResources adds the capacity delta of the node to the queues with a node pool that selects the node.
+// The delta is added to the node pool resources. A removal or decrease MUST be negative.
+// NOTE: this is a lock free call. It must be called holding the nodePoolLock.
+func (pc *PartitionContext) updateNodePoolResources(node *objects.Node, delta *resources.Resource) {
+	if delta == nil {
+		return
+	}
+	for _, queue := range pc.getNodePoolQueues() {
+		if queue.MatchesNode(node) {
+			queue.AddNodePoolResource(delta)
+		}
+	}
+}
+
+// resetNodePoolResources recalculates the node pool resources of all queues from the nodes in the partition.
+// Queues without a node pool have the node pool resource removed.
+// NOTE: this is a lock free call. It can be called holding the PartitionContext lock.
+func (pc *PartitionContext) resetNodePoolResources() {
+	pc.nodePoolLock.Lock()
+	defer pc.nodePoolLock.Unlock()
+	var nodes []*objects.Node
+	queues := []*objects.Queue{pc.root}
+	for len(queues) > 0 {
+		queue := queues[0]
+		queues = queues[1:]
+		if queue.HasNodePool() {
+			if nodes == nil {
+				nodes = pc.GetNodes()
+			}
+			pc.setNodePoolResource(queue, nodes)
+		} else {
+			queue.SetNodePoolResource(nil)
+		}
+		for _, child := range queue.GetCopyOfChildren() {
+			queues = append(queues, child)
+		}
+	}
+}
+
+// setNodePoolResource sets the node pool resource of the queue to the capacity of the nodes it selects.
+// NOTE: this is a lock free call. It must be called holding the nodePoolLock.
+func (pc *PartitionContext) setNodePoolResource(queue *objects.Queue, nodes []*objects.Node) {
+	capacity := resources.NewResource()
+	for _, node := range nodes {
+		if queue.MatchesNode(node) {
+			capacity.AddTo(node.GetCapacity())
+		}
+	}
+	queue.SetNodePoolResource(capacity)
+}
+
+// getNodePoolQueues returns all queues in the partition that have a node pool.
+func (pc *PartitionContext) getNodePoolQueues() []*objects.Queue {
+	var result []*objects.Queue
+	queues := []*objects.Queue{pc.root}
+	for len(queues) > 0 {
+		queue := queues[0]
+		queues = queues[1:]
+		if queue.HasNodePool() {
+			result = append(result, queue)
+		}
+		for _, child := range queue.GetCopyOfChildren() {
+			queues = append(queues, child)
+		}
+	}
+	return result
+}
+
+// updateNodeCapacity sets the new capacity of the node and updates the partition and node pool resources.
+// NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
+func (pc *PartitionContext) updateNodeCapacity(node *objects.Node, capacity *resources.Resource) {
+	pc.nodePoolLock.Lock()
+	delta := node.SetCapacity(capacity)
+	pc.updateNodePoolResources(node, delta)
+	pc.nodePoolLock.Unlock()
+	pc.updatePartitionResource(delta)
+}
+
 // addNodeToList adds a node to the partition, and updates the metrics & resource tracking information
 // if the node was added successfully to the partition.
 // NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
 func (pc *PartitionContext) addNodeToList(node *objects.Node) error {
-	// we don't grab a lock here because we only update pc.nodes which is internally protected
+	// we don't grab the partition lock here because we only update pc.nodes which is internally protected
+	pc.nodePoolLock.Lock()
 	if err := pc.nodes.AddNode(node); err != nil {
+		pc.nodePoolLock.Unlock()
 		return fmt.Errorf("failed to add node %s to partition %s, error: %v", node.NodeID, pc.Name, err)
 	}
+	pc.updateNodePoolResources(node, node.GetCapacity())
+	pc.nodePoolLock.Unlock()
 
 	pc.updatePartitionResource(node.GetCapacity())
 	metrics.GetSchedulerMetrics().IncActiveNodes()
@@ -619,8 +1013,14 @@ func (pc *PartitionContext) addNodeToList(node *objects.Node) error {
 }
 
 // removeNodeFromList removes the node from the list of partition nodes.
+// The node pool resources are updated as part of the removal.
 func (pc *PartitionContext) removeNodeFromList(nodeID string) *objects.Node {
+	pc.nodePoolLock.Lock()
 	node := pc.nodes.RemoveNode(nodeID)
+	if node != nil {
+		pc.updateNodePoolResources(node, resources.Multiply(node.GetCapacity(), -1))
+	}
+	pc.nodePoolLock.Unlock()
 	if node == nil {
 		log.Log(log.SchedPartition).Debug("node was not found, node already removed",
 			zap.String("nodeID", nodeID),
@@ -875,6 +1275,9 @@ func (pc *PartitionContext) allocate(result *objects.AllocationResult) *objects.
 			zap.String("appID", appID))
 		return nil
 	}
+	if len(result.Members) != 0 {
+		return pc.allocateGang(app, result)
+	}
 	// find the node make sure it still exists
 	// if the node was passed in use that ID instead of the one from the allocation
 	// the node ID is set when a reservation is allocated on a non-reserved node
@@ -937,24 +1340,63 @@ func (pc *PartitionContext) allocate(result *objects.AllocationResult) *objects.
 		result.ReservedNodeID = ""
 	}
 
+	pc.bindAllocation(result, targetNode)
+	// pass the allocation result back to the RM via the cluster context
+	return result
+}
+
+// allocateGang processes the minimum member set of a gang as a whole: the members are only passed back to the RM if
+// all their nodes still exist. If a node was removed while allocating all members are removed from their nodes and
+// the application, and are pending again.
+// NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
+func (pc *PartitionContext) allocateGang(app *objects.Application, result *objects.AllocationResult) *objects.AllocationResult {
+	gang := append([]*objects.AllocationResult{result}, result.Members...)
+	nodes := make([]*objects.Node, len(gang))
+	for i, member := range gang {
+		if nodes[i] = pc.GetNode(member.NodeID); nodes[i] == nil {
+			log.Log(log.SchedPartition).Info("Target node of a gang member was removed while allocating, unwinding gang",
+				zap.String("nodeID", member.NodeID),
+				zap.String("appID", app.ApplicationID),
+				zap.String("allocationKey", member.Request.GetAllocationKey()))
+			members := make([]*objects.Allocation, len(gang))
+			for j, unwind := range gang {
+				if node := pc.GetNode(unwind.NodeID); node != nil {
+					node.RemoveAllocation(unwind.Request.GetAllocationKey())
+				}
+				members[j] = unwind.Request
+			}
+			app.UnwindGangAllocation(members)
+			return nil
+		}
+	}
+	for i, member := range gang {
+		// gang members are never reserved, reservations could still be cancelled
+		pc.decReservationCount(member.CancelledReservations)
+		pc.bindAllocation(member, nodes[i])
+	}
+	// pass the allocation result with all members back to the RM via the cluster context
+	return result
+}
+
+// bindAllocation binds the allocation to the node and tracks it in the partition.
+func (pc *PartitionContext) bindAllocation(result *objects.AllocationResult, targetNode *objects.Node) {
+	alloc := result.Request
 	alloc.SetBindTime(time.Now())
-	alloc.SetNodeID(targetNodeID)
+	alloc.SetNodeID(targetNode.NodeID)
 	alloc.SetInstanceType(targetNode.GetInstanceType())
 
 	// track the number of allocations
 	pc.updateAllocationCount(1)
-	if result.Request.IsPlaceholder() {
+	if alloc.IsPlaceholder() {
 		pc.incPhAllocationCount()
 	}
 
 	log.Log(log.SchedPartition).Info("scheduler allocation processed",
-		zap.String("appID", result.Request.GetApplicationID()),
-		zap.String("allocationKey", result.Request.GetAllocationKey()),
-		zap.Stringer("allocatedResource", result.Request.GetAllocatedResource()),
-		zap.Bool("placeholder", result.Request.IsPlaceholder()),
-		zap.String("targetNode", targetNodeID))
-	// pass the allocation result back to the RM via the cluster context
-	return result
+		zap.String("appID", alloc.GetApplicationID()),
+		zap.String("allocationKey", alloc.GetAllocationKey()),
+		zap.Stringer("allocatedResource", alloc.GetAllocatedResource()),
+		zap.Bool("placeholder", alloc.IsPlaceholder()),
+		zap.String("targetNode", targetNode.NodeID))
 }
 
 // Process the reservation in the scheduler
@@ -1648,9 +2090,10 @@ func (pc *PartitionContext) moveTerminatedApp(appID string) {
 		zap.String("app status", app.CurrentState()))
 	app.LogAppSummary(pc.RmID)
 	pc.Lock()
-	defer pc.Unlock()
 	delete(pc.applications, appID)
 	pc.completedApplications[newID] = app
+	pc.Unlock()
+	pc.notifyDependents(appID, app.IsCompleted())
 }
 
 func (pc *PartitionContext) AddRejectedApplication(rejectedApplication *objects.Application, rejectedMessage string) {
@@ -1659,10 +2102,13 @@ func (pc *PartitionContext) AddRejectedApplication(rejectedApplication *objects.
 			zap.String("currentState", rejectedApplication.CurrentState()),
 			zap.Error(err))
 	}
+	pc.Lock()
 	if pc.rejectedApplications == nil {
 		pc.rejectedApplications = make(map[string]*objects.Application)
 	}
 	pc.rejectedApplications[rejectedApplication.ApplicationID] = rejectedApplication
+	pc.Unlock()
+	pc.notifyDependents(rejectedApplication.ApplicationID, false)
 }
 
 func (pc *PartitionContext) incPhAllocationCount() {
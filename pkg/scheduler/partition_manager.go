@@ -30,6 +30,8 @@ import (
 const (
 	DefaultCleanRootInterval        = 10000 * time.Millisecond // sleep between queue removal checks
 	DefaultCleanExpiredAppsInterval = 24 * time.Hour           // sleep between apps removal checks
+	MaxCapacityScheduleInterval     = time.Hour                // longest sleep between capacity schedule checks
+	DefaultExpireAsksInterval       = 10 * time.Second         // sleep between pending request expiry checks
 )
 
 type partitionManager struct {
@@ -37,8 +39,12 @@ type partitionManager struct {
 	cc                       *ClusterContext
 	stopCleanRoot            chan struct{}
 	stopCleanExpiredApps     chan struct{}
+	stopCapacitySchedules    chan struct{}
+	capacityScheduleUpdate   chan struct{}
+	stopExpireAsks           chan struct{}
 	cleanRootInterval        time.Duration
 	cleanExpiredAppsInterval time.Duration
+	expireAsksInterval       time.Duration
 }
 
 func newPartitionManager(pc *PartitionContext, cc *ClusterContext) *partitionManager {
@@ -47,17 +53,23 @@ func newPartitionManager(pc *PartitionContext, cc *ClusterContext) *partitionMan
 		cc:                       cc,
 		stopCleanRoot:            make(chan struct{}),
 		stopCleanExpiredApps:     make(chan struct{}),
+		stopCapacitySchedules:    make(chan struct{}),
+		capacityScheduleUpdate:   make(chan struct{}, 1),
+		stopExpireAsks:           make(chan struct{}),
 		cleanRootInterval:        DefaultCleanRootInterval,
 		cleanExpiredAppsInterval: DefaultCleanExpiredAppsInterval,
+		expireAsksInterval:       DefaultExpireAsksInterval,
 	}
 }
 
 // Run the manager for the partition.
-// The manager has four tasks:
+// The manager has six tasks:
 // - clean up the managed queues that are empty and removed from the configuration
 // - remove empty unmanaged queues
 // - remove completed applications from the partition
 // - remove rejected applications from the partition
+// - apply the capacity schedules at the window boundaries
+// - remove pending requests that passed their time-to-live
 // When the manager exits the partition is removed from the system and must be cleaned up
 func (manager *partitionManager) Run() {
 	log.Log(log.SchedPartition).Info("starting partition manager",
@@ -65,6 +77,8 @@ func (manager *partitionManager) Run() {
 		zap.Stringer("cleanRootInterval", manager.cleanRootInterval))
 	go manager.cleanExpiredApps()
 	go manager.cleanRoot()
+	go manager.applyCapacitySchedules()
+	go manager.expireAsks()
 }
 
 func (manager *partitionManager) cleanRoot() {
@@ -94,6 +108,8 @@ func (manager *partitionManager) Stop() {
 		zap.String("partition", manager.pc.Name))
 	close(manager.stopCleanExpiredApps)
 	close(manager.stopCleanRoot)
+	close(manager.stopCapacitySchedules)
+	close(manager.stopExpireAsks)
 	manager.remove()
 }
 
@@ -183,3 +199,48 @@ func (manager *partitionManager) cleanExpiredApps() {
 		}
 	}
 }
+
+func (manager *partitionManager) expireAsks() {
+	log.Log(log.SchedPartition).Info("Starting partition pending request expiry")
+	for {
+		expireAsksInterval := manager.expireAsksInterval
+		if expireAsksInterval <= 0 {
+			expireAsksInterval = DefaultExpireAsksInterval
+		}
+		select {
+		case <-manager.stopExpireAsks:
+			return
+		case <-time.After(expireAsksInterval):
+			manager.pc.expireAsks(time.Now())
+		}
+	}
+}
+
+// applyCapacitySchedules applies the active capacity schedule at each transition. The check is repeated at least
+// every MaxCapacityScheduleInterval to pick up wall clock changes, and after a configuration change.
+func (manager *partitionManager) applyCapacitySchedules() {
+	log.Log(log.SchedPartition).Info("Starting partition capacity scheduler")
+	for {
+		wait := MaxCapacityScheduleInterval
+		if next := manager.pc.applyCapacitySchedules(time.Now()); !next.IsZero() && time.Until(next) < wait {
+			wait = time.Until(next)
+		}
+		select {
+		case <-manager.stopCapacitySchedules:
+			return
+		case <-manager.capacityScheduleUpdate:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// capacitySchedulesChanged triggers a recalculation of the capacity schedule transitions, it never blocks.
+func (manager *partitionManager) capacitySchedulesChanged() {
+	if manager == nil {
+		return
+	}
+	select {
+	case manager.capacityScheduleUpdate <- struct{}{}:
+	default:
+	}
+}
@@ -56,6 +56,9 @@ func TestStopPartitionManager(t *testing.T) {
 
 	// this call should not be blocked forever
 	p.partitionManager.cleanRoot()
+
+	// this call should not be blocked forever
+	p.partitionManager.applyCapacitySchedules()
 }
 
 func TestCleanQueues(t *testing.T) {
@@ -4837,3 +4837,451 @@ func TestForeignAllocation(t *testing.T) { //nolint:funlen
 	assert.Equal(t, 0, len(partition.foreignAllocs))
 	assert.Equal(t, 0, len(node.GetYunikornAllocations()))
 }
+
+func TestMoveApplication(t *testing.T) {
+	setupUGM()
+	partition, err := newBasePartition()
+	assert.NilError(t, err, "partition create failed")
+	_, err = objects.NewDynamicQueue("other", true, partition.root)
+	assert.NilError(t, err, "failed to create dynamic queue")
+	setupNode(t, nodeID1, partition, resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 100}))
+
+	err = partition.MoveApplication(appID1, defQueue)
+	assert.ErrorContains(t, err, "application app-1 not found")
+	app := newApplication(appID1, "default", "root.other")
+	err = partition.AddApplication(app)
+	assert.NilError(t, err, "add application to partition should not have failed")
+	err = partition.MoveApplication(appID1, "root.unknown")
+	assert.ErrorContains(t, err, "queue root.unknown not found")
+
+	// the user limit of the default queue is 5 memory
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 6})))
+	assert.NilError(t, err, "add allocation to partition should not have failed")
+	err = partition.MoveApplication(appID1, defQueue)
+	assert.ErrorContains(t, err, "does not fit in the user or group limits")
+	assert.Equal(t, app.GetQueuePath(), "root.other")
+
+	appRes := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 4})
+	app = newApplication(appID2, "default", "root.other")
+	err = partition.AddApplication(app)
+	assert.NilError(t, err, "add application to partition should not have failed")
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey2, appID2, nodeID1, appRes))
+	assert.NilError(t, err, "add allocation to partition should not have failed")
+	err = partition.MoveApplication(appID2, defQueue)
+	assert.NilError(t, err, "move should not have failed")
+	assert.Equal(t, app.GetQueuePath(), defQueue)
+	assert.Assert(t, resources.Equals(partition.GetQueue(defQueue).GetAllocatedResource(), appRes), "allocation should be moved to the default queue")
+	assert.Assert(t, resources.Equals(partition.GetQueue("root.other").GetAllocatedResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 6})), "allocation should be removed from the other queue")
+
+	// the user usage is tracked globally: remove the usage of both applications
+	partition.removeApplication(appID1)
+	partition.removeApplication(appID2)
+	assertLimits(t, getTestUserGroup(), nil)
+}
+
+func TestNodePoolAffinity(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	conf := configs.PartitionConfig{
+		Name: "test",
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues: []configs.QueueConfig{
+					{
+						Name:       "ml",
+						Parent:     true,
+						Properties: map[string]string{configs.NodeSelector: "instance-type in (gpu-a, gpu-b)"},
+						Queues:     []configs.QueueConfig{{Name: "training"}},
+					},
+					{
+						Name:       "batch",
+						Properties: map[string]string{configs.NodeSelector: "pool=batch"},
+						Resources:  configs.Resources{Max: map[string]string{"vcore": "50"}},
+					},
+					{Name: "default"},
+				},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, rmID, nil, false)
+	assert.NilError(t, err, "partition create failed")
+	addNode := func(nodeID string, attributes map[string]string, vcore resources.Quantity) {
+		node := objects.NewNode(&si.NodeInfo{
+			NodeID:              nodeID,
+			Attributes:          attributes,
+			SchedulableResource: resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": vcore}).ToProto(),
+		})
+		assert.NilError(t, partition.AddNode(node), "node add failed")
+	}
+	vcore := func(quantity resources.Quantity) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": quantity})
+	}
+	addNode("gpu-1", map[string]string{"instance-type": "gpu-a"}, 10)
+	addNode("gpu-2", map[string]string{"instance-type": "gpu-c"}, 10)
+	addNode("batch-1", map[string]string{"pool": "batch"}, 20)
+
+	ml := partition.GetQueue("root.ml")
+	training := partition.GetQueue("root.ml.training")
+	batch := partition.GetQueue("root.batch")
+	assert.Assert(t, resources.Equals(ml.GetNodePoolResource(), vcore(10)))
+	assert.Assert(t, training.GetNodePoolResource() == nil, "inherited selector must not have its own pool")
+	assert.Assert(t, resources.Equals(training.GetMaxResource(), vcore(10)))
+	assert.Assert(t, resources.Equals(batch.GetMaxResource(), vcore(20)))
+	assert.Assert(t, resources.Equals(partition.GetQueue("root.default").GetMaxResource(), vcore(40)))
+	assert.DeepEqual(t, ml.GetPartitionQueueDAOInfo(false).NodePoolResource, map[string]int64{"vcore": 10})
+
+	// only the selected nodes are used and the headroom is limited to the pool
+	app := newApplication(appID1, "test", "root.ml.training")
+	assert.NilError(t, partition.AddApplication(app), "failed to add app")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey, appID1, vcore(2))), "failed to add ask")
+	result := partition.tryAllocate()
+	assert.Assert(t, result != nil && result.ResultType == objects.Allocated, "allocation expected")
+	assert.Equal(t, result.NodeID, "gpu-1")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey2, appID1, vcore(9))), "failed to add ask")
+	assert.Assert(t, partition.tryAllocate() == nil, "ask larger than the pool headroom must not be allocated")
+
+	// node changes update the pool
+	partition.updateNodeCapacity(partition.GetNode("gpu-1"), vcore(20))
+	assert.Assert(t, resources.Equals(ml.GetNodePoolResource(), vcore(20)))
+	partition.updateNodeCapacity(partition.GetNode("gpu-2"), vcore(20))
+	assert.Assert(t, resources.Equals(ml.GetNodePoolResource(), vcore(20)))
+	addNode("gpu-3", map[string]string{"instance-type": "gpu-b"}, 5)
+	assert.Assert(t, resources.Equals(ml.GetNodePoolResource(), vcore(25)))
+	partition.removeNode("gpu-1")
+	assert.Assert(t, resources.Equals(ml.GetNodePoolResource(), vcore(5)))
+
+	// removing the selector removes the pool
+	conf.Queues[0].Queues[0].Properties = nil
+	assert.NilError(t, partition.updatePartitionDetails(conf), "config update failed")
+	assert.Assert(t, ml.GetNodePoolResource() == nil, "pool should be removed")
+	assert.Assert(t, resources.Equals(training.GetMaxResource(), partition.GetTotalPartitionResource()))
+}
+
+func TestApplicationDependencies(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	conf := configs.PartitionConfig{
+		Name: "test",
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues:    []configs.QueueConfig{{Name: "default"}},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, rmID, nil, false)
+	assert.NilError(t, err, "partition create failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	node := objects.NewNode(&si.NodeInfo{
+		NodeID:              nodeID1,
+		SchedulableResource: resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10}).ToProto(),
+	})
+	assert.NilError(t, partition.AddNode(node), "node add failed")
+
+	// the dependent application is accepted but not scheduled until the dependency completes
+	app1 := newApplication(appID1, "test", "root.default")
+	assert.NilError(t, partition.AddApplication(app1), "failed to add app")
+	app2 := newApplicationTags(appID2, "test", "root.default", map[string]string{common.AppTagDependencies: appID1})
+	assert.NilError(t, partition.AddApplication(app2), "failed to add dependent app")
+	assert.NilError(t, app2.AddAllocationAsk(newAllocationAsk(allocKey, appID2, res)), "failed to add ask")
+	assert.Assert(t, app2.IsAccepted(), "dependent app should be accepted")
+	assert.Assert(t, partition.tryAllocate() == nil, "dependent app should not be scheduled")
+	assert.DeepEqual(t, app2.GetDependencies(), []string{appID1})
+	app1.SetState(objects.Completed.String())
+	partition.moveTerminatedApp(appID1)
+	assert.Equal(t, len(app2.GetDependencies()), 0, "dependency should be resolved")
+	result := partition.tryAllocate()
+	assert.Assert(t, result != nil && result.ResultType == objects.Allocated, "dependent app should be scheduled")
+	assert.Equal(t, result.Request.GetApplicationID(), appID2)
+
+	// a dependency that already completed is resolved when the application is added
+	app3 := newApplicationTags(appID3, "test", "root.default", map[string]string{common.AppTagDependencies: appID1})
+	assert.NilError(t, partition.AddApplication(app3), "failed to add dependent app")
+	assert.Equal(t, len(app3.GetDependencies()), 0, "completed dependency should be resolved")
+
+	// a rejected dependency fails the applications waiting on it
+	app4 := newApplicationTags("app-4", "test", "root.default", map[string]string{common.AppTagDependencies: "app-5, app-6"})
+	assert.NilError(t, partition.AddApplication(app4), "failed to add dependent app")
+	assert.DeepEqual(t, app4.GetDependencies(), []string{"app-5", "app-6"})
+	partition.AddRejectedApplication(newApplication("app-5", "test", "root.default"), "rejected")
+	assert.Assert(t, app4.IsFailed(), "application should fail when a dependency is rejected: %s", app4.CurrentState())
+	err = common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return partition.getApplication("app-4") == nil
+	})
+	assert.NilError(t, err, "failed application should have been moved to the completed applications")
+	partition.RLock()
+	assert.Equal(t, len(partition.dependents), 0, "failed application should not be tracked as waiting")
+	partition.RUnlock()
+
+	// a dependency that already failed fails the application when it is added
+	app7 := newApplicationTags("app-7", "test", "root.default", map[string]string{common.AppTagDependencies: "app-4"})
+	assert.NilError(t, partition.AddApplication(app7), "failed to add dependent app")
+	assert.Assert(t, app7.IsFailed(), "application should fail when a dependency has failed: %s", app7.CurrentState())
+
+	// cyclic dependencies are rejected, also through an application that is not submitted yet
+	app8 := newApplicationTags("app-8", "test", "root.default", map[string]string{common.AppTagDependencies: "app-9"})
+	assert.NilError(t, partition.AddApplication(app8), "failed to add dependent app")
+	app9 := newApplicationTags("app-9", "test", "root.default", map[string]string{common.AppTagDependencies: "app-10"})
+	assert.NilError(t, partition.AddApplication(app9), "failed to add dependent app")
+	app10 := newApplicationTags("app-10", "test", "root.default", map[string]string{common.AppTagDependencies: "app-8"})
+	err = partition.AddApplication(app10)
+	assert.ErrorContains(t, err, "dependency on app-8 is cyclic")
+	assert.Assert(t, partition.getApplication("app-10") == nil, "cyclic application should not be added")
+
+	// a running application removed by the RM completes: the applications waiting on it are resolved
+	app11 := newApplication("app-11", "test", "root.default")
+	assert.NilError(t, partition.AddApplication(app11), "failed to add app")
+	assert.NilError(t, app11.AddAllocationAsk(newAllocationAsk(allocKey2, "app-11", res)), "failed to add ask")
+	result = partition.tryAllocate()
+	assert.Assert(t, result != nil && result.ResultType == objects.Allocated, "app should be scheduled")
+	assert.Assert(t, app11.IsRunning(), "app should be running")
+	app12 := newApplicationTags("app-12", "test", "root.default", map[string]string{common.AppTagDependencies: "app-11"})
+	assert.NilError(t, partition.AddApplication(app12), "failed to add dependent app")
+	partition.removeApplication("app-11")
+	assert.Assert(t, app11.IsCompleting(), "removed app should be completing: %s", app11.CurrentState())
+	assert.Equal(t, len(app12.GetDependencies()), 0, "dependency should be resolved")
+	assert.Assert(t, !app12.IsFailed(), "dependent app should not fail")
+}
+
+func TestGangAllocate(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	partition, err := newBasePartition()
+	assert.NilError(t, err, "partition create failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 6})
+	for _, nodeID := range []string{nodeID1, nodeID2} {
+		node := objects.NewNode(&si.NodeInfo{
+			NodeID:              nodeID,
+			SchedulableResource: resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10}).ToProto(),
+		})
+		assert.NilError(t, partition.AddNode(node), "node add failed")
+	}
+	app := newApplicationTags(appID1, "default", defQueue, map[string]string{common.AppTagGangMinMembers: "2"})
+	assert.NilError(t, partition.AddApplication(app), "failed to add app")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey, appID1, res)), "failed to add ask")
+	assert.Assert(t, partition.tryAllocate() == nil, "incomplete gang should not be allocated")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey2, appID1, res)), "failed to add ask")
+
+	// both members are processed by the partition in one pass
+	result := partition.tryAllocate()
+	assert.Assert(t, result != nil && result.ResultType == objects.Allocated, "gang should be allocated")
+	assert.Equal(t, len(result.Members), 1, "second member should be allocated with the first")
+	assert.Assert(t, result.NodeID != result.Members[0].NodeID, "members should be on different nodes")
+	assert.Equal(t, result.Members[0].Request.GetNodeID(), result.Members[0].NodeID, "member should be bound to the node")
+	assert.Equal(t, partition.GetTotalAllocationCount(), 2, "both allocations should be tracked")
+	assert.Equal(t, len(partition.GetNode(nodeID1).GetYunikornAllocations())+len(partition.GetNode(nodeID2).GetYunikornAllocations()), 2)
+}
+
+func TestGangAllocateNodeRemoved(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	partition, err := newBasePartition()
+	assert.NilError(t, err, "partition create failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 6})
+	nodeInfo := func(nodeID string) *si.NodeInfo {
+		return &si.NodeInfo{
+			NodeID:              nodeID,
+			SchedulableResource: resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10}).ToProto(),
+		}
+	}
+	for _, nodeID := range []string{nodeID1, nodeID2} {
+		assert.NilError(t, partition.AddNode(objects.NewNode(nodeInfo(nodeID))), "node add failed")
+	}
+	app := newApplicationTags(appID1, "default", defQueue, map[string]string{common.AppTagGangMinMembers: "2"})
+	assert.NilError(t, partition.AddApplication(app), "failed to add app")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey, appID1, res)), "failed to add ask")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey2, appID1, res)), "failed to add ask")
+
+	// the node of the second member is removed after the gang was placed but before the partition processes it:
+	// the node is removed from the list first, its allocations are cleaned up after the gang is processed
+	result := partition.root.TryAllocate(partition.GetNodeIterator, partition.GetFullNodeIterator, partition.GetNode, false)
+	assert.Assert(t, result != nil && len(result.Members) == 1, "gang should be placed")
+	firstNode := result.NodeID
+	removed := result.Members[0].NodeID
+	node := partition.removeNodeFromList(removed)
+	assert.Assert(t, node != nil, "node should have been removed")
+	assert.Assert(t, partition.allocate(result) == nil, "gang with a removed node should not be allocated")
+	released, _ := partition.removeNodeAllocations(node)
+	assert.Equal(t, len(released), 0, "unwound member should not be released to the RM")
+
+	// no member is left behind: not on the node, the application, the queue or the partition
+	assert.Equal(t, len(partition.GetNode(firstNode).GetYunikornAllocations()), 0, "member should be removed from its node")
+	assert.Equal(t, partition.GetTotalAllocationCount(), 0, "no allocation should be tracked")
+	assert.Assert(t, resources.IsZero(app.GetAllocatedResource()), "app should have no allocations")
+	assert.Assert(t, resources.IsZero(app.GetQueue().GetAllocatedResource()), "queue should have no allocations")
+	assert.Assert(t, resources.Equals(app.GetPendingResource(), resources.Multiply(res, 2)), "both members should be pending")
+	for _, member := range []string{allocKey, allocKey2} {
+		assert.Assert(t, !app.GetAllocationAsk(member).IsAllocated(), "member %s should be pending", member)
+	}
+
+	// the gang is placed again once the node is back
+	assert.NilError(t, partition.AddNode(objects.NewNode(nodeInfo(removed))), "node add failed")
+	result = partition.tryAllocate()
+	assert.Assert(t, result != nil && len(result.Members) == 1, "gang should be allocated again")
+	assert.Equal(t, partition.GetTotalAllocationCount(), 2, "both allocations should be tracked")
+}
+
+func TestSuspendResumeApplication(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	conf := configs.PartitionConfig{
+		Name: "test",
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues:    []configs.QueueConfig{{Name: "default", MaxApplications: 1}},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, rmID, nil, false)
+	assert.NilError(t, err, "partition create failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	node := objects.NewNode(&si.NodeInfo{
+		NodeID:              nodeID1,
+		SchedulableResource: resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10}).ToProto(),
+	})
+	assert.NilError(t, partition.AddNode(node), "node add failed")
+
+	// the first application uses the only running slot of the queue, its second request never fits
+	app1 := newApplication(appID1, "test", "root.default")
+	assert.NilError(t, partition.AddApplication(app1), "failed to add app")
+	assert.NilError(t, app1.AddAllocationAsk(newAllocationAsk(allocKey, appID1, res)), "failed to add ask")
+	large := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 20})
+	assert.NilError(t, app1.AddAllocationAsk(newAllocationAsk(allocKey2, appID1, large)), "failed to add ask")
+	result := partition.tryAllocate()
+	assert.Assert(t, result != nil && result.ResultType == objects.Allocated, "first app should be scheduled")
+	assert.Assert(t, app1.IsRunning(), "first app should be running")
+	submitted := app1.SubmissionTime
+
+	app2 := newApplication(appID2, "test", "root.default")
+	assert.NilError(t, partition.AddApplication(app2), "failed to add app")
+	assert.NilError(t, app2.AddAllocationAsk(newAllocationAsk(allocKey3, appID2, res)), "failed to add ask")
+	assert.Assert(t, partition.tryAllocate() == nil, "second app should be limited by the running applications")
+
+	// suspending frees the running slot and keeps the pending request
+	assert.NilError(t, partition.SuspendApplication(appID1), "suspend failed")
+	assert.Assert(t, app1.IsSuspended(), "first app should be suspended: %s", app1.CurrentState())
+	assert.Assert(t, resources.Equals(app1.GetPendingResource(), large), "pending request should be kept")
+	assert.Assert(t, app1.GetAllocationAsk(allocKey).IsReleased(), "allocation should be released")
+	result = partition.tryAllocate()
+	assert.Assert(t, result != nil && result.ResultType == objects.Allocated, "second app should be scheduled")
+	assert.Equal(t, result.Request.GetApplicationID(), appID2)
+
+	// the release confirmation does not complete the suspended application
+	release := &si.AllocationRelease{
+		PartitionName:   partition.Name,
+		ApplicationID:   appID1,
+		AllocationKey:   allocKey,
+		TerminationType: si.TerminationType_PREEMPTED_BY_SCHEDULER,
+	}
+	releases, _ := partition.removeAllocation(release)
+	assert.Equal(t, len(releases), 0, "confirmation should not be sent back to the RM")
+	assert.Assert(t, app1.IsSuspended(), "first app should still be suspended: %s", app1.CurrentState())
+	assert.Assert(t, resources.IsZero(app1.GetAllocatedResource()), "allocated resources should be released")
+	assert.Assert(t, partition.SuspendApplication(appID1) != nil, "suspended app cannot be suspended again")
+
+	// resume keeps the submission time
+	assert.NilError(t, partition.ResumeApplication(appID1), "resume failed")
+	assert.Assert(t, app1.IsAccepted(), "first app should be accepted: %s", app1.CurrentState())
+	assert.Equal(t, app1.SubmissionTime, submitted)
+	assert.Assert(t, partition.ResumeApplication(appID1) != nil, "accepted app cannot be resumed")
+	assert.Assert(t, partition.ResumeApplication("unknown") != nil, "unknown app cannot be resumed")
+
+	// removing a suspended application by the RM completes it
+	assert.NilError(t, partition.SuspendApplication(appID2), "suspend failed")
+	partition.removeApplication(appID2)
+	assert.Assert(t, app2.IsCompleting(), "removed app should be completing: %s", app2.CurrentState())
+	assert.Assert(t, partition.getApplication(appID2) == nil, "removed app should not be tracked")
+}
+
+func TestExpireAsks(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	conf := configs.PartitionConfig{
+		Name: "test",
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues: []configs.QueueConfig{
+					{Name: "default", Properties: map[string]string{configs.AskTTL: "1m"}},
+					{Name: "other"},
+				},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, rmID, nil, false)
+	assert.NilError(t, err, "partition create failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	app1 := newApplication(appID1, "test", "root.default")
+	assert.NilError(t, partition.AddApplication(app1), "failed to add app")
+	assert.NilError(t, app1.AddAllocationAsk(newAllocationAsk(allocKey, appID1, res)), "failed to add ask")
+	app2 := newApplication(appID2, "test", "root.other")
+	assert.NilError(t, partition.AddApplication(app2), "failed to add app")
+	assert.NilError(t, app2.AddAllocationAsk(newAllocationAsk(allocKey2, appID2, res)), "failed to add ask")
+	before, err := metrics.GetSchedulerMetrics().GetExpiredAsks(partition.Name, "root.default")
+	assert.NilError(t, err, "failed to get expired asks metric")
+
+	partition.expireAsks(time.Now().Add(2 * time.Minute))
+	assert.Assert(t, app1.GetAllocationAsk(allocKey) == nil, "ask should have expired")
+	assert.Assert(t, resources.IsZero(partition.GetQueue("root.default").GetPendingResource()), "queue pending should be updated")
+	assert.Assert(t, app2.GetAllocationAsk(allocKey2) != nil, "ask without a ttl should not expire")
+	after, err := metrics.GetSchedulerMetrics().GetExpiredAsks(partition.Name, "root.default")
+	assert.NilError(t, err, "failed to get expired asks metric")
+	assert.Equal(t, after-before, 1, "expired ask should be counted for the queue")
+}
+
+func TestAskAntiAffinity(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	conf := configs.PartitionConfig{
+		Name: "test",
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues:    []configs.QueueConfig{{Name: "default"}},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, rmID, nil, false)
+	assert.NilError(t, err, "partition create failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	for _, nodeID := range []string{nodeID1, nodeID2} {
+		node := objects.NewNode(&si.NodeInfo{
+			NodeID:              nodeID,
+			SchedulableResource: resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10}).ToProto(),
+		})
+		assert.NilError(t, partition.AddNode(node), "node add failed")
+	}
+	app := newApplication(appID1, "test", "root.default")
+	assert.NilError(t, partition.AddApplication(app), "failed to add app")
+	for _, key := range []string{allocKey, allocKey2, allocKey3} {
+		ask := objects.NewAllocationFromSI(&si.Allocation{
+			AllocationKey:    key,
+			ApplicationID:    appID1,
+			PartitionName:    "test",
+			ResourcePerAlloc: res.ToProto(),
+			AllocationTags:   map[string]string{"role": "kafka", common.AskTagAntiAffinity: "role=kafka"},
+		})
+		assert.NilError(t, app.AddAllocationAsk(ask), "failed to add ask")
+	}
+
+	// no two asks on the same node: the third ask cannot be placed
+	first := partition.tryAllocate()
+	assert.Assert(t, first != nil && first.ResultType == objects.Allocated, "first ask should be allocated")
+	second := partition.tryAllocate()
+	assert.Assert(t, second != nil && second.ResultType == objects.Allocated, "second ask should be allocated")
+	assert.Assert(t, first.NodeID != second.NodeID, "asks should be on different nodes")
+	assert.Assert(t, partition.tryAllocate() == nil, "third ask should not be allocated")
+	assert.Equal(t, len(app.GetReservations()), 0, "nodes should not be reserved for the third ask")
+}
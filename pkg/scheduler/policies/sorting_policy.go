@@ -31,11 +31,13 @@ const (
 	FifoSortPolicy             SortPolicy = iota // first in first out, submit time
 	FairSortPolicy                               // fair based on usage
 	deprecatedStateAwarePolicy                   // deprecated: now alias for FIFO
+	DrfSortPolicy                                // dominant resource fairness based on weighted usage
+	EdfSortPolicy                                // earliest deadline first, submit time if no deadline
 	Undefined                                    // not initialised or parsing failed
 )
 
 func (s SortPolicy) String() string {
-	return [...]string{"fifo", "fair", "stateaware", "undefined"}[s]
+	return [...]string{"fifo", "fair", "stateaware", "drf", "edf", "undefined"}[s]
 }
 
 func SortPolicyFromString(str string) (SortPolicy, error) {
@@ -45,6 +47,10 @@ func SortPolicyFromString(str string) (SortPolicy, error) {
 		return FifoSortPolicy, nil
 	case FairSortPolicy.String():
 		return FairSortPolicy, nil
+	case DrfSortPolicy.String():
+		return DrfSortPolicy, nil
+	case EdfSortPolicy.String():
+		return EdfSortPolicy, nil
 	case deprecatedStateAwarePolicy.String():
 		log.Log(log.Deprecation).Warn("Sort policy 'stateaware' is deprecated; using 'fifo' instead")
 		return FifoSortPolicy, nil
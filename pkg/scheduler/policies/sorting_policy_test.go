@@ -32,6 +32,8 @@ func TestAppFromString(t *testing.T) {
 		{"EmptyString", "", FifoSortPolicy, false},
 		{"FifoString", "fifo", FifoSortPolicy, false},
 		{"FairString", "fair", FairSortPolicy, false},
+		{"DrfString", "drf", DrfSortPolicy, false},
+		{"EdfString", "edf", EdfSortPolicy, false},
 		{"StatusString", "stateaware", FifoSortPolicy, false},
 		{"UnknownString", "unknown", Undefined, true},
 	}
@@ -57,6 +59,8 @@ func TestAppToString(t *testing.T) {
 		{"FifoString", FifoSortPolicy, "fifo"},
 		{"FairString", FairSortPolicy, "fair"},
 		{"StatusString", deprecatedStateAwarePolicy, "stateaware"},
+		{"DrfString", DrfSortPolicy, "drf"},
+		{"EdfString", EdfSortPolicy, "edf"},
 		{"DefaultString", Undefined, "undefined"},
 		{"NoneString", someSP, "fifo"},
 	}
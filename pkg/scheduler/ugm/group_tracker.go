@@ -77,18 +77,18 @@ func (gt *GroupTracker) getTrackedApplications() map[string]string {
 	return gt.applications
 }
 
-func (gt *GroupTracker) setLimits(queuePath string, resource *resources.Resource, maxApps uint64) {
+func (gt *GroupTracker) setLimits(queuePath string, resource *resources.Resource, maxApps uint64, limitBudget *budget) {
 	gt.Lock()
 	defer gt.Unlock()
 	gt.events.sendLimitSetForGroup(gt.groupName, queuePath)
-	gt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), resource, maxApps, false, group, false)
+	gt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), resource, maxApps, limitBudget, false, group, false)
 }
 
 func (gt *GroupTracker) clearLimits(queuePath string) {
 	gt.Lock()
 	defer gt.Unlock()
 	gt.events.sendLimitRemoveForGroup(gt.groupName, queuePath)
-	gt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, 0, false, group, false)
+	gt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, 0, nil, false, group, false)
 }
 
 // headroom calculate the resource headroom for the group in the hierarchy defined
@@ -194,8 +194,8 @@ func TestGTSetAndClearMaxLimits(t *testing.T) {
 
 	// higher limits - apps can run
 	eventSystem.Reset()
-	groupTracker.setLimits(path1, resources.Multiply(usage1, 5), 5)
-	groupTracker.setLimits(path5, resources.Multiply(usage1, 10), 10)
+	groupTracker.setLimits(path1, resources.Multiply(usage1, 5), 5, nil)
+	groupTracker.setLimits(path5, resources.Multiply(usage1, 10), 10, nil)
 	assert.Equal(t, 2, len(eventSystem.Events))
 	assert.Equal(t, si.EventRecord_UG_GROUP_LIMIT, eventSystem.Events[0].EventChangeDetail)
 	assert.Equal(t, si.EventRecord_SET, eventSystem.Events[0].EventChangeType)
@@ -220,8 +220,8 @@ func TestGTSetAndClearMaxLimits(t *testing.T) {
 	assert.Assert(t, groupTracker.canRunApp(hierarchy1, TestApp4))
 
 	// lower limits
-	groupTracker.setLimits(path1, usage1, 1)
-	groupTracker.setLimits(path5, resources.Multiply(usage1, 2), 1)
+	groupTracker.setLimits(path1, usage1, 1, nil)
+	groupTracker.setLimits(path5, resources.Multiply(usage1, 2), 1, nil)
 	lowerChildHeadroom := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"mem":   -20000000,
 		"vcore": -20000,
@@ -274,7 +274,7 @@ func TestGTCanRunApp(t *testing.T) {
 	assert.Assert(t, !groupTracker.queueTracker.childQueueTrackers["parent"].useWildCard)
 
 	// maxApps limit hit
-	groupTracker.setLimits(path1, nil, 1)
+	groupTracker.setLimits(path1, nil, 1, nil)
 	groupTracker.increaseTrackedResource(path1, TestApp1, resources.NewResourceFromMap(map[string]resources.Quantity{
 		"cpu": 1000,
 	}), user.User)
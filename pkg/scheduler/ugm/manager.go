@@ -33,6 +33,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/locking"
 	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
 )
 
 var once sync.Once
@@ -48,6 +49,7 @@ type Manager struct {
 	configuredGroups          map[string][]string                // Hold groups for all configured queue paths.
 	userLimits                map[string]map[string]*LimitConfig // Holds queue path * user limit config
 	groupLimits               map[string]map[string]*LimitConfig // Holds queue path * group limit config
+	queueBudgetTracker        *QueueBudgetTracker                // Holds the usage of all applications for queue budgets
 	events                    *ugmEvents
 	locking.RWMutex
 }
@@ -58,6 +60,7 @@ func newManager() *Manager {
 		groupTrackers:             make(map[string]*GroupTracker),
 		userWildCardLimitsConfig:  make(map[string]*LimitConfig),
 		groupWildCardLimitsConfig: make(map[string]*LimitConfig),
+		queueBudgetTracker:        newQueueBudgetTracker(),
 		events:                    newUGMEvents(events.GetEventSystem()),
 	}
 	return manager
@@ -74,6 +77,7 @@ func GetUserManager() *Manager {
 type LimitConfig struct {
 	maxResources    *resources.Resource
 	maxApplications uint64
+	budget          *budget
 }
 
 // IncreaseTrackedResource Increase the resource usage for the given user group and queue path combination.
@@ -89,6 +93,13 @@ func (m *Manager) IncreaseTrackedResource(queuePath, applicationID string, usage
 		log.Log(log.SchedUGM).Debug("Mandatory parameters are missing to increase the resource usage")
 		return
 	}
+	// the queue usage is only tracked while budgets are set
+	qbt := m.GetQueueBudgetTracker()
+	tracked := qbt.lockUsage()
+	defer qbt.unlockUsage(tracked)
+	if tracked {
+		qbt.increaseTrackedResource(queuePath, applicationID, usage)
+	}
 	// since we check headroom before an increase this should never result in a creation...
 	// some tests might not go through a scheduling that cycle so leave this
 	userTracker := m.getUserTracker(user.User)
@@ -139,6 +150,12 @@ func (m *Manager) DecreaseTrackedResource(queuePath, applicationID string, usage
 		log.Log(log.SchedUGM).Debug("Mandatory parameters are missing to decrease the resource usage")
 		return
 	}
+	qbt := m.GetQueueBudgetTracker()
+	tracked := qbt.lockUsage()
+	defer qbt.unlockUsage(tracked)
+	if tracked {
+		qbt.decreaseTrackedResource(queuePath, applicationID, usage, removeApp)
+	}
 
 	userTracker := m.GetUserTracker(user.User)
 	if userTracker == nil {
@@ -311,11 +328,16 @@ func (m *Manager) UpdateConfig(config configs.QueueConfig, queuePath string) err
 
 	userLimits := make(map[string]map[string]*LimitConfig)  // Holds queue path * user limit config
 	groupLimits := make(map[string]map[string]*LimitConfig) // Holds queue path * group limit config
+	queueBudgets := make(map[string]*budget)                // Holds queue path * budget config
 
 	// as and when parse new configs, store them in temporary maps
 	if err := m.internalProcessConfig(config, queuePath, userLimits, groupLimits, userWildCardLimitsConfig, groupWildCardLimitsConfig, configuredGroups); err != nil {
 		return err
 	}
+	if err := processQueueBudgets(config, queuePath, queueBudgets); err != nil {
+		return err
+	}
+	m.GetQueueBudgetTracker().setBudgets(queueBudgets, m.GetUserTrackers)
 
 	// compare existing config with new configs stored in above temporary maps
 	m.clearEarlierSetLimits(userLimits, groupLimits)
@@ -345,7 +367,16 @@ func (m *Manager) internalProcessConfig(cur configs.QueueConfig, queuePath strin
 				zap.Error(err))
 			return errors.Join(fmt.Errorf("problem in using the max resources settings for queuepath: %s, reason: ", queuePath), err)
 		}
-		limitConfig := &LimitConfig{maxResources: maxResource, maxApplications: limit.MaxApplications}
+		var limitBudget *budget
+		if limitBudget, err = newBudget(limit.MaxResourceHours, limit.BudgetPeriod); err != nil {
+			log.Log(log.SchedUGM).Warn("Problem in using the limit budget settings.",
+				zap.String("queue path", queuePath),
+				zap.Any("limit max resource hours", limit.MaxResourceHours),
+				zap.String("limit budget period", limit.BudgetPeriod),
+				zap.Error(err))
+			return errors.Join(fmt.Errorf("problem in using the budget settings for queuepath: %s, reason: ", queuePath), err)
+		}
+		limitConfig := &LimitConfig{maxResources: maxResource, maxApplications: limit.MaxApplications, budget: limitBudget}
 		for _, user := range limit.Users {
 			if user == common.Empty {
 				continue
@@ -403,6 +434,28 @@ func (m *Manager) internalProcessConfig(cur configs.QueueConfig, queuePath strin
 	return nil
 }
 
+// processQueueBudgets collects the budgets set on the queues in the hierarchy
+func processQueueBudgets(cur configs.QueueConfig, queuePath string, queueBudgets map[string]*budget) error {
+	queueBudget, err := newBudget(cur.MaxResourceHours, cur.BudgetPeriod)
+	if err != nil {
+		log.Log(log.SchedUGM).Warn("Problem in using the queue budget settings.",
+			zap.String("queue path", queuePath),
+			zap.Any("queue max resource hours", cur.MaxResourceHours),
+			zap.String("queue budget period", cur.BudgetPeriod),
+			zap.Error(err))
+		return errors.Join(fmt.Errorf("problem in using the budget settings for queuepath: %s, reason: ", queuePath), err)
+	}
+	if queueBudget != nil {
+		queueBudgets[queuePath] = queueBudget
+	}
+	for _, child := range cur.Queues {
+		if err = processQueueBudgets(child, queuePath+configs.DOT+child.Name, queueBudgets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // clearEarlierSetLimits Clear already configured limits of users and groups for which limits have been configured before but not now
 func (m *Manager) clearEarlierSetLimits(newUserLimits map[string]map[string]*LimitConfig, newGroupLimits map[string]map[string]*LimitConfig) {
 	m.Lock()
@@ -442,14 +495,15 @@ func (m *Manager) clearEarlierSetUserWildCardLimits(newUserWildCardLimits map[st
 			// In case wild card user limit exists, compare the old wild card limits with new limits for existing users already using wild card limits.
 			// In case of difference, set new limits for all those users.
 			if currentLimitConfig.maxApplications != newLimitConfig.maxApplications ||
-				!resources.Equals(currentLimitConfig.maxResources, newLimitConfig.maxResources) {
+				!resources.Equals(currentLimitConfig.maxResources, newLimitConfig.maxResources) ||
+				!currentLimitConfig.budget.equals(newLimitConfig.budget) {
 				for _, ut := range m.userTrackers {
 					log.Log(log.SchedUGM).Debug("Need to update earlier set configs for user because wild card limit applied earlier has been updated",
 						zap.String("user", ut.userName),
 						zap.String("queue path", queuePath))
 					_, exists := m.userLimits[queuePath][ut.userName]
 					if _, ok = newUserLimits[queuePath][ut.userName]; !ok || !exists {
-						ut.setLimits(queuePath, newLimitConfig.maxResources, newLimitConfig.maxApplications, true, true)
+						ut.setLimits(queuePath, newLimitConfig.maxResources, newLimitConfig.maxApplications, newLimitConfig.budget, true, true)
 					}
 				}
 			}
@@ -464,7 +518,7 @@ func (m *Manager) applyWildCardUserLimits(newUserWildCardLimits map[string]*Limi
 	for queuePath, newLimitConfig := range newUserWildCardLimits {
 		for _, ut := range m.userTrackers {
 			if _, ok := newUserLimits[queuePath][ut.userName]; !ok {
-				ut.setLimits(queuePath, newLimitConfig.maxResources, newLimitConfig.maxApplications, true, false)
+				ut.setLimits(queuePath, newLimitConfig.maxResources, newLimitConfig.maxApplications, newLimitConfig.budget, true, false)
 			}
 		}
 	}
@@ -598,7 +652,7 @@ func (m *Manager) setUserLimits(user string, limitConfig *LimitConfig, queuePath
 		userTracker = newUserTracker(user, m.events)
 		m.userTrackers[user] = userTracker
 	}
-	userTracker.setLimits(queuePath, limitConfig.maxResources, limitConfig.maxApplications, false, false)
+	userTracker.setLimits(queuePath, limitConfig.maxResources, limitConfig.maxApplications, limitConfig.budget, false, false)
 	return nil
 }
 
@@ -618,7 +672,7 @@ func (m *Manager) setGroupLimits(group string, limitConfig *LimitConfig, queuePa
 		groupTracker = newGroupTracker(group, m.events)
 		m.groupTrackers[group] = groupTracker
 	}
-	groupTracker.setLimits(queuePath, limitConfig.maxResources, limitConfig.maxApplications)
+	groupTracker.setLimits(queuePath, limitConfig.maxResources, limitConfig.maxApplications, limitConfig.budget)
 	return nil
 }
 
@@ -649,7 +703,8 @@ func (m *Manager) getUserWildCardLimitsConfig(queuePath string) *LimitConfig {
 func (m *Manager) Headroom(queuePath, applicationID string, user security.UserGroup) *resources.Resource {
 	hierarchy := strings.Split(queuePath, configs.DOT)
 	userTracker := m.getUserTracker(user.User)
-	userHeadroom := userTracker.headroom(hierarchy)
+	// a used up queue budget applies to all users
+	userHeadroom := resources.ComponentWiseMin(userTracker.headroom(hierarchy), m.GetQueueBudgetTracker().headroom(hierarchy))
 	// make sure the user has a groupTracker for this application, if not yet there add it
 	if !userTracker.hasGroupForApp(applicationID) {
 		m.ensureGroupTrackerForApp(queuePath, applicationID, user)
@@ -703,6 +758,26 @@ func (m *Manager) ClearGroupTrackers() {
 	m.groupTrackers = make(map[string]*GroupTracker)
 }
 
+// GetQueueBudgetTracker returns the tracker of the usage of all applications per queue
+func (m *Manager) GetQueueBudgetTracker() *QueueBudgetTracker {
+	m.RLock()
+	defer m.RUnlock()
+	return m.queueBudgetTracker
+}
+
+// GetQueuesResourceUsageDAOInfo returns the DAO object used in the REST API for the usage of all applications per
+// queue, with the budgets set on the queues
+func (m *Manager) GetQueuesResourceUsageDAOInfo() *dao.ResourceUsageDAOInfo {
+	return m.GetQueueBudgetTracker().getResourceUsageDAOInfo(m.GetUserTrackers)
+}
+
+// ClearQueueBudgetTracker only for tests
+func (m *Manager) ClearQueueBudgetTracker() {
+	m.Lock()
+	defer m.Unlock()
+	m.queueBudgetTracker = newQueueBudgetTracker()
+}
+
 // ClearConfigLimits only for tests
 func (m *Manager) ClearConfigLimits() {
 	m.Lock()
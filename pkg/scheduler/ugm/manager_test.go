@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -1616,6 +1617,112 @@ func TestMultipleGroupLimitChange(t *testing.T) {
 	assert.Equal(t, headroom.FitInMaxUndef(usage), false)
 }
 
+func TestUserGroupBudget(t *testing.T) {
+	setupUGM()
+	manager := GetUserManager()
+	budgetLimit := configs.Limit{
+		Users:            []string{"user1"},
+		MaxResourceHours: map[string]string{"vcore": "10"},
+		BudgetPeriod:     "24h",
+	}
+	wildcardLimit := configs.Limit{
+		Users:            []string{"*"},
+		MaxResourceHours: map[string]string{"memory": "100"},
+		BudgetPeriod:     "168h",
+	}
+	conf := createConfigWithLimits([]configs.Limit{budgetLimit, wildcardLimit})
+	assert.NilError(t, manager.UpdateConfig(conf.Queues[0], "root"))
+
+	user1 := security.UserGroup{User: "user1", Groups: []string{"group1"}}
+	usage := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 2000, "memory": 10})
+	headroom := manager.Headroom("root.parent.leaf", TestApp1, user1)
+	assert.Assert(t, headroom.FitInMaxUndef(usage), "budget should not block before it is used")
+	manager.IncreaseTrackedResource("root.parent.leaf", TestApp1, usage, user1)
+
+	// use up the budget of user1
+	ut := manager.GetUserTracker(user1.User)
+	parent := ut.queueTracker.childQueueTrackers["parent"]
+	assert.Assert(t, parent.budget != nil, "budget should be set for user1")
+	parent.consumed["vcore"] = 10000
+	headroom = manager.Headroom("root.parent.leaf", TestApp1, user1)
+	assert.Assert(t, !headroom.FitInMaxUndef(usage), "exhausted budget should block: %s", headroom)
+	usageInfo := ut.GetResourceUsageDAOInfo().Queues.Children[0]
+	assert.Equal(t, usageInfo.QueuePath, "root.parent")
+	assert.DeepEqual(t, usageInfo.RemainingResourceHours, map[string]int64{"vcore": 0})
+
+	// the wildcard budget applies to other users
+	user2 := security.UserGroup{User: "user2", Groups: []string{"group2"}}
+	manager.Headroom("root.parent.leaf", TestApp2, user2)
+	wildcard := manager.GetUserTracker(user2.User).queueTracker.childQueueTrackers["parent"]
+	assert.Assert(t, wildcard.budget != nil && wildcard.useWildCard, "wildcard budget should be set for user2")
+	assert.Equal(t, wildcard.budget.period, 168*time.Hour)
+
+	// removing the budget removes the block
+	budgetLimit.MaxResourceHours = nil
+	budgetLimit.BudgetPeriod = ""
+	budgetLimit.MaxApplications = 10
+	conf = createConfigWithLimits([]configs.Limit{budgetLimit})
+	assert.NilError(t, manager.UpdateConfig(conf.Queues[0], "root"))
+	assert.Assert(t, parent.budget == nil, "budget should be removed")
+	headroom = manager.Headroom("root.parent.leaf", TestApp1, user1)
+	assert.Assert(t, headroom.FitInMaxUndef(usage), "budget should not block after removal: %s", headroom)
+
+	// an invalid budget fails the update
+	budgetLimit.MaxResourceHours = map[string]string{"vcore": "10"}
+	budgetLimit.BudgetPeriod = "x"
+	conf = createConfigWithLimits([]configs.Limit{budgetLimit})
+	assert.ErrorContains(t, manager.UpdateConfig(conf.Queues[0], "root"), "budget settings")
+	manager.DecreaseTrackedResource("root.parent.leaf", TestApp1, usage, user1, true)
+}
+
+func TestQueueBudget(t *testing.T) {
+	setupUGM()
+	manager := GetUserManager()
+	user1 := security.UserGroup{User: "user1", Groups: []string{"group1"}}
+	user2 := security.UserGroup{User: "user2", Groups: []string{"group2"}}
+	usage := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 2000})
+	manager.IncreaseTrackedResource("root.parent.leaf", TestApp1, usage, user1)
+	assert.Equal(t, len(manager.GetQueueBudgetTracker().queueTracker.childQueueTrackers), 0, "usage should not be tracked without budgets")
+	usageInfo := manager.GetQueuesResourceUsageDAOInfo()
+	assert.DeepEqual(t, usageInfo.ResourceUsage, usage.DAOMap())
+
+	// setting the first budget collects the usage of the users
+	conf := createConfigWithLimits(nil)
+	conf.Queues[0].Queues[0].MaxResourceHours = map[string]string{"vcore": "10"}
+	conf.Queues[0].Queues[0].BudgetPeriod = "24h"
+	assert.NilError(t, manager.UpdateConfig(conf.Queues[0], "root"))
+	parent := manager.GetQueueBudgetTracker().queueTracker.childQueueTrackers["parent"]
+	assert.Assert(t, parent.budget != nil, "budget should be set for the queue")
+	assert.Assert(t, resources.Equals(parent.resourceUsage, usage), "usage of user1 should be collected")
+	manager.IncreaseTrackedResource("root.parent.leaf", TestApp2, usage, user2)
+	assert.Assert(t, resources.Equals(parent.resourceUsage, resources.Multiply(usage, 2)), "usage of all users should be tracked")
+	headroom := manager.Headroom("root.parent.leaf", TestApp2, user2)
+	assert.Assert(t, headroom.FitInMaxUndef(usage), "budget should not block before it is used")
+
+	// the used up queue budget blocks all users
+	parent.consumed["vcore"] = 10000
+	headroom = manager.Headroom("root.parent.leaf", TestApp1, user1)
+	assert.Assert(t, !headroom.FitInMaxUndef(usage), "exhausted queue budget should block user1: %s", headroom)
+	headroom = manager.Headroom("root.parent.leaf", TestApp2, user2)
+	assert.Assert(t, !headroom.FitInMaxUndef(usage), "exhausted queue budget should block user2: %s", headroom)
+	usageInfo = manager.GetQueuesResourceUsageDAOInfo().Children[0]
+	assert.DeepEqual(t, usageInfo.RemainingResourceHours, map[string]int64{"vcore": 0})
+
+	// removing the budget removes the block
+	conf = createConfigWithLimits(nil)
+	assert.NilError(t, manager.UpdateConfig(conf.Queues[0], "root"))
+	headroom = manager.Headroom("root.parent.leaf", TestApp1, user1)
+	assert.Assert(t, headroom.FitInMaxUndef(usage), "budget should not block after removal: %s", headroom)
+
+	// an invalid budget fails the update
+	conf.Queues[0].Queues[0].MaxResourceHours = map[string]string{"vcore": "10"}
+	conf.Queues[0].Queues[0].BudgetPeriod = "x"
+	assert.ErrorContains(t, manager.UpdateConfig(conf.Queues[0], "root"), "budget settings")
+	manager.DecreaseTrackedResource("root.parent.leaf", TestApp1, usage, user1, true)
+	manager.DecreaseTrackedResource("root.parent.leaf", TestApp2, usage, user2, true)
+	assert.Assert(t, resources.IsZero(manager.GetQueueBudgetTracker().queueTracker.resourceUsage), "usage should not be tracked")
+}
+
 func createLimit(users, groups []string, maxResources map[string]string, maxApps uint64) configs.Limit {
 	return configs.Limit{
 		Users:           users,
@@ -1989,6 +2096,7 @@ func setupUGM() {
 	manager.ClearUserTrackers()
 	manager.ClearGroupTrackers()
 	manager.ClearConfigLimits()
+	manager.ClearQueueBudgetTracker()
 }
 
 func assertUGM(t *testing.T, userGroup security.UserGroup, expected *resources.Resource, usersCount int) {
@@ -0,0 +1,138 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ugm
+
+import (
+	"strings"
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+// QueueBudgetTracker tracks the usage of all applications in a queue, independent of the user or group, to enforce
+// the budgets configured on the queues. The usage is only tracked while budgets are configured.
+type QueueBudgetTracker struct {
+	queueTracker *QueueTracker      // Holds the resource usage of all applications per queue path
+	budgets      map[string]*budget // Holds the queue path * budget config
+
+	locking.RWMutex
+}
+
+func newQueueBudgetTracker() *QueueBudgetTracker {
+	return &QueueBudgetTracker{
+		queueTracker: newRootQueueTracker(queue),
+		budgets:      make(map[string]*budget),
+	}
+}
+
+// lockUsage locks the tracker for a change of the usage of the users. If budgets are set the write lock is taken and
+// true is returned: the change must be tracked. Without budgets the read lock keeps setBudgets from collecting the
+// usage of the users halfway through the change. The lock must be released using unlockUsage.
+func (qbt *QueueBudgetTracker) lockUsage() bool {
+	for {
+		qbt.RLock()
+		if len(qbt.budgets) == 0 {
+			return false
+		}
+		qbt.RUnlock()
+		qbt.Lock()
+		if len(qbt.budgets) != 0 {
+			return true
+		}
+		qbt.Unlock()
+	}
+}
+
+// unlockUsage releases the lock taken by lockUsage.
+func (qbt *QueueBudgetTracker) unlockUsage(tracked bool) {
+	if tracked {
+		qbt.Unlock()
+	} else {
+		qbt.RUnlock()
+	}
+}
+
+// Note: Lock free call. Must only be called if lockUsage returned true.
+func (qbt *QueueBudgetTracker) increaseTrackedResource(queuePath, applicationID string, usage *resources.Resource) {
+	qbt.queueTracker.increaseTrackedResource(strings.Split(queuePath, configs.DOT), applicationID, queue, usage)
+}
+
+// Note: Lock free call. Must only be called if lockUsage returned true.
+func (qbt *QueueBudgetTracker) decreaseTrackedResource(queuePath, applicationID string, usage *resources.Resource, removeApp bool) {
+	qbt.queueTracker.decreaseTrackedResource(strings.Split(queuePath, configs.DOT), applicationID, usage, removeApp)
+}
+
+// setBudgets replaces the queue budgets. Budgets that are not changed keep the resource hours consumed in the
+// current period. The usage is collected from the user trackers when the first budget is set and dropped when the
+// last budget is removed.
+func (qbt *QueueBudgetTracker) setBudgets(budgets map[string]*budget, userTrackers func() []*UserTracker) {
+	qbt.Lock()
+	defer qbt.Unlock()
+	if len(budgets) == 0 {
+		qbt.queueTracker = newRootQueueTracker(queue)
+		qbt.budgets = budgets
+		return
+	}
+	if len(qbt.budgets) == 0 {
+		qbt.queueTracker = newRootQueueTracker(queue)
+		for _, ut := range userTrackers() {
+			ut.addUsageTo(qbt.queueTracker)
+		}
+	}
+	for queuePath := range qbt.budgets {
+		if _, ok := budgets[queuePath]; !ok {
+			qbt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, 0, nil, false, queue, false)
+		}
+	}
+	for queuePath, queueBudget := range budgets {
+		if !queueBudget.equals(qbt.budgets[queuePath]) {
+			qbt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, 0, queueBudget, false, queue, false)
+		}
+	}
+	qbt.budgets = budgets
+}
+
+// headroom returns the resource types for which the budget of a queue in the hierarchy is used up, nil if there is
+// none. The tracker is not changed.
+func (qbt *QueueBudgetTracker) headroom(hierarchy []string) *resources.Resource {
+	qbt.RLock()
+	defer qbt.RUnlock()
+	if len(qbt.budgets) == 0 {
+		return nil
+	}
+	return qbt.queueTracker.exhaustedBudgets(hierarchy, time.Now())
+}
+
+// getResourceUsageDAOInfo returns the DAO object used in the REST API for the queue usage and budgets.
+// Without budgets the usage is not tracked and is collected from the user trackers instead.
+func (qbt *QueueBudgetTracker) getResourceUsageDAOInfo(userTrackers func() []*UserTracker) *dao.ResourceUsageDAOInfo {
+	qbt.RLock()
+	defer qbt.RUnlock()
+	if len(qbt.budgets) != 0 {
+		return qbt.queueTracker.getResourceUsageDAOInfo()
+	}
+	usage := newRootQueueTracker(queue)
+	for _, ut := range userTrackers() {
+		ut.addUsageTo(usage)
+	}
+	return usage.getResourceUsageDAOInfo()
+}
@@ -0,0 +1,79 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ugm
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+func TestQueueBudgetTracker(t *testing.T) {
+	qbt := newQueueBudgetTracker()
+	hierarchy := []string{"root", "parent", "leaf"}
+	usage := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 2})
+	noUsers := func() []*UserTracker { return nil }
+	assert.Assert(t, !qbt.lockUsage(), "usage should not be tracked without budgets")
+	qbt.unlockUsage(false)
+	assert.Assert(t, qbt.headroom(hierarchy) == nil, "no headroom without budget")
+
+	limitBudget, err := newBudget(map[string]string{"vcore": "10"}, "24h")
+	assert.NilError(t, err)
+	qbt.setBudgets(map[string]*budget{"root.parent": limitBudget}, noUsers)
+	assert.Assert(t, qbt.lockUsage(), "usage should be tracked with budgets")
+	qbt.increaseTrackedResource("root.parent.leaf", TestApp1, usage)
+	qbt.increaseTrackedResource("root.parent.leaf", TestApp2, usage)
+	qbt.unlockUsage(true)
+	parent := qbt.queueTracker.childQueueTrackers["parent"]
+	assert.Assert(t, parent.budget.equals(limitBudget))
+	assert.Assert(t, resources.Equals(parent.resourceUsage, resources.Multiply(usage, 2)), "usage of all applications should be tracked")
+	assert.Assert(t, qbt.headroom(hierarchy) == nil, "no headroom before the budget is used")
+
+	// headroom does not create trackers
+	assert.Assert(t, qbt.headroom([]string{"root", "other", "leaf"}) == nil, "no headroom for a queue without budget")
+	assert.Assert(t, qbt.queueTracker.childQueueTrackers["other"] == nil, "headroom should not create a tracker")
+
+	parent.consumed["vcore"] = 10000
+	assert.Assert(t, resources.Equals(qbt.headroom(hierarchy), resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 0})), "exhausted queue budget should block")
+	usageInfo := qbt.getResourceUsageDAOInfo(noUsers).Children[0]
+	assert.Equal(t, usageInfo.QueuePath, "root.parent")
+	assert.DeepEqual(t, usageInfo.RemainingResourceHours, map[string]int64{"vcore": 0})
+
+	// an unchanged budget keeps the consumption
+	sameBudget, err := newBudget(map[string]string{"vcore": "10"}, "24h")
+	assert.NilError(t, err)
+	qbt.setBudgets(map[string]*budget{"root.parent": sameBudget}, noUsers)
+	assert.Assert(t, parent.consumed["vcore"] >= 10000, "consumption should be kept")
+
+	// the tracker with a budget is kept without usage
+	assert.Assert(t, qbt.lockUsage(), "usage should be tracked with budgets")
+	qbt.decreaseTrackedResource("root.parent.leaf", TestApp1, usage, true)
+	qbt.decreaseTrackedResource("root.parent.leaf", TestApp2, usage, true)
+	qbt.unlockUsage(true)
+	assert.Assert(t, qbt.queueTracker.childQueueTrackers["parent"] != nil, "tracker with budget should not be removed")
+
+	// removing the budget removes the block and the tracked usage
+	qbt.setBudgets(map[string]*budget{}, noUsers)
+	assert.Assert(t, qbt.headroom(hierarchy) == nil, "no headroom after budget removal")
+	assert.Equal(t, len(qbt.queueTracker.childQueueTrackers), 0, "tracked usage should be removed")
+	assert.Assert(t, !qbt.lockUsage(), "usage should not be tracked after budget removal")
+	qbt.unlockUsage(false)
+}
@@ -19,6 +19,9 @@
 package ugm
 
 import (
+	"fmt"
+	"time"
+
 	"go.uber.org/zap"
 	"golang.org/x/exp/maps"
 
@@ -40,6 +43,46 @@ type QueueTracker struct {
 	maxRunningApps      uint64
 	childQueueTrackers  map[string]*QueueTracker
 	useWildCard         bool
+	budget              *budget            // resource-time limit, nil if not set
+	consumed            map[string]float64 // resource hours consumed in the current budget period
+	periodStart         time.Time          // start of the current budget period
+	lastAccrued         time.Time          // last time the usage was added to the consumed resource hours
+}
+
+// budget is a resource-time limit: the resources multiplied by hours that can be consumed in each period.
+type budget struct {
+	resourceHours *resources.Resource
+	period        time.Duration
+}
+
+// newBudget creates the budget from the limit configuration, nil is returned if no budget is configured.
+func newBudget(resourceHours map[string]string, period string) (*budget, error) {
+	if len(resourceHours) == 0 {
+		return nil, nil
+	}
+	hours, err := resources.NewResourceFromConf(resourceHours)
+	if err != nil {
+		return nil, err
+	}
+	var duration time.Duration
+	if duration, err = configs.ParseBudgetPeriod(period); err != nil {
+		return nil, err
+	}
+	return &budget{resourceHours: hours, period: duration}, nil
+}
+
+func (b *budget) equals(other *budget) bool {
+	if b == nil || other == nil {
+		return b == other
+	}
+	return b.period == other.period && resources.Equals(b.resourceHours, other.resourceHours)
+}
+
+func (b *budget) String() string {
+	if b == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%s per %s", b.resourceHours, b.period)
 }
 
 func newRootQueueTracker(trackType trackingType) *QueueTracker {
@@ -72,6 +115,7 @@ func newQueueTracker(queuePath string, queueName string, trackType trackingType)
 				zap.Stringer("max resources", config.maxResources))
 			queueTracker.maxResources = config.maxResources.Clone()
 			queueTracker.maxRunningApps = config.maxApplications
+			queueTracker.setBudget(config.budget, time.Now())
 			queueTracker.useWildCard = true
 		}
 	}
@@ -86,10 +130,11 @@ const (
 	none trackingType = iota
 	user
 	group
+	queue
 )
 
 func (tt trackingType) String() string {
-	return [...]string{"none", "user", "group"}[tt]
+	return [...]string{"none", "user", "group", "queue"}[tt]
 }
 
 // Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
@@ -110,6 +155,7 @@ func (qt *QueueTracker) increaseTrackedResource(hierarchy []string, applicationI
 		}
 		qt.childQueueTrackers[childName].increaseTrackedResource(hierarchy[1:], applicationID, trackType, usage)
 	}
+	qt.accrue(time.Now())
 	if qt.resourceUsage == nil {
 		qt.resourceUsage = resources.NewResource()
 	}
@@ -154,6 +200,7 @@ func (qt *QueueTracker) decreaseTrackedResource(hierarchy []string, applicationI
 			delete(qt.childQueueTrackers, childName)
 		}
 	}
+	qt.accrue(time.Now())
 	qt.resourceUsage.SubFrom(usage)
 	qt.resourceUsage.Prune()
 	if removeApp {
@@ -172,7 +219,7 @@ func (qt *QueueTracker) decreaseTrackedResource(hierarchy []string, applicationI
 
 	// Determine if the queue tracker should be removed
 	removeQT := len(qt.childQueueTrackers) == 0 && len(qt.runningApplications) == 0 && resources.IsZero(qt.resourceUsage) &&
-		qt.maxRunningApps == 0 && resources.IsZero(qt.maxResources)
+		qt.maxRunningApps == 0 && resources.IsZero(qt.maxResources) && qt.budget == nil
 	log.Log(log.SchedUGM).Debug("Remove queue tracker",
 		zap.String("queue path ", qt.queuePath),
 		zap.Bool("remove QT", removeQT))
@@ -180,12 +227,13 @@ func (qt *QueueTracker) decreaseTrackedResource(hierarchy []string, applicationI
 }
 
 // Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
-func (qt *QueueTracker) setLimit(hierarchy []string, maxResource *resources.Resource, maxApps uint64, useWildCard bool, trackType trackingType, doWildCardCheck bool) {
+func (qt *QueueTracker) setLimit(hierarchy []string, maxResource *resources.Resource, maxApps uint64, limitBudget *budget, useWildCard bool, trackType trackingType, doWildCardCheck bool) {
 	log.Log(log.SchedUGM).Debug("Setting limits",
 		zap.String("queue path", qt.queuePath),
 		zap.Strings("hierarchy", hierarchy),
 		zap.Uint64("max applications", maxApps),
 		zap.Stringer("max resources", maxResource),
+		zap.Stringer("budget", limitBudget),
 		zap.Bool("use wild card", useWildCard))
 	// depth first: all the way to the leaf, create if not exists
 	// more than 1 in the slice means we need to recurse down
@@ -194,7 +242,7 @@ func (qt *QueueTracker) setLimit(hierarchy []string, maxResource *resources.Reso
 		if qt.childQueueTrackers[childName] == nil {
 			qt.childQueueTrackers[childName] = newQueueTracker(qt.queuePath, childName, trackType)
 		}
-		qt.childQueueTrackers[childName].setLimit(hierarchy[1:], maxResource, maxApps, useWildCard, trackType, doWildCardCheck)
+		qt.childQueueTrackers[childName].setLimit(hierarchy[1:], maxResource, maxApps, limitBudget, useWildCard, trackType, doWildCardCheck)
 	} else if len(hierarchy) == 1 {
 		// don't override named user/group specific limits with wild card limits
 		if doWildCardCheck && !qt.useWildCard {
@@ -202,10 +250,138 @@ func (qt *QueueTracker) setLimit(hierarchy []string, maxResource *resources.Reso
 		}
 		qt.maxRunningApps = maxApps
 		qt.maxResources = maxResource
+		qt.setBudget(limitBudget, time.Now())
 		qt.useWildCard = useWildCard
 	}
 }
 
+// setBudget replaces the budget. The resource hours consumed in the current period are kept if a budget was set before.
+// Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+func (qt *QueueTracker) setBudget(limitBudget *budget, now time.Time) {
+	if limitBudget == nil {
+		qt.budget = nil
+		qt.consumed = nil
+		return
+	}
+	// account for the usage under the old budget before switching
+	qt.accrue(now)
+	if qt.budget == nil {
+		qt.consumed = make(map[string]float64)
+		qt.periodStart = now.Truncate(limitBudget.period)
+		qt.lastAccrued = now
+	}
+	qt.budget = limitBudget
+	qt.accrue(now)
+}
+
+// consumedAt returns the resource hours consumed in the budget period at the time, without updating the tracker.
+// Note: Lock free call. The RLock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+func (qt *QueueTracker) consumedAt(now time.Time) map[string]float64 {
+	consumed := make(map[string]float64)
+	from := qt.lastAccrued
+	if now.Truncate(qt.budget.period).After(qt.periodStart) {
+		// a new period started: only the usage since the start of the period counts
+		if start := now.Truncate(qt.budget.period); from.Before(start) {
+			from = start
+		}
+	} else {
+		maps.Copy(consumed, qt.consumed)
+	}
+	if qt.resourceUsage != nil && now.After(from) {
+		hours := now.Sub(from).Hours()
+		for name := range qt.budget.resourceHours.Resources {
+			consumed[name] += float64(qt.resourceUsage.Resources[name]) * hours
+		}
+	}
+	return consumed
+}
+
+// accrue adds the usage since the last update to the consumed resource hours. The consumed resource hours are reset
+// at the start of a new budget period. Must be called before the resource usage changes.
+// Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+func (qt *QueueTracker) accrue(now time.Time) {
+	if qt.budget == nil {
+		return
+	}
+	qt.consumed = qt.consumedAt(now)
+	if start := now.Truncate(qt.budget.period); start.After(qt.periodStart) {
+		qt.periodStart = start
+	}
+	if now.After(qt.lastAccrued) {
+		qt.lastAccrued = now
+	}
+}
+
+// remainingBudget returns the resource hours left in the budget period at the time, never below zero.
+// Returns nil if no budget is set.
+// Note: Lock free call. The RLock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+func (qt *QueueTracker) remainingBudget(now time.Time) *resources.Resource {
+	if qt.budget == nil {
+		return nil
+	}
+	consumed := qt.consumedAt(now)
+	remaining := resources.NewResource()
+	for name, hours := range qt.budget.resourceHours.Resources {
+		left := float64(hours) - consumed[name]
+		if left < 0 {
+			left = 0
+		}
+		remaining.Resources[name] = resources.Quantity(left)
+	}
+	return remaining
+}
+
+// exhaustedBudget returns a zero quantity for each resource type of which the budget is used up, nil if there is none.
+// Note: Lock free call. The RLock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+func (qt *QueueTracker) exhaustedBudget(now time.Time) *resources.Resource {
+	remaining := qt.remainingBudget(now)
+	if remaining == nil {
+		return nil
+	}
+	var exhausted *resources.Resource
+	for name, left := range remaining.Resources {
+		if left > 0 {
+			continue
+		}
+		if exhausted == nil {
+			exhausted = resources.NewResource()
+		}
+		exhausted.Resources[name] = 0
+	}
+	return exhausted
+}
+
+// exhaustedBudgets returns a zero quantity for each resource type of which the budget of a queue in the hierarchy is
+// used up, nil if there is none. Queues without a tracker have no usage and are skipped: the trackers are not changed.
+// Note: Lock free call. The RLock of the linked tracker should be held before calling this function.
+func (qt *QueueTracker) exhaustedBudgets(hierarchy []string, now time.Time) *resources.Resource {
+	exhausted := qt.exhaustedBudget(now)
+	if len(hierarchy) > 1 {
+		if child := qt.childQueueTrackers[hierarchy[1]]; child != nil {
+			return resources.ComponentWiseMin(exhausted, child.exhaustedBudgets(hierarchy[1:], now))
+		}
+	}
+	return exhausted
+}
+
+// addUsageTo adds the resource usage and running applications of the tracker and its children to the target.
+// Trackers that do not exist in the target are created.
+// Note: Lock free call. The RLock of the linked tracker should be held before calling this function.
+func (qt *QueueTracker) addUsageTo(target *QueueTracker, trackType trackingType) {
+	if !resources.IsZero(qt.resourceUsage) {
+		target.resourceUsage = resources.Add(target.resourceUsage, qt.resourceUsage)
+	}
+	for applicationID := range qt.runningApplications {
+		target.runningApplications[applicationID] = true
+	}
+	for childName, child := range qt.childQueueTrackers {
+		if target.childQueueTrackers[childName] == nil {
+			target.childQueueTrackers[childName] = newQueueTracker(target.queuePath, childName, trackType)
+		}
+		child.addUsageTo(target.childQueueTrackers[childName], trackType)
+	}
+}
+
 // Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
 // Note: headroom is not read-only, it also traverses the queue hierarchy and creates childQueueTracker if it does not exist.
 func (qt *QueueTracker) headroom(hierarchy []string, trackType trackingType) *resources.Resource {
@@ -224,6 +400,10 @@ func (qt *QueueTracker) headroom(hierarchy []string, trackType trackingType) *re
 	if !resources.IsZero(qt.maxResources) {
 		headroom = resources.SubOnlyExisting(qt.maxResources, qt.resourceUsage)
 	}
+	// a budget that is used up blocks the resource type until the next period
+	if exhausted := qt.exhaustedBudget(time.Now()); exhausted != nil {
+		headroom = resources.ComponentWiseMin(headroom, exhausted)
+	}
 
 	if headroom == nil {
 		return childHeadroom
@@ -249,7 +429,7 @@ func (qt *QueueTracker) getResourceUsageDAOInfo() *dao.ResourceUsageDAOInfo {
 		children[i] = cqt.getResourceUsageDAOInfo()
 		i++
 	}
-	return &dao.ResourceUsageDAOInfo{
+	usageInfo := &dao.ResourceUsageDAOInfo{
 		QueuePath:           qt.queuePath,
 		ResourceUsage:       qt.resourceUsage.DAOMap(),
 		MaxResources:        qt.maxResources.DAOMap(),
@@ -257,6 +437,14 @@ func (qt *QueueTracker) getResourceUsageDAOInfo() *dao.ResourceUsageDAOInfo {
 		RunningApplications: apps,
 		Children:            children,
 	}
+	if qt.budget != nil {
+		now := time.Now()
+		usageInfo.MaxResourceHours = qt.budget.resourceHours.DAOMap()
+		usageInfo.RemainingResourceHours = qt.remainingBudget(now).DAOMap()
+		usageInfo.BudgetPeriod = qt.budget.period.String()
+		usageInfo.BudgetReset = now.Truncate(qt.budget.period).Add(qt.budget.period).UnixNano()
+	}
+	return usageInfo
 }
 
 // getMaxResources returns a map of all maxResources defined in the queue hierarchy.
@@ -470,7 +658,7 @@ func (qt *QueueTracker) canBeRemoved() bool {
 
 func (qt *QueueTracker) canBeRemovedInternal() bool {
 	if len(qt.runningApplications) == 0 && resources.IsZero(qt.resourceUsage) && len(qt.childQueueTrackers) == 0 &&
-		qt.maxRunningApps == 0 && resources.IsZero(qt.maxResources) {
+		qt.maxRunningApps == 0 && resources.IsZero(qt.maxResources) && qt.budget == nil {
 		return true
 	}
 	return false
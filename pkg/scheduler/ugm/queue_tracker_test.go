@@ -21,6 +21,7 @@ package ugm
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -415,7 +416,7 @@ func TestSetLimit(t *testing.T) {
 	limit := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"mem":   10,
 		"vcore": 10})
-	root.setLimit(strings.Split(queuePath1, configs.DOT), limit.Clone(), 9, true, user, true)
+	root.setLimit(strings.Split(queuePath1, configs.DOT), limit.Clone(), 9, nil, true, user, true)
 
 	// check settings
 	parentQ := root.childQueueTrackers["parent"]
@@ -430,24 +431,85 @@ func TestSetLimit(t *testing.T) {
 	newLimit := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"mem":   20,
 		"vcore": 20})
-	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit.Clone(), 3, false, user, true) // override
+	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit.Clone(), 3, nil, false, user, true) // override
 	assert.Assert(t, resources.Equals(newLimit, childQ.maxResources))
 	assert.Assert(t, !childQ.useWildCard)
 	newLimit2 := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"mem":   30,
 		"vcore": 30})
-	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit2.Clone(), 2, true, user, true) // no override
+	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit2.Clone(), 2, nil, true, user, true) // no override
 	assert.Assert(t, !childQ.useWildCard)
 	assert.Assert(t, resources.Equals(newLimit, childQ.maxResources))
 	assert.Equal(t, uint64(3), childQ.maxRunningApps)
 
-	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit2.Clone(), 4, true, user, false) // override -> changes qt.doWildCardCheck
+	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit2.Clone(), 4, nil, true, user, false) // override -> changes qt.doWildCardCheck
 	assert.Assert(t, childQ.useWildCard)
 	assert.Assert(t, resources.Equals(newLimit2, childQ.maxResources))
 	assert.Equal(t, uint64(4), childQ.maxRunningApps)
 
-	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit.Clone(), 5, false, user, false) // override
+	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit.Clone(), 5, nil, false, user, false) // override
 	assert.Assert(t, !childQ.useWildCard)
 	assert.Assert(t, resources.Equals(newLimit, childQ.maxResources))
 	assert.Equal(t, uint64(5), childQ.maxRunningApps)
 }
+
+func TestQueueTrackerBudget(t *testing.T) {
+	GetUserManager()
+	_, err := newBudget(map[string]string{"vcore": "x"}, "24h")
+	assert.Assert(t, err != nil, "invalid resource hours should fail")
+	_, err = newBudget(map[string]string{"vcore": "10"}, "1s")
+	assert.Assert(t, err != nil, "invalid period should fail")
+	var limitBudget *budget
+	limitBudget, err = newBudget(nil, "")
+	assert.NilError(t, err)
+	assert.Assert(t, limitBudget == nil, "no resource hours should not set a budget")
+	// 10 vcore hours per day
+	limitBudget, err = newBudget(map[string]string{"vcore": "10"}, "24h")
+	assert.NilError(t, err)
+
+	hierarchy := strings.Split("root.parent", configs.DOT)
+	root := newRootQueueTracker(user)
+	root.setLimit(hierarchy, nil, 0, limitBudget, false, user, false)
+	parent := root.childQueueTrackers["parent"]
+	assert.Assert(t, parent.budget.equals(limitBudget))
+	usage, err := resources.NewResourceFromConf(map[string]string{"vcore": "2", "memory": "10"})
+	assert.NilError(t, err)
+	root.increaseTrackedResource(hierarchy, TestApp1, user, usage)
+
+	// the usage of 2 vcores started an hour into the period
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	parent.periodStart = day
+	parent.lastAccrued = day.Add(time.Hour)
+	parent.consumed = make(map[string]float64)
+	remaining := parent.remainingBudget(day.Add(5 * time.Hour))
+	assert.Assert(t, resources.Equals(remaining, resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 2000})), "unexpected remaining budget %s", remaining)
+	assert.Assert(t, parent.exhaustedBudget(day.Add(5*time.Hour)) == nil, "budget should not be exhausted")
+	assert.Assert(t, resources.Equals(parent.exhaustedBudget(day.Add(6*time.Hour)), resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 0})), "vcore budget should be exhausted")
+	parent.accrue(day.Add(7 * time.Hour))
+	assert.Equal(t, parent.consumed["vcore"], float64(12000))
+	_, ok := parent.consumed["memory"]
+	assert.Assert(t, !ok, "only resources in the budget should be tracked")
+	// the next period only counts the usage since the start of the period
+	remaining = parent.remainingBudget(day.Add(25 * time.Hour))
+	assert.Assert(t, resources.Equals(remaining, resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 8000})), "unexpected remaining budget %s", remaining)
+
+	// an exhausted budget blocks the resource type in the headroom
+	now := time.Now()
+	parent.periodStart = now.Truncate(limitBudget.period)
+	parent.lastAccrued = now
+	parent.consumed = map[string]float64{"vcore": 10000}
+	headroom := root.headroom(hierarchy, user)
+	assert.Assert(t, resources.Equals(headroom, resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 0})), "unexpected headroom %s", headroom)
+	usageInfo := root.getResourceUsageDAOInfo().Children[0]
+	assert.DeepEqual(t, usageInfo.MaxResourceHours, map[string]int64{"vcore": 10000})
+	assert.DeepEqual(t, usageInfo.RemainingResourceHours, map[string]int64{"vcore": 0})
+	assert.Equal(t, usageInfo.BudgetPeriod, "24h0m0s")
+	assert.Equal(t, usageInfo.BudgetReset, parent.periodStart.Add(24*time.Hour).UnixNano())
+
+	// the tracker with a budget must be kept after the usage is removed
+	assert.Assert(t, !root.decreaseTrackedResource(hierarchy, TestApp1, usage, true), "tracker with budget should not be removed")
+	assert.Assert(t, root.childQueueTrackers["parent"] != nil, "tracker with budget should not be removed")
+	root.setLimit(hierarchy, nil, 0, nil, false, user, false)
+	assert.Assert(t, parent.budget == nil && parent.consumed == nil, "budget should be cleared")
+	assert.Assert(t, parent.canBeRemoved(), "tracker without budget should be removable")
+}
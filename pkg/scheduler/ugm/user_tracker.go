@@ -107,18 +107,18 @@ func (ut *UserTracker) getTrackedApplications() map[string]*GroupTracker {
 	return ut.appGroupTrackers
 }
 
-func (ut *UserTracker) setLimits(queuePath string, resource *resources.Resource, maxApps uint64, useWildCard bool, doWildCardCheck bool) {
+func (ut *UserTracker) setLimits(queuePath string, resource *resources.Resource, maxApps uint64, limitBudget *budget, useWildCard bool, doWildCardCheck bool) {
 	ut.Lock()
 	defer ut.Unlock()
 	ut.events.sendLimitSetForUser(ut.userName, queuePath)
-	ut.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), resource, maxApps, useWildCard, user, doWildCardCheck)
+	ut.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), resource, maxApps, limitBudget, useWildCard, user, doWildCardCheck)
 }
 
 func (ut *UserTracker) clearLimits(queuePath string, doWildCardCheck bool) {
 	ut.Lock()
 	defer ut.Unlock()
 	ut.events.sendLimitRemoveForUser(ut.userName, queuePath)
-	ut.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, 0, false, user, doWildCardCheck)
+	ut.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, 0, nil, false, user, doWildCardCheck)
 }
 
 // headroom calculate the resource headroom for the user in the hierarchy defined
@@ -130,6 +130,13 @@ func (ut *UserTracker) headroom(hierarchy []string) *resources.Resource {
 	return ut.queueTracker.headroom(hierarchy, user)
 }
 
+// addUsageTo adds the resource usage of the user in all queues to the target queue tracker.
+func (ut *UserTracker) addUsageTo(target *QueueTracker) {
+	ut.RLock()
+	defer ut.RUnlock()
+	ut.queueTracker.addUsageTo(target, queue)
+}
+
 // GetResourceUsageDAOInfo returns the DAO object used in the REST API for this user tracker
 func (ut *UserTracker) GetResourceUsageDAOInfo() *dao.UserResourceUsageDAOInfo {
 	ut.RLock()
@@ -200,8 +200,8 @@ func TestSetAndClearMaxLimits(t *testing.T) {
 	userTracker.increaseTrackedResource(path1, TestApp1, usage1)
 
 	eventSystem.Reset()
-	userTracker.setLimits(path1, resources.Multiply(usage1, 5), 5, false, false)
-	userTracker.setLimits(path5, resources.Multiply(usage1, 10), 10, false, false)
+	userTracker.setLimits(path1, resources.Multiply(usage1, 5), 5, nil, false, false)
+	userTracker.setLimits(path5, resources.Multiply(usage1, 10), 10, nil, false, false)
 	assert.Equal(t, 2, len(eventSystem.Events))
 	assert.Equal(t, si.EventRecord_UG_USER_LIMIT, eventSystem.Events[0].EventChangeDetail)
 	assert.Equal(t, si.EventRecord_SET, eventSystem.Events[0].EventChangeType)
@@ -227,8 +227,8 @@ func TestSetAndClearMaxLimits(t *testing.T) {
 	assert.Assert(t, userTracker.canRunApp(hierarchy1, TestApp4))
 
 	// lower limits
-	userTracker.setLimits(path1, usage1, 1, false, false)
-	userTracker.setLimits(path5, resources.Multiply(usage1, 2), 1, false, false)
+	userTracker.setLimits(path1, usage1, 1, nil, false, false)
+	userTracker.setLimits(path5, resources.Multiply(usage1, 2), 1, nil, false, false)
 	lowerChildHeadroom := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"mem":   -20000000,
 		"vcore": -20000,
@@ -282,7 +282,7 @@ func TestUTCanRunApp(t *testing.T) {
 	assert.Assert(t, userTracker.queueTracker.childQueueTrackers["parent"].useWildCard)
 
 	// maxApps limit hit
-	userTracker.setLimits(path1, nil, 1, false, false)
+	userTracker.setLimits(path1, nil, 1, nil, false, false)
 	userTracker.increaseTrackedResource(path1, TestApp1, resources.NewResourceFromMap(map[string]resources.Quantity{
 		"cpu": 1000,
 	}))
@@ -0,0 +1,455 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+const (
+	NotAuthenticated      = "Authentication required"
+	NotAuthorized         = "Access denied"
+	WriteNotAuthenticated = "Changes require REST authentication to be configured"
+
+	authRealm = "yunikorn"
+)
+
+// accessLevel defines who can call a route when authentication is configured
+type accessLevel int
+
+const (
+	accessUser   accessLevel = iota // any authenticated caller, results may be filtered on the caller's access
+	accessPublic                    // no authentication required
+	accessAdmin                     // cluster admins only
+)
+
+// routes that are not restricted to the debug base but expose the state of all tenants
+var adminPatterns = map[string]bool{
+	WSBase + "/stack":                             true,
+	WSBase + "/fullstatedump":                     true,
+	WSBase + "/events/batch":                      true,
+	WSBase + "/events/stream":                     true,
+	WSBase + "/config":                            true,
+	WSBase + "/config/dryrun":                     true,
+	WSBase + "/config/history":                    true,
+	WSBase + "/config/history/:version/rollback":  true,
+	WSBase + "/history/apps":                      true,
+	WSBase + "/history/containers":                true,
+	WSBase + "/partition/:partition/nodes":        true,
+	WSBase + "/partition/:partition/node/:node":   true,
+	WSBase + "/scheduler/node-utilization":        true,
+	WSBase + "/scheduler/node-utilizations":       true,
+	WSBase + "/partition/:partition/usage/queues": true,
+}
+
+var publicPatterns = map[string]bool{
+	WSBase + "/scheduler/healthcheck": true,
+}
+
+var restAuth atomic.Pointer[authConfig]
+
+type authContextKey struct{}
+
+// requestUser is the authenticated caller of a REST request
+type requestUser struct {
+	userGroup security.UserGroup
+	admin     bool
+}
+
+// authenticator retrieves the identity of the caller from a request.
+// The returned flag is false if the request does not carry the credentials handled by the authenticator.
+// An error is returned if credentials were presented but could not be verified.
+type authenticator interface {
+	authenticate(r *http.Request) (*security.UserGroup, bool, error)
+}
+
+// authConfig is the REST authentication setup as defined in the config map
+type authConfig struct {
+	authenticators []authenticator
+	adminACL       security.ACL
+	basic          bool
+}
+
+func init() {
+	configs.AddConfigMapCallback("rest-auth", func() {
+		log.Log(log.REST).Info("Reloading REST authentication settings")
+		restAuth.Store(newAuthConfig(configs.GetConfigMap()))
+	})
+}
+
+// newAuthConfig creates the authentication setup from the config map. Nil is returned if no authentication method
+// is configured: all routes are then open as before. A file that cannot be loaded is logged and rejects all
+// credentials of its type, authentication never falls back to open access.
+func newAuthConfig(configMap map[string]string) *authConfig {
+	config := &authConfig{}
+	if file := configMap[configs.CMRESTAuthTokenFile]; file != "" {
+		tokens, err := loadTokenFile(file)
+		if err != nil {
+			log.Log(log.REST).Error("Failed to load REST token file, token authentication rejects all tokens",
+				zap.String("file", file),
+				zap.Error(err))
+		}
+		config.authenticators = append(config.authenticators, tokens)
+	}
+	if file := configMap[configs.CMRESTAuthHtpasswdFile]; file != "" {
+		users, err := loadHtpasswdFile(file)
+		if err != nil {
+			log.Log(log.REST).Error("Failed to load REST htpasswd file, basic authentication rejects all users",
+				zap.String("file", file),
+				zap.Error(err))
+		}
+		config.authenticators = append(config.authenticators, users)
+		config.basic = true
+	}
+	if common.GetConfigurationBool(configMap, configs.CMRESTAuthClientCert, configs.DefaultRESTAuthClientCert) {
+		config.authenticators = append(config.authenticators, certAuthenticator{})
+	}
+	if len(config.authenticators) == 0 {
+		return nil
+	}
+	acl, err := security.NewACL(configMap[configs.CMRESTAuthAdminACL], false)
+	if err != nil {
+		log.Log(log.REST).Error("Failed to parse REST admin ACL, no cluster admins defined",
+			zap.String("acl", configMap[configs.CMRESTAuthAdminACL]),
+			zap.Error(err))
+	}
+	config.adminACL = acl
+	return config
+}
+
+// authenticate returns the identity of the caller using the first authenticator that finds credentials.
+func (c *authConfig) authenticate(r *http.Request) (*security.UserGroup, error) {
+	for _, auth := range c.authenticators {
+		ug, found, err := auth.authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return ug, nil
+		}
+	}
+	return nil, errors.New("no credentials provided")
+}
+
+// challenge sets the authentication scheme the client should use for a retry
+func (c *authConfig) challenge(w http.ResponseWriter) {
+	if c.basic {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", authRealm))
+		return
+	}
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q", authRealm))
+}
+
+// tokenAuthenticator checks bearer tokens against a static list.
+// Tokens are stored as a hash to not keep the secret in memory and to make the lookup independent of the content.
+type tokenAuthenticator struct {
+	tokens map[[sha256.Size]byte]security.UserGroup
+}
+
+// loadTokenFile reads a token file: each line has the form "token,user[,group...]".
+// Empty lines and lines starting with a # are skipped.
+func loadTokenFile(file string) (*tokenAuthenticator, error) {
+	auth := &tokenAuthenticator{tokens: make(map[[sha256.Size]byte]security.UserGroup)}
+	err := readAuthFile(file, func(line string) error {
+		fields := strings.Split(line, ",")
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			return fmt.Errorf("expected token,user[,group...] got %d fields", len(fields))
+		}
+		auth.tokens[sha256.Sum256([]byte(fields[0]))] = security.UserGroup{
+			User:   fields[1],
+			Groups: trimList(fields[2:]),
+		}
+		return nil
+	})
+	if err != nil {
+		auth.tokens = make(map[[sha256.Size]byte]security.UserGroup)
+	}
+	return auth, err
+}
+
+func (t *tokenAuthenticator) authenticate(r *http.Request) (*security.UserGroup, bool, error) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, false, nil
+	}
+	ug, ok := t.tokens[sha256.Sum256([]byte(strings.TrimSpace(token)))]
+	if !ok {
+		return nil, true, errors.New("invalid bearer token")
+	}
+	return &ug, true, nil
+}
+
+// htpasswdEntry is a user from the htpasswd file with the bcrypt hash of the password
+type htpasswdEntry struct {
+	hash   []byte
+	groups []string
+}
+
+// htpasswdAuthenticator checks HTTP basic credentials against a htpasswd file
+type htpasswdAuthenticator struct {
+	users map[string]htpasswdEntry
+}
+
+// loadHtpasswdFile reads a htpasswd file: each line has the form "user:hash[:group,...]".
+// Only bcrypt hashes are accepted, as generated by "htpasswd -B".
+func loadHtpasswdFile(file string) (*htpasswdAuthenticator, error) {
+	auth := &htpasswdAuthenticator{users: make(map[string]htpasswdEntry)}
+	err := readAuthFile(file, func(line string) error {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) < 2 || fields[0] == "" {
+			return fmt.Errorf("expected user:hash[:group,...]")
+		}
+		if _, err := bcrypt.Cost([]byte(fields[1])); err != nil {
+			return fmt.Errorf("user %s: password hash is not bcrypt: %w", fields[0], err)
+		}
+		entry := htpasswdEntry{hash: []byte(fields[1])}
+		if len(fields) == 3 {
+			entry.groups = trimList(strings.Split(fields[2], ","))
+		}
+		auth.users[fields[0]] = entry
+		return nil
+	})
+	if err != nil {
+		auth.users = make(map[string]htpasswdEntry)
+	}
+	return auth, err
+}
+
+func (h *htpasswdAuthenticator) authenticate(r *http.Request) (*security.UserGroup, bool, error) {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, false, nil
+	}
+	entry, ok := h.users[user]
+	if !ok {
+		return nil, true, errors.New("invalid user or password")
+	}
+	if err := bcrypt.CompareHashAndPassword(entry.hash, []byte(password)); err != nil {
+		return nil, true, errors.New("invalid user or password")
+	}
+	return &security.UserGroup{User: user, Groups: entry.groups}, true, nil
+}
+
+// certAuthenticator uses the verified client certificate of a TLS connection as the identity.
+// The common name is the user, the organisations are the groups.
+type certAuthenticator struct{}
+
+func (certAuthenticator) authenticate(r *http.Request) (*security.UserGroup, bool, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false, nil
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	if cert.Subject.CommonName == "" {
+		return nil, true, errors.New("client certificate has no common name")
+	}
+	return &security.UserGroup{
+		User:   cert.Subject.CommonName,
+		Groups: cert.Subject.Organization,
+	}, true, nil
+}
+
+// readAuthFile calls the parser for each line that is not empty or a comment
+func readAuthFile(file string, parse func(line string) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err = parse(line); err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func trimList(list []string) []string {
+	result := make([]string, 0, len(list))
+	for _, entry := range list {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// routeAccess returns the access level for the route: debug and state dump routes are for cluster admins only.
+func routeAccess(webRoute route) accessLevel {
+	switch {
+	case publicPatterns[webRoute.Pattern]:
+		return accessPublic
+	case strings.HasPrefix(webRoute.Pattern, DebugBase), adminPatterns[webRoute.Pattern]:
+		return accessAdmin
+	default:
+		return accessUser
+	}
+}
+
+// authHandler authenticates the caller and checks the access level of the route before calling the handler.
+// The identity of the caller is added to the request context for handlers that filter on access.
+func authHandler(inner http.Handler, access accessLevel) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		config := restAuth.Load()
+		if config == nil || access == accessPublic {
+			inner.ServeHTTP(w, r)
+			return
+		}
+		ug, err := config.authenticate(r)
+		if err != nil {
+			log.Log(log.REST).Info("REST request authentication failed",
+				zap.String("uri", r.RequestURI),
+				zap.String("remote", r.RemoteAddr),
+				zap.Error(err))
+			writeHeaders(w, r.Method)
+			config.challenge(w)
+			buildJSONErrorResponse(w, NotAuthenticated, http.StatusUnauthorized)
+			return
+		}
+		caller := &requestUser{
+			userGroup: *ug,
+			admin:     config.adminACL.CheckAccess(*ug),
+		}
+		if access == accessAdmin && !caller.admin {
+			log.Log(log.REST).Info("REST request denied, cluster admin access required",
+				zap.String("uri", r.RequestURI),
+				zap.String("user", ug.User))
+			writeHeaders(w, r.Method)
+			buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+			return
+		}
+		inner.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authContextKey{}, caller)))
+	}
+}
+
+// getRequestUser returns the authenticated caller, nil if authentication is not configured.
+func getRequestUser(r *http.Request) *requestUser {
+	caller, ok := r.Context().Value(authContextKey{}).(*requestUser)
+	if !ok {
+		return nil
+	}
+	return caller
+}
+
+// checkQueueAccess returns true if the caller has admin access to the queue or a parent of the queue.
+func checkQueueAccess(r *http.Request, queue *objects.Queue) bool {
+	caller := getRequestUser(r)
+	if caller == nil || caller.admin {
+		return true
+	}
+	return queue != nil && queue.CheckAdminAccess(caller.userGroup)
+}
+
+// checkParentQueueAccess returns true if the caller has admin access to the parent of the queue or a queue above it.
+// The root queue has no parent: only cluster admins pass the check.
+func checkParentQueueAccess(r *http.Request, queue *objects.Queue) bool {
+	caller := getRequestUser(r)
+	if caller == nil || caller.admin {
+		return true
+	}
+	parent := queue.GetParent()
+	return parent != nil && parent.CheckAdminAccess(caller.userGroup)
+}
+
+// checkApplicationAccess returns true if the caller has admin access to the queue of the application.
+// Applications that are not linked to a queue anymore, like rejected applications, use the queue path.
+func checkApplicationAccess(r *http.Request, partition *scheduler.PartitionContext, app *objects.Application) bool {
+	caller := getRequestUser(r)
+	if caller == nil || caller.admin {
+		return true
+	}
+	queue := app.GetQueue()
+	if queue == nil {
+		queue = partition.GetQueue(app.GetQueuePath())
+	}
+	return checkQueueAccess(r, queue)
+}
+
+// filterQueueAccess removes the queues the caller has no admin access to from the queue hierarchy. A queue the
+// caller cannot access is kept, without its details, if the caller has access to a queue below it.
+// Returns false if the caller has no access to the queue or any queue below it.
+func filterQueueAccess(r *http.Request, partition *scheduler.PartitionContext, queueInfo *dao.PartitionQueueDAOInfo) bool {
+	if checkQueueAccess(r, partition.GetQueue(queueInfo.QueueName)) {
+		return true
+	}
+	children := make([]dao.PartitionQueueDAOInfo, 0, len(queueInfo.Children))
+	childNames := make([]string, 0, len(queueInfo.Children))
+	for i := range queueInfo.Children {
+		if filterQueueAccess(r, partition, &queueInfo.Children[i]) {
+			children = append(children, queueInfo.Children[i])
+			childNames = append(childNames, queueInfo.Children[i].QueueName)
+		}
+	}
+	*queueInfo = dao.PartitionQueueDAOInfo{
+		QueueName:  queueInfo.QueueName,
+		Partition:  queueInfo.Partition,
+		Parent:     queueInfo.Parent,
+		IsLeaf:     queueInfo.IsLeaf,
+		IsManaged:  queueInfo.IsManaged,
+		Children:   children,
+		ChildNames: childNames,
+	}
+	return len(children) != 0
+}
+
+// checkUserAccess returns true if the caller can see the resource usage of the user
+func checkUserAccess(r *http.Request, user string) bool {
+	caller := getRequestUser(r)
+	return caller == nil || caller.admin || caller.userGroup.User == user
+}
+
+// checkGroupAccess returns true if the caller can see the resource usage of the group
+func checkGroupAccess(r *http.Request, group string) bool {
+	caller := getRequestUser(r)
+	if caller == nil || caller.admin {
+		return true
+	}
+	for _, g := range caller.userGroup.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
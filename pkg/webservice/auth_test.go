@@ -0,0 +1,294 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+const configQueueACLs = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        submitacl: "*"
+        queues:
+          - name: tenant-a
+            adminacl: "alice"
+          - name: tenant-b
+            adminacl: " team-b"
+`
+
+func writeAuthFile(t *testing.T, name, content string) string {
+	file := filepath.Join(t.TempDir(), name)
+	assert.NilError(t, os.WriteFile(file, []byte(content), 0o600), "failed to write auth file")
+	return file
+}
+
+func TestNewAuthConfig(t *testing.T) {
+	assert.Assert(t, newAuthConfig(map[string]string{}) == nil, "no authentication configured should return nil")
+	assert.Assert(t, newAuthConfig(map[string]string{configs.CMRESTAuthClientCert: "false"}) == nil, "client cert disabled should return nil")
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NilError(t, err, "failed to hash password")
+	tokenFile := writeAuthFile(t, "tokens", "# comment\n\ntoken-1,alice\ntoken-2,bob,team-b, other\n")
+	htpasswdFile := writeAuthFile(t, "htpasswd", "carol:"+string(hash)+":team-b\n")
+	config := newAuthConfig(map[string]string{
+		configs.CMRESTAuthTokenFile:    tokenFile,
+		configs.CMRESTAuthHtpasswdFile: htpasswdFile,
+		configs.CMRESTAuthClientCert:   "true",
+		configs.CMRESTAuthAdminACL:     "admin",
+	})
+	assert.Assert(t, config != nil, "authentication should be configured")
+	assert.Equal(t, len(config.authenticators), 3, "expected token, basic and cert authenticators")
+	assert.Assert(t, config.basic, "basic challenge expected")
+	assert.Assert(t, config.adminACL.CheckAccess(security.UserGroup{User: "admin"}), "admin should be cluster admin")
+
+	tests := map[string]struct {
+		setup  func(r *http.Request)
+		user   string
+		groups []string
+		fail   bool
+	}{
+		"no credentials":  {setup: func(r *http.Request) {}, fail: true},
+		"token":           {setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer token-1") }, user: "alice", groups: []string{}},
+		"token groups":    {setup: func(r *http.Request) { r.Header.Set("Authorization", "bearer token-2") }, user: "bob", groups: []string{"team-b", "other"}},
+		"unknown token":   {setup: func(r *http.Request) { r.Header.Set("Authorization", "Bearer token-3") }, fail: true},
+		"basic":           {setup: func(r *http.Request) { r.SetBasicAuth("carol", "secret") }, user: "carol", groups: []string{"team-b"}},
+		"basic bad pass":  {setup: func(r *http.Request) { r.SetBasicAuth("carol", "wrong") }, fail: true},
+		"basic bad user":  {setup: func(r *http.Request) { r.SetBasicAuth("dave", "secret") }, fail: true},
+		"cert":            {setup: func(r *http.Request) { r.TLS = verifiedTLS("erin", "team-b") }, user: "erin", groups: []string{"team-b"}},
+		"cert no subject": {setup: func(r *http.Request) { r.TLS = verifiedTLS("") }, fail: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ws/v1/clusters", nil)
+			tt.setup(req)
+			ug, err := config.authenticate(req)
+			if tt.fail {
+				assert.Assert(t, err != nil, "authentication should have failed")
+				return
+			}
+			assert.NilError(t, err, "authentication failed")
+			assert.Equal(t, ug.User, tt.user)
+			assert.DeepEqual(t, ug.Groups, tt.groups)
+		})
+	}
+}
+
+func TestNewAuthConfigLoadFailure(t *testing.T) {
+	// a broken file must not open up access: all credentials of that type are rejected
+	tokenFile := writeAuthFile(t, "tokens", "token-1,alice\nbroken\n")
+	htpasswdFile := writeAuthFile(t, "htpasswd", "carol:plaintext\n")
+	config := newAuthConfig(map[string]string{
+		configs.CMRESTAuthTokenFile:    tokenFile,
+		configs.CMRESTAuthHtpasswdFile: htpasswdFile,
+	})
+	assert.Assert(t, config != nil, "authentication should be configured")
+	req := httptest.NewRequest(http.MethodGet, "/ws/v1/clusters", nil)
+	req.Header.Set("Authorization", "Bearer token-1")
+	_, err := config.authenticate(req)
+	assert.ErrorContains(t, err, "invalid bearer token")
+
+	config = newAuthConfig(map[string]string{configs.CMRESTAuthTokenFile: filepath.Join(t.TempDir(), "missing")})
+	assert.Assert(t, config != nil, "authentication should be configured for a missing file")
+}
+
+func TestRouteAccess(t *testing.T) {
+	assert.Equal(t, routeAccess(route{Pattern: "/debug/pprof/"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/debug/fullstatedump"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/fullstatedump"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/events/stream"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/scheduler/healthcheck"}), accessPublic)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/validate-conf"}), accessUser)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/partition/:partition/applications/:state"}), accessUser)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/partition/:partition/nodes"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/history/apps"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/partition/:partition/usage/queues"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/config"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/config/history"}), accessAdmin)
+	assert.Equal(t, routeAccess(route{Pattern: "/ws/v1/scheduler/node-utilization"}), accessAdmin)
+}
+
+func TestAuthHandler(t *testing.T) {
+	defer restAuth.Store(nil)
+	tokenFile := writeAuthFile(t, "tokens", "admin-token,admin\nuser-token,alice\n")
+	var caller *requestUser
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller = getRequestUser(r)
+		w.WriteHeader(http.StatusOK)
+	})
+	call := func(access accessLevel, token string) int {
+		caller = nil
+		req := httptest.NewRequest(http.MethodGet, "/debug/stack", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		authHandler(inner, access)(resp, req)
+		return resp.Code
+	}
+
+	// nothing configured: everything passes without an identity
+	restAuth.Store(nil)
+	assert.Equal(t, call(accessAdmin, ""), http.StatusOK)
+	assert.Assert(t, caller == nil, "no caller expected without authentication")
+
+	restAuth.Store(newAuthConfig(map[string]string{
+		configs.CMRESTAuthTokenFile: tokenFile,
+		configs.CMRESTAuthAdminACL:  "admin",
+	}))
+	assert.Equal(t, call(accessPublic, ""), http.StatusOK)
+	assert.Equal(t, call(accessUser, ""), http.StatusUnauthorized)
+	assert.Equal(t, call(accessUser, "wrong"), http.StatusUnauthorized)
+	assert.Equal(t, call(accessUser, "user-token"), http.StatusOK)
+	assert.Equal(t, caller.userGroup.User, "alice")
+	assert.Assert(t, !caller.admin, "alice is not a cluster admin")
+	assert.Equal(t, call(accessAdmin, "user-token"), http.StatusForbidden)
+	assert.Equal(t, call(accessAdmin, "admin-token"), http.StatusOK)
+	assert.Assert(t, caller.admin, "admin is a cluster admin")
+
+	// challenge header is set on a failure
+	req := httptest.NewRequest(http.MethodGet, "/ws/v1/clusters", nil)
+	resp := httptest.NewRecorder()
+	authHandler(inner, accessUser)(resp, req)
+	assert.Equal(t, resp.Header().Get("WWW-Authenticate"), `Bearer realm="yunikorn"`)
+}
+
+func TestApplicationAccess(t *testing.T) {
+	part := setup(t, configQueueACLs, 1)
+	addAppWithUserGroup(t, "app-a", part, "root.tenant-a", false, security.UserGroup{})
+	addAppWithUserGroup(t, "app-b", part, "root.tenant-b", false, security.UserGroup{})
+
+	alice := &requestUser{userGroup: security.UserGroup{User: "alice"}}
+	bob := &requestUser{userGroup: security.UserGroup{User: "bob", Groups: []string{"team-b"}}}
+	admin := &requestUser{userGroup: security.UserGroup{User: "admin"}, admin: true}
+	withCaller := func(req *http.Request, caller *requestUser) *http.Request {
+		return req.WithContext(context.WithValue(req.Context(), authContextKey{}, caller))
+	}
+
+	// partition application listing is filtered on the queue admin ACL
+	for caller, expected := range map[*requestUser][]string{alice: {"app-a"}, bob: {"app-b"}, admin: {"app-a", "app-b"}} {
+		req, err := createRequest(t, "/ws/v1/partition/default/applications/active", map[string]string{"partition": partitionNameWithoutClusterID, "state": "active"})
+		assert.NilError(t, err)
+		resp := &MockResponseWriter{}
+		getPartitionApplicationsByState(resp, withCaller(req, caller))
+		var appsDao []*dao.ApplicationDAOInfo
+		assert.NilError(t, json.Unmarshal(resp.outputBytes, &appsDao), unmarshalError)
+		ids := make([]string, 0, len(appsDao))
+		for _, app := range appsDao {
+			ids = append(ids, app.ApplicationID)
+		}
+		sort.Strings(ids)
+		assert.Equal(t, strings.Join(ids, ","), strings.Join(expected, ","), "wrong apps for %s", caller.userGroup.User)
+	}
+
+	// direct access to an application or queue of another tenant is denied
+	req, err := createRequest(t, "/ws/v1/partition/default/application/app-b", map[string]string{"partition": partitionNameWithoutClusterID, "application": "app-b"})
+	assert.NilError(t, err)
+	resp := &MockResponseWriter{}
+	getApplication(resp, withCaller(req, alice))
+	assert.Equal(t, resp.statusCode, http.StatusForbidden)
+	resp = &MockResponseWriter{}
+	getApplication(resp, withCaller(req, bob))
+	assert.Equal(t, resp.statusCode, 0, "bob should see app-b")
+
+	req, err = createRequest(t, "/ws/v1/partition/default/queue/root.tenant-a/applications", map[string]string{"partition": partitionNameWithoutClusterID, "queue": "root.tenant-a"})
+	assert.NilError(t, err)
+	resp = &MockResponseWriter{}
+	getQueueApplications(resp, withCaller(req, bob))
+	assert.Equal(t, resp.statusCode, http.StatusForbidden)
+}
+
+func TestQueueAccess(t *testing.T) {
+	setup(t, configQueueACLs, 1)
+	alice := &requestUser{userGroup: security.UserGroup{User: "alice"}}
+	admin := &requestUser{userGroup: security.UserGroup{User: "admin"}, admin: true}
+	withCaller := func(req *http.Request, caller *requestUser) *http.Request {
+		return req.WithContext(context.WithValue(req.Context(), authContextKey{}, caller))
+	}
+
+	// the queue hierarchy only shows the details of the queues the caller has access to
+	req, err := createRequest(t, "/ws/v1/partition/default/queues", map[string]string{"partition": partitionNameWithoutClusterID})
+	assert.NilError(t, err)
+	resp := &MockResponseWriter{}
+	getPartitionQueues(resp, withCaller(req, alice))
+	var queuesDao dao.PartitionQueueDAOInfo
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &queuesDao), unmarshalError)
+	assert.Equal(t, queuesDao.QueueName, "root")
+	assert.Equal(t, queuesDao.Status, "", "root details should be hidden")
+	assert.DeepEqual(t, queuesDao.ChildNames, []string{"root.tenant-a"})
+	assert.Equal(t, len(queuesDao.Children), 1)
+	assert.Equal(t, queuesDao.Children[0].QueueName, "root.tenant-a")
+	assert.Equal(t, queuesDao.Children[0].Status, "Active")
+	resp = &MockResponseWriter{}
+	getPartitionQueues(resp, withCaller(req, admin))
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &queuesDao), unmarshalError)
+	assert.Equal(t, len(queuesDao.Children), 2)
+	assert.Equal(t, queuesDao.Status, "Active")
+
+	// direct access to a queue of another tenant is denied
+	req, err = createRequest(t, "/ws/v1/partition/default/queue/root.tenant-b", map[string]string{"partition": partitionNameWithoutClusterID, "queue": "root.tenant-b"})
+	assert.NilError(t, err)
+	resp = &MockResponseWriter{}
+	getPartitionQueue(resp, withCaller(req, alice))
+	assert.Equal(t, resp.statusCode, http.StatusForbidden)
+	req, err = createRequest(t, "/ws/v1/partition/default/queue/root.tenant-a", map[string]string{"partition": partitionNameWithoutClusterID, "queue": "root.tenant-a"})
+	assert.NilError(t, err)
+	resp = &MockResponseWriter{}
+	getPartitionQueue(resp, withCaller(req, alice))
+	assert.Equal(t, resp.statusCode, 0, "alice should see her own queue")
+}
+
+func TestUsageAccess(t *testing.T) {
+	alice := &requestUser{userGroup: security.UserGroup{User: "alice", Groups: []string{"team-a"}}}
+	req := httptest.NewRequest(http.MethodGet, "/ws/v1/partition/default/usage/users", nil)
+	assert.Assert(t, checkUserAccess(req, "bob"), "no caller should allow access")
+	assert.Assert(t, checkGroupAccess(req, "team-b"), "no caller should allow access")
+
+	req = req.WithContext(context.WithValue(req.Context(), authContextKey{}, alice))
+	assert.Assert(t, checkUserAccess(req, "alice"), "own usage should be allowed")
+	assert.Assert(t, !checkUserAccess(req, "bob"), "other user usage should be denied")
+	assert.Assert(t, checkGroupAccess(req, "team-a"), "own group usage should be allowed")
+	assert.Assert(t, !checkGroupAccess(req, "team-b"), "other group usage should be denied")
+}
+
+// verifiedTLS returns a connection state with a verified client certificate for the subject
+func verifiedTLS(commonName string, organisations ...string) *tls.ConnectionState {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName, Organization: organisations}}
+	return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+}
@@ -0,0 +1,34 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dao
+
+// AccountingRecordDAOInfo is the resource usage attributed to one application, queue, user and group in a
+// period. The resource usage is the sum of the quantity of each resource type multiplied by the seconds it was used.
+type AccountingRecordDAOInfo struct {
+	Period          string            `json:"period"` // hourly or daily
+	Start           int64             `json:"start"`  // start of the period as unix nanoseconds
+	End             int64             `json:"end"`    // end of the period as unix nanoseconds
+	Partition       string            `json:"partition"`
+	QueuePath       string            `json:"queuePath"`
+	ApplicationID   string            `json:"applicationID"`
+	User            string            `json:"user"`
+	Group           string            `json:"group,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	ResourceSeconds map[string]int64  `json:"resourceSeconds"`
+}
@@ -42,8 +42,16 @@ type ApplicationDAOInfo struct {
 	HasReserved        bool                    `json:"hasReserved,omitempty"`
 	Reservations       []string                `json:"reservations,omitempty"`
 	MaxRequestPriority int32                   `json:"maxRequestPriority,omitempty"`
+	AgedPriority       int32                   `json:"agedPriority,omitempty"` // priority used for scheduling: max request priority after aging
 	StartTime          int64                   `json:"startTime,omitempty"`
 	ResourceHistory    ResourceHistory         `json:"resourceHistory,omitempty"`
+	Deadline           *int64                  `json:"deadline,omitempty"`
+	Dependencies       []string                `json:"dependencies,omitempty"` // applications that must complete before the application is runnable
+}
+
+// ApplicationMoveDAOInfo is the leaf queue an application is moved to, in the same partition.
+type ApplicationMoveDAOInfo struct {
+	Queue string `json:"queue"` // no omitempty, queue must be set
 }
 
 type StateDAOInfo struct {
@@ -21,8 +21,9 @@ package dao
 import "github.com/apache/yunikorn-core/pkg/common/configs"
 
 type ValidateConfResponse struct {
-	Allowed bool   `json:"allowed"` // no omitempty, a false value gives a quick way to understand the result.
-	Reason  string `json:"reason,omitempty"`
+	Allowed  bool     `json:"allowed"` // no omitempty, a false value gives a quick way to understand the result.
+	Reason   string   `json:"reason,omitempty"`
+	Warnings []string `json:"warnings,omitempty"` // likely mistakes in a valid configuration
 }
 
 type ConfigDAOInfo struct {
@@ -31,3 +32,50 @@ type ConfigDAOInfo struct {
 	DeadlockDetectionEnabled bool
 	DeadlockTimeoutSeconds   int
 }
+
+type ConfigDryRunResponse struct {
+	Allowed    bool                      `json:"allowed"` // no omitempty, a false value gives a quick way to understand the result.
+	Reason     string                    `json:"reason,omitempty"`
+	Partitions []*PartitionDryRunDAOInfo `json:"partitions,omitempty"`
+}
+
+type PartitionDryRunDAOInfo struct {
+	PartitionName    string                    `json:"partitionName"` // no omitempty, partition name should not be empty
+	Added            bool                      `json:"added,omitempty"`
+	Removed          bool                      `json:"removed,omitempty"`
+	QueuesAdded      []string                  `json:"queuesAdded,omitempty"`
+	QueuesRemoved    []string                  `json:"queuesRemoved,omitempty"`
+	QueuesDrained    []*QueueDrainedDAOInfo    `json:"queuesDrained,omitempty"`
+	LimitsExceeded   []*LimitExceededDAOInfo   `json:"limitsExceeded,omitempty"`
+	PlacementChanges []*PlacementChangeDAOInfo `json:"placementChanges,omitempty"`
+}
+
+type QueueDrainedDAOInfo struct {
+	QueuePath    string   `json:"queuePath"` // no omitempty, queue path should not be empty
+	Applications []string `json:"applications,omitempty"`
+}
+
+type LimitExceededDAOInfo struct {
+	QueuePath           string           `json:"queuePath"` // no omitempty, queue path should not be empty
+	User                string           `json:"user,omitempty"`
+	Group               string           `json:"group,omitempty"`
+	MaxResources        map[string]int64 `json:"maxResources,omitempty"`
+	MaxApplications     uint64           `json:"maxApplications,omitempty"`
+	ResourceUsage       map[string]int64 `json:"resourceUsage,omitempty"`
+	RunningApplications uint64           `json:"runningApplications,omitempty"`
+}
+
+type PlacementChangeDAOInfo struct {
+	ApplicationID string `json:"applicationID"` // no omitempty, application id should not be empty
+	CurrentQueue  string `json:"currentQueue"`
+	NewQueue      string `json:"newQueue,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+type ConfigHistoryDAOInfo struct {
+	Version   uint64 `json:"version"`
+	Checksum  string `json:"checksum,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Diff      string `json:"diff,omitempty"`
+}
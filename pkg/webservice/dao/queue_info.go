@@ -23,6 +23,7 @@ type TemplateInfo struct {
 	MaxResource        map[string]int64  `json:"maxResource,omitempty"`
 	GuaranteedResource map[string]int64  `json:"guaranteedResource,omitempty"`
 	Properties         map[string]string `json:"properties,omitempty"`
+	MaxRuntime         string            `json:"maxRuntime,omitempty"`
 }
 
 type PartitionQueueDAOInfo struct {
@@ -54,4 +55,24 @@ type PartitionQueueDAOInfo struct {
 	PreemptionDelay        string                  `json:"preemptionDelay,omitempty"`
 	IsPriorityFence        bool                    `json:"isPriorityFence"` // no omitempty, a false value gives a quick way to understand whether it's fenced.
 	PriorityOffset         int32                   `json:"priorityOffset,omitempty"`
+	WeightedUsage          map[string]float64      `json:"weightedUsage,omitempty"`          // weighted share of the partition capacity per resource, only if the parent uses drf
+	DominantShare          float64                 `json:"dominantShare,omitempty"`          // largest weighted share, only if the parent uses drf
+	MaxRuntime             string                  `json:"maxRuntime,omitempty"`             // lowest max runtime of the queue and its parents
+	CapacityProfile        string                  `json:"capacityProfile,omitempty"`        // active capacity schedule that overrides the queue capacity
+	NextCapacityTransition int64                   `json:"nextCapacityTransition,omitempty"` // time the active capacity schedule changes next
+	NodePoolResource       map[string]int64        `json:"nodePoolResource,omitempty"`       // capacity of the nodes the queue is restricted to
+}
+
+// QueueUpdateDAOInfo is a runtime change to a managed queue. Fields that are not set are not changed.
+// An empty resource map removes the resource setting, a property with an empty value is removed.
+type QueueUpdateDAOInfo struct {
+	GuaranteedResource map[string]string `json:"guaranteedResource,omitempty"`
+	MaxResource        map[string]string `json:"maxResource,omitempty"`
+	MaxApplications    *uint64           `json:"maxApplications,omitempty"`
+	Properties         map[string]string `json:"properties,omitempty"`
+}
+
+// QueueStateDAOInfo is a runtime state change of a managed queue: Active, Draining or Stopped.
+type QueueStateDAOInfo struct {
+	State string `json:"state"` // no omitempty, state must be set
 }
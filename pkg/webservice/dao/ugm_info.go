@@ -31,10 +31,14 @@ type GroupResourceUsageDAOInfo struct {
 }
 
 type ResourceUsageDAOInfo struct {
-	QueuePath           string                  `json:"queuePath"` // no omitempty, queue path should not be empty
-	ResourceUsage       map[string]int64        `json:"resourceUsage,omitempty"`
-	RunningApplications []string                `json:"runningApplications,omitempty"`
-	MaxResources        map[string]int64        `json:"maxResources,omitempty"`
-	MaxApplications     uint64                  `json:"maxApplications,omitempty"`
-	Children            []*ResourceUsageDAOInfo `json:"children,omitempty"`
+	QueuePath              string                  `json:"queuePath"` // no omitempty, queue path should not be empty
+	ResourceUsage          map[string]int64        `json:"resourceUsage,omitempty"`
+	RunningApplications    []string                `json:"runningApplications,omitempty"`
+	MaxResources           map[string]int64        `json:"maxResources,omitempty"`
+	MaxApplications        uint64                  `json:"maxApplications,omitempty"`
+	Children               []*ResourceUsageDAOInfo `json:"children,omitempty"`
+	MaxResourceHours       map[string]int64        `json:"maxResourceHours,omitempty"`       // budget per period
+	RemainingResourceHours map[string]int64        `json:"remainingResourceHours,omitempty"` // budget left in the current period
+	BudgetPeriod           string                  `json:"budgetPeriod,omitempty"`
+	BudgetReset            int64                   `json:"budgetReset,omitempty"` // start of the next budget period
 }
@@ -47,21 +47,23 @@ import (
 	"github.com/apache/yunikorn-core/pkg/metrics/history"
 	"github.com/apache/yunikorn-core/pkg/plugins"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
 	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
 	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
 	"github.com/apache/yunikorn-core/pkg/webservice/dao"
 )
 
 const (
-	PartitionDoesNotExists   = "Partition not found"
-	MissingParamsName        = "Missing parameters"
-	QueueDoesNotExists       = "Queue not found"
-	InvalidUserName          = "Invalid user name"
-	InvalidGroupName         = "Invalid group name"
-	UserDoesNotExists        = "User not found"
-	GroupDoesNotExists       = "Group not found"
-	ApplicationDoesNotExists = "Application not found"
-	NodeDoesNotExists        = "Node not found"
+	PartitionDoesNotExists     = "Partition not found"
+	MissingParamsName          = "Missing parameters"
+	QueueDoesNotExists         = "Queue not found"
+	InvalidUserName            = "Invalid user name"
+	InvalidGroupName           = "Invalid group name"
+	UserDoesNotExists          = "User not found"
+	GroupDoesNotExists         = "Group not found"
+	ApplicationDoesNotExists   = "Application not found"
+	NodeDoesNotExists          = "Node not found"
+	ConfigVersionDoesNotExists = "Config version not found"
 
 	AppStateActive    = "active"
 	AppStateRejected  = "rejected"
@@ -76,6 +78,16 @@ var allowedAppActiveStatuses map[string]bool
 var streamingLimiter *StreamingLimiter
 var maxRESTResponseSize atomic.Uint64
 
+// shareProperties are the queue properties that change the share of a queue relative to its siblings
+var shareProperties = map[string]bool{
+	configs.PriorityOffset:        true,
+	configs.PriorityPolicy:        true,
+	configs.PriorityAgingInterval: true,
+	configs.PriorityAgingMax:      true,
+	configs.PriorityDeadlineBoost: true,
+	configs.QueueWeight:           true,
+}
+
 func init() {
 	allowedAppActiveStatuses = make(map[string]bool)
 
@@ -85,6 +97,7 @@ func init() {
 	allowedAppActiveStatuses[strings.ToLower(objects.Completing.String())] = true
 	allowedAppActiveStatuses[strings.ToLower(objects.Failing.String())] = true
 	allowedAppActiveStatuses[strings.ToLower(objects.Resuming.String())] = true
+	allowedAppActiveStatuses[strings.ToLower(objects.Suspended.String())] = true
 
 	var activeStatuses []string
 	for k := range allowedAppActiveStatuses {
@@ -169,10 +182,36 @@ func validateQueue(queuePath string) error {
 func validateConf(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	requestBytes, err := io.ReadAll(r.Body)
+	var conf *configs.SchedulerConfig
 	if err == nil {
-		_, err = configs.LoadSchedulerConfigFromByteArray(requestBytes)
+		conf, err = configs.LoadSchedulerConfigFromByteArray(requestBytes)
 	}
 	var result dao.ValidateConfResponse
+	if err != nil {
+		result.Allowed = false
+		result.Reason = err.Error()
+	} else {
+		result.Allowed = true
+		for _, warning := range configs.CheckWarnings(conf) {
+			result.Warnings = append(result.Warnings, warning.String())
+		}
+	}
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func dryRunConf(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	var result dao.ConfigDryRunResponse
+	requestBytes, err := io.ReadAll(r.Body)
+	if err == nil {
+		var conf *configs.SchedulerConfig
+		conf, err = configs.LoadSchedulerConfigFromByteArray(requestBytes)
+		if err == nil {
+			result.Partitions, err = schedulerContext.Load().DryRunConfig(conf)
+		}
+	}
 	if err != nil {
 		result.Allowed = false
 		result.Reason = err.Error()
@@ -188,11 +227,14 @@ func writeHeaders(w http.ResponseWriter, method string) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	methods := "GET, OPTIONS"
-	if method == http.MethodPost {
+	switch method {
+	case http.MethodPost:
 		methods = "OPTIONS, POST"
+	case http.MethodPut:
+		methods = "OPTIONS, PUT"
 	}
 	w.Header().Set("Access-Control-Allow-Methods", methods)
-	w.Header().Set("Access-Control-Allow-Headers", "X-Requested-With,Content-Type,Accept,Origin")
+	w.Header().Set("Access-Control-Allow-Headers", "X-Requested-With,Content-Type,Accept,Origin,Authorization")
 }
 
 func buildJSONErrorResponse(w http.ResponseWriter, detail string, code int) {
@@ -372,8 +414,11 @@ func getApplicationDAO(app *objects.Application) *dao.ApplicationDAOInfo {
 		HasReserved:        app.HasReserved(),
 		Reservations:       app.GetReservations(),
 		MaxRequestPriority: app.GetAskMaxPriority(),
+		AgedPriority:       app.GetSchedulingPriority(),
 		StartTime:          app.StartTime().UnixMilli(),
 		ResourceHistory:    resHistory,
+		Deadline:           common.ZeroTimeInUnixNano(app.GetDeadline()),
+		Dependencies:       app.GetDependencies(),
 	}
 }
 
@@ -660,6 +705,57 @@ func getClusterConfigDAO() *dao.ConfigDAOInfo {
 	return &conf
 }
 
+func getConfigHistory(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	history := configs.ConfigContext.GetHistory(schedulerContext.Load().GetPolicyGroup())
+	result := make([]*dao.ConfigHistoryDAOInfo, 0, len(history))
+	// latest version first
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		result = append(result, &dao.ConfigHistoryDAOInfo{
+			Version:   entry.Version,
+			Checksum:  entry.Checksum,
+			Timestamp: entry.Timestamp.UnixNano(),
+			Source:    entry.Source,
+			Diff:      entry.Diff,
+		})
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func rollbackConfig(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	vars := httprouter.ParamsFromContext(r.Context())
+	if vars == nil {
+		buildJSONErrorResponse(w, MissingParamsName, http.StatusBadRequest)
+		return
+	}
+	caller := getRequestUser(r)
+	if caller == nil {
+		buildJSONErrorResponse(w, WriteNotAuthenticated, http.StatusForbidden)
+		return
+	}
+	version, err := strconv.ParseUint(vars.ByName("version"), 10, 64)
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if configs.ConfigContext.GetVersion(schedulerContext.Load().GetPolicyGroup(), version) == nil {
+		buildJSONErrorResponse(w, ConfigVersionDoesNotExists, http.StatusNotFound)
+		return
+	}
+	if err = schedulerContext.Load().RollbackConfig(version); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Log(log.REST).Info("scheduler config rolled back",
+		zap.Uint64("version", version),
+		zap.String("user", caller.userGroup.User))
+	getConfigHistory(w, r)
+}
+
 func checkHealthStatus(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 
@@ -709,6 +805,7 @@ func getPartitionQueues(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
 		return
 	}
+	filterQueueAccess(r, partition, &partitionQueuesDAOInfo)
 	if err := json.NewEncoder(w).Encode(partitionQueuesDAOInfo); err != nil {
 		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
 	}
@@ -743,12 +840,137 @@ func getPartitionQueue(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, QueueDoesNotExists, http.StatusNotFound)
 		return
 	}
+	// access to the queue includes access to the queues below it
+	if !checkQueueAccess(r, queue) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
 	queueDao := queue.GetPartitionQueueDAOInfo(r.URL.Query().Has("subtree"))
 	if err := json.NewEncoder(w).Encode(queueDao); err != nil {
 		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+// getQueueForUpdate returns the queue for a runtime change, the caller must be authenticated and have admin access
+// to the queue. If nil is returned the error response has been written.
+func getQueueForUpdate(w http.ResponseWriter, r *http.Request) (*scheduler.PartitionContext, *objects.Queue) {
+	vars := httprouter.ParamsFromContext(r.Context())
+	if vars == nil {
+		buildJSONErrorResponse(w, MissingParamsName, http.StatusBadRequest)
+		return nil, nil
+	}
+	if getRequestUser(r) == nil {
+		buildJSONErrorResponse(w, WriteNotAuthenticated, http.StatusForbidden)
+		return nil, nil
+	}
+	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(vars.ByName("partition"))
+	if partitionContext == nil {
+		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
+		return nil, nil
+	}
+	unescapedQueueName, err := url.QueryUnescape(vars.ByName("queue"))
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return nil, nil
+	}
+	if err = validateQueue(unescapedQueueName); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return nil, nil
+	}
+	queue := partitionContext.GetQueue(unescapedQueueName)
+	if queue == nil {
+		buildJSONErrorResponse(w, QueueDoesNotExists, http.StatusNotFound)
+		return nil, nil
+	}
+	if !checkQueueAccess(r, queue) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return nil, nil
+	}
+	return partitionContext, queue
+}
+
+func updatePartitionQueue(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	partitionContext, queue := getQueueForUpdate(w, r)
+	if queue == nil {
+		return
+	}
+	var update dao.QueueUpdateDAOInfo
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// the admin of a queue cannot raise the share of the queue: resources, limits and priority are set by the
+	// admin of the parent queue
+	if isShareUpdate(&update) && !checkParentQueueAccess(r, queue) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
+	if err := schedulerContext.Load().UpdateQueue(partitionContext.Name, queue.QueuePath, &update); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Log(log.REST).Info("queue updated",
+		zap.String("queue", queue.QueuePath),
+		zap.String("user", getRequestUser(r).userGroup.User))
+	if err := json.NewEncoder(w).Encode(queue.GetPartitionQueueDAOInfo(false)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// isShareUpdate returns true if the update changes the resources, limits or priority of the queue.
+func isShareUpdate(update *dao.QueueUpdateDAOInfo) bool {
+	if update.GuaranteedResource != nil || update.MaxResource != nil || update.MaxApplications != nil {
+		return true
+	}
+	for key := range update.Properties {
+		if shareProperties[key] {
+			return true
+		}
+	}
+	return false
+}
+
+func updatePartitionQueueState(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	_, queue := getQueueForUpdate(w, r)
+	if queue == nil {
+		return
+	}
+	var stateInfo dao.QueueStateDAOInfo
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&stateInfo); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var state objects.ObjectState
+	switch strings.ToLower(stateInfo.State) {
+	case strings.ToLower(objects.Active.String()):
+		state = objects.Active
+	case strings.ToLower(objects.Draining.String()):
+		state = objects.Draining
+	case strings.ToLower(objects.Stopped.String()):
+		state = objects.Stopped
+	default:
+		buildJSONErrorResponse(w, fmt.Sprintf("Only following queue states are allowed: %s, %s, %s", objects.Active, objects.Draining, objects.Stopped), http.StatusBadRequest)
+		return
+	}
+	if err := queue.ChangeState(state); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusConflict)
+		return
+	}
+	log.Log(log.REST).Info("queue state changed",
+		zap.String("queue", queue.QueuePath),
+		zap.Stringer("state", state),
+		zap.String("user", getRequestUser(r).userGroup.User))
+	if err := json.NewEncoder(w).Encode(queue.GetPartitionQueueDAOInfo(false)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func getPartitionNodes(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	vars := httprouter.ParamsFromContext(r.Context())
@@ -822,6 +1044,10 @@ func getQueueApplications(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, QueueDoesNotExists, http.StatusNotFound)
 		return
 	}
+	if !checkQueueAccess(r, queue) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
 
 	appsDao := make([]*dao.ApplicationDAOInfo, 0)
 	for _, app := range queue.GetCopyOfApps() {
@@ -874,7 +1100,9 @@ func getPartitionApplicationsByState(w http.ResponseWriter, r *http.Request) {
 	}
 	appsDao := make([]*dao.ApplicationDAOInfo, 0, len(appList))
 	for _, app := range appList {
-		appsDao = append(appsDao, getApplicationDAO(app))
+		if checkApplicationAccess(r, partitionContext, app) {
+			appsDao = append(appsDao, getApplicationDAO(app))
+		}
 	}
 	if err := json.NewEncoder(w).Encode(appsDao); err != nil {
 		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -921,6 +1149,10 @@ func getApplication(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, ApplicationDoesNotExists, http.StatusNotFound)
 		return
 	}
+	if !checkApplicationAccess(r, partitionContext, app) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
 
 	appDao := getApplicationDAO(app)
 	if err := json.NewEncoder(w).Encode(appDao); err != nil {
@@ -928,6 +1160,121 @@ func getApplication(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func moveApplication(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	vars := httprouter.ParamsFromContext(r.Context())
+	if vars == nil {
+		buildJSONErrorResponse(w, MissingParamsName, http.StatusBadRequest)
+		return
+	}
+	if getRequestUser(r) == nil {
+		buildJSONErrorResponse(w, WriteNotAuthenticated, http.StatusForbidden)
+		return
+	}
+	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(vars.ByName("partition"))
+	if partitionContext == nil {
+		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
+		return
+	}
+	app := partitionContext.GetApplication(vars.ByName("application"))
+	if app == nil {
+		buildJSONErrorResponse(w, ApplicationDoesNotExists, http.StatusNotFound)
+		return
+	}
+	var move dao.ApplicationMoveDAOInfo
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&move); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if move.Queue == "" {
+		buildJSONErrorResponse(w, MissingParamsName, http.StatusBadRequest)
+		return
+	}
+	if err := validateQueue(move.Queue); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	queue := partitionContext.GetQueue(move.Queue)
+	if queue == nil {
+		buildJSONErrorResponse(w, QueueDoesNotExists, http.StatusNotFound)
+		return
+	}
+	// the caller must be an admin of the current and the new queue
+	if !checkApplicationAccess(r, partitionContext, app) || !checkQueueAccess(r, queue) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
+	fromQueue := app.GetQueuePath()
+	if err := schedulerContext.Load().MoveApplication(partitionContext.Name, app.ApplicationID, queue.QueuePath); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Log(log.REST).Info("application moved",
+		zap.String("application", app.ApplicationID),
+		zap.String("fromQueue", fromQueue),
+		zap.String("toQueue", queue.QueuePath),
+		zap.String("user", getRequestUser(r).userGroup.User))
+	if err := json.NewEncoder(w).Encode(getApplicationDAO(app)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func suspendApplication(w http.ResponseWriter, r *http.Request) {
+	setApplicationSuspended(w, r, true)
+}
+
+func resumeApplication(w http.ResponseWriter, r *http.Request) {
+	setApplicationSuspended(w, r, false)
+}
+
+// setApplicationSuspended suspends or resumes the application referenced in the request.
+func setApplicationSuspended(w http.ResponseWriter, r *http.Request, suspend bool) {
+	writeHeaders(w, r.Method)
+	vars := httprouter.ParamsFromContext(r.Context())
+	if vars == nil {
+		buildJSONErrorResponse(w, MissingParamsName, http.StatusBadRequest)
+		return
+	}
+	if getRequestUser(r) == nil {
+		buildJSONErrorResponse(w, WriteNotAuthenticated, http.StatusForbidden)
+		return
+	}
+	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(vars.ByName("partition"))
+	if partitionContext == nil {
+		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
+		return
+	}
+	app := partitionContext.GetApplication(vars.ByName("application"))
+	if app == nil {
+		buildJSONErrorResponse(w, ApplicationDoesNotExists, http.StatusNotFound)
+		return
+	}
+	if !checkApplicationAccess(r, partitionContext, app) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
+	var err error
+	action := "application suspended"
+	if suspend {
+		err = schedulerContext.Load().SuspendApplication(partitionContext.Name, app.ApplicationID)
+	} else {
+		err = schedulerContext.Load().ResumeApplication(partitionContext.Name, app.ApplicationID)
+		action = "application resumed"
+	}
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Log(log.REST).Info(action,
+		zap.String("application", app.ApplicationID),
+		zap.String("user", getRequestUser(r).userGroup.User))
+	if err = json.NewEncoder(w).Encode(getApplicationDAO(app)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func getPartitionRules(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	vars := httprouter.ParamsFromContext(r.Context())
@@ -988,6 +1335,10 @@ func getQueueApplicationsByState(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, allowedActiveStatusMsg, http.StatusBadRequest)
 		return
 	}
+	if !checkQueueAccess(r, queue) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
 
 	appsDao := make([]*dao.ApplicationDAOInfo, 0)
 	for _, app := range queue.GetCopyOfApps() {
@@ -1188,15 +1539,27 @@ func getUsersResourceUsage(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	userManager := ugm.GetUserManager()
 	trackers := userManager.GetUserTrackers()
-	result := make([]*dao.UserResourceUsageDAOInfo, len(trackers))
-	for i, tracker := range trackers {
-		result[i] = tracker.GetResourceUsageDAOInfo()
+	result := make([]*dao.UserResourceUsageDAOInfo, 0, len(trackers))
+	for _, tracker := range trackers {
+		usage := tracker.GetResourceUsageDAOInfo()
+		if checkUserAccess(r, usage.UserName) {
+			result = append(result, usage)
+		}
 	}
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+// getQueuesResourceUsage returns the usage of all applications per queue, with the budgets set on the queues
+func getQueuesResourceUsage(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	result := ugm.GetUserManager().GetQueuesResourceUsageDAOInfo()
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func getUserResourceUsage(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	vars := httprouter.ParamsFromContext(r.Context())
@@ -1214,6 +1577,10 @@ func getUserResourceUsage(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, InvalidUserName, http.StatusBadRequest)
 		return
 	}
+	if !checkUserAccess(r, unescapedUser) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
 	userTracker := ugm.GetUserManager().GetUserTracker(unescapedUser)
 	if userTracker == nil {
 		buildJSONErrorResponse(w, UserDoesNotExists, http.StatusNotFound)
@@ -1229,9 +1596,12 @@ func getGroupsResourceUsage(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	userManager := ugm.GetUserManager()
 	trackers := userManager.GetGroupTrackers()
-	result := make([]*dao.GroupResourceUsageDAOInfo, len(trackers))
-	for i, tracker := range trackers {
-		result[i] = tracker.GetResourceUsageDAOInfo()
+	result := make([]*dao.GroupResourceUsageDAOInfo, 0, len(trackers))
+	for _, tracker := range trackers {
+		usage := tracker.GetResourceUsageDAOInfo()
+		if checkGroupAccess(r, usage.GroupName) {
+			result = append(result, usage)
+		}
 	}
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
@@ -1255,6 +1625,10 @@ func getGroupResourceUsage(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, InvalidGroupName, http.StatusBadRequest)
 		return
 	}
+	if !checkGroupAccess(r, unescapedGroupName) {
+		buildJSONErrorResponse(w, NotAuthorized, http.StatusForbidden)
+		return
+	}
 	groupTracker := ugm.GetUserManager().GetGroupTracker(unescapedGroupName)
 	if groupTracker == nil {
 		buildJSONErrorResponse(w, GroupDoesNotExists, http.StatusNotFound)
@@ -1266,6 +1640,59 @@ func getGroupResourceUsage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getAccountingRecords returns the accounting records that start in the time range. The range defaults to the last day
+// and the period to hourly. Callers only get the records of their own user or groups unless they are an admin.
+func getAccountingRecords(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	query := r.URL.Query()
+	end := time.Now()
+	if endStr := query.Get("end"); endStr != "" {
+		var err error
+		if end, err = parseAccountingTime(endStr); err != nil {
+			buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	start := end.Add(-24 * time.Hour)
+	if startStr := query.Get("start"); startStr != "" {
+		var err error
+		if start, err = parseAccountingTime(startStr); err != nil {
+			buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if !start.Before(end) {
+		buildJSONErrorResponse(w, `"start" must be before "end"`, http.StatusBadRequest)
+		return
+	}
+	period := accounting.Hourly
+	if periodStr := query.Get("period"); periodStr != "" {
+		period = strings.ToLower(periodStr)
+	}
+	records, err := accounting.GetTracker().GetRecords(period, start, end)
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result := make([]*dao.AccountingRecordDAOInfo, 0, len(records))
+	for _, record := range records {
+		if checkUserAccess(r, record.User) || (record.Group != "" && checkGroupAccess(r, record.Group)) {
+			result = append(result, record)
+		}
+	}
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseAccountingTime parses a time as unix nanoseconds or in RFC 3339 format.
+func parseAccountingTime(value string) (time.Time, error) {
+	if nanos, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(0, nanos), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 func getEvents(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	eventSystem := events.GetEventSystem()
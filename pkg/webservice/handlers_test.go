@@ -27,6 +27,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -43,6 +44,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/metrics/history"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
 	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
 	"github.com/apache/yunikorn-core/pkg/scheduler/placement/types"
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
@@ -103,6 +105,17 @@ partitions:
       - name: root
 `
 
+const warningConf = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        submitacl: "*"
+        queues:
+          - name: default
+            submitacl: "user1"
+`
+
 const configDefault = `
 partitions:
   - name: default
@@ -361,6 +374,13 @@ func TestValidateConf(t *testing.T) {
 				Reason:  "undefined policy: invalid",
 			},
 		},
+		{
+			content: warningConf,
+			expectedResponse: dao.ValidateConfResponse{
+				Allowed:  true,
+				Warnings: []string{"partition default, queue root.default: submit ACL is shadowed by the submit ACL of a parent queue: all entries"},
+			},
+		},
 	}
 	for _, test := range confTests {
 		// No err check: new request always returns correctly
@@ -373,7 +393,78 @@ func TestValidateConf(t *testing.T) {
 		assert.NilError(t, err, unmarshalError)
 		assert.Equal(t, vcr.Allowed, test.expectedResponse.Allowed, "allowed flag incorrect")
 		assert.Equal(t, vcr.Reason, test.expectedResponse.Reason, "response text not as expected")
+		assert.DeepEqual(t, vcr.Warnings, test.expectedResponse.Warnings)
+	}
+}
+
+func TestDryRunConf(t *testing.T) {
+	setup(t, configDefault, 1)
+	// No err check: new request always returns correctly
+	//nolint: errcheck
+	req, _ := http.NewRequest("POST", "", strings.NewReader(invalidConf))
+	resp := &MockResponseWriter{}
+	dryRunConf(resp, req)
+	var result dao.ConfigDryRunResponse
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &result), unmarshalError)
+	assert.Assert(t, !result.Allowed, "invalid config should not be allowed")
+	assert.Equal(t, result.Reason, "undefined policy: invalid", "response text not as expected")
+
+	//nolint: errcheck
+	req, _ = http.NewRequest("POST", "", strings.NewReader(baseConf))
+	resp = &MockResponseWriter{}
+	dryRunConf(resp, req)
+	result = dao.ConfigDryRunResponse{}
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &result), unmarshalError)
+	assert.Assert(t, result.Allowed, "valid config should be allowed: %s", result.Reason)
+	assert.Equal(t, len(result.Partitions), 1, "unexpected partitions in result")
+	assert.Equal(t, result.Partitions[0].PartitionName, "default")
+}
+
+func TestConfigHistoryAndRollback(t *testing.T) {
+	part := setup(t, configDefault, 1)
+	getHistory := func() []*dao.ConfigHistoryDAOInfo {
+		req, err := createRequest(t, "/ws/v1/config/history", map[string]string{})
+		assert.NilError(t, err)
+		resp := &MockResponseWriter{}
+		getConfigHistory(resp, req)
+		var history []*dao.ConfigHistoryDAOInfo
+		assert.NilError(t, json.Unmarshal(resp.outputBytes, &history), unmarshalError)
+		return history
+	}
+	history := getHistory()
+	assert.Assert(t, len(history) > 0, "config history should not be empty")
+	current := history[0]
+	assert.Equal(t, current.Checksum, configs.ConfigContext.Get(policyGroup).Checksum, "latest version should be first")
+	assert.NilError(t, schedulerContext.Load().UpdateRMSchedulerConfig(rmID, []byte(baseConf), rmID), "config update failed")
+	history = getHistory()
+	assert.Equal(t, history[0].Version, current.Version+1, "update should add a version")
+	assert.Assert(t, history[0].Diff != "", "update should have a diff")
+
+	rollback := func(version string, caller *requestUser) *MockResponseWriter {
+		req, err := createRequest(t, "/ws/v1/config/history/"+version+"/rollback", map[string]string{"version": version})
+		assert.NilError(t, err)
+		if caller != nil {
+			req = req.WithContext(context.WithValue(req.Context(), authContextKey{}, caller))
+		}
+		resp := &MockResponseWriter{}
+		rollbackConfig(resp, req)
+		return resp
 	}
+	admin := &requestUser{userGroup: security.UserGroup{User: "admin"}, admin: true}
+	version := strconv.FormatUint(current.Version, 10)
+	resp := rollback(version, nil)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = rollback("x", admin)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = rollback("100000", admin)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+	resp = rollback(version, admin)
+	assert.Equal(t, resp.statusCode, 0, "rollback failed: %s", string(resp.outputBytes))
+	var history2 []*dao.ConfigHistoryDAOInfo
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &history2), unmarshalError)
+	assert.Equal(t, history2[0].Source, "rollback to version "+version)
+	assert.Equal(t, history2[0].Checksum, current.Checksum)
+	assert.Assert(t, part.GetQueue("root.noapps").IsRunning(), "queue from the earlier config should be running")
 }
 
 func TestUserGroupLimits(t *testing.T) {
@@ -548,7 +639,7 @@ func TestGetConfigYAML(t *testing.T) {
 	assert.Assert(t, len(startConfSum) > 0, "checksum boundary not found")
 
 	// change the config
-	err = schedulerContext.Load().UpdateRMSchedulerConfig(rmID, []byte(updatedConf))
+	err = schedulerContext.Load().UpdateRMSchedulerConfig(rmID, []byte(updatedConf), rmID)
 	assert.NilError(t, err, "Error when updating clusterInfo from config")
 	configs.SetConfigMap(updatedExtraConf)
 
@@ -582,7 +673,7 @@ func TestGetConfigJSON(t *testing.T) {
 	assert.Equal(t, conf.Partitions[0].NodeSortPolicy.Type, "fair", "node sort policy set incorrectly, not fair (json)")
 
 	// change the config
-	err = schedulerContext.Load().UpdateRMSchedulerConfig(rmID, []byte(updatedConf))
+	err = schedulerContext.Load().UpdateRMSchedulerConfig(rmID, []byte(updatedConf), rmID)
 	assert.NilError(t, err, "Error when updating clusterInfo from config")
 	configs.SetConfigMap(updatedExtraConf)
 
@@ -2298,6 +2389,19 @@ func TestUsersAndGroupsResourceUsage(t *testing.T) {
 	assert.Equal(t, len(groupsResourceUsageDao), 1)
 	assert.Equal(t, groupsResourceUsageDao[0].GroupName, "testgroup")
 
+	req, err = http.NewRequest("GET", "/ws/v1/partition/default/usage/queues", strings.NewReader(""))
+	assert.NilError(t, err, "Get Queues Resource Usage Handler request failed")
+	var queuesResourceUsageDao *dao.ResourceUsageDAOInfo
+	resp = &MockResponseWriter{}
+	getQueuesResourceUsage(resp, req)
+	err = json.Unmarshal(resp.outputBytes, &queuesResourceUsageDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, queuesResourceUsageDao.QueuePath, "root")
+	assert.DeepEqual(t, queuesResourceUsageDao.ResourceUsage,
+		resources.NewResourceFromMap(map[string]resources.Quantity{siCommon.CPU: 1}).DAOMap())
+	assert.Equal(t, len(queuesResourceUsageDao.Children), 1)
+	assert.Equal(t, queuesResourceUsageDao.Children[0].QueuePath, "root.default")
+
 	// test empty user group
 	prepareEmptyUserGroupContext()
 
@@ -2824,6 +2928,7 @@ func clearUserManager() {
 	userManager := ugm.GetUserManager()
 	userManager.ClearUserTrackers()
 	userManager.ClearGroupTrackers()
+	userManager.ClearQueueBudgetTracker()
 }
 
 func verifyStateDumpJSON(t *testing.T, aggregated *AggregatedStateInfo, partitionCount int) {
@@ -2937,7 +3042,7 @@ func TestGetPartitionRuleHandler(t *testing.T) {
 	assert.Equal(t, partitionRules[1].Name, types.Recovery)
 
 	// change the config: 3 rules, expect recovery also
-	err = schedulerContext.Load().UpdateRMSchedulerConfig(rmID, []byte(placementRuleConfig))
+	err = schedulerContext.Load().UpdateRMSchedulerConfig(rmID, []byte(placementRuleConfig), rmID)
 	assert.NilError(t, err, "Error when updating clusterInfo from config")
 	req, err = createRequest(t, "/ws/v1/partition/default/placementrules", map[string]string{"partition": partitionNameWithoutClusterID})
 	assert.NilError(t, err, httpRequestError)
@@ -3057,3 +3162,220 @@ func newForeignAlloc(allocationKey string, nodeID string, resAlloc *resources.Re
 		Priority: priority,
 	})
 }
+
+func TestUpdatePartitionQueue(t *testing.T) {
+	setup(t, configQueueACLs, 1)
+	alice := &requestUser{userGroup: security.UserGroup{User: "alice"}}
+	update := func(handler http.HandlerFunc, queue, body string, caller *requestUser) *MockResponseWriter {
+		req, err := http.NewRequest("PUT", "/ws/v1/partition/default/queue/"+queue, strings.NewReader(body))
+		assert.NilError(t, err, "HTTP request create failed")
+		ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+			httprouter.Param{Key: "partition", Value: partitionNameWithoutClusterID},
+			httprouter.Param{Key: "queue", Value: queue},
+		})
+		if caller != nil {
+			ctx = context.WithValue(ctx, authContextKey{}, caller)
+		}
+		resp := &MockResponseWriter{}
+		handler(resp, req.WithContext(ctx))
+		return resp
+	}
+
+	// changes need an authenticated caller with admin access to the queue
+	resp := update(updatePartitionQueue, "root.tenant-a", `{"maxApplications": 5}`, nil)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = update(updatePartitionQueue, "root.tenant-b", `{"maxApplications": 5}`, alice)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = update(updatePartitionQueue, "root.unknown", `{"maxApplications": 5}`, alice)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+	resp = update(updatePartitionQueue, "root.tenant-a", `{"unknown": 5}`, alice)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = update(updatePartitionQueue, "root.tenant-a", `{"properties": {"application.sort.policy": "random"}}`, alice)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+
+	// the admin of the queue cannot change its resources, limits or priority, the admin of the parent can
+	for _, body := range []string{`{"maxApplications": 5}`, `{"guaranteedResource": {"memory": "100"}}`, `{"maxResource": {"memory": "100"}}`, `{"properties": {"priority.offset": "100"}}`} {
+		resp = update(updatePartitionQueue, "root.tenant-a", body, alice)
+		assert.Equal(t, resp.statusCode, http.StatusForbidden, "update should be denied: %s", body)
+	}
+	resp = update(updatePartitionQueue, "root.tenant-a", `{"properties": {"preemption.policy": "disabled"}}`, alice)
+	assert.Equal(t, resp.statusCode, 0, "update should succeed: %s", string(resp.outputBytes))
+	var queueDao dao.PartitionQueueDAOInfo
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &queueDao), unmarshalError)
+	assert.Equal(t, queueDao.Properties[configs.PreemptionPolicy], "disabled")
+	admin := &requestUser{userGroup: security.UserGroup{User: "admin"}, admin: true}
+	resp = update(updatePartitionQueue, "root.tenant-a", `{"maxApplications": 5, "maxResource": {"memory": "100"}}`, admin)
+	assert.Equal(t, resp.statusCode, 0, "update should succeed: %s", string(resp.outputBytes))
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &queueDao), unmarshalError)
+	assert.Equal(t, queueDao.MaxRunningApps, uint64(5))
+	assert.Equal(t, queueDao.Properties[configs.PreemptionPolicy], "disabled")
+	assert.DeepEqual(t, queueDao.MaxResource, map[string]int64{"memory": 100})
+
+	resp = update(updatePartitionQueueState, "root.tenant-a", `{"state": "unknown"}`, alice)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = update(updatePartitionQueueState, "root.tenant-a", `{"state": "stopped"}`, alice)
+	assert.Equal(t, resp.statusCode, 0, "stop should succeed: %s", string(resp.outputBytes))
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &queueDao), unmarshalError)
+	assert.Equal(t, queueDao.Status, objects.Stopped.String())
+	resp = update(updatePartitionQueueState, "root.tenant-a", `{"state": "Active"}`, alice)
+	assert.Equal(t, resp.statusCode, 0, "start should succeed: %s", string(resp.outputBytes))
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &queueDao), unmarshalError)
+	assert.Equal(t, queueDao.Status, objects.Active.String())
+}
+
+func TestMoveApplication(t *testing.T) {
+	part := setup(t, configQueueACLs, 1)
+	app := newApplication("app-1", part.Name, "root.tenant-a", rmID, security.UserGroup{})
+	assert.NilError(t, part.AddApplication(app), "failed to add application")
+	alice := &requestUser{userGroup: security.UserGroup{User: "alice"}}
+	admin := &requestUser{userGroup: security.UserGroup{User: "admin"}, admin: true}
+	move := func(appID, body string, caller *requestUser) *MockResponseWriter {
+		req, err := http.NewRequest("PUT", "/ws/v1/partition/default/application/"+appID+"/queue", strings.NewReader(body))
+		assert.NilError(t, err, "HTTP request create failed")
+		ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+			httprouter.Param{Key: "partition", Value: partitionNameWithoutClusterID},
+			httprouter.Param{Key: "application", Value: appID},
+		})
+		if caller != nil {
+			ctx = context.WithValue(ctx, authContextKey{}, caller)
+		}
+		resp := &MockResponseWriter{}
+		moveApplication(resp, req.WithContext(ctx))
+		return resp
+	}
+
+	// moves need an authenticated caller with admin access to both queues
+	resp := move("app-1", `{"queue": "root.tenant-b"}`, nil)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = move("app-1", `{"queue": "root.tenant-b"}`, alice)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = move("unknown", `{"queue": "root.tenant-b"}`, admin)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+	resp = move("app-1", `{"queue": "root.unknown"}`, admin)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+	resp = move("app-1", `{}`, admin)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = move("app-1", `{"queue": "root"}`, admin)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+
+	resp = move("app-1", `{"queue": "root.tenant-b"}`, admin)
+	assert.Equal(t, resp.statusCode, 0, "move should succeed: %s", string(resp.outputBytes))
+	var appDao dao.ApplicationDAOInfo
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &appDao), unmarshalError)
+	assert.Equal(t, appDao.QueueName, "root.tenant-b")
+	assert.Assert(t, part.GetQueue("root.tenant-b").GetApplication("app-1") != nil, "application should be in the new queue")
+	assert.Assert(t, part.GetQueue("root.tenant-a").GetApplication("app-1") == nil, "application should be removed from the old queue")
+}
+
+func TestSuspendResumeApplication(t *testing.T) {
+	part := setup(t, configQueueACLs, 1)
+	app := newApplication("app-1", part.Name, "root.tenant-b", rmID, security.UserGroup{})
+	assert.NilError(t, part.AddApplication(app), "failed to add application")
+	alice := &requestUser{userGroup: security.UserGroup{User: "alice"}}
+	admin := &requestUser{userGroup: security.UserGroup{User: "admin"}, admin: true}
+	call := func(handler http.HandlerFunc, action, appID string, caller *requestUser) *MockResponseWriter {
+		req, err := http.NewRequest("PUT", "/ws/v1/partition/default/application/"+appID+"/"+action, strings.NewReader(""))
+		assert.NilError(t, err, "HTTP request create failed")
+		ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+			httprouter.Param{Key: "partition", Value: partitionNameWithoutClusterID},
+			httprouter.Param{Key: "application", Value: appID},
+		})
+		if caller != nil {
+			ctx = context.WithValue(ctx, authContextKey{}, caller)
+		}
+		resp := &MockResponseWriter{}
+		handler(resp, req.WithContext(ctx))
+		return resp
+	}
+
+	// suspending needs an authenticated caller with admin access to the queue
+	resp := call(suspendApplication, "suspend", "app-1", nil)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = call(suspendApplication, "suspend", "app-1", alice)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = call(suspendApplication, "suspend", "unknown", admin)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+	// a new application cannot be suspended
+	resp = call(suspendApplication, "suspend", "app-1", admin)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+
+	app.SetState(objects.Accepted.String())
+	resp = call(suspendApplication, "suspend", "app-1", admin)
+	assert.Equal(t, resp.statusCode, 0, "suspend should succeed: %s", string(resp.outputBytes))
+	var appDao dao.ApplicationDAOInfo
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &appDao), unmarshalError)
+	assert.Equal(t, appDao.State, objects.Suspended.String())
+	resp = call(suspendApplication, "suspend", "app-1", admin)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+
+	resp = call(resumeApplication, "resume", "app-1", alice)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = call(resumeApplication, "resume", "app-1", admin)
+	assert.Equal(t, resp.statusCode, 0, "resume should succeed: %s", string(resp.outputBytes))
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &appDao), unmarshalError)
+	assert.Equal(t, appDao.State, objects.Accepted.String())
+	resp = call(resumeApplication, "resume", "app-1", admin)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+}
+
+func TestGetAccountingRecords(t *testing.T) {
+	tracker := accounting.GetTracker()
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	bindTime := time.Now().Add(-2 * time.Hour)
+	tracker.AddAllocation("alloc-1", accounting.Attribution{Partition: "accounting", Queue: "root.a", ApplicationID: "app-1", User: "alice", Group: "dev"}, res, bindTime)
+	tracker.AddAllocation("alloc-2", accounting.Attribution{Partition: "accounting", Queue: "root.b", ApplicationID: "app-2", User: "bob"}, res, bindTime)
+	defer tracker.RemoveAllocation("accounting", "alloc-1")
+	defer tracker.RemoveAllocation("accounting", "alloc-2")
+	get := func(query string, caller *requestUser) *MockResponseWriter {
+		req, err := http.NewRequest("GET", "/ws/v1/accounting?"+query, strings.NewReader(""))
+		assert.NilError(t, err, "HTTP request create failed")
+		if caller != nil {
+			req = req.WithContext(context.WithValue(req.Context(), authContextKey{}, caller))
+		}
+		resp := &MockResponseWriter{}
+		getAccountingRecords(resp, req)
+		return resp
+	}
+	records := func(resp *MockResponseWriter) []*dao.AccountingRecordDAOInfo {
+		assert.Equal(t, resp.statusCode, 0, "request should succeed: %s", string(resp.outputBytes))
+		var result []*dao.AccountingRecordDAOInfo
+		assert.NilError(t, json.Unmarshal(resp.outputBytes, &result), unmarshalError)
+		return result
+	}
+	count := func(result []*dao.AccountingRecordDAOInfo, user string) int {
+		found := 0
+		for _, record := range result {
+			if record.Partition == "accounting" && record.User == user {
+				found++
+			}
+		}
+		return found
+	}
+
+	result := records(get("", nil))
+	assert.Assert(t, count(result, "alice") > 0, "records of alice expected")
+	assert.Assert(t, count(result, "bob") > 0, "records of bob expected")
+	for _, record := range result {
+		if record.User == "alice" {
+			assert.Equal(t, record.Period, accounting.Hourly)
+			assert.Equal(t, record.Group, "dev")
+		}
+	}
+	result = records(get("period=daily&start="+bindTime.Add(-48*time.Hour).Format(time.RFC3339), nil))
+	assert.Assert(t, count(result, "alice") > 0, "daily records of alice expected")
+	// a time range before the usage
+	result = records(get(fmt.Sprintf("start=%d&end=%d", bindTime.Add(-3*time.Hour).UnixNano(), bindTime.Add(-2*time.Hour).UnixNano()), nil))
+	assert.Equal(t, count(result, "alice"), 0)
+	// callers only see their own records
+	result = records(get("", &requestUser{userGroup: security.UserGroup{User: "alice"}}))
+	assert.Assert(t, count(result, "alice") > 0, "records of alice expected")
+	assert.Equal(t, count(result, "bob"), 0)
+	result = records(get("", &requestUser{userGroup: security.UserGroup{User: "carol", Groups: []string{"dev"}}}))
+	assert.Assert(t, count(result, "alice") > 0, "group records expected")
+	assert.Equal(t, count(result, "bob"), 0)
+
+	// illegal requests
+	assert.Equal(t, get("period=monthly", nil).statusCode, http.StatusBadRequest, statusCodeError)
+	assert.Equal(t, get("start=yesterday", nil).statusCode, http.StatusBadRequest, statusCodeError)
+	assert.Equal(t, get("start=10&end=5", nil).statusCode, http.StatusBadRequest, statusCodeError)
+}
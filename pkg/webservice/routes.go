@@ -58,6 +58,24 @@ var webRoutes = routes{
 		"/ws/v1/validate-conf",
 		validateConf,
 	},
+	route{
+		"Cluster",
+		"POST",
+		"/ws/v1/config/dryrun",
+		dryRunConf,
+	},
+	route{
+		"Cluster",
+		"GET",
+		"/ws/v1/config/history",
+		getConfigHistory,
+	},
+	route{
+		"Cluster",
+		"POST",
+		"/ws/v1/config/history/:version/rollback",
+		rollbackConfig,
+	},
 
 	// endpoints to retrieve general scheduler info
 	route{
@@ -96,6 +114,18 @@ var webRoutes = routes{
 		"/ws/v1/partition/:partition/queue/:queue",
 		getPartitionQueue,
 	},
+	route{
+		"Scheduler",
+		"PUT",
+		"/ws/v1/partition/:partition/queue/:queue",
+		updatePartitionQueue,
+	},
+	route{
+		"Scheduler",
+		"PUT",
+		"/ws/v1/partition/:partition/queue/:queue/state",
+		updatePartitionQueueState,
+	},
 	route{
 		"Scheduler",
 		"GET",
@@ -126,6 +156,24 @@ var webRoutes = routes{
 		"/ws/v1/partition/:partition/application/:application",
 		getApplication,
 	},
+	route{
+		"Scheduler",
+		"PUT",
+		"/ws/v1/partition/:partition/application/:application/queue",
+		moveApplication,
+	},
+	route{
+		"Scheduler",
+		"PUT",
+		"/ws/v1/partition/:partition/application/:application/suspend",
+		suspendApplication,
+	},
+	route{
+		"Scheduler",
+		"PUT",
+		"/ws/v1/partition/:partition/application/:application/resume",
+		resumeApplication,
+	},
 	route{
 		"Scheduler",
 		"GET",
@@ -144,6 +192,12 @@ var webRoutes = routes{
 		"/ws/v1/partition/:partition/usage/users",
 		getUsersResourceUsage,
 	},
+	route{
+		"Scheduler",
+		"GET",
+		"/ws/v1/partition/:partition/usage/queues",
+		getQueuesResourceUsage,
+	},
 	route{
 		"Scheduler",
 		"GET",
@@ -162,6 +216,12 @@ var webRoutes = routes{
 		"/ws/v1/partition/:partition/usage/group/:group",
 		getGroupResourceUsage,
 	},
+	route{
+		"Scheduler",
+		"GET",
+		"/ws/v1/accounting",
+		getAccountingRecords,
+	},
 	route{
 		"Scheduler",
 		"GET",
@@ -20,6 +20,7 @@ package webservice
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"net/http"
 	"sync/atomic"
@@ -29,6 +30,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-core/pkg/metrics/history"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
@@ -44,7 +46,7 @@ type WebService struct {
 func newRouter() *httprouter.Router {
 	router := httprouter.New()
 	for _, webRoute := range webRoutes {
-		handler := loggingHandler(webRoute.HandlerFunc, webRoute.Name)
+		handler := loggingHandler(authHandler(webRoute.HandlerFunc, routeAccess(webRoute)), webRoute.Name)
 		router.Handler(webRoute.Method, webRoute.Pattern, handler)
 	}
 	return router
@@ -64,16 +66,44 @@ func loggingHandler(inner http.Handler, name string) http.HandlerFunc {
 
 // StartWebApp starts the web app on the default port.
 func (m *WebService) StartWebApp() {
+	m.start(nil)
+}
+
+// StartWebAppWithTLS starts the web app on the default port serving HTTPS.
+// Client certificates are verified when offered, they are not required: a client certificate is one of the ways a
+// caller can authenticate to the REST API. The web app is started without TLS if the TLS config is not enabled.
+func (m *WebService) StartWebAppWithTLS(tlsConfig *common.ServerTLSConfig) {
+	if !tlsConfig.Enabled() {
+		m.start(nil)
+		return
+	}
+	config, err := common.NewReloadingTLSConfig(tlsConfig, tls.VerifyClientCertIfGiven)
+	if err != nil {
+		log.Log(log.REST).Fatal("failed to configure TLS for the web app",
+			zap.Error(err))
+	}
+	m.start(config)
+}
+
+func (m *WebService) start(tlsConfig *tls.Config) {
 	router := newRouter()
 	m.httpServer = &http.Server{
 		Addr:              ":9080",
 		Handler:           router,
 		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         tlsConfig,
 	}
 
-	log.Log(log.REST).Info("web-app started", zap.Int("port", 9080))
+	log.Log(log.REST).Info("web-app started",
+		zap.Int("port", 9080),
+		zap.Bool("tls", tlsConfig != nil))
 	go func() {
-		httpError := m.httpServer.ListenAndServe()
+		var httpError error
+		if tlsConfig != nil {
+			httpError = m.httpServer.ListenAndServeTLS("", "")
+		} else {
+			httpError = m.httpServer.ListenAndServe()
+		}
 		if httpError != nil && !errors.Is(httpError, http.ErrServerClosed) {
 			log.Log(log.REST).Error("HTTP serving error",
 				zap.Error(httpError))